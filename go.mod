@@ -29,6 +29,8 @@ require (
 	github.com/urfave/cli/v2 v2.3.0
 	golang.org/x/net v0.8.0
 	golang.org/x/sys v0.6.0
+	google.golang.org/grpc v1.33.2
+	google.golang.org/protobuf v1.29.0
 	k8s.io/api v0.22.9
 	k8s.io/apimachinery v0.22.9
 	k8s.io/cli-runtime v0.22.9
@@ -98,7 +100,7 @@ require (
 	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac // indirect
 	golang.org/x/tools v0.7.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
-	google.golang.org/protobuf v1.29.0 // indirect
+	google.golang.org/genproto v0.0.0-20201110150050-8816d57aaa9a // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -58,6 +58,8 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagTargetKubeWorkloadImage),
 		commands.Cflag(commands.FlagKubeManifestFile),
 		commands.Cflag(commands.FlagKubeKubeconfigFile),
+		commands.Cflag(commands.FlagKubeAttach),
+		commands.Cflag(commands.FlagKubeAttachDuration),
 
 		commands.Cflag(commands.FlagPublishPort),
 		commands.Cflag(commands.FlagPublishExposedPorts),
@@ -157,11 +159,20 @@ var CLI = &cli.Command{
 		cflag(FlagPathPerms),
 		cflag(FlagPathPermsFile),
 		cflag(FlagObfuscateMetadata),
+		cflag(FlagExcludePhase),
 		commands.Cflag(commands.FlagContinueAfter),
+		commands.Cflag(commands.FlagQuiescenceWindow),
+		commands.Cflag(commands.FlagQuiescenceTimeout),
+		commands.Cflag(commands.FlagHTTPProbeRecord),
+		commands.Cflag(commands.FlagHTTPProbeRecordPort),
+		commands.Cflag(commands.FlagHTTPProbeRecordFile),
+		commands.Cflag(commands.FlagHTTPProbeRecordHost),
+		commands.Cflag(commands.FlagHTTPProbeRecordAuth),
 		commands.Cflag(commands.FlagUseLocalMounts),
 		commands.Cflag(commands.FlagUseSensorVolume),
 		commands.Cflag(commands.FlagRTAOnbuildBaseImage),
 		commands.Cflag(commands.FlagRTASourcePT),
+		commands.Cflag(commands.FlagRTAFileRanges),
 		//Sensor flags:
 		commands.Cflag(commands.FlagSensorIPCEndpoint),
 		commands.Cflag(commands.FlagSensorIPCMode),
@@ -319,6 +330,24 @@ var CLI = &cli.Command{
 			xc.Exit(-1)
 		}
 
+		//the pod inspector doesn't track the sensor activity events
+		if kubeOpts.HasTargetSet() && commands.HasContinueAfterMode(continueAfter.Mode, config.CAMQuiescence) {
+			xc.Out.Error("param.error.continue.after", "the quiescence mode is not supported for Kubernetes targets")
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": -1,
+				})
+			xc.Exit(-1)
+		}
+
+		if httpProbeOpts.Do && ctx.String(commands.FlagNetwork) == "none" {
+			httpProbeOpts.Do = false
+			xc.Out.Info("exec",
+				ovars{
+					"message": "disabling http-probe because the target container has no network (--network none)",
+				})
+		}
+
 		if continueAfter.Mode == config.CAMProbe && !httpProbeOpts.Do {
 			continueAfter.Mode = ""
 			xc.Out.Info("exec",
@@ -718,8 +747,10 @@ var CLI = &cli.Command{
 
 		rtaOnbuildBaseImage := ctx.Bool(commands.FlagRTAOnbuildBaseImage)
 		rtaSourcePT := ctx.Bool(commands.FlagRTASourcePT)
+		rtaFileRanges := ctx.Bool(commands.FlagRTAFileRanges)
 
 		doObfuscateMetadata := ctx.Bool(FlagObfuscateMetadata)
+		excludePhases := ctx.StringSlice(FlagExcludePhase)
 
 		imageBuildEngine, err := getImageBuildEngine(ctx)
 		if err != nil {
@@ -816,7 +847,9 @@ var CLI = &cli.Command{
 			deleteFatImage,
 			rtaOnbuildBaseImage,
 			rtaSourcePT,
+			rtaFileRanges,
 			doObfuscateMetadata,
+			excludePhases,
 			ctx.String(commands.FlagSensorIPCEndpoint),
 			ctx.String(commands.FlagSensorIPCMode),
 			kubeOpts,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/docker-slim/docker-slim/pkg/app/master/commands"
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
@@ -109,6 +110,8 @@ const (
 
 	//Experimenal flags
 	FlagObfuscateMetadata = "obfuscate-metadata"
+
+	FlagExcludePhase = "exclude-phase"
 )
 
 // Build command flag usage info
@@ -198,6 +201,8 @@ const (
 	FlagCBOCacheFromUsage        = "Add an image to the build cache"
 
 	FlagObfuscateMetadataUsage = "Obfuscate the standard system and application metadata to make it more challenging to identify the image components"
+
+	FlagExcludePhaseUsage = "Exclude the files accessed only during the monitoring phase (startup | probe)"
 )
 
 var Flags = map[string]cli.Flag{
@@ -574,6 +579,12 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagObfuscateMetadataUsage,
 		EnvVars: []string{"DSLIM_OBFUSCATE_METADATA"},
 	},
+	FlagExcludePhase: &cli.StringSliceFlag{
+		Name:    FlagExcludePhase,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagExcludePhaseUsage,
+		EnvVars: []string{"DSLIM_EXCLUDE_PHASE"},
+	},
 }
 
 func cflag(name string) cli.Flag {
@@ -793,14 +804,26 @@ func GetKubernetesOptions(ctx *cli.Context) (config.KubernetesOptions, error) {
 		TargetOverride: config.KubernetesTargetOverride{
 			Image: ctx.String(commands.FlagTargetKubeWorkloadImage),
 		},
-		Manifests:  ctx.StringSlice(commands.FlagKubeManifestFile),
-		Kubeconfig: ctx.String(commands.FlagKubeKubeconfigFile),
+		Manifests:      ctx.StringSlice(commands.FlagKubeManifestFile),
+		Kubeconfig:     ctx.String(commands.FlagKubeKubeconfigFile),
+		Attach:         ctx.Bool(commands.FlagKubeAttach),
+		AttachDuration: time.Duration(ctx.Int(commands.FlagKubeAttachDuration)) * time.Second,
 	}
 
 	if len(cfg.Target.Namespace)+len(cfg.Target.Container)+len(cfg.TargetOverride.Image)+len(cfg.Manifests) > 0 && cfg.Target.Workload == "" {
 		return cfg, errors.New("--target-kube-workload flag must be provided")
 	}
 
+	if cfg.Attach {
+		if cfg.Target.Workload == "" {
+			return cfg, errors.New("--target-kube-workload flag must be provided with --kube-attach")
+		}
+
+		if len(cfg.Manifests) > 0 || cfg.TargetOverride.Image != "" {
+			return cfg, errors.New("--kube-attach can't be used with --kube-manifest-file or --target-kube-workload-image")
+		}
+	}
+
 	return cfg, nil
 }
 
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	ipcommand "github.com/docker-slim/docker-slim/pkg/ipc/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
@@ -42,6 +44,9 @@ import (
 const appName = commands.AppName
 const composeProjectNamePat = "dsbuild_%v_%v"
 
+// Max number of the reported sparse file access candidates
+const maxSparseAccessCandidates = 10
+
 // Build command exit codes
 const (
 	ecbOther = iota + 1
@@ -141,7 +146,9 @@ func OnCommand(
 	doDeleteFatImage bool,
 	rtaOnbuildBaseImage bool,
 	rtaSourcePT bool,
+	rtaFileRanges bool,
 	doObfuscateMetadata bool,
+	excludePhases []string,
 	sensorIPCEndpoint string,
 	sensorIPCMode string,
 	kubeOpts config.KubernetesOptions,
@@ -272,6 +279,8 @@ func OnCommand(
 				execCmd:                   execCmd,
 				imageBuildEngine:          imageBuildEngine,
 				imageBuildArch:            imageBuildArch,
+				attach:                    kubeOpts.Attach,
+				attachDuration:            kubeOpts.AttachDuration,
 			})
 
 		vinfo := <-viChan
@@ -1091,7 +1100,9 @@ func OnCommand(
 		gparams.LogFormat,
 		gparams.InContainer,
 		rtaSourcePT,
+		rtaFileRanges,
 		doObfuscateMetadata,
+		excludePhases,
 		sensorIPCEndpoint,
 		sensorIPCMode,
 		printState,
@@ -1115,6 +1126,7 @@ func OnCommand(
 
 	logger.Info("starting instrumented 'fat' container...")
 	err = containerInspector.RunContainer()
+	cmdReport.Sensor = containerInspector.SensorInfo
 	if err != nil && containerInspector.DoShowContainerLogs {
 		containerInspector.ShowContainerLogs()
 	}
@@ -1258,7 +1270,7 @@ func monitorContainer(
 	cmdReport *report.BuildCommand,
 	printState bool,
 ) {
-	if hasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
+	if commands.HasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
 		httpProbeOpts.Do = true
 	}
 
@@ -1290,6 +1302,15 @@ func monitorContainer(
 			xc.Exit(exitCode)
 		}
 
+		if err := containerInspector.MarkPhase(ipcommand.PhaseProbe); err != nil {
+			xc.Out.Info("sensor.phase", ovars{"status": "not.marked", "error": err})
+		}
+
+		//the monitoring progress shows the probe phase activity
+		if err := containerInspector.ResetCounters(); err != nil {
+			xc.Out.Info("sensor.counters", ovars{"status": "not.reset", "error": err})
+		}
+
 		probe.Start()
 		continueAfter.ContinueChan = probe.DoneChan()
 	}
@@ -1299,16 +1320,25 @@ func monitorContainer(
 		continueAfterMsg = "no input required, execution will resume after the timeout"
 	}
 
-	if hasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
+	if commands.HasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
 		continueAfterMsg = "no input required, execution will resume when HTTP probing is completed"
 	}
 
+	if commands.HasContinueAfterMode(continueAfter.Mode, config.CAMQuiescence) {
+		continueAfterMsg = "no input required, execution will resume when the target app has no new activity"
+	}
+
 	xc.Out.Info("continue.after",
 		ovars{
 			"mode":    continueAfter.Mode,
 			"message": continueAfterMsg,
 		})
 
+	var recorder *http.RecordingProxy
+	if httpProbeOpts.Record {
+		recorder = startRecordingProxy(xc, continueAfter, httpProbeOpts, containerInspector)
+	}
+
 	execFail := false
 
 	modes := commands.GetContinueAfterModeNames(continueAfter.Mode)
@@ -1451,6 +1481,15 @@ func monitorContainer(
 				xc.Out.State("exited", ovars{"exit.code": -1})
 				xc.Exit(-1)
 			}
+		case config.CAMQuiescence:
+			if probe != nil && !commands.HasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
+				xc.Out.Prompt("waiting for the HTTP probe to finish")
+				<-probe.DoneChan()
+			}
+
+			commands.WaitForQuiescence(xc, continueAfter, func() time.Time {
+				return containerInspector.MonitorActivity().LastActivityTime()
+			})
 		case config.CAMHostExec:
 			commands.RunHostExecProbes(printState, xc, hostExecProbes)
 		case config.CAMAppExit:
@@ -1461,6 +1500,25 @@ func monitorContainer(
 		}
 	}
 
+	if probe != nil {
+		//the failed assertions are checked in all modes where the probe is done
+		//(e.g., 'probe&quiescence' too)
+		if probe.IsDone() && probe.AssertFailCount > 0 && httpProbeOpts.ExitOnFailure {
+			xc.Out.Error("probe.error", "failed.assertions")
+
+			containerInspector.ShowContainerLogs()
+			xc.Out.State("exited", ovars{"exit.code": -1})
+			xc.Exit(-1)
+		}
+
+		cmdReport.APISpecCoverage = probe.APISpecCoverageReport()
+		showSensorSnapshot(xc, containerInspector)
+	}
+
+	if recorder != nil {
+		stopRecordingProxy(xc, recorder, httpProbeOpts.RecordFile)
+	}
+
 	if execFail {
 		xc.Out.Info("continue.after",
 			ovars{
@@ -1479,6 +1537,39 @@ func monitorContainer(
 	}
 }
 
+// showSensorSnapshot shows the partial monitoring report summary
+// (the number of the files accessed in each monitoring phase)
+func showSensorSnapshot(xc *app.ExecutionContext, containerInspector *container.Inspector) {
+	snapshot, err := containerInspector.Snapshot()
+	if err != nil {
+		xc.Out.Info("sensor.snapshot", ovars{"status": "not.available", "error": err})
+		return
+	}
+
+	phaseFiles := map[string]int{}
+	for _, finfo := range snapshot.Files {
+		for _, phase := range finfo.Phases {
+			phaseFiles[phase]++
+		}
+	}
+
+	var phaseInfo []string
+	for phase, count := range phaseFiles {
+		phaseInfo = append(phaseInfo, fmt.Sprintf("%s=%d", phase, count))
+	}
+	sort.Strings(phaseInfo)
+
+	xc.Out.Info("sensor.snapshot",
+		ovars{
+			"phase":       snapshot.Phase,
+			"files":       len(snapshot.Files),
+			"phase.files": strings.Join(phaseInfo, ","),
+			"processes":   snapshot.Processes,
+			"events":      snapshot.EventCount,
+			"syscalls":    snapshot.SyscallCount,
+		})
+}
+
 func finishCommand(
 	xc *app.ExecutionContext,
 	minifiedImageName string,
@@ -1614,6 +1705,41 @@ func finishCommand(
 					Release: creport.System.Release,
 					Distro:  creport.System.Distro,
 				}
+
+				if usage := creport.ResourceUsage; usage != nil {
+					cmdReport.ResourceUsage = usage
+					xc.Out.Info("resource.usage",
+						ovars{
+							"duration":      usage.Duration,
+							"memory.peak":   humanize.Bytes(usage.MemoryPeakBytes),
+							"cpu.time":      (time.Duration(usage.CPUUsageUsec) * time.Microsecond).String(),
+							"cpu.avg.cores": usage.AvgCPUCores,
+							"io.read":       humanize.Bytes(usage.IOReadBytes),
+							"io.write":      humanize.Bytes(usage.IOWriteBytes),
+							"pids.peak":     usage.PidsPeak,
+						})
+				}
+
+				for _, warning := range creport.Warnings {
+					xc.Out.Info("sensor.warning",
+						ovars{
+							"message": warning,
+						})
+				}
+
+				//the bytes accessed are reported only if the file ranges are recorded
+				for _, props := range creport.Image.SparseAccessCandidates(
+					report.SparseAccessMinFileSize,
+					report.SparseAccessMaxRatio,
+					maxSparseAccessCandidates) {
+					xc.Out.Info("sparse.access",
+						ovars{
+							"file":           props.FilePath,
+							"file_size":      props.FileSize,
+							"bytes_accessed": props.BytesAccessed,
+							"bytes_mapped":   props.BytesMapped,
+						})
+				}
 			} else {
 				logger.Infof("could not read container report - json parsing error - %v", err)
 			}
@@ -1670,14 +1796,93 @@ func finishCommand(
 	}
 }
 
-func hasContinueAfterMode(modeSet, mode string) bool {
-	for _, current := range strings.Split(modeSet, "&") {
-		if current == mode {
-			return true
-		}
+// startRecordingProxy starts the recording proxy
+// for the continue-after modes where the users interact with the target app
+func startRecordingProxy(
+	xc *app.ExecutionContext,
+	continueAfter *config.ContinueAfter,
+	httpProbeOpts config.HTTPProbeOptions,
+	containerInspector *container.Inspector) *http.RecordingProxy {
+	if !commands.HasContinueAfterMode(continueAfter.Mode, config.CAMEnter) &&
+		!commands.HasContinueAfterMode(continueAfter.Mode, config.CAMSignal) &&
+		!commands.HasContinueAfterMode(continueAfter.Mode, config.CAMTimeout) {
+		xc.Out.Info("http.probe.record",
+			ovars{
+				"status":  "skipped",
+				"message": "recording proxy requires the 'enter', 'signal' or 'timeout' continue-after mode",
+			})
+		return nil
+	}
+
+	recorder, err := http.NewContainerRecordingProxy(containerInspector, httpProbeOpts)
+	if err != nil {
+		xc.Out.Info("http.probe.record",
+			ovars{
+				"status": "error",
+				"error":  err,
+			})
+		return nil
+	}
+
+	recorder.Start()
+
+	message := fmt.Sprintf("send your requests to %s to record them", recorder.URL())
+	if recorder.ListensOnAllAddrs() {
+		message = fmt.Sprintf("send your requests to %s (or to port %d on the other host addresses) to record them",
+			recorder.URL(), recorder.Port())
+	}
+
+	xc.Out.Info("http.probe.record",
+		ovars{
+			"status":      "running",
+			"addr":        recorder.Addr(),
+			"port":        recorder.Port(),
+			"target":      recorder.Target,
+			"target.port": recorder.TargetPort,
+			"message":     message,
+		})
+
+	return recorder
+}
+
+// stopRecordingProxy stops the recording proxy and saves the recorded HTTP probe commands
+func stopRecordingProxy(
+	xc *app.ExecutionContext,
+	recorder *http.RecordingProxy,
+	recordFile string) {
+	recorder.Stop()
+
+	cmds := recorder.Cmds()
+	if len(cmds) == 0 {
+		xc.Out.Info("http.probe.record",
+			ovars{
+				"status":  "done",
+				"message": "no requests recorded",
+			})
+		return
 	}
 
-	return false
+	if recordFile == "" {
+		recordFile = http.DefaultRecordFileName
+	}
+
+	if err := recorder.Save(recordFile); err != nil {
+		xc.Out.Info("http.probe.record",
+			ovars{
+				"status": "error",
+				"file":   recordFile,
+				"error":  err,
+			})
+		return
+	}
+
+	xc.Out.Info("http.probe.record",
+		ovars{
+			"status":   "done",
+			"commands": len(cmds),
+			"file":     recordFile,
+			"message":  fmt.Sprintf("use --http-probe-cmd-file %s to replay the recorded requests", recordFile),
+		})
 }
 
 func NewLogWriter(name string) *chanWriter {
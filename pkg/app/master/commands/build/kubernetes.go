@@ -97,6 +97,9 @@ type kubeHandleOptions struct {
 	execCmd          string
 	imageBuildEngine string
 	imageBuildArch   string
+
+	attach         bool
+	attachDuration time.Duration
 }
 
 func (h *kubeHandler) Handle(
@@ -142,6 +145,9 @@ func (h *kubeHandler) Handle(
 	//    - copy sensor to the volume via the init container
 	//    - terminate (successfully) the init container
 	//    - send StartCommand to the sensor
+	//    ...or attach to a running workload pod (no patching and no restart)
+	//    - copy sensor to the target container and start it there
+	//    - send StartCommand (with the app pid to monitor) to the sensor
 	podInspector, err := pod.NewInspector(
 		h.ctx,
 		h.ExecutionContext,
@@ -169,8 +175,14 @@ func (h *kubeHandler) Handle(
 		podInspector.ShutdownPod(manifests == nil)
 	})
 
-	h.logger.Info("starting instrumented 'fat' workload...")
-	err = podInspector.RunPod()
+	if opts.attach {
+		h.logger.Info("attaching to the running 'fat' workload pod...")
+		err = podInspector.AttachPod()
+	} else {
+		h.logger.Info("starting instrumented 'fat' workload...")
+		err = podInspector.RunPod()
+	}
+	h.report.Sensor = podInspector.SensorInfo()
 	if err != nil && opts.DoShowContainerLogs {
 		podInspector.ShowPodLogs()
 	}
@@ -330,12 +342,19 @@ func (h *kubeHandler) monitorPod(
 		opts.continueAfter.ContinueChan = probe.DoneChan()
 	}
 
+	if opts.attach && opts.attachDuration > 0 {
+		h.Out.Prompt(fmt.Sprintf("monitoring the attached pod (%v)", opts.attachDuration))
+		<-time.After(opts.attachDuration)
+		h.Out.Info("event", ovars{"message": "done monitoring the attached pod"})
+		return
+	}
+
 	continueAfterMsg := "provide the expected input to allow the container inspector to continue its execution"
 	if opts.continueAfter.Mode == config.CAMTimeout {
 		continueAfterMsg = "no input required, execution will resume after the timeout"
 	}
 
-	if hasContinueAfterMode(opts.continueAfter.Mode, config.CAMProbe) {
+	if commands.HasContinueAfterMode(opts.continueAfter.Mode, config.CAMProbe) {
 		continueAfterMsg = "no input required, execution will resume when HTTP probing is completed"
 	}
 
@@ -389,6 +408,20 @@ func (h *kubeHandler) monitorPod(
 			errutil.Fail("unknown continue-after mode")
 		}
 	}
+
+	if probe != nil {
+		//the failed assertions are checked in all modes where the probe is done
+		//(e.g., 'probe&timeout' too)
+		if probe.IsDone() && probe.AssertFailCount > 0 && opts.httpProbeOpts.ExitOnFailure {
+			h.Out.Error("probe.error", "failed.assertions")
+
+			podInspector.ShowPodLogs()
+			h.Out.State("exited", ovars{"exit.code": -1})
+			h.Exit(-1)
+		}
+
+		h.report.APISpecCoverage = probe.APISpecCoverageReport()
+	}
 }
 
 func (h *kubeHandler) processCollectedDataOrFail(podInspector *pod.Inspector, imageInspector *image.Inspector) {
@@ -34,6 +34,8 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagTargetKubeWorkloadImage), Description: commands.FlagTargetKubeWorkloadImageUsage},
 		{Text: commands.FullFlagName(commands.FlagKubeManifestFile), Description: commands.FlagKubeManifestFileUsage},
 		{Text: commands.FullFlagName(commands.FlagKubeKubeconfigFile), Description: commands.FlagKubeKubeconfigFileUsage},
+		{Text: commands.FullFlagName(commands.FlagKubeAttach), Description: commands.FlagKubeAttachUsage},
+		{Text: commands.FullFlagName(commands.FlagKubeAttachDuration), Description: commands.FlagKubeAttachDurationUsage},
 		{Text: commands.FullFlagName(commands.FlagPull), Description: commands.FlagPullUsage},
 		{Text: commands.FullFlagName(commands.FlagShowPullLogs), Description: commands.FlagShowPullLogsUsage},
 		{Text: commands.FullFlagName(commands.FlagRegistryAccount), Description: commands.FlagRegistryAccountUsage},
@@ -50,6 +52,10 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeCmd), Description: commands.FlagHTTPProbeCmdUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeCmdFile), Description: commands.FlagHTTPProbeCmdFileUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeStartWait), Description: commands.FlagHTTPProbeStartWaitUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyURL), Description: commands.FlagHTTPProbeReadyURLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyStatus), Description: commands.FlagHTTPProbeReadyStatusUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyTimeout), Description: commands.FlagHTTPProbeReadyTimeoutUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyHealthcheck), Description: commands.FlagHTTPProbeReadyHealthcheckUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeRetryCount), Description: commands.FlagHTTPProbeRetryCountUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeRetryWait), Description: commands.FlagHTTPProbeRetryWaitUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbePorts), Description: commands.FlagHTTPProbePortsUsage},
@@ -60,8 +66,38 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagHTTPCrawlMaxPageCount), Description: commands.FlagHTTPCrawlMaxPageCountUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPCrawlConcurrency), Description: commands.FlagHTTPCrawlConcurrencyUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPMaxConcurrentCrawlers), Description: commands.FlagHTTPMaxConcurrentCrawlersUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPCrawlSitemap), Description: commands.FlagHTTPCrawlSitemapUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPCrawlForms), Description: commands.FlagHTTPCrawlFormsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPCrawlPostForms), Description: commands.FlagHTTPCrawlPostFormsUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpec), Description: commands.FlagHTTPProbeAPISpecUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile), Description: commands.FlagHTTPProbeAPISpecFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpecAuth), Description: commands.FlagHTTPProbeAPISpecAuthUsage},
+		{Text: commands.FullFlagName(commands.FlagGRPCProbe), Description: commands.FlagGRPCProbeUsage},
+		{Text: commands.FullFlagName(commands.FlagGRPCProbeProtoset), Description: commands.FlagGRPCProbeProtosetUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeGraphQL), Description: commands.FlagHTTPProbeGraphQLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeGraphQLMutations), Description: commands.FlagHTTPProbeGraphQLMutationsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeGraphQLVars), Description: commands.FlagHTTPProbeGraphQLVarsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeScenario), Description: commands.FlagHTTPProbeScenarioUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHAR), Description: commands.FlagHTTPProbeHARUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbePostman), Description: commands.FlagHTTPProbePostmanUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportInclude), Description: commands.FlagHTTPProbeImportIncludeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportExclude), Description: commands.FlagHTTPProbeImportExcludeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportStripAuth), Description: commands.FlagHTTPProbeImportStripAuthUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportAllHosts), Description: commands.FlagHTTPProbeImportAllHostsUsage},
+		{Text: commands.FullFlagName(commands.FlagTCPProbe), Description: commands.FlagTCPProbeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthBearer), Description: commands.FlagHTTPProbeAuthBearerUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2TokenURL), Description: commands.FlagHTTPProbeAuthOAuth2TokenURLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2ClientID), Description: commands.FlagHTTPProbeAuthOAuth2ClientIDUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2ClientSecret), Description: commands.FlagHTTPProbeAuthOAuth2ClientSecretUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2Scope), Description: commands.FlagHTTPProbeAuthOAuth2ScopeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientCert), Description: commands.FlagHTTPProbeClientCertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientKey), Description: commands.FlagHTTPProbeClientKeyUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeCACert), Description: commands.FlagHTTPProbeCACertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRecord), Description: commands.FlagHTTPProbeRecordUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRecordPort), Description: commands.FlagHTTPProbeRecordPortUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRecordFile), Description: commands.FlagHTTPProbeRecordFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRecordHost), Description: commands.FlagHTTPProbeRecordHostUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeRecordAuth), Description: commands.FlagHTTPProbeRecordAuthUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishPort), Description: commands.FlagPublishPortUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishExposedPorts), Description: commands.FlagPublishExposedPortsUsage},
 		{Text: commands.FullFlagName(commands.FlagHostExec), Description: commands.FlagHostExecUsage},
@@ -126,6 +162,8 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagIncludeNew), Description: FlagIncludeNewUsage},
 		{Text: commands.FullFlagName(commands.FlagMount), Description: commands.FlagMountUsage},
 		{Text: commands.FullFlagName(commands.FlagContinueAfter), Description: commands.FlagContinueAfterUsage},
+		{Text: commands.FullFlagName(commands.FlagQuiescenceWindow), Description: commands.FlagQuiescenceWindowUsage},
+		{Text: commands.FullFlagName(commands.FlagQuiescenceTimeout), Description: commands.FlagQuiescenceTimeoutUsage},
 		{Text: commands.FullFlagName(commands.FlagUseLocalMounts), Description: commands.FlagUseLocalMountsUsage},
 		{Text: commands.FullFlagName(commands.FlagUseSensorVolume), Description: commands.FlagUseSensorVolumeUsage},
 		{Text: commands.FullFlagName(FlagKeepTmpArtifacts), Description: FlagKeepTmpArtifactsUsage},
@@ -152,11 +190,13 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagDeleteFatImage), Description: commands.FlagDeleteFatImageUsage},
 		{Text: commands.FullFlagName(commands.FlagRTAOnbuildBaseImage), Description: commands.FlagRTAOnbuildBaseImageUsage},
 		{Text: commands.FullFlagName(commands.FlagRTASourcePT), Description: commands.FlagRTASourcePTUsage},
+		{Text: commands.FullFlagName(commands.FlagRTAFileRanges), Description: commands.FlagRTAFileRangesUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCMode), Description: commands.FlagSensorIPCModeUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCEndpoint), Description: commands.FlagSensorIPCEndpointUsage},
 		{Text: commands.FullFlagName(FlagImageBuildEngine), Description: FlagImageBuildEngineUsage},
 		{Text: commands.FullFlagName(FlagImageBuildArch), Description: FlagImageBuildArchUsage},
 		{Text: commands.FullFlagName(FlagObfuscateMetadata), Description: FlagObfuscateMetadataUsage},
+		{Text: commands.FullFlagName(FlagExcludePhase), Description: FlagExcludePhaseUsage},
 	},
 	Values: map[string]commands.CompleteValue{
 		//NOTE: with FlagPull target complete needs to check remote registries too
@@ -171,6 +211,7 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(commands.FlagComposeWorkdir):                 commands.CompleteFile,
 		commands.FullFlagName(commands.FlagKubeManifestFile):               commands.CompleteFile,
 		commands.FullFlagName(commands.FlagKubeKubeconfigFile):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagKubeAttach):                     commands.CompleteBool,
 		commands.FullFlagName(FlagShowBuildLogs):                           commands.CompleteBool,
 		commands.FullFlagName(commands.FlagShowContainerLogs):              commands.CompleteBool,
 		commands.FullFlagName(commands.FlagPublishExposedPorts):            commands.CompleteBool,
@@ -180,7 +221,25 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(commands.FlagHTTPProbeFull):                  commands.CompleteBool,
 		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure):         commands.CompleteBool,
 		commands.FullFlagName(commands.FlagHTTPProbeCrawl):                 commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPCrawlSitemap):               commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeReadyHealthcheck):      commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPCrawlForms):                 commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPCrawlPostForms):             commands.CompleteBool,
 		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):           commands.CompleteFile,
+		commands.FullFlagName(commands.FlagGRPCProbe):                      commands.CompleteBool,
+		commands.FullFlagName(commands.FlagGRPCProbeProtoset):              commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeGraphQLVars):           commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeScenario):              commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientCert):            commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientKey):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeCACert):                commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeHAR):                   commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbePostman):               commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeImportStripAuth):       commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeImportAllHosts):        commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeRecord):                commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeRecordFile):            commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeRecordAuth):            commands.CompleteBool,
 		commands.FullFlagName(commands.FlagHostExecFile):                   commands.CompleteFile,
 		commands.FullFlagName(FlagKeepPerms):                               commands.CompleteTBool,
 		commands.FullFlagName(commands.FlagRunTargetAsUser):                commands.CompleteTBool,
@@ -223,6 +282,7 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(FlagDeleteFatImage):               commands.CompleteBool,
 		commands.FullFlagName(commands.FlagRTAOnbuildBaseImage): commands.CompleteBool,
 		commands.FullFlagName(commands.FlagRTASourcePT):         commands.CompleteBool,
+		commands.FullFlagName(commands.FlagRTAFileRanges):       commands.CompleteBool,
 		commands.FullFlagName(commands.FlagSensorIPCMode):       commands.CompleteIPCMode,
 		commands.FullFlagName(FlagImageBuildEngine):             CompleteImageBuildEngine,
 		commands.FullFlagName(FlagImageBuildArch):               CompleteImageBuildArch,
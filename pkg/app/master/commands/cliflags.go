@@ -87,6 +87,8 @@ const (
 	FlagTargetKubeWorkloadImage     = "target-kube-workload-image"
 	FlagKubeManifestFile            = "kube-manifest-file"
 	FlagKubeKubeconfigFile          = "kube-kubeconfig-file"
+	FlagKubeAttach                  = "kube-attach"
+	FlagKubeAttachDuration          = "kube-attach-duration"
 	// TODO: FlagKubeContext        = "kube-context"
 	//       FlagKubeCluster        =" kube-cluster"
 	//       etc.
@@ -110,11 +112,47 @@ const (
 	FlagHTTPCrawlMaxPageCount     = "http-crawl-max-page-count"
 	FlagHTTPCrawlConcurrency      = "http-crawl-concurrency"
 	FlagHTTPMaxConcurrentCrawlers = "http-max-concurrent-crawlers"
+	FlagHTTPCrawlSitemap          = "http-crawl-sitemap"
+	FlagHTTPCrawlForms            = "http-crawl-forms"
+	FlagHTTPCrawlPostForms        = "http-crawl-post-forms"
 	FlagHTTPProbeAPISpec          = "http-probe-apispec"
 	FlagHTTPProbeAPISpecFile      = "http-probe-apispec-file"
+	FlagHTTPProbeAPISpecAuth      = "http-probe-apispec-auth"
+	FlagGRPCProbe                 = "grpc-probe"
+	FlagGRPCProbeProtoset         = "grpc-probe-protoset"
+	FlagTCPProbe                  = "tcp-probe"
+	FlagHTTPProbeGraphQL          = "http-probe-graphql"
+	FlagHTTPProbeGraphQLMutations = "http-probe-graphql-mutations"
+	FlagHTTPProbeGraphQLVars      = "http-probe-graphql-vars"
+	FlagHTTPProbeScenario         = "http-probe-scenario"
+	FlagHTTPProbeHAR              = "http-probe-har"
+	FlagHTTPProbePostman          = "http-probe-postman"
+	FlagHTTPProbeImportInclude    = "http-probe-import-include"
+	FlagHTTPProbeImportExclude    = "http-probe-import-exclude"
+	FlagHTTPProbeImportStripAuth  = "http-probe-import-strip-auth"
+	FlagHTTPProbeImportAllHosts   = "http-probe-import-all-hosts"
+	FlagHTTPProbeRecord           = "http-probe-record"
+	FlagHTTPProbeRecordPort       = "http-probe-record-port"
+	FlagHTTPProbeRecordFile       = "http-probe-record-file"
+	FlagHTTPProbeRecordHost       = "http-probe-record-host"
+	FlagHTTPProbeRecordAuth       = "http-probe-record-auth"
 	FlagHTTPProbeProxyEndpoint    = "http-probe-proxy-endpoint"
 	FlagHTTPProbeProxyPort        = "http-probe-proxy-port"
 
+	FlagHTTPProbeReadyURL         = "http-probe-ready-url"
+	FlagHTTPProbeReadyStatus      = "http-probe-ready-status"
+	FlagHTTPProbeReadyTimeout     = "http-probe-ready-timeout"
+	FlagHTTPProbeReadyHealthcheck = "http-probe-ready-healthcheck"
+
+	FlagHTTPProbeAuthBearer             = "http-probe-auth-bearer"
+	FlagHTTPProbeAuthOAuth2TokenURL     = "http-probe-auth-oauth2-token-url"
+	FlagHTTPProbeAuthOAuth2ClientID     = "http-probe-auth-oauth2-client-id"
+	FlagHTTPProbeAuthOAuth2ClientSecret = "http-probe-auth-oauth2-client-secret"
+	FlagHTTPProbeAuthOAuth2Scope        = "http-probe-auth-oauth2-scope"
+	FlagHTTPProbeClientCert             = "http-probe-client-cert"
+	FlagHTTPProbeClientKey              = "http-probe-client-key"
+	FlagHTTPProbeCACert                 = "http-probe-ca-cert"
+
 	FlagHostExec     = "host-exec"
 	FlagHostExecFile = "host-exec-file"
 
@@ -130,9 +168,13 @@ const (
 	FlagUseSensorVolume = "use-sensor-volume"
 	FlagContinueAfter   = "continue-after"
 
+	FlagQuiescenceWindow  = "quiescence-window"
+	FlagQuiescenceTimeout = "quiescence-timeout"
+
 	//RunTime Analysis Options
 	FlagRTAOnbuildBaseImage = "rta-onbuild-base-image"
 	FlagRTASourcePT         = "rta-source-ptrace"
+	FlagRTAFileRanges       = "rta-file-ranges"
 
 	//Sensor IPC Options (for build and profile commands)
 	FlagSensorIPCEndpoint = "sensor-ipc-endpoint"
@@ -203,6 +245,8 @@ const (
 	FlagTargetKubeWorkloadImageUsage     = "[Experimental] Override the container image name and/or tag when targetting a Kubernetes workload (format: tag_name or image_name:tag_name)"
 	FlagKubeManifestFileUsage            = "[Experimental] Kubernetes manifest(s) to apply before run"
 	FlagKubeKubeconfigFileUsage          = "[Experimental] Path to the kubeconfig file"
+	FlagKubeAttachUsage                  = "[Experimental] Attach the sensor to a running pod of the target Kubernetes workload instead of patching and restarting the workload (the target container must be privileged and must have tar for kubectl cp)"
+	FlagKubeAttachDurationUsage          = "[Experimental] How long to monitor the attached Kubernetes pod (in seconds; if not set, the --continue-after mode is used)"
 
 	FlagRemoveFileArtifactsUsage = "remove file artifacts when command is done"
 	FlagCopyMetaArtifactsUsage   = "copy metadata artifacts to the selected location when command is done"
@@ -216,17 +260,53 @@ const (
 	FlagHTTPProbeRetryWaitUsage        = "Number of seconds to wait before retrying HTTP probe (doubles when target is not ready)"
 	FlagHTTPProbePortsUsage            = "Explicit list of ports to probe (in the order you want them to be probed)"
 	FlagHTTPProbeFullUsage             = "Do full HTTP probe for all selected ports (if false, finish after first successful scan)"
-	FlagHTTPProbeExitOnFailureUsage    = "Exit when all HTTP probe commands fail"
+	FlagHTTPProbeExitOnFailureUsage    = "Exit when all HTTP probe commands fail (or when the HTTP probe scenario assertions fail)"
 	FlagHTTPProbeCrawlUsage            = "Enable crawling for the default HTTP probe command"
 	FlagHTTPCrawlMaxDepthUsage         = "Max depth to use for the HTTP probe crawler"
 	FlagHTTPCrawlMaxPageCountUsage     = "Max number of pages to visit for the HTTP probe crawler"
 	FlagHTTPCrawlConcurrencyUsage      = "Number of concurrent workers when crawling an HTTP target"
 	FlagHTTPMaxConcurrentCrawlersUsage = "Number of concurrent crawlers in the HTTP probe"
+	FlagHTTPCrawlSitemapUsage          = "Seed the HTTP probe crawler with the sitemap.xml pages and the robots.txt paths"
+	FlagHTTPCrawlFormsUsage            = "Submit the GET forms (with synthetic values for the empty fields) when crawling"
+	FlagHTTPCrawlPostFormsUsage        = "Submit the POST forms (with synthetic values for the empty fields) when crawling"
 	FlagHTTPProbeAPISpecUsage          = "Run HTTP probes for API spec"
 	FlagHTTPProbeAPISpecFileUsage      = "Run HTTP probes for API spec from file"
+	FlagGRPCProbeUsage                 = "Enable gRPC probing (the unary methods of the services discovered using the server reflection API or the protoset files are called with example messages)"
+	FlagGRPCProbeProtosetUsage         = "Load the gRPC service descriptors from the protoset file (instead of using the server reflection API)"
+	FlagTCPProbeUsage                  = "Probe the container port using a non-HTTP protocol driver (format => port:driver[:key=value,...] where driver is redis, postgres, mysql, memcached, nats or script with the 'file' send/expect script param)"
+	FlagHTTPProbeAPISpecAuthUsage      = "Credentials for the API spec security schemes (<scheme_name>=<value>: the API key, 'user:password' for basic auth or the bearer/OAuth2 token)"
+	FlagHTTPProbeGraphQLUsage          = "Run HTTP probes for the GraphQL endpoint (the queries are generated using the schema introspection)"
+	FlagHTTPProbeGraphQLMutationsUsage = "Top-level GraphQL mutation field to probe (mutations are not probed by default)"
+	FlagHTTPProbeGraphQLVarsUsage      = "JSON file with the GraphQL field argument values (keyed by '<field>' or '<Type>.<field>')"
+	FlagHTTPProbeScenarioUsage         = "YAML or JSON file with the HTTP probe scenarios (ordered steps with variable captures and assertions)"
+	FlagHTTPProbeHARUsage              = "Create HTTP probe commands from the requests recorded in the HAR file"
+	FlagHTTPProbePostmanUsage          = "Create HTTP probe commands from the requests in the Postman collection file"
+	FlagHTTPProbeImportIncludeUsage    = "Import only the HAR/Postman requests with the URLs matching the regular expression"
+	FlagHTTPProbeImportExcludeUsage    = "Skip the HAR/Postman requests with the URLs matching the regular expression"
+	FlagHTTPProbeImportStripAuthUsage  = "Remove the auth headers (and the Postman auth settings) from the imported HAR/Postman requests"
+	FlagHTTPProbeImportAllHostsUsage   = "Import the HAR requests for all hosts (by default, only the requests for the host of the first imported request are used)"
+	FlagHTTPProbeRecordUsage           = "Run a recording reverse proxy for the target app when continue-after is 'enter', 'signal' or 'timeout' (the client requests are saved as HTTP probe commands)"
+	FlagHTTPProbeRecordPortUsage       = "Recording proxy port (a random port is used by default)"
+	FlagHTTPProbeRecordFileUsage       = "File to save the recorded HTTP probe commands"
+	FlagHTTPProbeRecordHostUsage       = "Recording proxy listen address (use 0.0.0.0 to accept the requests from the other hosts)"
+	FlagHTTPProbeRecordAuthUsage       = "Keep the Authorization and Cookie headers in the recorded HTTP probe commands"
 	FlagHTTPProbeProxyEndpointUsage    = "Endpoint to proxy HTTP probes"
 	FlagHTTPProbeProxyPortUsage        = "Port to proxy HTTP probes (used with HTTP probe proxy endpoint)"
 
+	FlagHTTPProbeReadyURLUsage         = "URL (or path) to poll before starting the HTTP probes (the target app is ready when it returns the expected status)"
+	FlagHTTPProbeReadyStatusUsage      = "Expected readiness URL status code (any non-5xx status by default)"
+	FlagHTTPProbeReadyTimeoutUsage     = "Max number of seconds to wait for the target app to be ready before starting the HTTP probes"
+	FlagHTTPProbeReadyHealthcheckUsage = "Run the image HEALTHCHECK command in the target container to detect if the app is ready"
+
+	FlagHTTPProbeAuthBearerUsage             = "Bearer token for the HTTP probe requests (the commands can override the global auth settings)"
+	FlagHTTPProbeAuthOAuth2TokenURLUsage     = "OAuth2 token endpoint URL for the client credentials flow (a path uses the probe target address)"
+	FlagHTTPProbeAuthOAuth2ClientIDUsage     = "OAuth2 client ID for the client credentials flow"
+	FlagHTTPProbeAuthOAuth2ClientSecretUsage = "OAuth2 client secret for the client credentials flow"
+	FlagHTTPProbeAuthOAuth2ScopeUsage        = "OAuth2 scope to request in the client credentials flow"
+	FlagHTTPProbeClientCertUsage             = "Client TLS certificate file (PEM) for the HTTP probes (mTLS)"
+	FlagHTTPProbeClientKeyUsage              = "Client TLS key file (PEM) for the HTTP probes (mTLS)"
+	FlagHTTPProbeCACertUsage                 = "CA bundle file (PEM) to verify the target certificates in the HTTP probes"
+
 	FlagHostExecUsage     = "Host commands to execute (aka host commands probes)"
 	FlagHostExecFileUsage = "Host commands to execute loaded from file (aka host commands probes)"
 
@@ -240,13 +320,17 @@ const (
 	FlagExcludePatternUsage  = "Exclude path pattern (Glob/Match in Go and **) from image"
 	FlagUseLocalMountsUsage  = "Mount local paths for target container artifact input and output"
 	FlagUseSensorVolumeUsage = "Sensor volume name to use"
-	FlagContinueAfterUsage   = "Select continue mode: enter | signal | probe | timeout-number-in-seconds | container.probe"
+	FlagContinueAfterUsage   = "Select continue mode: enter | signal | probe | quiescence | timeout-number-in-seconds | container.probe"
+
+	FlagQuiescenceWindowUsage  = "Number of seconds without new file or syscall activity to wait in the 'quiescence' continue-after mode"
+	FlagQuiescenceTimeoutUsage = "Max number of seconds to wait for the target app to become quiet in the 'quiescence' continue-after mode"
 
 	FlagRTAOnbuildBaseImageUsage = "Enable runtime analysis for onbuild base images"
 	FlagRTASourcePTUsage         = "Enable PTRACE runtime analysis source"
+	FlagRTAFileRangesUsage       = "Record the file read/mmap ranges (PTRACE runtime analysis source) to report the bytes accessed vs the file size for the large data files (analysis only)"
 
 	FlagSensorIPCEndpointUsage = "Override sensor IPC endpoint"
-	FlagSensorIPCModeUsage     = "Select sensor IPC mode: proxy | direct"
+	FlagSensorIPCModeUsage     = "Select sensor IPC mode: proxy | direct | unix (Unix domain sockets in the sensor volume, requires a local Docker daemon; selected automatically when the container network is 'none')"
 
 	FlagExecUsage     = "A shell script snippet to run via Docker exec"
 	FlagExecFileUsage = "A shell script file to run via Docker exec"
@@ -541,6 +625,17 @@ var CommonFlags = map[string]cli.Flag{
 			"KUBECONFIG", // subject to an industry-wide convention
 		},
 	},
+	FlagKubeAttach: &cli.BoolFlag{
+		Name:    FlagKubeAttach,
+		Usage:   FlagKubeAttachUsage,
+		EnvVars: []string{"DSLIM_KUBE_ATTACH"},
+	},
+	FlagKubeAttachDuration: &cli.IntFlag{
+		Name:    FlagKubeAttachDuration,
+		Value:   0,
+		Usage:   FlagKubeAttachDurationUsage,
+		EnvVars: []string{"DSLIM_KUBE_ATTACH_DURATION"},
+	},
 	//
 	FlagRemoveFileArtifacts: &cli.BoolFlag{
 		Name:    FlagRemoveFileArtifacts,
@@ -588,12 +683,193 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagHTTPProbeAPISpecFileUsage,
 		EnvVars: []string{"DSLIM_HTTP_PROBE_API_SPEC_FILE"},
 	},
+	FlagHTTPProbeAPISpecAuth: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeAPISpecAuth,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeAPISpecAuthUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_API_SPEC_AUTH"},
+	},
+	FlagGRPCProbe: &cli.BoolFlag{
+		Name:    FlagGRPCProbe,
+		Usage:   FlagGRPCProbeUsage,
+		EnvVars: []string{"DSLIM_GRPC_PROBE"},
+	},
+	FlagGRPCProbeProtoset: &cli.StringSliceFlag{
+		Name:    FlagGRPCProbeProtoset,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagGRPCProbeProtosetUsage,
+		EnvVars: []string{"DSLIM_GRPC_PROBE_PROTOSET"},
+	},
+	FlagTCPProbe: &cli.StringSliceFlag{
+		Name:    FlagTCPProbe,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagTCPProbeUsage,
+		EnvVars: []string{"DSLIM_TCP_PROBE"},
+	},
+	FlagHTTPProbeGraphQL: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeGraphQL,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeGraphQLUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_GRAPHQL"},
+	},
+	FlagHTTPProbeGraphQLMutations: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeGraphQLMutations,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeGraphQLMutationsUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_GRAPHQL_MUTATIONS"},
+	},
+	FlagHTTPProbeGraphQLVars: &cli.StringFlag{
+		Name:    FlagHTTPProbeGraphQLVars,
+		Value:   "",
+		Usage:   FlagHTTPProbeGraphQLVarsUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_GRAPHQL_VARS"},
+	},
+	FlagHTTPProbeScenario: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeScenario,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeScenarioUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_SCENARIO"},
+	},
+	FlagHTTPProbeAuthBearer: &cli.StringFlag{
+		Name:    FlagHTTPProbeAuthBearer,
+		Value:   "",
+		Usage:   FlagHTTPProbeAuthBearerUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_AUTH_BEARER"},
+	},
+	FlagHTTPProbeAuthOAuth2TokenURL: &cli.StringFlag{
+		Name:    FlagHTTPProbeAuthOAuth2TokenURL,
+		Value:   "",
+		Usage:   FlagHTTPProbeAuthOAuth2TokenURLUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_AUTH_OAUTH2_TOKEN_URL"},
+	},
+	FlagHTTPProbeAuthOAuth2ClientID: &cli.StringFlag{
+		Name:    FlagHTTPProbeAuthOAuth2ClientID,
+		Value:   "",
+		Usage:   FlagHTTPProbeAuthOAuth2ClientIDUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_AUTH_OAUTH2_CLIENT_ID"},
+	},
+	FlagHTTPProbeAuthOAuth2ClientSecret: &cli.StringFlag{
+		Name:    FlagHTTPProbeAuthOAuth2ClientSecret,
+		Value:   "",
+		Usage:   FlagHTTPProbeAuthOAuth2ClientSecretUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_AUTH_OAUTH2_CLIENT_SECRET"},
+	},
+	FlagHTTPProbeAuthOAuth2Scope: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeAuthOAuth2Scope,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeAuthOAuth2ScopeUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_AUTH_OAUTH2_SCOPE"},
+	},
+	FlagHTTPProbeClientCert: &cli.StringFlag{
+		Name:    FlagHTTPProbeClientCert,
+		Value:   "",
+		Usage:   FlagHTTPProbeClientCertUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CLIENT_CERT"},
+	},
+	FlagHTTPProbeClientKey: &cli.StringFlag{
+		Name:    FlagHTTPProbeClientKey,
+		Value:   "",
+		Usage:   FlagHTTPProbeClientKeyUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CLIENT_KEY"},
+	},
+	FlagHTTPProbeCACert: &cli.StringFlag{
+		Name:    FlagHTTPProbeCACert,
+		Value:   "",
+		Usage:   FlagHTTPProbeCACertUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_CA_CERT"},
+	},
+	FlagHTTPProbeHAR: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeHAR,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeHARUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_HAR"},
+	},
+	FlagHTTPProbePostman: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbePostman,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbePostmanUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_POSTMAN"},
+	},
+	FlagHTTPProbeImportInclude: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeImportInclude,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeImportIncludeUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_IMPORT_INCLUDE"},
+	},
+	FlagHTTPProbeImportExclude: &cli.StringSliceFlag{
+		Name:    FlagHTTPProbeImportExclude,
+		Value:   cli.NewStringSlice(),
+		Usage:   FlagHTTPProbeImportExcludeUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_IMPORT_EXCLUDE"},
+	},
+	FlagHTTPProbeRecord: &cli.BoolFlag{
+		Name:    FlagHTTPProbeRecord,
+		Usage:   FlagHTTPProbeRecordUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_RECORD"},
+	},
+	FlagHTTPProbeRecordPort: &cli.IntFlag{
+		Name:    FlagHTTPProbeRecordPort,
+		Value:   0,
+		Usage:   FlagHTTPProbeRecordPortUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_RECORD_PORT"},
+	},
+	FlagHTTPProbeRecordFile: &cli.StringFlag{
+		Name:    FlagHTTPProbeRecordFile,
+		Value:   "",
+		Usage:   FlagHTTPProbeRecordFileUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_RECORD_FILE"},
+	}, FlagHTTPProbeRecordHost: &cli.StringFlag{
+		Name:    FlagHTTPProbeRecordHost,
+		Value:   "127.0.0.1",
+		Usage:   FlagHTTPProbeRecordHostUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_RECORD_HOST"},
+	},
+	FlagHTTPProbeRecordAuth: &cli.BoolFlag{
+		Name:    FlagHTTPProbeRecordAuth,
+		Usage:   FlagHTTPProbeRecordAuthUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_RECORD_AUTH"},
+	},
+
+	FlagHTTPProbeImportStripAuth: &cli.BoolFlag{
+		Name:    FlagHTTPProbeImportStripAuth,
+		Usage:   FlagHTTPProbeImportStripAuthUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_IMPORT_STRIP_AUTH"},
+	},
+	FlagHTTPProbeImportAllHosts: &cli.BoolFlag{
+		Name:    FlagHTTPProbeImportAllHosts,
+		Usage:   FlagHTTPProbeImportAllHostsUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_IMPORT_ALL_HOSTS"},
+	},
 	FlagHTTPProbeStartWait: &cli.IntFlag{
 		Name:    FlagHTTPProbeStartWait,
 		Value:   0,
 		Usage:   FlagHTTPProbeStartWaitUsage,
 		EnvVars: []string{"DSLIM_HTTP_PROBE_START_WAIT"},
 	},
+	FlagHTTPProbeReadyURL: &cli.StringFlag{
+		Name:    FlagHTTPProbeReadyURL,
+		Value:   "",
+		Usage:   FlagHTTPProbeReadyURLUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_READY_URL"},
+	},
+	FlagHTTPProbeReadyStatus: &cli.IntFlag{
+		Name:    FlagHTTPProbeReadyStatus,
+		Value:   0,
+		Usage:   FlagHTTPProbeReadyStatusUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_READY_STATUS"},
+	},
+	FlagHTTPProbeReadyTimeout: &cli.IntFlag{
+		Name:    FlagHTTPProbeReadyTimeout,
+		Value:   120,
+		Usage:   FlagHTTPProbeReadyTimeoutUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_READY_TIMEOUT"},
+	},
+	FlagHTTPProbeReadyHealthcheck: &cli.BoolFlag{
+		Name:    FlagHTTPProbeReadyHealthcheck,
+		Value:   true,
+		Usage:   FlagHTTPProbeReadyHealthcheckUsage,
+		EnvVars: []string{"DSLIM_HTTP_PROBE_READY_HEALTHCHECK"},
+	},
 	FlagHTTPProbeRetryCount: &cli.IntFlag{
 		Name:    FlagHTTPProbeRetryCount,
 		Value:   5,
@@ -653,6 +929,24 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagHTTPMaxConcurrentCrawlersUsage,
 		EnvVars: []string{"DSLIM_HTTP_MAX_CONCURRENT_CRAWLERS"},
 	},
+	FlagHTTPCrawlSitemap: &cli.BoolFlag{
+		Name:    FlagHTTPCrawlSitemap,
+		Value:   true,
+		Usage:   FlagHTTPCrawlSitemapUsage,
+		EnvVars: []string{"DSLIM_HTTP_CRAWL_SITEMAP"},
+	},
+	FlagHTTPCrawlForms: &cli.BoolFlag{
+		Name:    FlagHTTPCrawlForms,
+		Value:   true,
+		Usage:   FlagHTTPCrawlFormsUsage,
+		EnvVars: []string{"DSLIM_HTTP_CRAWL_FORMS"},
+	},
+	FlagHTTPCrawlPostForms: &cli.BoolFlag{
+		Name:    FlagHTTPCrawlPostForms,
+		Value:   false,
+		Usage:   FlagHTTPCrawlPostFormsUsage,
+		EnvVars: []string{"DSLIM_HTTP_CRAWL_POST_FORMS"},
+	},
 	FlagHTTPProbeProxyEndpoint: &cli.StringFlag{
 		Name:    FlagHTTPProbeProxyEndpoint,
 		Value:   "",
@@ -752,6 +1046,18 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagContinueAfterUsage,
 		EnvVars: []string{"DSLIM_CONTINUE_AFTER"},
 	},
+	FlagQuiescenceWindow: &cli.IntFlag{
+		Name:    FlagQuiescenceWindow,
+		Value:   30,
+		Usage:   FlagQuiescenceWindowUsage,
+		EnvVars: []string{"DSLIM_QUIESCENCE_WINDOW"},
+	},
+	FlagQuiescenceTimeout: &cli.IntFlag{
+		Name:    FlagQuiescenceTimeout,
+		Value:   600,
+		Usage:   FlagQuiescenceTimeoutUsage,
+		EnvVars: []string{"DSLIM_QUIESCENCE_TIMEOUT"},
+	},
 	//Container Run Options
 	FlagCRORuntime: &cli.StringFlag{
 		Name:    FlagCRORuntime,
@@ -883,6 +1189,11 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:   FlagRTASourcePTUsage,
 		EnvVars: []string{"DSLIM_RTA_SRC_PT"},
 	},
+	FlagRTAFileRanges: &cli.BoolFlag{
+		Name:    FlagRTAFileRanges,
+		Usage:   FlagRTAFileRangesUsage,
+		EnvVars: []string{"DSLIM_RTA_FILE_RANGES"},
+	},
 }
 
 //var CommonFlags
@@ -903,6 +1214,10 @@ func HTTPProbeFlags() []cli.Flag {
 		Cflag(FlagHTTPProbeCmd),
 		Cflag(FlagHTTPProbeCmdFile),
 		Cflag(FlagHTTPProbeStartWait),
+		Cflag(FlagHTTPProbeReadyURL),
+		Cflag(FlagHTTPProbeReadyStatus),
+		Cflag(FlagHTTPProbeReadyTimeout),
+		Cflag(FlagHTTPProbeReadyHealthcheck),
 		Cflag(FlagHTTPProbeRetryCount),
 		Cflag(FlagHTTPProbeRetryWait),
 		Cflag(FlagHTTPProbePorts),
@@ -913,8 +1228,33 @@ func HTTPProbeFlags() []cli.Flag {
 		Cflag(FlagHTTPCrawlMaxPageCount),
 		Cflag(FlagHTTPCrawlConcurrency),
 		Cflag(FlagHTTPMaxConcurrentCrawlers),
+		Cflag(FlagHTTPCrawlSitemap),
+		Cflag(FlagHTTPCrawlForms),
+		Cflag(FlagHTTPCrawlPostForms),
 		Cflag(FlagHTTPProbeAPISpec),
 		Cflag(FlagHTTPProbeAPISpecFile),
+		Cflag(FlagHTTPProbeAPISpecAuth),
+		Cflag(FlagGRPCProbe),
+		Cflag(FlagGRPCProbeProtoset),
+		Cflag(FlagTCPProbe),
+		Cflag(FlagHTTPProbeGraphQL),
+		Cflag(FlagHTTPProbeGraphQLMutations),
+		Cflag(FlagHTTPProbeGraphQLVars),
+		Cflag(FlagHTTPProbeScenario),
+		Cflag(FlagHTTPProbeHAR),
+		Cflag(FlagHTTPProbePostman),
+		Cflag(FlagHTTPProbeImportInclude),
+		Cflag(FlagHTTPProbeImportExclude),
+		Cflag(FlagHTTPProbeImportStripAuth),
+		Cflag(FlagHTTPProbeImportAllHosts),
+		Cflag(FlagHTTPProbeAuthBearer),
+		Cflag(FlagHTTPProbeAuthOAuth2TokenURL),
+		Cflag(FlagHTTPProbeAuthOAuth2ClientID),
+		Cflag(FlagHTTPProbeAuthOAuth2ClientSecret),
+		Cflag(FlagHTTPProbeAuthOAuth2Scope),
+		Cflag(FlagHTTPProbeClientCert),
+		Cflag(FlagHTTPProbeClientKey),
+		Cflag(FlagHTTPProbeCACert),
 	}
 }
 
@@ -4,6 +4,7 @@ package commands
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -64,10 +65,18 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 		RetryCount: ctx.Int(FlagHTTPProbeRetryCount),
 		RetryWait:  ctx.Int(FlagHTTPProbeRetryWait),
 
+		ReadyURL:         ctx.String(FlagHTTPProbeReadyURL),
+		ReadyStatus:      ctx.Int(FlagHTTPProbeReadyStatus),
+		ReadyTimeout:     ctx.Int(FlagHTTPProbeReadyTimeout),
+		ReadyHealthcheck: ctx.Bool(FlagHTTPProbeReadyHealthcheck),
+
 		CrawlMaxDepth:       ctx.Int(FlagHTTPCrawlMaxDepth),
 		CrawlMaxPageCount:   ctx.Int(FlagHTTPCrawlMaxPageCount),
 		CrawlConcurrency:    ctx.Int(FlagHTTPCrawlConcurrency),
 		CrawlConcurrencyMax: ctx.Int(FlagHTTPMaxConcurrentCrawlers),
+		CrawlSitemap:        ctx.Bool(FlagHTTPCrawlSitemap),
+		CrawlForms:          ctx.Bool(FlagHTTPCrawlForms),
+		CrawlPostForms:      ctx.Bool(FlagHTTPCrawlPostForms),
 	}
 
 	cmds, err := GetHTTPProbes(ctx)
@@ -132,10 +141,135 @@ func GetHTTPProbeOptions(xc *app.ExecutionContext, ctx *cli.Context) config.HTTP
 	}
 	opts.APISpecFiles = apiSpecFiles
 
+	apiSpecCredentials, err := ParseTokenMap(ctx.StringSlice(FlagHTTPProbeAPISpecAuth))
+	if err != nil {
+		xc.Out.Error("param.http.probe.apispec.auth", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": -1,
+			})
+		xc.Exit(-1)
+	}
+	opts.APISpecCredentials = apiSpecCredentials
+
 	if len(opts.APISpecs)+len(opts.APISpecFiles) > 0 {
 		opts.Do = true
 	}
 
+	grpcProtosets, fileErrors := ValidateFiles(ctx.StringSlice(FlagGRPCProbeProtoset))
+	if len(fileErrors) > 0 {
+		for k, v := range fileErrors {
+			xc.Out.Info("error",
+				ovars{
+					"file":  k,
+					"error": v,
+				})
+
+			xc.Out.Error("param.error.grpc.probe.protoset", v.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": -1,
+				})
+			xc.Exit(-1)
+		}
+	}
+	opts.GRPCProtosets = grpcProtosets
+	opts.GRPC = ctx.Bool(FlagGRPCProbe) || len(opts.GRPCProtosets) > 0
+
+	if opts.GRPC {
+		opts.Do = true
+	}
+
+	opts.GraphQLEndpoints = ctx.StringSlice(FlagHTTPProbeGraphQL)
+	opts.GraphQLMutations = ctx.StringSlice(FlagHTTPProbeGraphQLMutations)
+	graphQLVars, err := ParseGraphQLVarsFile(ctx.String(FlagHTTPProbeGraphQLVars))
+	if err != nil {
+		xc.Out.Error("param.http.probe.graphql.vars", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": -1,
+			})
+		xc.Exit(-1)
+	}
+	opts.GraphQLVariables = graphQLVars
+
+	if len(opts.GraphQLEndpoints) > 0 {
+		opts.Do = true
+	}
+
+	for _, scenarioFile := range ctx.StringSlice(FlagHTTPProbeScenario) {
+		scenarios, err := ParseHTTPProbeScenariosFile(scenarioFile)
+		if err != nil {
+			xc.Out.Error("param.http.probe.scenario", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": -1,
+				})
+			xc.Exit(-1)
+		}
+
+		opts.Scenarios = append(opts.Scenarios, scenarios...)
+	}
+
+	if len(opts.Scenarios) > 0 {
+		opts.Do = true
+	}
+
+	opts.Auth = config.HTTPProbeAuth{
+		BearerToken: ctx.String(FlagHTTPProbeAuthBearer),
+		ClientCert:  ctx.String(FlagHTTPProbeClientCert),
+		ClientKey:   ctx.String(FlagHTTPProbeClientKey),
+		CACert:      ctx.String(FlagHTTPProbeCACert),
+	}
+
+	if tokenURL := ctx.String(FlagHTTPProbeAuthOAuth2TokenURL); tokenURL != "" {
+		opts.Auth.OAuth2 = &config.HTTPProbeOAuth2{
+			TokenURL:     tokenURL,
+			ClientID:     ctx.String(FlagHTTPProbeAuthOAuth2ClientID),
+			ClientSecret: ctx.String(FlagHTTPProbeAuthOAuth2ClientSecret),
+			Scopes:       ctx.StringSlice(FlagHTTPProbeAuthOAuth2Scope),
+		}
+	}
+
+	if err := ValidateHTTPProbeAuth(&opts.Auth); err != nil {
+		xc.Out.Error("param.http.probe.auth", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": -1,
+			})
+		xc.Exit(-1)
+	}
+
+	tcpProbes, err := ParseTCPProbes(ctx.StringSlice(FlagTCPProbe))
+	if err != nil {
+		xc.Out.Error("param.tcp.probe", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": -1,
+			})
+		xc.Exit(-1)
+	}
+	opts.TCPProbes = tcpProbes
+
+	if err := ValidateHTTPProbeReadiness(&opts); err != nil {
+		xc.Out.Error("param.http.probe.ready", err.Error())
+		xc.Out.State("exited",
+			ovars{
+				"exit.code": -1,
+			})
+		xc.Exit(-1)
+	}
+
+	if len(opts.TCPProbes) > 0 {
+		opts.Do = true
+	}
+
+	opts.Record = ctx.Bool(FlagHTTPProbeRecord)
+	opts.RecordPort = ctx.Int(FlagHTTPProbeRecordPort)
+	opts.RecordFile = ctx.String(FlagHTTPProbeRecordFile)
+	opts.RecordHost = ctx.String(FlagHTTPProbeRecordHost)
+	opts.RecordAuth = ctx.Bool(FlagHTTPProbeRecordAuth)
+
 	return opts
 }
 
@@ -162,6 +296,37 @@ func GetHTTPProbes(ctx *cli.Context) ([]config.HTTPProbeCmd, error) {
 		httpProbeCmds = append(httpProbeCmds, moreHTTPProbeCmds...)
 	}
 
+	harFiles := ctx.StringSlice(FlagHTTPProbeHAR)
+	postmanFiles := ctx.StringSlice(FlagHTTPProbePostman)
+	if len(harFiles)+len(postmanFiles) > 0 {
+		importOpts, err := ParseHTTPProbeImportOptions(
+			ctx.StringSlice(FlagHTTPProbeImportInclude),
+			ctx.StringSlice(FlagHTTPProbeImportExclude),
+			ctx.Bool(FlagHTTPProbeImportStripAuth),
+			ctx.Bool(FlagHTTPProbeImportAllHosts))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, harFile := range harFiles {
+			importedCmds, err := ParseHTTPProbesHARFile(harFile, importOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			httpProbeCmds = append(httpProbeCmds, importedCmds...)
+		}
+
+		for _, postmanFile := range postmanFiles {
+			importedCmds, err := ParseHTTPProbesPostmanFile(postmanFile, importOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			httpProbeCmds = append(httpProbeCmds, importedCmds...)
+		}
+	}
+
 	return httpProbeCmds, nil
 }
 
@@ -190,6 +355,8 @@ func GetContinueAfter(ctx *cli.Context) (*config.ContinueAfter, error) {
 	case config.CAMTimeout:
 		info.Mode = config.CAMTimeout
 		info.Timeout = 60
+	case config.CAMQuiescence:
+		info.Mode = config.CAMQuiescence
 	default:
 		modes := strings.Split(doContinueAfter, "&")
 		if len(modes) > 1 {
@@ -203,6 +370,18 @@ func GetContinueAfter(ctx *cli.Context) (*config.ContinueAfter, error) {
 		}
 	}
 
+	for _, mode := range GetContinueAfterModeNames(info.Mode) {
+		if mode != config.CAMQuiescence {
+			continue
+		}
+
+		info.QuiescenceWindow = time.Duration(ctx.Int(FlagQuiescenceWindow)) * time.Second
+		info.QuiescenceTimeout = time.Duration(ctx.Int(FlagQuiescenceTimeout)) * time.Second
+		if info.QuiescenceWindow <= 0 {
+			return nil, fmt.Errorf("invalid quiescence window - %d", ctx.Int(FlagQuiescenceWindow))
+		}
+	}
+
 	return info, nil
 }
 
@@ -226,6 +405,17 @@ func GetContinueAfterModeNames(continueAfter string) []string {
 	return strings.Split(continueAfter, "&")
 }
 
+// HasContinueAfterMode checks if the (combined) continue-after mode includes the mode
+func HasContinueAfterMode(continueAfter, mode string) bool {
+	for _, current := range GetContinueAfterModeNames(continueAfter) {
+		if current == mode {
+			return true
+		}
+	}
+
+	return false
+}
+
 func GetContainerOverrides(ctx *cli.Context) (*config.ContainerOverrides, error) {
 	const op = "commands.GetContainerOverrides"
 
@@ -4,9 +4,12 @@ package commands
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,10 +19,12 @@ import (
 
 	"github.com/docker/go-connections/nat"
 	"github.com/fsouza/go-dockerclient"
+	"github.com/ghodss/yaml"
 	"github.com/google/shlex"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/tcp"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/sysenv"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
@@ -713,6 +718,12 @@ func ParseHTTPProbesFile(filePath string) ([]config.HTTPProbeCmd, error) {
 				//will load the data at runtime
 			}
 
+			if cmd.Auth != nil {
+				if err := ValidateHTTPProbeAuth(cmd.Auth); err != nil {
+					return nil, fmt.Errorf("invalid HTTP probe command auth (%s %s): %v", cmd.Method, cmd.Resource, err)
+				}
+			}
+
 			probes = append(probes, cmd)
 		}
 	}
@@ -720,6 +731,232 @@ func ParseHTTPProbesFile(filePath string) ([]config.HTTPProbeCmd, error) {
 	return probes, nil
 }
 
+func ParseHTTPProbeScenariosFile(filePath string) ([]config.HTTPProbeScenario, error) {
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	//YAML is a superset of JSON, so the JSON files are also ok
+	var configs config.HTTPProbeScenarios
+	if err := yaml.Unmarshal(fileData, &configs); err != nil {
+		return nil, fmt.Errorf("malformed HTTP probe scenario file (%s): %v", filePath, err)
+	}
+
+	for i := range configs.Scenarios {
+		scenario := &configs.Scenarios[i]
+		if scenario.Name == "" {
+			scenario.Name = fmt.Sprintf("%s#%d", filepath.Base(filePath), i+1)
+		}
+
+		switch scenario.Protocol {
+		case "", config.ProtoHTTP, config.ProtoHTTPS, config.ProtoHTTP2, config.ProtoHTTP2C:
+		default:
+			return nil, fmt.Errorf("invalid HTTP probe scenario protocol: %s (%s)", scenario.Protocol, scenario.Name)
+		}
+
+		if len(scenario.Steps) == 0 {
+			return nil, fmt.Errorf("no HTTP probe scenario steps (%s)", scenario.Name)
+		}
+
+		stepNames := map[string]struct{}{}
+		for _, step := range scenario.Steps {
+			if step.Name != "" {
+				stepNames[step.Name] = struct{}{}
+			}
+		}
+
+		for j := range scenario.Steps {
+			step := &scenario.Steps[j]
+			if step.Method != "" && !isMethod(step.Method) {
+				return nil, fmt.Errorf("invalid HTTP probe scenario step method: %s (%s)", step.Method, scenario.Name)
+			}
+
+			if step.Method == "" {
+				step.Method = "GET"
+			}
+
+			step.Method = strings.ToUpper(step.Method)
+
+			if !isResource(step.Resource) {
+				return nil, fmt.Errorf("invalid HTTP probe scenario step resource: '%s' (%s)", step.Resource, scenario.Name)
+			}
+
+			if _, found := stepNames[step.Goto]; step.Goto != "" && !found {
+				return nil, fmt.Errorf("unknown HTTP probe scenario goto step: %s (%s)", step.Goto, scenario.Name)
+			}
+
+			for _, c := range step.Capture {
+				if c.Var == "" || (c.JSON == "" && c.Header == "" && c.Cookie == "") {
+					return nil, fmt.Errorf("invalid HTTP probe scenario capture: %+v (%s)", c, scenario.Name)
+				}
+			}
+		}
+	}
+
+	return configs.Scenarios, nil
+}
+
+// ValidateHTTPProbeAuth checks the HTTP probe auth settings
+// (the certificate file paths are converted to absolute paths)
+func ValidateHTTPProbeAuth(auth *config.HTTPProbeAuth) error {
+	if auth.BearerToken != "" && auth.OAuth2 != nil {
+		return fmt.Errorf("both bearer token and OAuth2 settings are configured")
+	}
+
+	if auth.OAuth2 != nil {
+		if auth.OAuth2.TokenURL == "" {
+			return fmt.Errorf("missing OAuth2 token URL")
+		}
+
+		if _, err := url.Parse(auth.OAuth2.TokenURL); err != nil {
+			return fmt.Errorf("bad OAuth2 token URL: %v", err)
+		}
+
+		if auth.OAuth2.ClientID == "" {
+			return fmt.Errorf("missing OAuth2 client ID")
+		}
+	}
+
+	if (auth.ClientCert == "") != (auth.ClientKey == "") {
+		return fmt.Errorf("both client certificate and key files are required")
+	}
+
+	for _, filePath := range []*string{&auth.ClientCert, &auth.ClientKey, &auth.CACert} {
+		if *filePath == "" {
+			continue
+		}
+
+		fullPath, err := filepath.Abs(*filePath)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(fullPath); err != nil {
+			return err
+		}
+
+		*filePath = fullPath
+	}
+
+	if auth.ClientCert != "" {
+		if _, err := tls.LoadX509KeyPair(auth.ClientCert, auth.ClientKey); err != nil {
+			return fmt.Errorf("bad client certificate: %v", err)
+		}
+	}
+
+	if auth.CACert != "" {
+		data, err := ioutil.ReadFile(auth.CACert)
+		if err != nil {
+			return err
+		}
+
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates in the CA bundle: %s", auth.CACert)
+		}
+	}
+
+	return nil
+}
+
+// ValidateHTTPProbeReadiness checks the HTTP probe readiness detection settings
+func ValidateHTTPProbeReadiness(opts *config.HTTPProbeOptions) error {
+	if opts.ReadyURL != "" {
+		u, err := url.Parse(opts.ReadyURL)
+		if err != nil {
+			return fmt.Errorf("bad readiness URL: %v", err)
+		}
+
+		if u.IsAbs() && u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported readiness URL scheme: %s", u.Scheme)
+		}
+
+		if !u.IsAbs() && !strings.HasPrefix(u.Path, "/") {
+			return fmt.Errorf("readiness URL path must start with '/': %s", opts.ReadyURL)
+		}
+	}
+
+	if opts.ReadyStatus != 0 && (opts.ReadyStatus < 100 || opts.ReadyStatus > 599) {
+		return fmt.Errorf("bad readiness status code: %d", opts.ReadyStatus)
+	}
+
+	if opts.ReadyTimeout < 0 {
+		return fmt.Errorf("bad readiness timeout: %d", opts.ReadyTimeout)
+	}
+
+	return nil
+}
+
+// ParseTCPProbes parses the TCP probe flag values
+// (format => port:driver[:key=value,...])
+func ParseTCPProbes(values []string) ([]config.TCPProbeCmd, error) {
+	var probes []config.TCPProbeCmd
+	ports := map[uint16]struct{}{}
+	for _, raw := range values {
+		parts := strings.SplitN(raw, ":", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid TCP probe: %s", raw)
+		}
+
+		port, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid TCP probe port: %s", raw)
+		}
+
+		if _, found := ports[uint16(port)]; found {
+			return nil, fmt.Errorf("duplicate TCP probe port: %s", raw)
+		}
+		ports[uint16(port)] = struct{}{}
+
+		cmd := config.TCPProbeCmd{
+			Port:   uint16(port),
+			Driver: strings.ToLower(parts[1]),
+			Params: map[string]string{},
+		}
+
+		if _, err := tcp.GetDriver(cmd.Driver); err != nil {
+			return nil, fmt.Errorf("%v (available: %s)", err, strings.Join(tcp.DriverNames(), ","))
+		}
+
+		if len(parts) == 3 {
+			for _, param := range strings.Split(parts[2], ",") {
+				kv := strings.SplitN(param, "=", 2)
+				if len(kv) != 2 || kv[0] == "" {
+					return nil, fmt.Errorf("invalid TCP probe param: %s (%s)", param, raw)
+				}
+
+				cmd.Params[kv[0]] = kv[1]
+			}
+		}
+
+		if cmd.Driver == "script" {
+			if cmd.Params["file"] == "" {
+				return nil, fmt.Errorf("missing TCP probe script file param: %s", raw)
+			}
+
+			fullPath, err := filepath.Abs(cmd.Params["file"])
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := tcp.LoadScript(fullPath); err != nil {
+				return nil, fmt.Errorf("invalid TCP probe script (%s): %v", cmd.Params["file"], err)
+			}
+
+			cmd.Params["file"] = fullPath
+		}
+
+		probes = append(probes, cmd)
+	}
+
+	return probes, nil
+}
+
 func isMethod(value string) bool {
 	switch strings.ToUpper(value) {
 	case "HEAD", "GET", "POST", "PUT", "DELETE", "PATCH":
@@ -803,6 +1040,33 @@ func ParseHTTPProbeExecFile(filePath string) ([]string, error) {
 	return appCalls, nil
 }
 
+func ParseGraphQLVarsFile(filePath string) (map[string]map[string]interface{}, error) {
+	if filePath == "" {
+		return nil, nil
+	}
+
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fileData) == 0 {
+		return nil, nil
+	}
+
+	var vars map[string]map[string]interface{}
+	if err := json.Unmarshal(fileData, &vars); err != nil {
+		return nil, fmt.Errorf("malformed GraphQL variables file (%s): %v", filePath, err)
+	}
+
+	return vars, nil
+}
+
 func ParseLinesWithCommentsFile(filePath string) ([]string, error) {
 	var output []string
 
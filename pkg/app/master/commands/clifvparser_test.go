@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestParseTCPProbes(t *testing.T) {
+	scriptFile := filepath.Join(t.TempDir(), "smtp.script")
+	if err := ioutil.WriteFile(scriptFile, []byte("expect 220\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	badScriptFile := filepath.Join(t.TempDir(), "bad.script")
+	if err := ioutil.WriteFile(badScriptFile, []byte("read 220\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		values   []string
+		expected []config.TCPProbeCmd
+		err      string
+	}{
+		{
+			name:   "drivers",
+			values: []string{"6379:redis", "5432:Postgres:user=app,password=p:w=d"},
+			expected: []config.TCPProbeCmd{
+				{Port: 6379, Driver: "redis", Params: map[string]string{}},
+				{Port: 5432, Driver: "postgres", Params: map[string]string{"user": "app", "password": "p:w=d"}},
+			},
+		},
+		{
+			name:   "script",
+			values: []string{"25:script:file=" + scriptFile},
+			expected: []config.TCPProbeCmd{
+				{Port: 25, Driver: "script", Params: map[string]string{"file": scriptFile}},
+			},
+		},
+		{name: "no driver", values: []string{"6379"}, err: "invalid TCP probe: 6379"},
+		{name: "bad port", values: []string{"redis:6379"}, err: "invalid TCP probe port"},
+		{name: "zero port", values: []string{"0:redis"}, err: "invalid TCP probe port"},
+		{name: "large port", values: []string{"65536:redis"}, err: "invalid TCP probe port"},
+		{name: "duplicate port", values: []string{"6379:redis", "6379:memcached"}, err: "duplicate TCP probe port"},
+		{name: "unknown driver", values: []string{"1521:oracle"}, err: "unknown TCP probe driver: oracle (available: "},
+		{name: "bad param", values: []string{"3306:mysql:user"}, err: "invalid TCP probe param: user"},
+		{name: "empty param name", values: []string{"3306:mysql:=root"}, err: "invalid TCP probe param: =root"},
+		{name: "no script file", values: []string{"25:script"}, err: "missing TCP probe script file param"},
+		{name: "bad script", values: []string{"25:script:file=" + badScriptFile}, err: "invalid TCP probe script"},
+	}
+
+	for _, test := range tests {
+		probes, err := ParseTCPProbes(test.values)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)):
+			t.Errorf("%s: expected error %q, got: %v", test.name, test.err, err)
+		case test.err == "" && !reflect.DeepEqual(probes, test.expected):
+			t.Errorf("%s: unexpected probes: %+v", test.name, probes)
+		}
+	}
+}
+
+func TestGetHTTPProbeOptionsGraphQL(t *testing.T) {
+	varsFile := filepath.Join(t.TempDir(), "vars.json")
+	if err := ioutil.WriteFile(varsFile, []byte(`{"user":{"id":"42"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, name := range []string{
+		FlagHTTPProbeGraphQL,
+		FlagHTTPProbeGraphQLMutations,
+		FlagHTTPProbeGraphQLVars,
+	} {
+		if err := Cflag(name).Apply(set); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := set.Parse([]string{
+		"--" + FlagHTTPProbeGraphQL, "/graphql",
+		"--" + FlagHTTPProbeGraphQL, "/api/graphql",
+		"--" + FlagHTTPProbeGraphQLMutations, "createUser",
+		"--" + FlagHTTPProbeGraphQLVars, varsFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := cli.NewContext(cli.NewApp(), set, nil)
+	opts := GetHTTPProbeOptions(app.NewExecutionContext("test", "text"), ctx)
+
+	//the GraphQL endpoints enable the HTTP probing
+	if !opts.Do {
+		t.Error("expected HTTP probing to be enabled")
+	}
+
+	if !reflect.DeepEqual(opts.GraphQLEndpoints, []string{"/graphql", "/api/graphql"}) {
+		t.Errorf("unexpected endpoints: %v", opts.GraphQLEndpoints)
+	}
+
+	if !reflect.DeepEqual(opts.GraphQLMutations, []string{"createUser"}) {
+		t.Errorf("unexpected mutations: %v", opts.GraphQLMutations)
+	}
+
+	if opts.GraphQLVariables["user"]["id"] != "42" {
+		t.Errorf("unexpected variables: %v", opts.GraphQLVariables)
+	}
+}
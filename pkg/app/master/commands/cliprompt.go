@@ -243,6 +243,7 @@ var continueAfterValues = []prompt.Suggest{
 	{Text: config.CAMEnter, Description: "Use the <enter> key to indicate you that you are done using the container"},
 	{Text: config.CAMSignal, Description: "Use SIGUSR1 to signal that you are done using the container"},
 	{Text: config.CAMTimeout, Description: "Continue after the default timeout (60 seconds)"},
+	{Text: config.CAMQuiescence, Description: "Continue after the target app has no new file or syscall activity"},
 	{Text: config.CAMContainerProbe, Description: "Continue after the probed container exits"},
 	{Text: "<seconds>", Description: "Enter the number of seconds to wait instead of <seconds>"},
 }
@@ -255,6 +256,7 @@ var consoleOutputValues = []prompt.Suggest{
 var ipcModeValues = []prompt.Suggest{
 	{Text: "proxy", Description: "Proxy sensor ipc mode"},
 	{Text: "direct", Description: "Direct sensor ipc mode"},
+	{Text: "unix", Description: "Unix domain socket sensor ipc mode"},
 }
 
 func CompleteProgress(ia *InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
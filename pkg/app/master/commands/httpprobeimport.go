@@ -0,0 +1,531 @@
+package commands
+
+//HTTP probe command importers (HAR files and Postman collections)
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+// Request headers that are not copied to the imported HTTP probe commands
+// (the connection specific headers and the headers set by the HTTP client)
+var importSkipHeaders = map[string]struct{}{
+	"host":              {},
+	"content-length":    {},
+	"connection":        {},
+	"keep-alive":        {},
+	"transfer-encoding": {},
+	"upgrade":           {},
+	"te":                {},
+	"accept-encoding":   {},
+}
+
+// Request headers removed when the auth header stripping is enabled
+var importAuthHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"x-api-key":           {},
+	"x-auth-token":        {},
+	"x-csrf-token":        {},
+	"x-xsrf-token":        {},
+}
+
+// HTTPProbeImportOptions provides the filters for the imported HTTP probe commands
+type HTTPProbeImportOptions struct {
+	// Include only the requests with the matching URLs (all requests if empty)
+	Include []*regexp.Regexp
+	// Exclude the requests with the matching URLs
+	Exclude []*regexp.Regexp
+	// Remove the auth headers (and the Postman auth settings)
+	StripAuth bool
+	// Import the HAR requests for all hosts
+	// (only the requests for the host of the first imported request are used by default)
+	AllHosts bool
+}
+
+func ParseHTTPProbeImportOptions(include, exclude []string, stripAuth, allHosts bool) (*HTTPProbeImportOptions, error) {
+	opts := &HTTPProbeImportOptions{
+		StripAuth: stripAuth,
+		AllHosts:  allHosts,
+	}
+
+	for _, pattern := range include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTP probe import include pattern (%s): %v", pattern, err)
+		}
+
+		opts.Include = append(opts.Include, re)
+	}
+
+	for _, pattern := range exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTP probe import exclude pattern (%s): %v", pattern, err)
+		}
+
+		opts.Exclude = append(opts.Exclude, re)
+	}
+
+	return opts, nil
+}
+
+func (o *HTTPProbeImportOptions) match(rawURL string) bool {
+	for _, re := range o.Exclude {
+		if re.MatchString(rawURL) {
+			return false
+		}
+	}
+
+	if len(o.Include) == 0 {
+		return true
+	}
+
+	for _, re := range o.Include {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newImportedHTTPProbeCmd creates the HTTP probe command for the recorded request
+// (the recorded host is replaced with the target, so only the path and the query are kept)
+func (o *HTTPProbeImportOptions) newCmd(method, rawURL string, headers [][2]string, body string) (*config.HTTPProbeCmd, bool) {
+	if !o.match(rawURL) {
+		log.Debugf("HTTP probe import - skipping filtered request: %s %s", method, rawURL)
+		return nil, false
+	}
+
+	if method == "" {
+		method = "GET"
+	}
+
+	method = strings.ToUpper(method)
+	if !isMethod(method) {
+		log.Debugf("HTTP probe import - skipping request with unsupported method: %s %s", method, rawURL)
+		return nil, false
+	}
+
+	resource, err := importResource(rawURL)
+	if err != nil {
+		log.Debugf("HTTP probe import - skipping request with unsupported URL (%s): %v", rawURL, err)
+		return nil, false
+	}
+
+	cmd := &config.HTTPProbeCmd{
+		Method:   method,
+		Resource: resource,
+		Body:     body,
+	}
+
+	for _, header := range headers {
+		name := strings.TrimSpace(header[0])
+		lname := strings.ToLower(name)
+		if name == "" || strings.HasPrefix(name, ":") {
+			//HTTP/2 pseudo-headers
+			continue
+		}
+
+		if _, found := importSkipHeaders[lname]; found {
+			continue
+		}
+
+		if _, found := importAuthHeaders[lname]; found && o.StripAuth {
+			continue
+		}
+
+		cmd.Headers = append(cmd.Headers, fmt.Sprintf("%s: %s", name, header[1]))
+	}
+
+	return cmd, true
+}
+
+func importResource(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "http", "https", "":
+	default:
+		return "", fmt.Errorf("unsupported scheme: %s", u.Scheme)
+	}
+
+	resource := u.EscapedPath()
+	if resource == "" {
+		resource = "/"
+	}
+
+	if !strings.HasPrefix(resource, "/") {
+		resource = "/" + resource
+	}
+
+	if u.RawQuery != "" {
+		resource = fmt.Sprintf("%s?%s", resource, u.RawQuery)
+	}
+
+	return resource, nil
+}
+
+// importHost returns the request URL host (empty if the URL is malformed)
+func importHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Host)
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harLog struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string         `json:"method"`
+				URL      string         `json:"url"`
+				Headers  []harNameValue `json:"headers"`
+				PostData *struct {
+					MimeType string         `json:"mimeType"`
+					Text     string         `json:"text"`
+					Params   []harNameValue `json:"params"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// ParseHTTPProbesHARFile creates the HTTP probe commands from the HAR file requests
+// (in the recorded order)
+func ParseHTTPProbesHARFile(filePath string, opts *HTTPProbeImportOptions) ([]config.HTTPProbeCmd, error) {
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var har harLog
+	if err := json.Unmarshal(fileData, &har); err != nil {
+		return nil, fmt.Errorf("malformed HAR file (%s): %v", filePath, err)
+	}
+
+	//the browser captures include the third-party requests (e.g., analytics or CDNs),
+	//so only the primary host requests are used by default
+	//(the recorded hosts are replaced with the target)
+	var primaryHost string
+	var cmds []config.HTTPProbeCmd
+	for _, entry := range har.Log.Entries {
+		req := entry.Request
+		if !opts.AllHosts && opts.match(req.URL) {
+			host := importHost(req.URL)
+			if primaryHost == "" {
+				primaryHost = host
+			}
+
+			if host != primaryHost {
+				log.Debugf("HTTP probe import - skipping request for another host (%s): %s %s", primaryHost, req.Method, req.URL)
+				continue
+			}
+		}
+
+		var headers [][2]string
+		for _, h := range req.Headers {
+			headers = append(headers, [2]string{h.Name, h.Value})
+		}
+
+		var body string
+		if req.PostData != nil {
+			if !hasImportHeader(headers, "content-type") && req.PostData.MimeType != "" {
+				headers = append(headers, [2]string{"Content-Type", req.PostData.MimeType})
+			}
+
+			body = req.PostData.Text
+			if body == "" && len(req.PostData.Params) > 0 {
+				form := url.Values{}
+				for _, param := range req.PostData.Params {
+					form.Add(param.Name, param.Value)
+				}
+
+				body = form.Encode()
+			}
+		}
+
+		if cmd, ok := opts.newCmd(req.Method, req.URL, headers, body); ok {
+			cmds = append(cmds, *cmd)
+		}
+	}
+
+	return cmds, nil
+}
+
+type postmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanAuth struct {
+	Type   string          `json:"type"`
+	Bearer json.RawMessage `json:"bearer"`
+	Basic  json.RawMessage `json:"basic"`
+}
+
+// params returns the auth type parameters
+// (v2.1 collections use key/value lists and v2.0 collections use objects)
+func (a *postmanAuth) params(raw json.RawMessage) map[string]string {
+	params := map[string]string{}
+	if len(raw) == 0 {
+		return params
+	}
+
+	var list []struct {
+		Key   string      `json:"key"`
+		Value interface{} `json:"value"`
+	}
+
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, kv := range list {
+			params[kv.Key] = fmt.Sprintf("%v", kv.Value)
+		}
+
+		return params
+	}
+
+	json.Unmarshal(raw, &params)
+	return params
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    json.RawMessage   `json:"url"`
+	Auth   *postmanAuth      `json:"auth"`
+	Body   *struct {
+		Mode       string            `json:"mode"`
+		Raw        string            `json:"raw"`
+		URLEncoded []postmanKeyValue `json:"urlencoded"`
+		GraphQL    *struct {
+			Query     string `json:"query"`
+			Variables string `json:"variables"`
+		} `json:"graphql"`
+	} `json:"body"`
+}
+
+// rawURL returns the request URL (the URL can be a string or an object)
+func (r *postmanRequest) rawURL() string {
+	var value string
+	if err := json.Unmarshal(r.URL, &value); err == nil {
+		return value
+	}
+
+	var obj struct {
+		Raw string `json:"raw"`
+	}
+
+	json.Unmarshal(r.URL, &obj)
+	return obj.Raw
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Request json.RawMessage `json:"request"`
+	Item    []postmanItem   `json:"item"`
+	Auth    *postmanAuth    `json:"auth"`
+}
+
+type postmanCollection struct {
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+var postmanVarPattern = regexp.MustCompile(`{{\s*([^{}\s]+)\s*}}`)
+
+// ParseHTTPProbesPostmanFile creates the HTTP probe commands from the Postman collection
+// (v2.0 and v2.1) requests (in the collection order)
+func ParseHTTPProbesPostmanFile(filePath string, opts *HTTPProbeImportOptions) ([]config.HTTPProbeCmd, error) {
+	fullPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileData, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var collection postmanCollection
+	if err := json.Unmarshal(fileData, &collection); err != nil {
+		return nil, fmt.Errorf("malformed Postman collection file (%s): %v", filePath, err)
+	}
+
+	vars := map[string]string{}
+	for _, v := range collection.Variable {
+		vars[v.Key] = v.Value
+	}
+
+	expand := func(value string) string {
+		return postmanVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			name := postmanVarPattern.FindStringSubmatch(ref)[1]
+			if val, found := vars[name]; found {
+				return val
+			}
+
+			return ref
+		})
+	}
+
+	var cmds []config.HTTPProbeCmd
+	var walk func(items []postmanItem, auth *postmanAuth)
+	walk = func(items []postmanItem, auth *postmanAuth) {
+		for _, item := range items {
+			itemAuth := auth
+			if item.Auth != nil {
+				itemAuth = item.Auth
+			}
+
+			if len(item.Item) > 0 {
+				//folder
+				walk(item.Item, itemAuth)
+				continue
+			}
+
+			if len(item.Request) == 0 {
+				continue
+			}
+
+			var req postmanRequest
+			var reqURL string
+			if err := json.Unmarshal(item.Request, &reqURL); err != nil {
+				if err := json.Unmarshal(item.Request, &req); err != nil {
+					log.Debugf("HTTP probe import - skipping malformed Postman request (%s): %v", item.Name, err)
+					continue
+				}
+
+				reqURL = req.rawURL()
+			}
+
+			reqURL = postmanURL(expand(reqURL))
+
+			var headers [][2]string
+			for _, h := range req.Header {
+				if !h.Disabled {
+					headers = append(headers, [2]string{h.Key, expand(h.Value)})
+				}
+			}
+
+			if req.Auth != nil {
+				itemAuth = req.Auth
+			}
+
+			var username, password string
+			if itemAuth != nil && !opts.StripAuth {
+				switch itemAuth.Type {
+				case "bearer":
+					if token := itemAuth.params(itemAuth.Bearer)["token"]; token != "" {
+						headers = append(headers, [2]string{"Authorization", "Bearer " + expand(token)})
+					}
+				case "basic":
+					params := itemAuth.params(itemAuth.Basic)
+					username = expand(params["username"])
+					password = expand(params["password"])
+				}
+			}
+
+			var body string
+			var bodyType string
+			if req.Body != nil {
+				switch req.Body.Mode {
+				case "raw":
+					body = expand(req.Body.Raw)
+				case "urlencoded":
+					bodyType = "application/x-www-form-urlencoded"
+					form := url.Values{}
+					for _, param := range req.Body.URLEncoded {
+						if !param.Disabled {
+							form.Add(param.Key, expand(param.Value))
+						}
+					}
+
+					body = form.Encode()
+				case "graphql":
+					bodyType = "application/json"
+					if req.Body.GraphQL != nil {
+						gql := map[string]interface{}{"query": req.Body.GraphQL.Query}
+						var variables interface{}
+						if err := json.Unmarshal([]byte(expand(req.Body.GraphQL.Variables)), &variables); err == nil {
+							gql["variables"] = variables
+						}
+
+						data, _ := json.Marshal(gql)
+						body = string(data)
+					}
+				default:
+					if req.Body.Mode != "" {
+						log.Debugf("HTTP probe import - unsupported Postman body mode (%s): %s", item.Name, req.Body.Mode)
+					}
+				}
+			}
+
+			if bodyType != "" && !hasImportHeader(headers, "content-type") {
+				//Postman sets the content type for these body modes
+				headers = append(headers, [2]string{"Content-Type", bodyType})
+			}
+
+			if cmd, ok := opts.newCmd(req.Method, reqURL, headers, body); ok {
+				cmd.Username = username
+				cmd.Password = password
+				cmds = append(cmds, *cmd)
+			}
+		}
+	}
+
+	walk(collection.Item, collection.Auth)
+	return cmds, nil
+}
+
+func hasImportHeader(headers [][2]string, name string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header[0]), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// postmanURL normalizes the Postman request URL
+// (the URLs often start with an undefined base URL variable or they don't have a scheme)
+func postmanURL(value string) string {
+	if loc := postmanVarPattern.FindStringIndex(value); loc != nil && loc[0] == 0 {
+		value = value[loc[1]:]
+	}
+
+	if !strings.Contains(value, "://") && !strings.HasPrefix(value, "/") {
+		//host without a scheme
+		value = "http://" + value
+	}
+
+	return value
+}
@@ -0,0 +1,399 @@
+package commands
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func writeImportFile(t *testing.T, name, data string) string {
+	fpath := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(fpath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return fpath
+}
+
+const testHARFile = `{
+  "log": {
+    "entries": [
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://app.example.com/",
+          "headers": [
+            {"name": ":authority", "value": "app.example.com"},
+            {"name": "Host", "value": "app.example.com"},
+            {"name": "Accept", "value": "text/html"},
+            {"name": "Accept-Encoding", "value": "gzip"},
+            {"name": "Cookie", "value": "session=123"}
+          ]
+        }
+      },
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://www.google-analytics.com/collect?v=1"
+        }
+      },
+      {
+        "request": {
+          "method": "post",
+          "url": "https://APP.example.com/api/login?next=%2Fhome",
+          "headers": [
+            {"name": "Authorization", "value": "Basic dXNlcjpwYXNz"},
+            {"name": "Content-Length", "value": "18"}
+          ],
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "params": [
+              {"name": "user", "value": "bob"}
+            ]
+          }
+        }
+      },
+      {
+        "request": {
+          "method": "GET",
+          "url": "https://app.example.com/static/app.css"
+        }
+      },
+      {
+        "request": {
+          "method": "CONNECT",
+          "url": "https://app.example.com:443"
+        }
+      }
+    ]
+  }
+}`
+
+func TestParseHTTPProbesHARFile(t *testing.T) {
+	harFile := writeImportFile(t, "test.har", testHARFile)
+
+	home := config.HTTPProbeCmd{
+		Method:   "GET",
+		Resource: "/",
+		Headers:  []string{"Accept: text/html", "Cookie: session=123"},
+	}
+
+	login := config.HTTPProbeCmd{
+		Method:   "POST",
+		Resource: "/api/login?next=%2Fhome",
+		Headers: []string{
+			"Authorization: Basic dXNlcjpwYXNz",
+			"Content-Type: application/x-www-form-urlencoded",
+		},
+		Body: "user=bob",
+	}
+
+	css := config.HTTPProbeCmd{
+		Method:   "GET",
+		Resource: "/static/app.css",
+	}
+
+	analytics := config.HTTPProbeCmd{
+		Method:   "GET",
+		Resource: "/collect?v=1",
+	}
+
+	tests := []struct {
+		name      string
+		include   []string
+		exclude   []string
+		stripAuth bool
+		allHosts  bool
+		expected  []config.HTTPProbeCmd
+	}{
+		{
+			name:     "primary host",
+			expected: []config.HTTPProbeCmd{home, login, css},
+		},
+		{
+			name:     "all hosts",
+			allHosts: true,
+			expected: []config.HTTPProbeCmd{home, analytics, login, css},
+		},
+		{
+			name:     "exclude",
+			exclude:  []string{`\.css$`},
+			expected: []config.HTTPProbeCmd{home, login},
+		},
+		{
+			//the primary host is selected from the included requests
+			name:     "include",
+			include:  []string{`google-analytics`},
+			expected: []config.HTTPProbeCmd{analytics},
+		},
+		{
+			name:      "strip auth",
+			stripAuth: true,
+			expected: []config.HTTPProbeCmd{
+				{Method: "GET", Resource: "/", Headers: []string{"Accept: text/html"}},
+				{
+					Method:   "POST",
+					Resource: "/api/login?next=%2Fhome",
+					Headers:  []string{"Content-Type: application/x-www-form-urlencoded"},
+					Body:     "user=bob",
+				},
+				css,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		opts, err := ParseHTTPProbeImportOptions(test.include, test.exclude, test.stripAuth, test.allHosts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		cmds, err := ParseHTTPProbesHARFile(harFile, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if !reflect.DeepEqual(cmds, test.expected) {
+			t.Errorf("%s: unexpected commands:\n%+v\nexpected:\n%+v", test.name, cmds, test.expected)
+		}
+	}
+}
+
+// v2.1 collection (object URLs and key/value list auth params)
+const testPostmanV21File = `{
+  "info": {"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [
+    {"key": "baseUrl", "value": "https://api.example.com"},
+    {"key": "token", "value": "secret"},
+    {"key": "userId", "value": "42"}
+  ],
+  "auth": {
+    "type": "bearer",
+    "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]
+  },
+  "item": [
+    {
+      "name": "users",
+      "item": [
+        {
+          "name": "get user",
+          "request": {
+            "method": "GET",
+            "header": [
+              {"key": "Accept", "value": "application/json"},
+              {"key": "X-Debug", "value": "1", "disabled": true}
+            ],
+            "url": {
+              "raw": "{{baseUrl}}/users/{{userId}}?full=true",
+              "host": ["{{baseUrl}}"],
+              "path": ["users", "{{userId}}"]
+            }
+          }
+        },
+        {
+          "name": "create user",
+          "request": {
+            "method": "POST",
+            "url": {"raw": "{{baseUrl}}/users"},
+            "body": {
+              "mode": "urlencoded",
+              "urlencoded": [
+                {"key": "name", "value": "bob"},
+                {"key": "debug", "value": "1", "disabled": true}
+              ]
+            }
+          }
+        }
+      ]
+    },
+    {
+      "name": "graphql",
+      "request": {
+        "method": "POST",
+        "url": {"raw": "{{undefinedBase}}/graphql"},
+        "body": {
+          "mode": "graphql",
+          "graphql": {"query": "{ user(id: 1) { name } }", "variables": "{\"id\": {{userId}}}"}
+        }
+      }
+    },
+    {
+      "name": "basic auth",
+      "request": {
+        "method": "GET",
+        "url": "localhost:8080/admin",
+        "auth": {
+          "type": "basic",
+          "basic": [
+            {"key": "username", "value": "admin"},
+            {"key": "password", "value": "{{token}}"}
+          ]
+        }
+      }
+    }
+  ]
+}`
+
+// v2.0 collection (string URLs and object auth params)
+const testPostmanV20File = `{
+  "info": {"schema": "https://schema.getpostman.com/json/collection/v2.0.0/collection.json"},
+  "variable": [
+    {"key": "host", "value": "api.example.com"}
+  ],
+  "item": [
+    {
+      "name": "status",
+      "request": "http://{{host}}/status"
+    },
+    {
+      "name": "orders",
+      "auth": {
+        "type": "bearer",
+        "bearer": {"token": "v20token"}
+      },
+      "request": {
+        "method": "PUT",
+        "url": "http://{{host}}/orders/1",
+        "header": [{"key": "Content-Type", "value": "application/json"}],
+        "body": {"mode": "raw", "raw": "{\"host\": \"{{host}}\"}"}
+      }
+    },
+    {
+      "name": "basic auth",
+      "request": {
+        "url": "http://{{host}}/admin",
+        "auth": {
+          "type": "basic",
+          "basic": {"username": "admin", "password": "pass"}
+        }
+      }
+    }
+  ]
+}`
+
+func TestParseHTTPProbesPostmanFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		stripAuth bool
+		expected  []config.HTTPProbeCmd
+	}{
+		{
+			name: "v2.1",
+			data: testPostmanV21File,
+			expected: []config.HTTPProbeCmd{
+				{
+					Method:   "GET",
+					Resource: "/users/42?full=true",
+					Headers:  []string{"Accept: application/json", "Authorization: Bearer secret"},
+				},
+				{
+					Method:   "POST",
+					Resource: "/users",
+					Headers:  []string{"Authorization: Bearer secret", "Content-Type: application/x-www-form-urlencoded"},
+					Body:     "name=bob",
+				},
+				{
+					Method:   "POST",
+					Resource: "/graphql",
+					Headers:  []string{"Authorization: Bearer secret", "Content-Type: application/json"},
+					Body:     `{"query":"{ user(id: 1) { name } }","variables":{"id":42}}`,
+				},
+				{
+					Method:   "GET",
+					Resource: "/admin",
+					Username: "admin",
+					Password: "secret",
+				},
+			},
+		},
+		{
+			name:      "v2.1 strip auth",
+			data:      testPostmanV21File,
+			stripAuth: true,
+			expected: []config.HTTPProbeCmd{
+				{
+					Method:   "GET",
+					Resource: "/users/42?full=true",
+					Headers:  []string{"Accept: application/json"},
+				},
+				{
+					Method:   "POST",
+					Resource: "/users",
+					Headers:  []string{"Content-Type: application/x-www-form-urlencoded"},
+					Body:     "name=bob",
+				},
+				{
+					Method:   "POST",
+					Resource: "/graphql",
+					Headers:  []string{"Content-Type: application/json"},
+					Body:     `{"query":"{ user(id: 1) { name } }","variables":{"id":42}}`,
+				},
+				{
+					Method:   "GET",
+					Resource: "/admin",
+				},
+			},
+		},
+		{
+			name: "v2.0",
+			data: testPostmanV20File,
+			expected: []config.HTTPProbeCmd{
+				{
+					Method:   "GET",
+					Resource: "/status",
+				},
+				{
+					Method:   "PUT",
+					Resource: "/orders/1",
+					Headers:  []string{"Content-Type: application/json", "Authorization: Bearer v20token"},
+					Body:     `{"host": "api.example.com"}`,
+				},
+				{
+					Method:   "GET",
+					Resource: "/admin",
+					Username: "admin",
+					Password: "pass",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		postmanFile := writeImportFile(t, "collection.json", test.data)
+		opts, err := ParseHTTPProbeImportOptions(nil, nil, test.stripAuth, false)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		cmds, err := ParseHTTPProbesPostmanFile(postmanFile, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if !reflect.DeepEqual(cmds, test.expected) {
+			t.Errorf("%s: unexpected commands:\n%+v\nexpected:\n%+v", test.name, cmds, test.expected)
+		}
+	}
+}
+
+func TestPostmanURL(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "https://api.example.com/users", expected: "https://api.example.com/users"},
+		{value: "{{baseUrl}}/users", expected: "/users"},
+		{value: "api.example.com/users", expected: "http://api.example.com/users"},
+		{value: "/users?id=1", expected: "/users?id=1"},
+	}
+
+	for _, test := range tests {
+		if actual := postmanURL(test.value); actual != test.expected {
+			t.Errorf("postmanURL(%q) = %q, expected %q", test.value, actual, test.expected)
+		}
+	}
+}
@@ -78,6 +78,8 @@ var CLI = &cli.Command{
 		commands.Cflag(commands.FlagExcludePattern), //should remove too (no need)
 		commands.Cflag(commands.FlagMount),
 		commands.Cflag(commands.FlagContinueAfter),
+		commands.Cflag(commands.FlagQuiescenceWindow),
+		commands.Cflag(commands.FlagQuiescenceTimeout),
 		commands.Cflag(commands.FlagUseLocalMounts),
 		commands.Cflag(commands.FlagUseSensorVolume),
 		//Sensor flags:
@@ -146,6 +148,14 @@ var CLI = &cli.Command{
 			xc.Exit(-1)
 		}
 
+		if httpProbeOpts.Do && ctx.String(commands.FlagNetwork) == "none" {
+			httpProbeOpts.Do = false
+			xc.Out.Info("enter",
+				ovars{
+					"message": "disabling http-probe because the target container has no network (--network none)",
+				})
+		}
+
 		if !httpProbeOpts.Do && continueAfter.Mode == "probe" {
 			continueAfter.Mode = "enter"
 			xc.Out.Info("enter",
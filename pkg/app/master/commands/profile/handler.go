@@ -265,7 +265,9 @@ func OnCommand(
 		logFormat,
 		gparams.InContainer,
 		true,  //rtaSourcePT
+		false, //rtaFileRanges
 		false, //doObfuscateMetadata
+		nil,   //excludePhases
 		sensorIPCEndpoint,
 		sensorIPCMode,
 		printState,
@@ -287,6 +289,7 @@ func OnCommand(
 
 	logger.Info("starting instrumented 'fat' container...")
 	err = containerInspector.RunContainer()
+	cmdReport.Sensor = containerInspector.SensorInfo
 	errutil.FailOn(err)
 
 	xc.Out.Info("container",
@@ -330,13 +333,18 @@ func OnCommand(
 	}
 
 	continueAfterMsg := "provide the expected input to allow the container inspector to continue its execution"
-	switch continueAfter.Mode {
-	case config.CAMTimeout:
+	if continueAfter.Mode == config.CAMTimeout {
 		continueAfterMsg = "no input required, execution will resume after the timeout"
-	case config.CAMProbe:
+	}
+
+	if commands.HasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
 		continueAfterMsg = "no input required, execution will resume when HTTP probing is completed"
 	}
 
+	if commands.HasContinueAfterMode(continueAfter.Mode, config.CAMQuiescence) {
+		continueAfterMsg = "no input required, execution will resume when the target app has no new activity"
+	}
+
 	xc.Out.Info("continue.after",
 		ovars{
 			"mode":    continueAfter.Mode,
@@ -434,6 +442,15 @@ func OnCommand(
 				xc.Out.State("exited", ovars{"exit.code": -1})
 				xc.Exit(-1)
 			}
+		case config.CAMQuiescence:
+			if probe != nil && !commands.HasContinueAfterMode(continueAfter.Mode, config.CAMProbe) {
+				xc.Out.Prompt("waiting for the HTTP probe to finish")
+				<-probe.DoneChan()
+			}
+
+			commands.WaitForQuiescence(xc, continueAfter, func() time.Time {
+				return containerInspector.MonitorActivity().LastActivityTime()
+			})
 		case config.CAMHostExec:
 			commands.RunHostExecProbes(printState, xc, hostExecProbes)
 		case config.CAMAppExit:
@@ -444,6 +461,20 @@ func OnCommand(
 		}
 	}
 
+	if probe != nil {
+		//the failed assertions are checked in all modes where the probe is done
+		//(e.g., 'probe&quiescence' too)
+		if probe.IsDone() && probe.AssertFailCount > 0 && httpProbeOpts.ExitOnFailure {
+			xc.Out.Error("probe.error", "failed.assertions")
+
+			containerInspector.ShowContainerLogs()
+			xc.Out.State("exited", ovars{"exit.code": -1})
+			xc.Exit(-1)
+		}
+
+		cmdReport.APISpecCoverage = probe.APISpecCoverageReport()
+	}
+
 	xc.Out.State("container.inspection.finishing")
 
 	containerInspector.FinishMonitoring()
@@ -26,6 +26,10 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeCmd), Description: commands.FlagHTTPProbeCmdUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeCmdFile), Description: commands.FlagHTTPProbeCmdFileUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeStartWait), Description: commands.FlagHTTPProbeStartWaitUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyURL), Description: commands.FlagHTTPProbeReadyURLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyStatus), Description: commands.FlagHTTPProbeReadyStatusUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyTimeout), Description: commands.FlagHTTPProbeReadyTimeoutUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeReadyHealthcheck), Description: commands.FlagHTTPProbeReadyHealthcheckUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeRetryCount), Description: commands.FlagHTTPProbeRetryCountUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeRetryWait), Description: commands.FlagHTTPProbeRetryWaitUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbePorts), Description: commands.FlagHTTPProbePortsUsage},
@@ -36,8 +40,33 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagHTTPCrawlMaxPageCount), Description: commands.FlagHTTPCrawlMaxPageCountUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPCrawlConcurrency), Description: commands.FlagHTTPCrawlConcurrencyUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPMaxConcurrentCrawlers), Description: commands.FlagHTTPMaxConcurrentCrawlersUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPCrawlSitemap), Description: commands.FlagHTTPCrawlSitemapUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPCrawlForms), Description: commands.FlagHTTPCrawlFormsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPCrawlPostForms), Description: commands.FlagHTTPCrawlPostFormsUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpec), Description: commands.FlagHTTPProbeAPISpecUsage},
 		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile), Description: commands.FlagHTTPProbeAPISpecFileUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAPISpecAuth), Description: commands.FlagHTTPProbeAPISpecAuthUsage},
+		{Text: commands.FullFlagName(commands.FlagGRPCProbe), Description: commands.FlagGRPCProbeUsage},
+		{Text: commands.FullFlagName(commands.FlagGRPCProbeProtoset), Description: commands.FlagGRPCProbeProtosetUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeGraphQL), Description: commands.FlagHTTPProbeGraphQLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeGraphQLMutations), Description: commands.FlagHTTPProbeGraphQLMutationsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeGraphQLVars), Description: commands.FlagHTTPProbeGraphQLVarsUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeScenario), Description: commands.FlagHTTPProbeScenarioUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeHAR), Description: commands.FlagHTTPProbeHARUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbePostman), Description: commands.FlagHTTPProbePostmanUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportInclude), Description: commands.FlagHTTPProbeImportIncludeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportExclude), Description: commands.FlagHTTPProbeImportExcludeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportStripAuth), Description: commands.FlagHTTPProbeImportStripAuthUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeImportAllHosts), Description: commands.FlagHTTPProbeImportAllHostsUsage},
+		{Text: commands.FullFlagName(commands.FlagTCPProbe), Description: commands.FlagTCPProbeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthBearer), Description: commands.FlagHTTPProbeAuthBearerUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2TokenURL), Description: commands.FlagHTTPProbeAuthOAuth2TokenURLUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2ClientID), Description: commands.FlagHTTPProbeAuthOAuth2ClientIDUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2ClientSecret), Description: commands.FlagHTTPProbeAuthOAuth2ClientSecretUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeAuthOAuth2Scope), Description: commands.FlagHTTPProbeAuthOAuth2ScopeUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientCert), Description: commands.FlagHTTPProbeClientCertUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeClientKey), Description: commands.FlagHTTPProbeClientKeyUsage},
+		{Text: commands.FullFlagName(commands.FlagHTTPProbeCACert), Description: commands.FlagHTTPProbeCACertUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishPort), Description: commands.FlagPublishPortUsage},
 		{Text: commands.FullFlagName(commands.FlagPublishExposedPorts), Description: commands.FlagPublishExposedPortsUsage},
 		{Text: commands.FullFlagName(commands.FlagHostExec), Description: commands.FlagHostExecUsage},
@@ -64,25 +93,41 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagExcludePattern), Description: commands.FlagExcludePatternUsage},
 		{Text: commands.FullFlagName(commands.FlagMount), Description: commands.FlagMountUsage},
 		{Text: commands.FullFlagName(commands.FlagContinueAfter), Description: commands.FlagContinueAfterUsage},
+		{Text: commands.FullFlagName(commands.FlagQuiescenceWindow), Description: commands.FlagQuiescenceWindowUsage},
+		{Text: commands.FullFlagName(commands.FlagQuiescenceTimeout), Description: commands.FlagQuiescenceTimeoutUsage},
 		{Text: commands.FullFlagName(commands.FlagUseLocalMounts), Description: commands.FlagUseLocalMountsUsage},
 		{Text: commands.FullFlagName(commands.FlagUseSensorVolume), Description: commands.FlagUseSensorVolumeUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCMode), Description: commands.FlagSensorIPCModeUsage},
 		{Text: commands.FullFlagName(commands.FlagSensorIPCEndpoint), Description: commands.FlagSensorIPCEndpointUsage},
 	},
 	Values: map[string]commands.CompleteValue{
-		commands.FullFlagName(commands.FlagPull):                   commands.CompleteBool,
-		commands.FullFlagName(commands.FlagShowPullLogs):           commands.CompleteBool,
-		commands.FullFlagName(commands.FlagTarget):                 commands.CompleteTarget,
-		commands.FullFlagName(commands.FlagShowContainerLogs):      commands.CompleteBool,
-		commands.FullFlagName(commands.FlagPublishExposedPorts):    commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeOff):           commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbe):              commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeCmdFile):       commands.CompleteFile,
-		commands.FullFlagName(commands.FlagHTTPProbeFull):          commands.CompleteBool,
-		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure): commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeCrawl):         commands.CompleteTBool,
-		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):   commands.CompleteFile,
-		commands.FullFlagName(commands.FlagHostExecFile):           commands.CompleteFile,
+		commands.FullFlagName(commands.FlagPull):                     commands.CompleteBool,
+		commands.FullFlagName(commands.FlagShowPullLogs):             commands.CompleteBool,
+		commands.FullFlagName(commands.FlagTarget):                   commands.CompleteTarget,
+		commands.FullFlagName(commands.FlagShowContainerLogs):        commands.CompleteBool,
+		commands.FullFlagName(commands.FlagPublishExposedPorts):      commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeOff):             commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbe):                commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeCmdFile):         commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeFull):            commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeExitOnFailure):   commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPProbeCrawl):           commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPCrawlSitemap):         commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPCrawlForms):           commands.CompleteTBool,
+		commands.FullFlagName(commands.FlagHTTPCrawlPostForms):       commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeAPISpecFile):     commands.CompleteFile,
+		commands.FullFlagName(commands.FlagGRPCProbe):                commands.CompleteBool,
+		commands.FullFlagName(commands.FlagGRPCProbeProtoset):        commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeGraphQLVars):     commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeScenario):        commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientCert):      commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeClientKey):       commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeCACert):          commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeHAR):             commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbePostman):         commands.CompleteFile,
+		commands.FullFlagName(commands.FlagHTTPProbeImportStripAuth): commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHTTPProbeImportAllHosts):  commands.CompleteBool,
+		commands.FullFlagName(commands.FlagHostExecFile):             commands.CompleteFile,
 		//commands.FullFlagName(commands.FlagKeepPerms):              commands.CompleteTBool,
 		commands.FullFlagName(commands.FlagRunTargetAsUser):     commands.CompleteTBool,
 		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
@@ -96,7 +141,8 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(commands.FlagUseLocalMounts):  commands.CompleteBool,
 		commands.FullFlagName(commands.FlagUseSensorVolume): commands.CompleteVolume,
 		//commands.FullFlagName(commands.FlagKeepTmpArtifacts):       commands.CompleteBool,
-		commands.FullFlagName(commands.FlagCROHostConfigFile): commands.CompleteFile,
-		commands.FullFlagName(commands.FlagSensorIPCMode):     commands.CompleteIPCMode,
+		commands.FullFlagName(commands.FlagCROHostConfigFile):         commands.CompleteFile,
+		commands.FullFlagName(commands.FlagSensorIPCMode):             commands.CompleteIPCMode,
+		commands.FullFlagName(commands.FlagHTTPProbeReadyHealthcheck): commands.CompleteTBool,
 	},
 }
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const quiescenceCheckInterval = 1 * time.Second
+
+// WaitForQuiescence waits until the monitored app has no new activity (new files or new syscalls)
+// for the configured window or until the hard timeout expires. The lastActivity callback
+// returns the last time new activity was observed by the sensor.
+func WaitForQuiescence(
+	xc *app.ExecutionContext,
+	continueAfter *config.ContinueAfter,
+	lastActivity func() time.Time,
+) {
+	xc.Out.Prompt(fmt.Sprintf("waiting for the target app to become quiet (window: %v, timeout: %v)",
+		continueAfter.QuiescenceWindow, continueAfter.QuiescenceTimeout))
+
+	startTime := time.Now()
+	var timeoutCh <-chan time.Time
+	if continueAfter.QuiescenceTimeout > 0 {
+		timeoutCh = time.After(continueAfter.QuiescenceTimeout)
+	}
+
+	ticker := time.NewTicker(quiescenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeoutCh:
+			xc.Out.Info("event",
+				ovars{
+					"message": "quiescence timeout - done waiting for the target app",
+					"elapsed": time.Since(startTime).Round(time.Second),
+				})
+			return
+
+		case <-ticker.C:
+			since := lastActivity()
+			//the quiet window starts when we start waiting (e.g., after the probes are done)
+			if since.Before(startTime) {
+				since = startTime
+			}
+
+			if quietTime := time.Since(since); quietTime >= continueAfter.QuiescenceWindow {
+				xc.Out.Info("event",
+					ovars{
+						"message":    "target app is quiet - done waiting for the target app",
+						"quiet.time": quietTime.Round(time.Second),
+						"elapsed":    time.Since(startTime).Round(time.Second),
+					})
+				return
+			}
+		}
+	}
+}
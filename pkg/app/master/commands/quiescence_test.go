@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestWaitForQuiescence(t *testing.T) {
+	xc := app.NewExecutionContext("test", "text")
+
+	//no activity after the start of the wait
+	startTime := time.Now()
+	WaitForQuiescence(xc,
+		&config.ContinueAfter{
+			QuiescenceWindow:  time.Second,
+			QuiescenceTimeout: 10 * time.Second,
+		},
+		func() time.Time { return startTime.Add(-time.Hour) })
+
+	if elapsed := time.Since(startTime); elapsed < time.Second || elapsed > 5*time.Second {
+		t.Errorf("unexpected quiet wait time: %v", elapsed)
+	}
+
+	//constant activity (the hard timeout is used)
+	startTime = time.Now()
+	WaitForQuiescence(xc,
+		&config.ContinueAfter{
+			QuiescenceWindow:  time.Minute,
+			QuiescenceTimeout: 1500 * time.Millisecond,
+		},
+		time.Now)
+
+	if elapsed := time.Since(startTime); elapsed < 1500*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("unexpected timeout wait time: %v", elapsed)
+	}
+}
@@ -53,6 +53,7 @@ var CLI = &cli.Command{
 		cflag(FlagShowSpecialPerms),
 		cflag(FlagChangeDataHash),
 		cflag(FlagExportAllDataArtifacts),
+		cflag(FlagFileRangesCreportFile),
 		commands.Cflag(commands.FlagRemoveFileArtifacts),
 	},
 	Action: func(ctx *cli.Context) error {
@@ -84,6 +85,7 @@ var CLI = &cli.Command{
 		doDetectAllCertPKFiles := ctx.Bool(FlagDetectAllCertPKFiles)
 
 		xdArtifactsPath := ctx.String(FlagExportAllDataArtifacts)
+		fileRangesCreportFile := ctx.String(FlagFileRangesCreportFile)
 
 		doPull := ctx.Bool(commands.FlagPull)
 		dockerConfigPath := ctx.String(commands.FlagDockerConfigPath)
@@ -260,6 +262,7 @@ var CLI = &cli.Command{
 			doDetectAllCertFiles,
 			doDetectAllCertPKFiles,
 			xdArtifactsPath,
+			fileRangesCreportFile,
 		)
 
 		return nil
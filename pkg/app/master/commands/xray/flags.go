@@ -31,6 +31,7 @@ const (
 	FlagExportAllDataArtifacts = "export-all-data-artifacts"
 	FlagDetectAllCertFiles     = "detect-all-certs"
 	FlagDetectAllCertPKFiles   = "detect-all-cert-pks"
+	FlagFileRangesCreportFile  = "file-ranges-creport-file"
 )
 
 // Xray command flag usage info
@@ -59,6 +60,7 @@ const (
 	FlagExportAllDataArtifactsUsage = "TAR archive file path to export all text data artifacts (if value is set to `.` then the archive file path defaults to `./data-artifacts.tar`)"
 	FlagDetectAllCertFilesUsage     = "Detect all certifcate files"
 	FlagDetectAllCertPKFilesUsage   = "Detect all certifcate private key files"
+	FlagFileRangesCreportFileUsage  = "Container report file (creport.json from a 'build' run with --rta-file-ranges) to report the bytes accessed vs the file size for the large image files (analysis only)"
 )
 
 var Flags = map[string]cli.Flag{
@@ -197,6 +199,11 @@ var Flags = map[string]cli.Flag{
 		Usage:   FlagDetectAllCertPKFilesUsage,
 		EnvVars: []string{"DSLIM_XRAY_DETECT_ALL_CERT_PKS"},
 	},
+	FlagFileRangesCreportFile: &cli.StringFlag{
+		Name:    FlagFileRangesCreportFile,
+		Usage:   FlagFileRangesCreportFileUsage,
+		EnvVars: []string{"DSLIM_XRAY_FILE_RANGES_CREPORT"},
+	},
 }
 
 func cflag(name string) cli.Flag {
@@ -1,7 +1,9 @@
 package xray
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -38,6 +40,8 @@ const (
 
 const (
 	fatDockerfileName = "Dockerfile.fat"
+
+	maxSparseAccessCandidates = 10
 )
 
 const (
@@ -83,6 +87,7 @@ func OnCommand(
 	doDetectAllCertFiles bool,
 	doDetectAllCertPKFiles bool,
 	xdArtifactsPath string,
+	fileRangesCreportFile string,
 ) {
 	const cmdName = Name
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
@@ -517,6 +522,33 @@ func OnCommand(
 		}
 	}
 
+	if fileRangesCreportFile != "" {
+		candidates, err := sparseAccessCandidates(fileRangesCreportFile, imagePkg)
+		if err != nil {
+			xc.Out.Error("param.file.ranges.creport", err.Error())
+			xc.Out.State("exited",
+				ovars{
+					"exit.code": -1,
+				})
+			xc.Exit(-1)
+		}
+
+		cmdReport.SparseAccessCandidates = candidates
+		for idx, props := range candidates {
+			if idx == maxSparseAccessCandidates {
+				break
+			}
+
+			xc.Out.Info("sparse.access",
+				ovars{
+					"file":           props.FilePath,
+					"file_size":      props.FileSize,
+					"bytes_accessed": props.BytesAccessed,
+					"bytes_mapped":   props.BytesMapped,
+				})
+		}
+	}
+
 	xc.Out.State("completed")
 	cmdReport.State = command.StateCompleted
 
@@ -574,6 +606,32 @@ func OnCommand(
 	}
 }
 
+// sparseAccessCandidates returns the sparse access candidates
+// (using the file ranges in the container report) that are in the image
+func sparseAccessCandidates(creportPath string, pkg *dockerimage.Package) ([]*report.ArtifactProps, error) {
+	creportData, err := ioutil.ReadFile(creportPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var creport report.ContainerReport
+	if err := json.Unmarshal(creportData, &creport); err != nil {
+		return nil, err
+	}
+
+	var candidates []*report.ArtifactProps
+	for _, props := range creport.Image.SparseAccessCandidates(
+		report.SparseAccessMinFileSize,
+		report.SparseAccessMaxRatio,
+		0) {
+		if findChange(pkg, props.FilePath) != nil {
+			candidates = append(candidates, props)
+		}
+	}
+
+	return candidates, nil
+}
+
 func findChange(pkg *dockerimage.Package, filepath string) *dockerimage.ObjectMetadata {
 	for _, layer := range pkg.Layers {
 		if object, found := layer.References[filepath]; found {
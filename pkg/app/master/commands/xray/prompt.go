@@ -42,6 +42,7 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagDetectAllCertFiles), Description: FlagDetectAllCertFilesUsage},
 		{Text: commands.FullFlagName(FlagDetectAllCertPKFiles), Description: FlagDetectAllCertPKFilesUsage},
 		{Text: commands.FullFlagName(FlagExportAllDataArtifacts), Description: FlagExportAllDataArtifactsUsage},
+		{Text: commands.FullFlagName(FlagFileRangesCreportFile), Description: FlagFileRangesCreportFileUsage},
 		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
 	},
 	Values: map[string]commands.CompleteValue{
@@ -60,6 +61,7 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(FlagReuseSavedImage):              commands.CompleteTBool,
 		commands.FullFlagName(FlagDetectAllCertFiles):           commands.CompleteBool,
 		commands.FullFlagName(FlagDetectAllCertPKFiles):         commands.CompleteBool,
+		commands.FullFlagName(FlagFileRangesCreportFile):        commands.CompleteFile,
 		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
 	},
 }
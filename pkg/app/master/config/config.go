@@ -138,6 +138,8 @@ const (
 	ProtoHTTP2C = "http2c"
 	ProtoWS     = "ws"
 	ProtoWSS    = "wss"
+	ProtoGRPC   = "grpc"
+	ProtoGRPCS  = "grpcs"
 )
 
 func IsProto(value string) bool {
@@ -147,7 +149,9 @@ func IsProto(value string) bool {
 		ProtoHTTP2,
 		ProtoHTTP2C,
 		ProtoWS,
-		ProtoWSS:
+		ProtoWSS,
+		ProtoGRPC,
+		ProtoGRPCS:
 		return true
 	default:
 		return false
@@ -167,9 +171,71 @@ type HTTPProbeCmd struct {
 	Password string   `json:"password"`
 	Crawl    bool     `json:"crawl"`
 
+	// Auth overrides the global probe auth settings for the command
+	Auth *HTTPProbeAuth `json:"auth,omitempty"`
+
 	FastCGI *FastCGIProbeWrapperConfig `json:"fastcgi,omitempty"`
 }
 
+// HTTPProbeAuth provides the HTTP probe authentication settings
+type HTTPProbeAuth struct {
+	// Static bearer token
+	BearerToken string `json:"bearer_token,omitempty"`
+	// OAuth2 client credentials flow (used instead of the static bearer token)
+	OAuth2 *HTTPProbeOAuth2 `json:"oauth2,omitempty"`
+	// Client TLS certificate and key files (PEM) for the mTLS services
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// CA bundle file (PEM) to verify the target certificates
+	CACert string `json:"ca_cert,omitempty"`
+}
+
+// HTTPProbeOAuth2 provides the OAuth2 client credentials flow settings
+type HTTPProbeOAuth2 struct {
+	// Token endpoint URL (a path is resolved using the probe target address)
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+	// Extra token request params (e.g., 'audience')
+	Params map[string]string `json:"params,omitempty"`
+	// Send the client credentials in the request body (instead of using basic auth)
+	CredentialsInBody bool `json:"credentials_in_body,omitempty"`
+}
+
+// IsEmpty returns true if no auth settings are configured
+func (a *HTTPProbeAuth) IsEmpty() bool {
+	return a == nil ||
+		(a.BearerToken == "" &&
+			a.OAuth2 == nil &&
+			a.ClientCert == "" &&
+			a.ClientKey == "" &&
+			a.CACert == "")
+}
+
+// Merge returns the auth settings with the non-empty values overridden by the provided settings
+func (a HTTPProbeAuth) Merge(override *HTTPProbeAuth) HTTPProbeAuth {
+	if override == nil {
+		return a
+	}
+
+	if override.BearerToken != "" || override.OAuth2 != nil {
+		a.BearerToken = override.BearerToken
+		a.OAuth2 = override.OAuth2
+	}
+
+	if override.ClientCert != "" {
+		a.ClientCert = override.ClientCert
+		a.ClientKey = override.ClientKey
+	}
+
+	if override.CACert != "" {
+		a.CACert = override.CACert
+	}
+
+	return a
+}
+
 // FastCGI permits fine-grained configuration of the fastcgi RoundTripper.
 type FastCGIProbeWrapperConfig struct {
 	// Root is the fastcgi root directory.
@@ -200,6 +266,68 @@ type HTTPProbeCmds struct {
 	Commands []HTTPProbeCmd `json:"commands"`
 }
 
+// HTTPProbeScenario provides a sequence of dependent HTTP probe steps
+// (the steps share the cookies and the captured variables)
+type HTTPProbeScenario struct {
+	Name     string            `json:"name"`
+	Protocol string            `json:"protocol"`
+	Vars     map[string]string `json:"vars"`
+	Steps    []HTTPProbeStep   `json:"steps"`
+}
+
+// HTTPProbeStep provides the HTTP probe scenario step parameters.
+// The request fields can reference the scenario variables ("{{name}}").
+type HTTPProbeStep struct {
+	Name     string   `json:"name"`
+	Method   string   `json:"method"`
+	Resource string   `json:"resource"`
+	Headers  []string `json:"headers"`
+	Body     string   `json:"body"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+
+	Capture []HTTPProbeCapture `json:"capture"`
+	Assert  *HTTPProbeAssert   `json:"assert,omitempty"`
+
+	// Condition to run the step (skipped if false)
+	When string `json:"when"`
+	// Max number of step executions (stops early when the 'until' condition is true)
+	Repeat int    `json:"repeat"`
+	Until  string `json:"until"`
+	// Step to continue with (the next step by default)
+	Goto string `json:"goto"`
+}
+
+// HTTPProbeCapture selects the response value saved in the scenario variable
+// (from the JSON body path, the header or the cookie)
+type HTTPProbeCapture struct {
+	Var    string `json:"var"`
+	JSON   string `json:"json"`
+	Header string `json:"header"`
+	Cookie string `json:"cookie"`
+}
+
+// HTTPProbeAssert provides the HTTP probe scenario step response checks
+type HTTPProbeAssert struct {
+	Status       []int             `json:"status"`
+	BodyContains string            `json:"body_contains"`
+	JSON         map[string]string `json:"json"`
+	// Max response latency in milliseconds
+	MaxLatency int `json:"max_latency"`
+}
+
+// HTTPProbeScenarios is a list of HTTPProbeScenario instances
+type HTTPProbeScenarios struct {
+	Scenarios []HTTPProbeScenario `json:"scenarios"`
+}
+
+// TCPProbeCmd provides the non-HTTP TCP protocol probe configuration for a port
+type TCPProbeCmd struct {
+	Port   uint16
+	Driver string
+	Params map[string]string
+}
+
 // DockerClient provides Docker client parameters
 type DockerClient struct {
 	UseTLS      bool
@@ -218,6 +346,7 @@ const (
 	CAMExec           = "exec"
 	CAMHostExec       = "host-exec"
 	CAMAppExit        = "app-exit"
+	CAMQuiescence     = "quiescence"
 )
 
 // ContinueAfter provides the command execution mode parameters
@@ -225,6 +354,10 @@ type ContinueAfter struct {
 	Mode         string
 	Timeout      time.Duration
 	ContinueChan <-chan struct{}
+
+	//quiescence mode parameters
+	QuiescenceWindow  time.Duration
+	QuiescenceTimeout time.Duration
 }
 
 type HTTPProbeOptions struct {
@@ -232,23 +365,65 @@ type HTTPProbeOptions struct {
 	Full          bool
 	ExitOnFailure bool
 
-	Cmds  []HTTPProbeCmd
-	Ports []uint16
+	Cmds      []HTTPProbeCmd
+	Scenarios []HTTPProbeScenario
+	Ports     []uint16
+
+	// Global auth settings (the command auth settings override them)
+	Auth HTTPProbeAuth
+
+	// Non-HTTP protocol probes (the probed ports are excluded from the HTTP probes)
+	TCPProbes []TCPProbeCmd
 
 	StartWait  int
 	RetryCount int
 	RetryWait  int
 
+	// Readiness detection (the probes start as soon as the target app is ready):
+	// the readiness URL (a path uses the first probe port) with the expected status
+	// (0 means any non-5xx status), the max wait time (in seconds)
+	// and the image health check use (if the image has a 'HEALTHCHECK' instruction)
+	ReadyURL         string
+	ReadyStatus      int
+	ReadyTimeout     int
+	ReadyHealthcheck bool
+
 	CrawlMaxDepth       int
 	CrawlMaxPageCount   int
 	CrawlConcurrency    int
 	CrawlConcurrencyMax int
+	// Seed the crawler with the robots.txt and sitemap.xml paths
+	CrawlSitemap bool
+	// Submit the GET (and optionally the POST) forms with synthetic values
+	CrawlForms     bool
+	CrawlPostForms bool
 
 	APISpecs     []string
 	APISpecFiles []string
+	// Security scheme credentials (keyed by the scheme names)
+	APISpecCredentials map[string]string
+
+	// gRPC service discovery (server reflection or protoset files)
+	GRPC          bool
+	GRPCProtosets []string
+
+	// GraphQL endpoint paths (probed using the schema introspection)
+	GraphQLEndpoints []string
+	// Allowed top-level mutation fields
+	GraphQLMutations []string
+	// Argument values (keyed by "<field>" or "<Type>.<field>")
+	GraphQLVariables map[string]map[string]interface{}
 
 	ProxyEndpoint string
 	ProxyPort     int
+
+	// Recording proxy for the interactive continue-after modes
+	Record     bool
+	RecordPort int
+	RecordFile string
+	RecordHost string
+	// Keep the auth headers (Authorization and Cookie) in the recorded commands
+	RecordAuth bool
 }
 
 type AppNodejsInspectOptions struct {
@@ -271,6 +446,10 @@ type KubernetesOptions struct {
 
 	Manifests  []string
 	Kubeconfig string
+
+	// Attach to a running workload pod instead of patching and restarting the workload
+	Attach         bool
+	AttachDuration time.Duration
 }
 
 type KubernetesTarget struct {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -39,6 +40,8 @@ import (
 const (
 	SensorIPCModeDirect = "direct"
 	SensorIPCModeProxy  = "proxy"
+	SensorIPCModeUnix   = "unix"
+	SensorIPCSocketDir  = "/opt/_slim/ipc"
 	SensorBinPath       = "/opt/_slim/bin/slim-sensor"
 	ContainerNamePat    = "slimk_%v_%v"
 	ArtifactsDir        = "artifacts"
@@ -64,6 +67,7 @@ var ErrStartMonitorTimeout = goerr.New("start monitor timeout")
 
 const (
 	sensorVolumeBaseName = "slim-sensor"
+	maxUnixSocketPathLen = 104
 )
 
 type NetNameInfo struct {
@@ -81,6 +85,7 @@ type Inspector struct {
 	ContainerPortsInfo    string
 	ContainerPortList     string
 	AvailablePorts        map[dockerapi.Port]dockerapi.PortBinding // Ports found to be available for probing.
+	SensorInfo            *report.SensorInfo                       // Sensor version and capabilities (from the IPC handshake).
 	ContainerID           string
 	ContainerName         string
 	FatContainerCmd       []string
@@ -129,14 +134,20 @@ type Inspector struct {
 	PrintState            bool
 	InContainer           bool
 	RTASourcePT           bool
+	RTAFileRanges         bool
 	DoObfuscateMetadata   bool
+	ExcludePhases         []string
 	SensorIPCEndpoint     string
 	SensorIPCMode         string
 	TargetHost            string
 	dockerEventCh         chan *dockerapi.APIEvents
 	dockerEventStopCh     chan struct{}
 	isDone                aflag.Type
+	isExited              aflag.Type
 	ipcClient             *ipc.Client
+	ipcAuthKey            string
+	ipcSocketDir          string
+	sensorEvents          *sensorEventStream
 	logger                *log.Entry
 	xc                    *app.ExecutionContext
 	crOpts                *config.ContainerRunOptions
@@ -205,7 +216,9 @@ func NewInspector(
 	logFormat string,
 	inContainer bool,
 	rtaSourcePT bool,
+	rtaFileRanges bool,
 	doObfuscateMetadata bool,
+	excludePhases []string,
 	sensorIPCEndpoint string,
 	sensorIPCMode string,
 	printState bool,
@@ -258,7 +271,9 @@ func NewInspector(
 		PrintState:            printState,
 		InContainer:           inContainer,
 		RTASourcePT:           rtaSourcePT,
+		RTAFileRanges:         rtaFileRanges,
 		DoObfuscateMetadata:   doObfuscateMetadata,
+		ExcludePhases:         excludePhases,
 		SensorIPCEndpoint:     sensorIPCEndpoint,
 		SensorIPCMode:         sensorIPCMode,
 		xc:                    xc,
@@ -424,8 +439,14 @@ func (i *Inspector) RunContainer() error {
 	}
 
 	var err error
+
+	//per-run IPC key to authenticate the sensor channels
+	if i.ipcAuthKey, err = channel.GenerateAuthKey(); err != nil {
+		return err
+	}
+
 	var volumeName string
-	if !i.DoUseLocalMounts {
+	if !i.DoUseLocalMounts || i.useUnixIPC() {
 		volumeName, err = ensureSensorVolume(i.logger, i.APIClient, sensorPath, i.SensorVolumeName)
 		errutil.FailOn(err)
 	}
@@ -482,11 +503,39 @@ func (i *Inspector) RunContainer() error {
 
 	//volumeBinds = append(volumeBinds, sensorMountInfo)
 
+	if i.useUnixIPC() {
+		i.ipcSocketDir, err = i.unixIPCSocketDir(volumeName)
+		switch {
+		case err == nil:
+			i.SensorIPCMode = SensorIPCModeUnix
+			vm := dockerapi.HostMount{
+				Type:   "volume",
+				Source: volumeName,
+				Target: SensorIPCSocketDir,
+			}
+
+			mkey := fmt.Sprintf("%s:%s:%s", vm.Type, vm.Source, vm.Target)
+			allMountsMap[mkey] = vm
+		case i.SensorIPCMode == SensorIPCModeUnix:
+			return err
+		case i.IsNetworkNone():
+			//the TCP-based IPC modes can't work without the container network
+			return fmt.Errorf("target container has no network and the Unix domain socket IPC mode is not available (the sensor volume must be accessible on this host, so a remote Docker daemon or Docker Desktop can't be used with --network none): %w", err)
+		default:
+			i.logger.Warnf("can't use the Unix domain socket IPC mode (using the default mode) - %v", err)
+		}
+	}
+
 	var containerCmd []string
 	if i.DoDebug {
 		containerCmd = append(containerCmd, "-d")
 	}
 
+	if i.ipcSocketDir != "" {
+		containerCmd = append(containerCmd,
+			"-ipc-socket-dir", path.Join(SensorIPCSocketDir, filepath.Base(i.ipcSocketDir)))
+	}
+
 	if i.LogLevel != "" {
 		containerCmd = append(containerCmd, "-log-level", i.LogLevel)
 	}
@@ -540,7 +589,7 @@ func (i *Inspector) RunContainer() error {
 			Image:      i.ImageInspector.ImageRef,
 			Entrypoint: []string{SensorBinPath},
 			Cmd:        containerCmd,
-			Env:        i.Overrides.Env,
+			Env:        append(append([]string{}, i.Overrides.Env...), channel.AuthKeyEnvVar+"="+i.ipcAuthKey),
 			Labels:     labels,
 			Hostname:   i.Overrides.Hostname,
 			WorkingDir: i.Overrides.Workdir,
@@ -674,6 +723,7 @@ func (i *Inspector) RunContainer() error {
 
 				if devent.ID == i.ContainerID {
 					if devent.Status == "die" {
+						i.isExited.On()
 						nonZeroExitCode := false
 						if exitCodeStr, ok := devent.Actor.Attributes["exitCode"]; ok && exitCodeStr != "" && exitCodeStr != "0" {
 							nonZeroExitCode = true
@@ -758,8 +808,9 @@ func (i *Inspector) RunContainer() error {
 	}
 
 	cmd := &command.StartMonitor{
-		RTASourcePT: i.RTASourcePT,
-		AppName:     i.FatContainerCmd[0],
+		RTASourcePT:      i.RTASourcePT,
+		RecordFileRanges: i.RTASourcePT && i.RTAFileRanges,
+		AppName:          i.FatContainerCmd[0],
 	}
 
 	if len(i.FatContainerCmd) > 1 {
@@ -829,6 +880,21 @@ func (i *Inspector) RunContainer() error {
 	cmd.IncludeNodePackages = i.appNodejsInspectOpts.IncludePackages
 
 	cmd.ObfuscateMetadata = i.DoObfuscateMetadata
+	cmd.ExcludePhases = i.ExcludePhases
+	cmd.StreamEvents = true
+	cmd.Phase = command.PhaseStartup
+
+	if i.SensorInfo, err = i.ipcClient.Negotiate(cmd); err != nil {
+		return err
+	}
+
+	if i.PrintState {
+		i.xc.Out.Info("sensor",
+			ovars{
+				"version":  i.SensorInfo.Version,
+				"protocol": i.SensorInfo.ProtocolVersion,
+			})
+	}
 
 	_, err = i.ipcClient.SendCommand(cmd)
 	if err != nil {
@@ -876,6 +942,8 @@ func (i *Inspector) RunContainer() error {
 						"status": "received",
 					})
 			}
+
+			i.startSensorEventStream()
 			return nil
 		}
 
@@ -922,6 +990,53 @@ func (i *Inspector) isHostNetworked() bool {
 	return containertypes.NetworkMode(i.Overrides.Network).IsHost()
 }
 
+// useUnixIPC returns true if the sensor IPC channels should use the Unix domain sockets
+// (selected explicitly or automatically when the container has no network)
+func (i *Inspector) useUnixIPC() bool {
+	switch i.SensorIPCMode {
+	case SensorIPCModeUnix:
+		return true
+	case SensorIPCModeDirect, SensorIPCModeProxy:
+		return false
+	}
+
+	return i.IsNetworkNone()
+}
+
+// HasExited returns true if the target container stopped
+// (based on the Docker events, so it's set only when the container is running)
+func (i *Inspector) HasExited() bool {
+	return i.isExited.IsOn()
+}
+
+// IsNetworkNone returns true if the target container is configured without network
+func (i *Inspector) IsNetworkNone() bool {
+	return i.Overrides != nil && containertypes.NetworkMode(i.Overrides.Network).IsNone()
+}
+
+// unixIPCSocketDir returns the host path for the per-run IPC socket directory
+// in the sensor volume. The sockets are accessed through the volume mount point,
+// so the Unix domain socket IPC mode works only when the volume is accessible
+// (a local Docker daemon and enough permissions to access its volumes).
+func (i *Inspector) unixIPCSocketDir(volumeName string) (string, error) {
+	volume, err := i.APIClient.InspectVolume(volumeName)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(volume.Mountpoint); err != nil {
+		return "", fmt.Errorf("sensor volume (%s) is not accessible: %w", volumeName, err)
+	}
+
+	socketDir := filepath.Join(volume.Mountpoint,
+		fmt.Sprintf("ipc.%d.%d", os.Getpid(), time.Now().UnixNano()%1000000))
+	if len(filepath.Join(socketDir, channel.EvtSocketName)) > maxUnixSocketPathLen {
+		return "", fmt.Errorf("IPC socket path is too long (%s)", socketDir)
+	}
+
+	return socketDir, nil
+}
+
 const localHostIP = "127.0.0.1"
 
 // setPorts sets all port fields in CreateContainerOptions from user input and defaults.
@@ -935,6 +1050,12 @@ func (i *Inspector) setPorts(ctrOpts *dockerapi.CreateContainerOptions) (hostPro
 		i.EvtPort: {},
 	}
 
+	isUnixIPC := i.SensorIPCMode == SensorIPCModeUnix
+	if isUnixIPC {
+		// No IPC ports with the Unix domain sockets.
+		commsExposedPorts = map[dockerapi.Port]struct{}{}
+	}
+
 	//add comms ports to the exposed ports in the container
 	if len(i.Overrides.ExposedPorts) > 0 {
 		ctrOpts.Config.ExposedPorts = i.Overrides.ExposedPorts
@@ -952,18 +1073,20 @@ func (i *Inspector) setPorts(ctrOpts *dockerapi.CreateContainerOptions) (hostPro
 	}
 
 	if len(i.portBindings) > 0 {
-		//need to add the IPC ports too
-		cmdPort := dockerapi.Port(i.CmdPort)
-		evtPort := dockerapi.Port(i.EvtPort)
-		if pbInfo, ok := i.portBindings[cmdPort]; ok {
-			i.exitIPCPortConflict(pbInfo, "cmd", -126)
-		}
-		if pbInfo, ok := i.portBindings[evtPort]; ok {
-			i.exitIPCPortConflict(pbInfo, "evt", -127)
-		}
+		if !isUnixIPC {
+			//need to add the IPC ports too
+			cmdPort := dockerapi.Port(i.CmdPort)
+			evtPort := dockerapi.Port(i.EvtPort)
+			if pbInfo, ok := i.portBindings[cmdPort]; ok {
+				i.exitIPCPortConflict(pbInfo, "cmd", -126)
+			}
+			if pbInfo, ok := i.portBindings[evtPort]; ok {
+				i.exitIPCPortConflict(pbInfo, "evt", -127)
+			}
 
-		i.portBindings[cmdPort] = []dockerapi.PortBinding{{HostPort: cmdPortStrDefault}}
-		i.portBindings[evtPort] = []dockerapi.PortBinding{{HostPort: evtPortStrDefault}}
+			i.portBindings[cmdPort] = []dockerapi.PortBinding{{HostPort: cmdPortStrDefault}}
+			i.portBindings[evtPort] = []dockerapi.PortBinding{{HostPort: evtPortStrDefault}}
+		}
 
 		ctrOpts.HostConfig.PortBindings = i.portBindings
 	} else if i.DoPublishExposedPorts {
@@ -1143,6 +1266,10 @@ func (i *Inspector) ShutdownContainer() error {
 	defer func() {
 		i.shutdownContainerChannels()
 
+		if i.ipcSocketDir != "" {
+			errutil.WarnOn(os.RemoveAll(i.ipcSocketDir))
+		}
+
 		if i.DoShowContainerLogs {
 			i.ShowContainerLogs()
 		}
@@ -1244,7 +1371,8 @@ func (i *Inspector) FinishMonitoring() {
 
 	i.logger.Info("waiting for the container to finish its work...")
 
-	evt, err := i.ipcClient.GetEvent()
+	evt, err := i.nextSensorEvent()
+	i.stopSensorEventStream()
 	i.logger.Debugf("sensor event => '%v'", evt)
 
 	errutil.WarnOn(err)
@@ -1261,6 +1389,26 @@ func (i *Inspector) FinishMonitoring() {
 func (i *Inspector) initContainerChannels() error {
 	const op = "container.Inspector.initContainerChannels"
 
+	if i.SensorIPCMode == SensorIPCModeUnix {
+		//the probes use the published ports (like in the proxy mode)
+		i.DockerHostIP = dockerhost.GetIP(i.APIClient)
+		i.TargetHost = i.DockerHostIP
+
+		i.logger.WithFields(log.Fields{
+			"op":         op,
+			"ipc.mode":   i.SensorIPCMode,
+			"socket.dir": i.ipcSocketDir,
+		}).Debugf("target.container.ipc.connect")
+
+		ipcClient, err := ipc.NewUnixClient(i.ipcSocketDir, sensor.DefaultConnectWait, i.ipcAuthKey)
+		if err != nil {
+			return err
+		}
+
+		i.ipcClient = ipcClient
+		return nil
+	}
+
 	var cn string
 	if i.Overrides != nil {
 		cn = i.Overrides.Network
@@ -1334,7 +1482,7 @@ func (i *Inspector) initContainerChannels() error {
 		"port.evt":          evtPort,
 	}).Debugf("target.container.ipc.connect")
 
-	ipcClient, err := ipc.NewClient(i.TargetHost, cmdPort, evtPort, sensor.DefaultConnectWait)
+	ipcClient, err := ipc.NewClient(i.TargetHost, cmdPort, evtPort, sensor.DefaultConnectWait, i.ipcAuthKey)
 	if err != nil {
 		return err
 	}
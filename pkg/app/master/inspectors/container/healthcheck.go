@@ -0,0 +1,105 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dockerapi "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	defaultHealthcheckTimeout = 30 * time.Second
+	maxHealthcheckOutputSize  = 512
+)
+
+var (
+	ErrNoHealthcheck = errors.New("no image health check")
+)
+
+// HealthcheckCmd returns the exec command for the target image 'HEALTHCHECK' instruction
+// (nil if the image doesn't have a health check or if it's disabled with 'HEALTHCHECK NONE')
+func (i *Inspector) HealthcheckCmd() []string {
+	if i.ImageInspector == nil ||
+		i.ImageInspector.ImageInfo == nil ||
+		i.ImageInspector.ImageInfo.Config == nil {
+		return nil
+	}
+
+	return healthcheckCmd(i.ImageInspector.ImageInfo.Config.Healthcheck)
+}
+
+func healthcheckCmd(hc *dockerapi.HealthConfig) []string {
+	if hc == nil || len(hc.Test) < 2 {
+		return nil
+	}
+
+	switch hc.Test[0] {
+	case "CMD":
+		return hc.Test[1:]
+	case "CMD-SHELL":
+		return []string{"/bin/sh", "-c", strings.Join(hc.Test[1:], " ")}
+	}
+
+	return nil
+}
+
+// RunHealthcheck executes the image health check command in the target container
+// (returns an error if the command fails or if it exits with a non-zero code)
+func (i *Inspector) RunHealthcheck() error {
+	cmd := i.HealthcheckCmd()
+	if len(cmd) == 0 {
+		return ErrNoHealthcheck
+	}
+
+	timeout := i.ImageInspector.ImageInfo.Config.Healthcheck.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthcheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exec, err := i.APIClient.CreateExec(dockerapi.CreateExecOptions{
+		Container:    i.ContainerID,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+		Context:      ctx,
+	})
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	if err := i.APIClient.StartExec(exec.ID, dockerapi.StartExecOptions{
+		OutputStream: &output,
+		ErrorStream:  &output,
+		Context:      ctx,
+	}); err != nil {
+		return err
+	}
+
+	inspect, err := i.APIClient.InspectExec(exec.ID)
+	if err != nil {
+		return err
+	}
+
+	if inspect.Running {
+		return fmt.Errorf("health check is still running")
+	}
+
+	if inspect.ExitCode != 0 {
+		out := strings.TrimSpace(output.String())
+		if len(out) > maxHealthcheckOutputSize {
+			out = out[:maxHealthcheckOutputSize]
+		}
+
+		return fmt.Errorf("health check failed (exit code %d): %s", inspect.ExitCode, out)
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+	"github.com/docker-slim/docker-slim/pkg/ipc/event"
+)
+
+const (
+	monitorProgressInterval = 5 * time.Second
+	snapshotWaitTime        = 30 * time.Second
+)
+
+var (
+	ErrSensorCmdNotSupported = errors.New("sensor command is not supported")
+	ErrNoSensorEventStream   = errors.New("no sensor event stream")
+	ErrSnapshotTimeout       = errors.New("timeout waiting for sensor snapshot")
+)
+
+// MonitorActivity contains the monitored app activity stats
+// collected from the events streamed by the sensor
+type MonitorActivity struct {
+	Files            uint64
+	Processes        uint64
+	Syscalls         uint64
+	SyscallNum       uint32
+	ListenCount      uint32
+	LastNewFileTime  time.Time
+	LastSyscallTime  time.Time
+	LastEventTime    time.Time
+	IsStreamingStats bool
+	CounterResets    uint32
+}
+
+// newActivity returns the number of the new files and processes since the previous stats
+// (the counters restart from zero when they are reset)
+func (a MonitorActivity) newActivity(prev MonitorActivity) (uint64, uint64) {
+	if a.CounterResets != prev.CounterResets {
+		prev = MonitorActivity{}
+	}
+
+	var files, processes uint64
+	if a.Files > prev.Files {
+		files = a.Files - prev.Files
+	}
+
+	if a.Processes > prev.Processes {
+		processes = a.Processes - prev.Processes
+	}
+
+	return files, processes
+}
+
+// LastActivityTime returns the last time a new file or a new syscall type was observed
+func (a MonitorActivity) LastActivityTime() time.Time {
+	if a.LastSyscallTime.After(a.LastNewFileTime) {
+		return a.LastSyscallTime
+	}
+
+	return a.LastNewFileTime
+}
+
+type sensorEventStream struct {
+	mu         sync.Mutex
+	activity   MonitorActivity
+	snapshotCh chan *event.SnapshotInfo
+	stopCh     chan struct{}
+
+	//the non-activity events are queued without a limit
+	//(the stream reader must never block, or the activity accounting stops)
+	events      []*event.Message
+	isClosed    bool
+	eventSignal chan struct{}
+}
+
+func newSensorEventStream() *sensorEventStream {
+	stream := &sensorEventStream{
+		snapshotCh:  make(chan *event.SnapshotInfo, 1),
+		stopCh:      make(chan struct{}),
+		eventSignal: make(chan struct{}, 1),
+	}
+
+	startTime := time.Now()
+	stream.activity.LastNewFileTime = startTime
+	stream.activity.LastSyscallTime = startTime
+	stream.activity.LastEventTime = startTime
+	return stream
+}
+
+// startSensorEventStream starts consuming the sensor events after the monitor is started.
+// The activity events are aggregated (and reported as the monitoring progress)
+// while all other events are queued for the regular event processing (see nextSensorEvent).
+func (i *Inspector) startSensorEventStream() {
+	stream := newSensorEventStream()
+	i.sensorEvents = stream
+
+	go stream.consume(i.ipcClient.GetEvent, i.logger)
+
+	if !i.PrintState {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(monitorProgressInterval)
+		defer ticker.Stop()
+
+		var prev MonitorActivity
+		for {
+			select {
+			case <-stream.stopCh:
+				return
+			case <-ticker.C:
+				current := i.MonitorActivity()
+				newFiles, newProcesses := current.newActivity(prev)
+				prev = current
+
+				if newFiles == 0 && newProcesses == 0 {
+					continue
+				}
+
+				i.xc.Out.Info("monitor.activity",
+					ovars{
+						"new.files":       newFiles,
+						"new.processes":   newProcesses,
+						"period":          monitorProgressInterval,
+						"total.files":     current.Files,
+						"total.processes": current.Processes,
+					})
+			}
+		}
+	}()
+}
+
+// consume reads the sensor events until the event channel is closed
+func (s *sensorEventStream) consume(getEvent func() (*event.Message, error), logger *log.Entry) {
+	defer s.close()
+
+	for {
+		evt, err := getEvent()
+		if err != nil {
+			logger.Debugf("sensor event stream - error => %v", err)
+			return
+		}
+
+		if evt == nil {
+			logger.Debug("sensor event stream - closed connection")
+			return
+		}
+
+		if evt.Name == event.SnapshotDone {
+			if data, ok := evt.Data.(*event.SnapshotInfo); ok {
+				select {
+				case s.snapshotCh <- data:
+				default:
+					logger.Debug("sensor event stream - dropping unexpected snapshot")
+				}
+			}
+
+			continue
+		}
+
+		if !evt.Name.IsActivity() {
+			s.push(evt)
+			continue
+		}
+
+		s.addActivity(evt)
+	}
+}
+
+func (s *sensorEventStream) addActivity(evt *event.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.activity.LastEventTime = time.Now()
+	switch data := evt.Data.(type) {
+	case *event.FileAccessBatch:
+		if len(data.Files) > 0 {
+			s.activity.Files += uint64(len(data.Files))
+			s.activity.LastNewFileTime = s.activity.LastEventTime
+		}
+	case *event.ProcessExecBatch:
+		s.activity.Processes += uint64(len(data.Processes))
+	case *event.MonitorStatsInfo:
+		s.activity.IsStreamingStats = true
+		s.activity.Syscalls = data.SyscallCount
+		s.activity.ListenCount = data.ListenCount
+		if data.SyscallNum > s.activity.SyscallNum {
+			s.activity.SyscallNum = data.SyscallNum
+			s.activity.LastSyscallTime = s.activity.LastEventTime
+		}
+	}
+}
+
+// resetCounters restarts the activity counters
+// (the last activity times and the listen() signal are kept)
+func (s *sensorEventStream) resetCounters() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.activity.Files = 0
+	s.activity.Processes = 0
+	s.activity.Syscalls = 0
+	s.activity.CounterResets++
+}
+
+func (s *sensorEventStream) push(evt *event.Message) {
+	s.mu.Lock()
+	s.events = append(s.events, evt)
+	s.mu.Unlock()
+
+	s.signal()
+}
+
+func (s *sensorEventStream) close() {
+	s.mu.Lock()
+	s.isClosed = true
+	s.mu.Unlock()
+
+	s.signal()
+}
+
+func (s *sensorEventStream) signal() {
+	select {
+	case s.eventSignal <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the next queued event
+// (it waits for a new event; nil means the stream is closed)
+func (s *sensorEventStream) next() *event.Message {
+	for {
+		s.mu.Lock()
+		if len(s.events) > 0 {
+			evt := s.events[0]
+			s.events[0] = nil
+			s.events = s.events[1:]
+			s.mu.Unlock()
+			return evt
+		}
+
+		isClosed := s.isClosed
+		s.mu.Unlock()
+
+		if isClosed {
+			return nil
+		}
+
+		<-s.eventSignal
+	}
+}
+
+func (s *sensorEventStream) activitySnapshot() MonitorActivity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activity
+}
+
+func (i *Inspector) stopSensorEventStream() {
+	if i.sensorEvents == nil {
+		return
+	}
+
+	select {
+	case <-i.sensorEvents.stopCh:
+	default:
+		close(i.sensorEvents.stopCh)
+	}
+}
+
+// nextSensorEvent returns the next (non-activity) sensor event
+func (i *Inspector) nextSensorEvent() (*event.Message, error) {
+	if i.sensorEvents == nil {
+		return i.ipcClient.GetEvent()
+	}
+
+	return i.sensorEvents.next(), nil
+}
+
+// MonitorActivity returns the activity stats for the monitored app
+// (the stats are empty if the sensor doesn't stream its events)
+func (i *Inspector) MonitorActivity() MonitorActivity {
+	if i.sensorEvents == nil {
+		return MonitorActivity{}
+	}
+
+	return i.sensorEvents.activitySnapshot()
+}
+
+// MarkPhase tags the subsequent monitored app activity with the phase name
+// (the files in the container report are attributed to the phases)
+func (i *Inspector) MarkPhase(name string) error {
+	return i.sendMonitorCommand(&command.MarkPhase{Name: name})
+}
+
+// ResetCounters restarts the sensor activity counters and the monitor activity stats
+// (the files collected so far are kept)
+func (i *Inspector) ResetCounters() error {
+	if err := i.sendMonitorCommand(&command.ResetCounters{}); err != nil {
+		return err
+	}
+
+	if i.sensorEvents != nil {
+		i.sensorEvents.resetCounters()
+	}
+
+	return nil
+}
+
+// Snapshot returns the current partial monitoring report
+// without stopping the sensor monitor
+func (i *Inspector) Snapshot() (*event.SnapshotInfo, error) {
+	if i.sensorEvents == nil {
+		return nil, ErrNoSensorEventStream
+	}
+
+	//discarding the stale snapshot (if any)
+	select {
+	case <-i.sensorEvents.snapshotCh:
+	default:
+	}
+
+	if err := i.sendMonitorCommand(&command.Snapshot{}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case snapshot := <-i.sensorEvents.snapshotCh:
+		return snapshot, nil
+	case <-time.After(snapshotWaitTime):
+		return nil, ErrSnapshotTimeout
+	}
+}
+
+func (i *Inspector) sendMonitorCommand(cmd command.Message) error {
+	if i.ipcClient == nil || !i.ipcClient.SupportsCommand(cmd.GetName()) {
+		return ErrSensorCmdNotSupported
+	}
+
+	resp, err := i.ipcClient.SendCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	if resp == nil || resp.Status != command.ResponseStatusOk {
+		return fmt.Errorf("sensor command (%s) failed", cmd.GetName())
+	}
+
+	return nil
+}
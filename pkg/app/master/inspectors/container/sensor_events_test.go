@@ -0,0 +1,136 @@
+package container
+
+import (
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/event"
+)
+
+func TestSensorEventStream(t *testing.T) {
+	var events []*event.Message
+	//the error events are not consumed until the monitoring is done
+	for i := 0; i < 100; i++ {
+		events = append(events, &event.Message{Name: event.Error})
+	}
+
+	events = append(events,
+		&event.Message{Name: event.FileAccess, Data: &event.FileAccessBatch{
+			Files: []event.FileAccessInfo{{Path: "/etc/hosts"}, {Path: "/etc/passwd"}},
+		}},
+		&event.Message{Name: event.MonitorStats, Data: &event.MonitorStatsInfo{
+			SyscallCount: 42,
+			SyscallNum:   7,
+			ListenCount:  1,
+		}},
+		&event.Message{Name: event.SnapshotDone, Data: &event.SnapshotInfo{EventCount: 3}},
+		&event.Message{Name: event.StopMonitorDone},
+	)
+
+	stream := newSensorEventStream()
+	startTime := stream.activity.LastNewFileTime
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stream.consume(func() (*event.Message, error) {
+			if len(events) == 0 {
+				return nil, nil
+			}
+
+			evt := events[0]
+			events = events[1:]
+			return evt, nil
+		}, log.WithField("test", "stream"))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sensor event stream reader is blocked")
+	}
+
+	activity := stream.activitySnapshot()
+	if activity.Files != 2 || activity.Syscalls != 42 || activity.SyscallNum != 7 || activity.ListenCount != 1 {
+		t.Errorf("unexpected activity: %+v", activity)
+	}
+
+	if !activity.IsStreamingStats || !activity.LastSyscallTime.After(startTime) {
+		t.Errorf("expected streamed stats and new syscall activity: %+v", activity)
+	}
+
+	select {
+	case snapshot := <-stream.snapshotCh:
+		if snapshot.EventCount != 3 {
+			t.Errorf("unexpected snapshot: %+v", snapshot)
+		}
+	default:
+		t.Error("expected snapshot")
+	}
+
+	for i := 0; i < 100; i++ {
+		if evt := stream.next(); evt == nil || evt.Name != event.Error {
+			t.Fatalf("expected error event #%d, got %+v", i, evt)
+		}
+	}
+
+	if evt := stream.next(); evt == nil || evt.Name != event.StopMonitorDone {
+		t.Fatalf("expected stop monitor event, got %+v", evt)
+	}
+
+	if evt := stream.next(); evt != nil {
+		t.Errorf("expected closed stream, got %+v", evt)
+	}
+}
+
+func TestSensorEventStreamNextWaits(t *testing.T) {
+	stream := newSensorEventStream()
+	result := make(chan *event.Message)
+	go func() {
+		result <- stream.next()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	stream.push(&event.Message{Name: event.StopMonitorDone})
+
+	select {
+	case evt := <-result:
+		if evt == nil || evt.Name != event.StopMonitorDone {
+			t.Errorf("unexpected event: %+v", evt)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("next() didn't return the pushed event")
+	}
+}
+
+func TestMonitorActivityAfterCounterReset(t *testing.T) {
+	stream := newSensorEventStream()
+	stream.addActivity(&event.Message{Name: event.FileAccess, Data: &event.FileAccessBatch{
+		Files: []event.FileAccessInfo{{Path: "/etc/hosts"}, {Path: "/etc/passwd"}, {Path: "/etc/group"}},
+	}})
+	stream.addActivity(&event.Message{Name: event.ProcessExec, Data: &event.ProcessExecBatch{
+		Processes: []event.ProcessExecInfo{{Pid: 1}, {Pid: 2}},
+	}})
+
+	prev := stream.activitySnapshot()
+	if files, processes := prev.newActivity(MonitorActivity{}); files != 3 || processes != 2 {
+		t.Errorf("unexpected new activity: files=%d processes=%d", files, processes)
+	}
+
+	stream.resetCounters()
+	stream.addActivity(&event.Message{Name: event.FileAccess, Data: &event.FileAccessBatch{
+		Files: []event.FileAccessInfo{{Path: "/app/main"}},
+	}})
+
+	//the counters restart after the reset (no wrap-around)
+	current := stream.activitySnapshot()
+	if files, processes := current.newActivity(prev); files != 1 || processes != 0 {
+		t.Errorf("unexpected new activity after the counter reset: files=%d processes=%d", files, processes)
+	}
+
+	if files, processes := current.newActivity(current); files != 0 || processes != 0 {
+		t.Errorf("unexpected new activity without changes: files=%d processes=%d", files, processes)
+	}
+}
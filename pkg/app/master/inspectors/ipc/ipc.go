@@ -2,14 +2,19 @@ package ipc
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
 	"github.com/docker-slim/docker-slim/pkg/ipc/event"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/version"
 )
 
 const (
@@ -19,17 +24,23 @@ const (
 )
 
 type Client struct {
+	authKey     []byte
 	connectWait int
+	socketDir   string
 	target      string
 	cmdPort     string
 	evtPort     string
 	evtChannel  *channel.EventClient
 	cmdChannel  *channel.CommandClient
+	sensorInfo  *command.HandshakeInfo
 }
 
-func NewClient(target, cmdChannelPort, evtChannelPort string, connectWait int) (*Client, error) {
+// NewClient creates the sensor IPC client. If the auth key is not empty
+// the client authenticates with it (the sensor must use the same key).
+func NewClient(target, cmdChannelPort, evtChannelPort string, connectWait int, authKey string) (*Client, error) {
 	log.Debugf("ipc.NewClient(%s,%s,%s)", target, cmdChannelPort, evtChannelPort)
 	client := Client{
+		authKey:     []byte(authKey),
 		target:      target,
 		cmdPort:     cmdChannelPort,
 		evtPort:     evtChannelPort,
@@ -52,20 +63,43 @@ func NewClient(target, cmdChannelPort, evtChannelPort string, connectWait int) (
 	return &client, nil
 }
 
+// NewUnixClient creates the sensor IPC client connecting to the Unix domain sockets
+// in the socket directory (shared with the sensor)
+func NewUnixClient(socketDir string, connectWait int, authKey string) (*Client, error) {
+	log.Debugf("ipc.NewUnixClient(%s)", socketDir)
+	client := Client{
+		authKey:     []byte(authKey),
+		socketDir:   socketDir,
+		connectWait: connectWait,
+	}
+
+	if err := client.initChannels(); err != nil {
+		log.Errorf("ipc.NewUnixClient init error = %v", err)
+		return nil, err
+	}
+
+	return &client, nil
+}
+
 func (c *Client) initChannels() error {
 	cmdChannelAddr := fmt.Sprintf("%s:%s", c.target, c.cmdPort)
-	cmdChannel, err := channel.NewCommandClient(cmdChannelAddr, c.connectWait, connectTimeout, readTimeout, writeTimeout)
+	evtChannelAddr := fmt.Sprintf("%s:%s", c.target, c.evtPort)
+	if c.socketDir != "" {
+		cmdChannelAddr = channel.UnixAddr(filepath.Join(c.socketDir, channel.CmdSocketName))
+		evtChannelAddr = channel.UnixAddr(filepath.Join(c.socketDir, channel.EvtSocketName))
+	}
+
+	cmdChannel, err := channel.NewCommandClient(cmdChannelAddr, c.connectWait, connectTimeout, readTimeout, writeTimeout, c.authKey)
 	if os.IsTimeout(err) {
 		log.Debug("ipc.initChannels(): connect timeout...")
 		return err
 	} else if err != nil {
-		return err
+		return incompatibleSensorError(err)
 	}
 
 	c.cmdChannel = cmdChannel
 
-	evtChannelAddr := fmt.Sprintf("%s:%s", c.target, c.evtPort)
-	evtChannel, err := channel.NewEventClient(evtChannelAddr, c.connectWait, connectTimeout, -1)
+	evtChannel, err := channel.NewEventClient(evtChannelAddr, c.connectWait, connectTimeout, -1, c.authKey)
 	if os.IsTimeout(err) {
 		log.Debug("ipc.initChannels(): connect timeout...")
 		return err
@@ -126,6 +160,83 @@ func (c *Client) SendCommand(cmd command.Message) (*command.Response, error) {
 	return &resp, nil
 }
 
+// Handshake exchanges the protocol versions with the sensor and returns
+// the sensor capabilities. The sensors with the IPC authentication support,
+// but without the handshake support (they reject the command) are described
+// by the legacy handshake info (they fail the Negotiate checks). The older sensors
+// (without the IPC authentication) can't be used (their channels are rejected
+// with command.ErrIncompatibleSensor).
+func (c *Client) Handshake() (*command.HandshakeInfo, error) {
+	resp, err := c.SendCommand(&command.Handshake{
+		ProtocolVersion: command.ProtocolVersion,
+		MasterVersion:   version.Current(),
+	})
+	if err != nil {
+		return nil, incompatibleSensorError(err)
+	}
+
+	if resp != nil && resp.Status != command.ResponseStatusOk && resp.Error != "" {
+		//the sensor rejected the master protocol version
+		return nil, fmt.Errorf("%w: %s", command.ErrIncompatibleSensor, resp.Error)
+	}
+
+	if resp == nil || resp.Status != command.ResponseStatusOk || resp.Handshake == nil {
+		log.Debug("ipc.Client.Handshake(): no handshake support in sensor (legacy protocol)")
+		c.sensorInfo = command.LegacyHandshakeInfo()
+		return c.sensorInfo, nil
+	}
+
+	c.sensorInfo = resp.Handshake
+	return c.sensorInfo, nil
+}
+
+// SupportsCommand returns true if the sensor supports the command
+// (based on the handshake; false if there was no handshake)
+func (c *Client) SupportsCommand(name command.MessageName) bool {
+	return c.sensorInfo != nil && c.sensorInfo.HasCommand(name)
+}
+
+// Negotiate does the handshake with the sensor and adjusts the start monitor command
+// to the sensor capabilities (the unsupported optional fields are cleared).
+// It fails if the sensor is incompatible. The returned sensor info is always set
+// when the handshake is done (to record it in the command report).
+func (c *Client) Negotiate(cmd *command.StartMonitor) (*report.SensorInfo, error) {
+	info, err := c.Handshake()
+	if err != nil {
+		return nil, err
+	}
+
+	sensorInfo := &report.SensorInfo{
+		Version:               info.SensorVersion,
+		ProtocolVersion:       info.ProtocolVersion,
+		MasterProtocolVersion: command.ProtocolVersion,
+		Monitors:              info.Monitors,
+	}
+
+	unsupported, err := info.Check(cmd)
+	if err != nil {
+		return sensorInfo, err
+	}
+
+	if len(unsupported) > 0 {
+		log.Warnf("ipc.Client.Negotiate(): sensor (version='%s' protocol=%d) doesn't support %s (ignored)",
+			info.SensorVersion, info.ProtocolVersion, strings.Join(unsupported, ","))
+		sensorInfo.UnsupportedFields = unsupported
+	}
+
+	return sensorInfo, nil
+}
+
+// incompatibleSensorError maps the frame authentication errors
+// (the sensors without the IPC authentication support send unsigned frames)
+func incompatibleSensorError(err error) error {
+	if errors.Is(err, channel.ErrFrameUnauthenticated) {
+		return fmt.Errorf("%w (%v)", command.ErrIncompatibleSensor, err)
+	}
+
+	return err
+}
+
 func (c *Client) GetEvent() (*event.Message, error) {
 	raw, id, err := c.evtChannel.Next(3)
 	if err != nil {
@@ -0,0 +1,90 @@
+package ipc
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+)
+
+// legacySensor is a sensor stub without the handshake support
+// (it rejects the unknown commands like the older sensors)
+type legacySensor struct{}
+
+func (legacySensor) OnRequest(data []byte) ([]byte, error) {
+	resp := command.Response{Status: command.ResponseStatusError}
+	if _, err := command.Decode(data); err == nil {
+		resp.Status = command.ResponseStatusOk
+	}
+
+	return json.Marshal(&resp)
+}
+
+func startLegacySensor(t *testing.T, authKey []byte) string {
+	dir := t.TempDir()
+
+	evtServer := channel.NewEventServer(channel.UnixAddr(filepath.Join(dir, channel.EvtSocketName)), authKey)
+	if err := evtServer.Start(true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(evtServer.Stop)
+
+	cmdServer := channel.NewCommandServer(channel.UnixAddr(filepath.Join(dir, channel.CmdSocketName)), authKey, legacySensor{})
+	if err := cmdServer.Start(true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cmdServer.Stop)
+
+	return dir
+}
+
+func TestClientLegacySensorWithoutAuth(t *testing.T) {
+	key, err := channel.GenerateAuthKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the sensors without the IPC authentication send unsigned frames
+	dir := startLegacySensor(t, nil)
+
+	client, err := NewUnixClient(dir, 1, key)
+	if err == nil {
+		client.Stop()
+		t.Fatal("expected an error")
+	}
+
+	if !errors.Is(err, command.ErrIncompatibleSensor) {
+		t.Errorf("expected the incompatible sensor error, got: %v", err)
+	}
+}
+
+func TestClientLegacySensorHandshake(t *testing.T) {
+	key, err := channel.GenerateAuthKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := startLegacySensor(t, []byte(key))
+
+	client, err := NewUnixClient(dir, 1, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Stop()
+
+	info, err := client.Negotiate(&command.StartMonitor{AppName: "/bin/app"})
+	if !errors.Is(err, command.ErrIncompatibleSensor) {
+		t.Errorf("expected the incompatible sensor error, got: %v", err)
+	}
+
+	if info == nil || info.ProtocolVersion != command.LegacyProtocolVersion {
+		t.Errorf("unexpected sensor protocol version: %d", info.ProtocolVersion)
+	}
+
+	if client.SupportsCommand(command.SnapshotName) {
+		t.Error("unexpected snapshot command support")
+	}
+}
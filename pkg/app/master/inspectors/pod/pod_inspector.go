@@ -31,7 +31,9 @@ import (
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
+	k8sclient "k8s.io/client-go/kubernetes"
 )
 
 type ovars = app.OutVars
@@ -42,11 +44,15 @@ const (
 	sensorVolumeMountPath = "/opt/_slim/bin"
 	sensorBinFileAbs      = sensorVolumeMountPath + "/" + sensor.LocalBinFile
 	sensorLoaderContainer = "slim-sensor-loader"
+	sensorIPCKeyFileAbs   = sensorVolumeMountPath + "/ipc.key"
 
 	artifactsVolumeName = "slim-artifacts"
 
 	targetPodLabelName = "dockersl.im/target-pod"
 	targetPodLabelPat  = "slimk_%v_%v"
+
+	// The main process of the target container (in the container's PID namespace)
+	attachTargetPid = 1
 )
 
 type portInfo struct {
@@ -99,6 +105,9 @@ type Inspector struct {
 
 	pod             *corev1.Pod
 	sensorIPCClient *ipc.Client
+	ipcAuthKey      string
+	isAttached      bool
+	sensorInfo      *report.SensorInfo
 }
 
 func NewInspector(
@@ -134,6 +143,12 @@ func NewInspector(
 	portBindings map[dockerapi.Port][]dockerapi.PortBinding,
 	doPublishExposedPorts bool,
 ) (*Inspector, error) {
+	//per-run IPC key to authenticate the sensor channels
+	ipcAuthKey, err := channel.GenerateAuthKey()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancelFn := context.WithCancel(ctx)
 	return &Inspector{
 		ctx:                   ctx,
@@ -155,6 +170,7 @@ func NewInspector(
 		sensorIPCEndpoint:     sensorIPCEndpoint,
 		portBindings:          portBindings,
 		doPublishExposedPorts: doPublishExposedPorts,
+		ipcAuthKey:            ipcAuthKey,
 		portInfo: portInfo{
 			availablePorts: map[dockerapi.Port]dockerapi.PortBinding{},
 		},
@@ -182,7 +198,7 @@ func (i *Inspector) RunPod() error {
 	localSensorPath := sensor.EnsureLocalBinary(i.xc, i.logger, i.statePath, true)
 	i.logger.Debugf("RunPod: detected sensor at %q", localSensorPath)
 
-	if err := i.injectSensor(localSensorPath); err != nil {
+	if err := i.injectSensor(sensorLoaderContainer, localSensorPath); err != nil {
 		return err
 	}
 
@@ -201,6 +217,72 @@ func (i *Inspector) RunPod() error {
 	return i.publishPorts()
 }
 
+// AttachPod attaches the sensor to an already running workload pod
+// without patching or restarting the workload. The sensor is copied
+// into the target container and started there (with kubectl exec,
+// so it shares the PID namespace with the target app), then it monitors
+// the existing app process. The target container needs to be privileged
+// (or at least to have the SYS_ADMIN capability) for the sensor to work.
+func (i *Inspector) AttachPod() error {
+	targetCont := i.workload.TargetContainer()
+
+	pod, err := findRunningPod(
+		i.ctx,
+		i.kubeClient.Static(),
+		i.workload.Namespace(),
+		labels.SelectorFromSet(i.workload.Template().Labels).String(),
+		targetCont.Name)
+	if err != nil {
+		return err
+	}
+	i.logger.Debugf("AttachPod: found running workload pod '%s/%s'", pod.Namespace, pod.Name)
+
+	i.xc.Out.Info("pod",
+		ovars{
+			"status":    "attaching",
+			"namespace": pod.Namespace,
+			"name":      pod.Name,
+		})
+
+	i.pod = &pod
+	i.isAttached = true
+
+	out, err := i.Exec("mkdir", "-p", sensorVolumeMountPath, app.DefaultArtifactsDirPath)
+	if err != nil {
+		i.logger.Debugf("AttachPod: kubectl exec mkdir failed with %q: %s", err, string(out))
+		return err
+	}
+
+	localSensorPath := sensor.EnsureLocalBinary(i.xc, i.logger, i.statePath, true)
+	i.logger.Debugf("AttachPod: detected sensor at %q", localSensorPath)
+
+	if err := i.injectSensor(targetCont.Name, localSensorPath); err != nil {
+		return err
+	}
+
+	if err := i.injectIPCKey(targetCont.Name); err != nil {
+		return err
+	}
+
+	go func() {
+		// Blocks until the sensor exits (after the 'shutdown' command).
+		out, err := i.Exec(sensorBinFileAbs, "--ipc-key-file", sensorIPCKeyFileAbs)
+		if err != nil {
+			i.logger.Debugf("AttachPod: kubectl exec sensor failed with %q: %s", err, string(out))
+		}
+	}()
+
+	if err := i.sensorConnect(); err != nil {
+		return err
+	}
+
+	if err := i.sensorCommandStart(); err != nil {
+		return err
+	}
+
+	return i.publishPorts()
+}
+
 func (i *Inspector) PodName() string {
 	return i.pod.Namespace + "/" + i.pod.Name
 }
@@ -217,6 +299,11 @@ func (i *Inspector) AvailablePorts() map[dockerapi.Port]dockerapi.PortBinding {
 	return i.portInfo.availablePorts
 }
 
+// SensorInfo returns the sensor version and capabilities (from the IPC handshake)
+func (i *Inspector) SensorInfo() *report.SensorInfo {
+	return i.sensorInfo
+}
+
 func (i *Inspector) FinishMonitoring() {
 	if i.sensorIPCClient == nil {
 		return
@@ -269,12 +356,18 @@ func (i *Inspector) ShutdownPod(resetChanges bool) {
 	}
 	i.logger.Debugf("'shutdown' sensor response => '%v'", resp)
 
-	if resetChanges {
+	switch {
+	case i.isAttached:
+		// The workload was never changed - just removing the injected sensor and its artifacts.
+		if out, err := i.Exec("rm", "-rf", sensorVolumeMountPath, app.DefaultArtifactsDirPath); err != nil {
+			i.logger.Debugf("error removing sensor from the pod => '%v': %s", err, string(out))
+		}
+	case resetChanges:
 		i.workload.ResetChanges()
 		if err := i.kubeClient.CreateOrUpdate(i.ctx, i.workload.Info()); err != nil {
 			i.logger.Debugf("error resetting workload changes => '%v'", err)
 		}
-	} else if i.workload.SetReplicasIfApplicable(0) {
+	case i.workload.SetReplicasIfApplicable(0):
 		if err := i.kubeClient.CreateOrUpdate(i.ctx, i.workload.Info()); err != nil {
 			i.logger.Debugf("error scaling down the workload => '%v'", err)
 		}
@@ -349,6 +442,10 @@ func (i *Inspector) prepareWorkload() error {
 	)
 
 	targetCont.Command = []string{sensorBinFileAbs}
+	targetCont.Env = append(targetCont.Env, corev1.EnvVar{
+		Name:  channel.AuthKeyEnvVar,
+		Value: i.ipcAuthKey,
+	})
 
 	if targetCont.SecurityContext == nil {
 		targetCont.SecurityContext = &corev1.SecurityContext{}
@@ -488,7 +585,7 @@ func (i *Inspector) exitIPCPortConflict(port []dockerapi.PortBinding, typ string
 	i.xc.Exit(code)
 }
 
-func (i *Inspector) injectSensor(localSensorPath string) error {
+func (i *Inspector) injectSensor(contName string, localSensorPath string) error {
 	// Trying to inject the sensor binary atomically using a "cp then mv" trick.
 
 	i.logger.Debugf("RunPod: sending sensor to pod")
@@ -496,7 +593,7 @@ func (i *Inspector) injectSensor(localSensorPath string) error {
 		i.ctx,
 		i.pod.Namespace,
 		i.pod.Name,
-		sensorLoaderContainer,
+		contName,
 		localSensorPath,
 		sensorBinFileAbs+".uploading")
 	if err != nil {
@@ -508,7 +605,7 @@ func (i *Inspector) injectSensor(localSensorPath string) error {
 		i.ctx,
 		i.pod.Namespace,
 		i.pod.Name,
-		sensorLoaderContainer,
+		contName,
 		"mv", sensorBinFileAbs+".uploading", sensorBinFileAbs)
 	if err != nil {
 		i.logger.Debugf("RunPod: kubectl exec sensor.uploading -> sensor failed with %q: %s", err, string(out))
@@ -518,6 +615,36 @@ func (i *Inspector) injectSensor(localSensorPath string) error {
 	return nil
 }
 
+// injectIPCKey copies the IPC key file to the container
+// (when the sensor is started with kubectl exec and it's not possible to set its env)
+func (i *Inspector) injectIPCKey(contName string) error {
+	keyFile, err := os.CreateTemp("", "slim-ipc-key-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(keyFile.Name())
+
+	_, err = keyFile.WriteString(i.ipcAuthKey)
+	keyFile.Close()
+	if err != nil {
+		return err
+	}
+
+	out, err := i.kubectl.CpTo(
+		i.ctx,
+		i.pod.Namespace,
+		i.pod.Name,
+		contName,
+		keyFile.Name(),
+		sensorIPCKeyFileAbs)
+	if err != nil {
+		i.logger.Debugf("AttachPod: kubectl cp ipc.key -> pod failed with %q: %s", err, string(out))
+		return err
+	}
+
+	return nil
+}
+
 func (i *Inspector) sensorConnect() error {
 	sensorListenIP := "127.0.0.1"
 	for _, p := range []int32{channel.CmdPort, channel.EvtPort} {
@@ -544,7 +671,8 @@ func (i *Inspector) sensorConnect() error {
 		sensorListenIP,
 		strconv.Itoa(channel.CmdPort),
 		strconv.Itoa(channel.EvtPort),
-		sensor.DefaultConnectWait)
+		sensor.DefaultConnectWait,
+		i.ipcAuthKey)
 	if err != nil {
 		return err
 	}
@@ -556,11 +684,16 @@ func (i *Inspector) sensorConnect() error {
 func (i *Inspector) sensorCommandStart() error {
 	cmd := &command.StartMonitor{
 		RTASourcePT: i.rtaSourcePT,
-		AppName:     i.fatContainerCmd[0],
 		KeepPerms:   i.keepPerms,
 	}
-	if len(i.fatContainerCmd) > 1 {
-		cmd.AppArgs = i.fatContainerCmd[1:]
+
+	if i.isAttached {
+		cmd.AttachPid = attachTargetPid
+	} else {
+		cmd.AppName = i.fatContainerCmd[0]
+		if len(i.fatContainerCmd) > 1 {
+			cmd.AppArgs = i.fatContainerCmd[1:]
+		}
 	}
 
 	// if len(i.ExcludePatterns) > 0 {
@@ -617,6 +750,12 @@ func (i *Inspector) sensorCommandStart() error {
 
 	// cmd.IncludeNodePackages = i.appNodejsInspectOpts.IncludePackages
 
+	sensorInfo, err := i.sensorIPCClient.Negotiate(cmd)
+	i.sensorInfo = sensorInfo
+	if err != nil {
+		return err
+	}
+
 	if _, err := i.sensorIPCClient.SendCommand(cmd); err != nil {
 		return err
 	}
@@ -732,6 +871,45 @@ func findPod(
 	return pod, err
 }
 
+// findRunningPod finds a workload pod with the target container running
+func findRunningPod(
+	ctx context.Context,
+	client k8sclient.Interface,
+	namespace string,
+	selector string,
+	contName string,
+) (corev1.Pod, error) {
+	var pod corev1.Pod
+	err := wait.PollImmediateWithContext(ctx, 1*time.Second, 5*time.Minute, func(ctx context.Context) (bool, error) {
+		pods, err := client.
+			CoreV1().
+			Pods(namespace).
+			List(ctx, metav1.ListOptions{
+				LabelSelector: selector,
+			})
+		if err != nil {
+			return false, err
+		}
+
+		for _, p := range pods.Items {
+			if p.Status.Phase != corev1.PodRunning || p.DeletionTimestamp != nil {
+				continue
+			}
+
+			for _, contStatus := range p.Status.ContainerStatuses {
+				if contStatus.Name == contName && contStatus.State.Running != nil {
+					pod = p
+					return true, nil // Done
+				}
+			}
+		}
+
+		return false, nil // Keep waiting
+	})
+
+	return pod, err
+}
+
 func waitForContainer(
 	ctx context.Context,
 	client *kubernetes.Client,
@@ -0,0 +1,116 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newTestClient creates a clientset for a fake API server listing the pods
+// (filtered by the label selector)
+func newTestClient(t *testing.T, pods ...*corev1.Pod) k8sclient.Interface {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/default/pods" {
+			http.NotFound(w, r)
+			return
+		}
+
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		list := corev1.PodList{
+			TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+		}
+		for _, p := range pods {
+			if selector.Matches(labels.Set(p.Labels)) {
+				list.Items = append(list.Items, *p)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := k8sclient.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func testPod(name string, phase corev1.PodPhase, contName string, running bool) *corev1.Pod {
+	state := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}
+	if running {
+		state = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "sidecar", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: contName, State: state},
+			},
+		},
+	}
+}
+
+func TestFindRunningPod(t *testing.T) {
+	terminating := testPod("web-terminating", corev1.PodRunning, "app", true)
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+
+	other := testPod("other", corev1.PodRunning, "app", true)
+	other.Labels = map[string]string{"app": "other"}
+
+	client := newTestClient(t,
+		testPod("web-pending", corev1.PodPending, "app", false),
+		testPod("web-starting", corev1.PodRunning, "app", false),
+		terminating,
+		other,
+		testPod("web-running", corev1.PodRunning, "app", true),
+	)
+
+	pod, err := findRunningPod(context.Background(), client, "default", "app=web", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pod.Name != "web-running" {
+		t.Errorf("unexpected pod: %s", pod.Name)
+	}
+}
+
+func TestFindRunningPodNotRunning(t *testing.T) {
+	client := newTestClient(t,
+		testPod("web-starting", corev1.PodRunning, "app", false),
+		//only the sidecar container is running in this pod
+		testPod("web-sidecar", corev1.PodRunning, "other", true),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if pod, err := findRunningPod(ctx, client, "default", "app=web", "app"); err == nil {
+		t.Errorf("unexpected pod: %s", pod.Name)
+	}
+}
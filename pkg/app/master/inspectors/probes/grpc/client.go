@@ -0,0 +1,367 @@
+// Package grpc provides the gRPC service probe client.
+// The service descriptors come from the server reflection API
+// or from the user provided protoset files.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// The connections are not established when the clients are created
+// (non-gRPC ports fail fast on the first call), so the per-call deadline
+// limits the time spent on each probed port
+const callTimeout = 5 * time.Second
+
+// The reflection services are not probed
+var reflectionServices = map[string]struct{}{
+	"grpc.reflection.v1alpha.ServerReflection": {},
+	"grpc.reflection.v1.ServerReflection":      {},
+}
+
+var ErrMethodNotFound = errors.New("method not found")
+
+// Client is a gRPC probe client
+type Client struct {
+	Addr string
+
+	conn  *grpc.ClientConn
+	files *protoregistry.Files
+}
+
+// NewClient creates the gRPC server client (the connection is established lazily).
+// If the protoset files are not provided the service descriptors
+// are loaded using the server reflection API.
+func NewClient(addr string, useTLS bool, protosets []string) (*Client, error) {
+	client := &Client{Addr: addr}
+	if len(protosets) > 0 {
+		files, err := LoadProtosets(protosets)
+		if err != nil {
+			return nil, err
+		}
+
+		client.files = files
+	}
+
+	var opts []grpc.DialOption
+	if useTLS {
+		//the target is a temporary container, so the cert is not verified
+		opts = append(opts, grpc.WithTransportCredentials(
+			credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client.conn = conn
+	return client, nil
+}
+
+// Close closes the server connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// WaitReady waits until the server connection is established
+// (including the TLS and the HTTP/2 handshakes), so the protocol mismatches
+// are detected even if the service descriptors are not loaded from the server
+func (c *Client) WaitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return fmt.Errorf("connection failed (state=%s)", state)
+		}
+
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+}
+
+// LoadProtosets loads the FileDescriptorSet files
+// (e.g., created with 'protoc --include_imports --descriptor_set_out')
+func LoadProtosets(names []string) (*protoregistry.Files, error) {
+	fdset := &descriptorpb.FileDescriptorSet{}
+	known := map[string]struct{}{}
+	for _, name := range names {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var current descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(data, &current); err != nil {
+			return nil, fmt.Errorf("malformed protoset file (%s): %w", name, err)
+		}
+
+		for _, fd := range current.File {
+			if _, found := known[fd.GetName()]; !found {
+				known[fd.GetName()] = struct{}{}
+				fdset.File = append(fdset.File, fd)
+			}
+		}
+	}
+
+	return protodesc.NewFiles(fdset)
+}
+
+func (c *Client) descriptors(ctx context.Context) (*protoregistry.Files, error) {
+	if c.files != nil {
+		return c.files, nil
+	}
+
+	files, err := c.reflectDescriptors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.files = files
+	return files, nil
+}
+
+// reflectDescriptors loads the service file descriptors (and their dependencies)
+// using the server reflection API
+func (c *Client) reflectDescriptors(ctx context.Context) (*protoregistry.Files, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(c.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	request := func(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+
+		res, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		if errRes := res.GetErrorResponse(); errRes != nil {
+			return nil, fmt.Errorf("reflection error: %s (code=%d)", errRes.ErrorMessage, errRes.ErrorCode)
+		}
+
+		return res, nil
+	}
+
+	res, err := request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fdProtos := map[string]*descriptorpb.FileDescriptorProto{}
+	addFiles := func(res *rpb.ServerReflectionResponse) error {
+		for _, data := range res.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, fd); err != nil {
+				return err
+			}
+
+			fdProtos[fd.GetName()] = fd
+		}
+
+		return nil
+	}
+
+	for _, svc := range res.GetListServicesResponse().GetService() {
+		if _, found := reflectionServices[svc.Name]; found {
+			continue
+		}
+
+		res, err := request(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{
+				FileContainingSymbol: svc.Name,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addFiles(res); err != nil {
+			return nil, err
+		}
+	}
+
+	//the servers might not return all dependencies
+	for {
+		var missing []string
+		for _, fd := range fdProtos {
+			for _, dep := range fd.Dependency {
+				if _, found := fdProtos[dep]; !found {
+					missing = append(missing, dep)
+				}
+			}
+		}
+
+		if len(missing) == 0 {
+			break
+		}
+
+		for _, name := range missing {
+			if _, found := fdProtos[name]; found {
+				continue
+			}
+
+			res, err := request(&rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{
+					FileByFilename: name,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if err := addFiles(res); err != nil {
+				return nil, err
+			}
+
+			if _, found := fdProtos[name]; !found {
+				return nil, fmt.Errorf("missing file descriptor: %s", name)
+			}
+		}
+	}
+
+	fdset := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fdProtos {
+		fdset.File = append(fdset.File, fd)
+	}
+
+	return protodesc.NewFiles(fdset)
+}
+
+// UnaryMethods returns the full names ("/package.Service/Method")
+// of the unary service methods
+func (c *Client) UnaryMethods(ctx context.Context) ([]string, error) {
+	files, err := c.descriptors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			svc := services.Get(i)
+			if _, found := reflectionServices[string(svc.FullName())]; found {
+				continue
+			}
+
+			for j := 0; j < svc.Methods().Len(); j++ {
+				md := svc.Methods().Get(j)
+				if md.IsStreamingClient() || md.IsStreamingServer() {
+					log.Debugf("grpc.Client.UnaryMethods - skipping streaming method %s", md.FullName())
+					continue
+				}
+
+				methods = append(methods, fullMethodName(md))
+			}
+		}
+
+		return true
+	})
+
+	sort.Strings(methods)
+	return methods, nil
+}
+
+func fullMethodName(md protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+}
+
+func (c *Client) findMethod(ctx context.Context, name string) (protoreflect.MethodDescriptor, error) {
+	files, err := c.descriptors(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	//both "/package.Service/Method" and "package.Service.Method" are accepted
+	name = strings.TrimPrefix(name, "/")
+	name = strings.Replace(name, "/", ".", 1)
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMethodNotFound, name)
+	}
+
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMethodNotFound, name)
+	}
+
+	return md, nil
+}
+
+// Call calls the unary method. The request message is created
+// from the JSON body (or an example message is generated if the body is empty).
+// The returned error is set only if the call didn't reach the service
+// (the service errors are returned as status codes).
+func (c *Client) Call(ctx context.Context, method string, body string) (codes.Code, error) {
+	md, err := c.findMethod(ctx, method)
+	if err != nil {
+		return codes.Unknown, err
+	}
+
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return codes.Unknown, fmt.Errorf("streaming methods are not supported: %s", md.FullName())
+	}
+
+	var req *dynamicpb.Message
+	if body == "" {
+		req = ExampleMessage(md.Input())
+	} else {
+		req = dynamicpb.NewMessage(md.Input())
+		if err := protojson.Unmarshal([]byte(body), req); err != nil {
+			return codes.Unknown, fmt.Errorf("malformed request message: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res := dynamicpb.NewMessage(md.Output())
+	err = c.conn.Invoke(ctx, fullMethodName(md), req, res)
+	code := status.Code(err)
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return code, err
+	}
+
+	return code, nil
+}
@@ -0,0 +1,156 @@
+package grpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const healthCheckMethod = "/grpc.health.v1.Health/Check"
+
+func startTestServer(t *testing.T, withReflection bool) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	if withReflection {
+		reflection.Register(srv)
+	}
+
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+
+	return ln.Addr().String()
+}
+
+func TestClientReflection(t *testing.T) {
+	addr := startTestServer(t, true)
+
+	client, err := NewClient(addr, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	methods, err := client.UnaryMethods(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the streaming Watch method is skipped
+	if len(methods) != 1 || methods[0] != healthCheckMethod {
+		t.Fatalf("unexpected methods: %v", methods)
+	}
+
+	//example message (service="string" is unknown)
+	code, err := client.Call(ctx, healthCheckMethod, "")
+	if err != nil || code != codes.NotFound {
+		t.Errorf("unexpected example call result: code=%v err=%v", code, err)
+	}
+
+	code, err = client.Call(ctx, healthCheckMethod, `{"service":"svc"}`)
+	if err != nil || code != codes.OK {
+		t.Errorf("unexpected call result: code=%v err=%v", code, err)
+	}
+
+	if _, err := client.Call(ctx, "/grpc.health.v1.Health/Unknown", ""); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
+
+func TestClientProtoset(t *testing.T) {
+	addr := startTestServer(t, false)
+
+	fdset := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto),
+		},
+	}
+
+	data, err := proto.Marshal(fdset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protoset := filepath.Join(t.TempDir(), "health.protoset")
+	if err := ioutil.WriteFile(protoset, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(addr, false, []string{protoset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	code, err := client.Call(context.Background(), "grpc.health.v1.Health.Check", "{}")
+	if err != nil || code != codes.OK {
+		t.Errorf("unexpected call result: code=%v err=%v", code, err)
+	}
+}
+
+func TestClientNonGRPCPort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	//the client is created without connecting to the target
+	client, err := NewClient(srv.Listener.Addr().String(), false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	if _, err := client.UnaryMethods(context.Background()); err == nil {
+		t.Error("expected service discovery error for a non-gRPC port")
+	}
+
+	if elapsed := time.Since(start); elapsed >= callTimeout {
+		t.Errorf("non-gRPC port probe took too long: %v", elapsed)
+	}
+}
+
+func TestClientWaitReady(t *testing.T) {
+	addr := startTestServer(t, false)
+
+	client, err := NewClient(addr, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(context.Background()); err != nil {
+		t.Errorf("unexpected plaintext connection error: %v", err)
+	}
+
+	//the TLS handshake fails with the plaintext server
+	tlsClient, err := NewClient(addr, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tlsClient.Close()
+
+	if err := tlsClient.WaitReady(context.Background()); err == nil {
+		t.Error("expected TLS connection error for a plaintext server")
+	}
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Max message nesting level for the example messages
+// (also protects from the recursive messages)
+const maxMessageDepth = 5
+
+// ExampleMessage creates a message with the example values for all fields
+// (the first field of each oneof group; the map fields are left empty)
+func ExampleMessage(md protoreflect.MessageDescriptor) *dynamicpb.Message {
+	return exampleMessage(md, 0)
+}
+
+func exampleMessage(md protoreflect.MessageDescriptor, depth int) *dynamicpb.Message {
+	msg := dynamicpb.NewMessage(md)
+	if depth >= maxMessageDepth {
+		return msg
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsMap() {
+			continue
+		}
+
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() &&
+			msg.WhichOneof(oneof) != nil {
+			continue
+		}
+
+		value := exampleValue(fd, depth)
+		if fd.IsList() {
+			msg.Mutable(fd).List().Append(value)
+			continue
+		}
+
+		msg.Set(fd, value)
+	}
+
+	return msg
+}
+
+func exampleValue(fd protoreflect.FieldDescriptor, depth int) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true)
+	case protoreflect.EnumKind:
+		//the first value is usually the "unspecified" one
+		values := fd.Enum().Values()
+		if values.Len() > 1 {
+			return protoreflect.ValueOfEnum(values.Get(1).Number())
+		}
+
+		return protoreflect.ValueOfEnum(values.Get(0).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(1)
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(1)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(1)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(1)
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1)
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString("string")
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte("bytes"))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoreflect.ValueOfMessage(exampleMessage(fd.Message(), depth+1))
+	}
+
+	return fd.Default()
+}
@@ -0,0 +1,556 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	log "github.com/sirupsen/logrus"
+)
+
+// Max schema nesting level for the generated values
+// (also protects from the recursive schemas)
+const maxSchemaDepth = 8
+
+// apiSpecRequest is an API spec operation request generated from the spec schemas
+type apiSpecRequest struct {
+	method      string
+	path        string //path template
+	operationID string
+	endpoint    string
+	headers     http.Header
+	cookies     []*http.Cookie
+	body        []byte
+	contentType string
+}
+
+func (r *apiSpecRequest) newHTTPRequest() (*http.Request, error) {
+	req, err := http.NewRequest(r.method, r.endpoint, bytes.NewReader(r.body))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range r.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	return req, nil
+}
+
+// newAPISpecRequest creates an operation request with the parameter
+// and body values generated from the spec (examples, defaults, enums
+// and type-correct synthetic values) and with the credentials
+// for the operation security schemes (if provided)
+func newAPISpecRequest(
+	spec *openapi3.T,
+	baseAddr string,
+	apiPath string,
+	method string,
+	pathItem *openapi3.PathItem,
+	op *openapi3.Operation,
+	credentials map[string]string,
+) *apiSpecRequest {
+	r := &apiSpecRequest{
+		method:      strings.ToUpper(method),
+		path:        apiPath,
+		operationID: op.OperationID,
+		headers:     http.Header{},
+	}
+
+	query := url.Values{}
+	resourcePath := apiPath
+	for _, param := range operationParams(pathItem, op) {
+		value := paramValue(param)
+		if value == nil && param.In == openapi3.ParameterInPath {
+			//keeping the old behavior for the params without any value info
+			value = param.Name
+		}
+
+		if value == nil {
+			continue
+		}
+
+		switch param.In {
+		case openapi3.ParameterInPath:
+			resourcePath = strings.ReplaceAll(resourcePath,
+				fmt.Sprintf("{%s}", param.Name),
+				url.PathEscape(formatParamValue(value)))
+		case openapi3.ParameterInQuery:
+			if !param.Required && !hasExplicitValue(param) {
+				//the optional params with the synthetic values might break the calls
+				continue
+			}
+
+			if list, ok := value.([]interface{}); ok && (param.Explode == nil || *param.Explode) {
+				for _, item := range list {
+					query.Add(param.Name, formatParamValue(item))
+				}
+			} else {
+				query.Set(param.Name, formatParamValue(value))
+			}
+		case openapi3.ParameterInHeader:
+			r.headers.Set(param.Name, formatParamValue(value))
+		case openapi3.ParameterInCookie:
+			r.cookies = append(r.cookies, &http.Cookie{Name: param.Name, Value: formatParamValue(value)})
+		}
+	}
+
+	//undeclared path params (very primitive way to set them)
+	resourcePath = strings.ReplaceAll(resourcePath, "{", "")
+	resourcePath = strings.ReplaceAll(resourcePath, "}", "")
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		r.contentType, r.body = requestBodyData(op.RequestBody.Value)
+	}
+
+	applySecurity(spec, op, credentials, r, query)
+
+	r.endpoint = fmt.Sprintf("%s%s", baseAddr, resourcePath)
+	if len(query) > 0 {
+		r.endpoint = fmt.Sprintf("%s?%s", r.endpoint, query.Encode())
+	}
+
+	return r
+}
+
+// operationParams returns the path item params overridden by the operation params
+func operationParams(pathItem *openapi3.PathItem, op *openapi3.Operation) []*openapi3.Parameter {
+	var params []*openapi3.Parameter
+	idx := map[string]int{}
+	add := func(refs openapi3.Parameters) {
+		for _, ref := range refs {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+
+			key := ref.Value.In + ":" + ref.Value.Name
+			if i, found := idx[key]; found {
+				params[i] = ref.Value
+				continue
+			}
+
+			idx[key] = len(params)
+			params = append(params, ref.Value)
+		}
+	}
+
+	add(pathItem.Parameters)
+	add(op.Parameters)
+	return params
+}
+
+func hasExplicitValue(param *openapi3.Parameter) bool {
+	if param.Example != nil || len(param.Examples) > 0 {
+		return true
+	}
+
+	if param.Schema != nil && param.Schema.Value != nil {
+		s := param.Schema.Value
+		return s.Example != nil || s.Default != nil || len(s.Enum) > 0
+	}
+
+	return false
+}
+
+func paramValue(param *openapi3.Parameter) interface{} {
+	if param.Example != nil {
+		return param.Example
+	}
+
+	if value := examplesValue(param.Examples); value != nil {
+		return value
+	}
+
+	if param.Schema != nil {
+		return schemaValue(param.Schema, 0)
+	}
+
+	//complex params (serialized using the media type)
+	for _, ct := range sortedContentTypes(param.Content) {
+		if value := mediaTypeValue(param.Content[ct]); value != nil {
+			if data, err := json.Marshal(value); err == nil {
+				return string(data)
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatParamValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		//json/yaml numbers
+		if v == float64(int64(v)) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, formatParamValue(item))
+		}
+
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+func examplesValue(examples openapi3.Examples) interface{} {
+	var names []string
+	for name := range examples {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if ref := examples[name]; ref != nil && ref.Value != nil && ref.Value.Value != nil {
+			return ref.Value.Value
+		}
+	}
+
+	return nil
+}
+
+func mediaTypeValue(mt *openapi3.MediaType) interface{} {
+	if mt == nil {
+		return nil
+	}
+
+	if mt.Example != nil {
+		return mt.Example
+	}
+
+	if value := examplesValue(mt.Examples); value != nil {
+		return value
+	}
+
+	return schemaValue(mt.Schema, 0)
+}
+
+func sortedContentTypes(content openapi3.Content) []string {
+	var list []string
+	for ct := range content {
+		list = append(list, ct)
+	}
+
+	sort.Strings(list)
+	return list
+}
+
+// requestBodyData returns the request body data (JSON is preferred)
+func requestBodyData(body *openapi3.RequestBody) (string, []byte) {
+	contentTypes := sortedContentTypes(body.Content)
+	for _, ct := range contentTypes {
+		if !strings.Contains(ct, "json") {
+			continue
+		}
+
+		value := mediaTypeValue(body.Content[ct])
+		if value == nil {
+			value = map[string]interface{}{}
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			log.Debugf("http.CustomProbe.requestBodyData - json.Marshal error=%v", err)
+			continue
+		}
+
+		return ct, data
+	}
+
+	for _, ct := range contentTypes {
+		value := mediaTypeValue(body.Content[ct])
+		switch {
+		case ct == "application/x-www-form-urlencoded":
+			form := url.Values{}
+			if fields, ok := value.(map[string]interface{}); ok {
+				for name, fvalue := range fields {
+					form.Set(name, formatParamValue(fvalue))
+				}
+			}
+
+			return ct, []byte(form.Encode())
+		case strings.HasPrefix(ct, "text/"):
+			if value == nil {
+				value = ""
+			}
+
+			return ct, []byte(formatParamValue(value))
+		}
+	}
+
+	//unsupported body content types (no body for now)
+	return "", nil
+}
+
+// schemaValue generates a value for the schema using (in order):
+// example, default, the first enum value and a type-correct synthetic value
+func schemaValue(ref *openapi3.SchemaRef, depth int) interface{} {
+	if ref == nil || ref.Value == nil || depth > maxSchemaDepth {
+		return nil
+	}
+
+	s := ref.Value
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+
+	if len(s.AllOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, sub := range s.AllOf {
+			value := schemaValue(sub, depth+1)
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				if value != nil {
+					return value
+				}
+
+				continue
+			}
+
+			for name, fvalue := range fields {
+				merged[name] = fvalue
+			}
+		}
+
+		for name, fvalue := range objectValue(s, depth) {
+			merged[name] = fvalue
+		}
+
+		return merged
+	}
+
+	if len(s.OneOf) > 0 {
+		return schemaValue(s.OneOf[0], depth+1)
+	}
+
+	if len(s.AnyOf) > 0 {
+		return schemaValue(s.AnyOf[0], depth+1)
+	}
+
+	switch s.Type {
+	case "string":
+		return stringValue(s)
+	case "integer":
+		return int64(numberValue(s))
+	case "number":
+		return numberValue(s)
+	case "boolean":
+		return true
+	case "array":
+		count := int(s.MinItems)
+		if count == 0 {
+			count = 1
+		}
+
+		list := []interface{}{}
+		if item := schemaValue(s.Items, depth+1); item != nil {
+			for i := 0; i < count; i++ {
+				list = append(list, item)
+			}
+		}
+
+		return list
+	case "object":
+		return objectValue(s, depth)
+	case "":
+		if len(s.Properties) > 0 {
+			return objectValue(s, depth)
+		}
+	}
+
+	return nil
+}
+
+func objectValue(s *openapi3.Schema, depth int) map[string]interface{} {
+	value := map[string]interface{}{}
+	for name, prop := range s.Properties {
+		if prop != nil && prop.Value != nil && prop.Value.ReadOnly {
+			continue
+		}
+
+		if pvalue := schemaValue(prop, depth+1); pvalue != nil {
+			value[name] = pvalue
+		}
+	}
+
+	return value
+}
+
+func stringValue(s *openapi3.Schema) string {
+	var value string
+	switch s.Format {
+	case "date-time":
+		value = "2023-01-01T00:00:00Z"
+	case "date":
+		value = "2023-01-01"
+	case "time":
+		value = "00:00:00"
+	case "uuid":
+		value = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		value = "user@example.com"
+	case "uri", "url":
+		value = "https://example.com"
+	case "hostname":
+		value = "example.com"
+	case "ipv4":
+		value = "127.0.0.1"
+	case "ipv6":
+		value = "::1"
+	case "byte":
+		value = "c3RyaW5n"
+	case "password":
+		value = "password"
+	default:
+		value = "string"
+	}
+
+	if uint64(len(value)) < s.MinLength {
+		value += strings.Repeat("x", int(s.MinLength)-len(value))
+	}
+
+	if s.MaxLength != nil && uint64(len(value)) > *s.MaxLength {
+		value = value[:*s.MaxLength]
+	}
+
+	return value
+}
+
+func numberValue(s *openapi3.Schema) float64 {
+	value := float64(1)
+	if s.Min != nil && value <= *s.Min {
+		value = *s.Min
+		if s.ExclusiveMin {
+			value++
+		}
+	}
+
+	if s.Max != nil && value >= *s.Max {
+		value = *s.Max
+		if s.ExclusiveMax {
+			value--
+		}
+	}
+
+	return value
+}
+
+// applySecurity adds the credentials for the first operation security requirement
+// where all schemes have the provided credentials (the credentials are keyed
+// by the security scheme names)
+func applySecurity(
+	spec *openapi3.T,
+	op *openapi3.Operation,
+	credentials map[string]string,
+	r *apiSpecRequest,
+	query url.Values) {
+	requirements := spec.Security
+	if op.Security != nil {
+		requirements = *op.Security
+	}
+
+	if len(requirements) == 0 || len(credentials) == 0 {
+		return
+	}
+
+	for _, requirement := range requirements {
+		if len(requirement) == 0 {
+			//optional security
+			return
+		}
+
+		var schemes []string
+		for name := range requirement {
+			if _, found := credentials[name]; !found {
+				schemes = nil
+				break
+			}
+
+			schemes = append(schemes, name)
+		}
+
+		if len(schemes) == 0 {
+			continue
+		}
+
+		sort.Strings(schemes)
+		for _, name := range schemes {
+			ref, found := spec.Components.SecuritySchemes[name]
+			if !found || ref == nil || ref.Value == nil {
+				log.Debugf("http.CustomProbe.applySecurity - unknown security scheme '%s'", name)
+				continue
+			}
+
+			applySecurityScheme(ref.Value, credentials[name], r, query)
+		}
+
+		return
+	}
+
+	log.Debugf("http.CustomProbe.applySecurity - no credentials for %s %s", r.method, r.path)
+}
+
+func applySecurityScheme(scheme *openapi3.SecurityScheme, credential string, r *apiSpecRequest, query url.Values) {
+	switch strings.ToLower(scheme.Type) {
+	case "apikey":
+		switch scheme.In {
+		case "header":
+			r.headers.Set(scheme.Name, credential)
+		case "query":
+			query.Set(scheme.Name, credential)
+		case "cookie":
+			r.cookies = append(r.cookies, &http.Cookie{Name: scheme.Name, Value: credential})
+		}
+	case "http":
+		if strings.EqualFold(scheme.Scheme, "basic") {
+			if user, password, found := strings.Cut(credential, ":"); found {
+				req := &http.Request{Header: http.Header{}}
+				req.SetBasicAuth(user, password)
+				r.headers.Set("Authorization", req.Header.Get("Authorization"))
+			} else {
+				//already encoded
+				r.headers.Set("Authorization", "Basic "+credential)
+			}
+
+			return
+		}
+
+		authScheme := "Bearer"
+		if scheme.Scheme != "" && !strings.EqualFold(scheme.Scheme, "bearer") {
+			authScheme = scheme.Scheme
+		}
+
+		r.headers.Set("Authorization", fmt.Sprintf("%s %s", authScheme, credential))
+	case "oauth2", "openidconnect":
+		r.headers.Set("Authorization", "Bearer "+credential)
+	}
+}
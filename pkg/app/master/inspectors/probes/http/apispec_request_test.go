@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const testAPISpec = `
+openapi: 3.0.0
+info:
+  title: pets
+  version: "1.0"
+paths:
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+          minimum: 10
+    put:
+      operationId: updatePet
+      security:
+        - apiKey: []
+      parameters:
+        - name: verbose
+          in: query
+          schema:
+            type: boolean
+            default: false
+        - name: filter
+          in: query
+          schema:
+            type: string
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+          example: rex
+        kind:
+          type: string
+          enum: [dog, cat]
+        tags:
+          type: array
+          items:
+            type: string
+            format: uuid
+  securitySchemes:
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+func TestNewAPISpecRequest(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(testAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pathItem := spec.Paths["/pets/{petId}"]
+	r := newAPISpecRequest(spec, "http://localhost:8080/api", "/pets/{petId}", "put",
+		pathItem, pathItem.Put, map[string]string{"apiKey": "secret"})
+
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Method != "PUT" {
+		t.Errorf("unexpected method: %s", req.Method)
+	}
+
+	if expected := "http://localhost:8080/api/pets/10?verbose=false"; req.URL.String() != expected {
+		t.Errorf("unexpected url: %s (expected: %s)", req.URL, expected)
+	}
+
+	if req.Header.Get("X-API-Key") != "secret" {
+		t.Errorf("missing api key header")
+	}
+
+	if req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected content type: %s", req.Header.Get("Content-Type"))
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("malformed body (%s): %v", data, err)
+	}
+
+	if _, found := body["id"]; found {
+		t.Errorf("unexpected read-only property in body: %s", data)
+	}
+
+	if body["name"] != "rex" || body["kind"] != "dog" {
+		t.Errorf("unexpected body: %s", data)
+	}
+
+	if tags, ok := body["tags"].([]interface{}); !ok || len(tags) != 1 || tags[0] != "3fa85f64-5717-4562-b3fc-2c963f66afa6" {
+		t.Errorf("unexpected tags in body: %s", data)
+	}
+}
+
+func TestNewAPISpecRequestNoCredentials(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(testAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pathItem := spec.Paths["/pets/{petId}"]
+	r := newAPISpecRequest(spec, "http://localhost", "/pets/{petId}", "put",
+		pathItem, pathItem.Put, nil)
+
+	if len(r.headers) != 0 {
+		t.Errorf("unexpected headers: %v", r.headers)
+	}
+}
+
+func TestProbeAPISpecEndpointsCoverage(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(testAPISpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), host, config.HTTPProbeOptions{}, false)
+	probe.probeAPISpecEndpoints("http", host, port, "/api", spec)
+
+	if len(probe.APISpecCoverage) != 1 {
+		t.Fatalf("unexpected coverage: %+v", probe.APISpecCoverage)
+	}
+
+	info := probe.APISpecCoverage[0]
+	if info.Method != "PUT" || info.Path != "/pets/{petId}" || info.OperationID != "updatePet" {
+		t.Errorf("unexpected operation: %+v", info)
+	}
+
+	if info.StatusCode != http.StatusUnauthorized || info.Result != "unauthorized" || info.Attempts != 1 {
+		t.Errorf("unexpected operation result: %+v", info)
+	}
+
+	//the coverage is reported only when the probe is done
+	if coverage := probe.APISpecCoverageReport(); coverage != nil {
+		t.Errorf("unexpected coverage report for a running probe: %+v", coverage)
+	}
+
+	close(probe.doneChan)
+	if coverage := probe.APISpecCoverageReport(); len(coverage) != 1 || coverage[0] != info {
+		t.Errorf("unexpected coverage report: %+v", coverage)
+	}
+}
@@ -0,0 +1,304 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const (
+	oauth2TokenTimeout      = 30 * time.Second
+	oauth2TokenExpiryMargin = 30 * time.Second
+	maxOAuth2TokenRespSize  = 1024 * 1024
+)
+
+// probeAuth applies the auth settings to the HTTP probe clients
+type probeAuth struct {
+	cfg          config.HTTPProbeAuth
+	certificates []tls.Certificate
+	rootCAs      *x509.CertPool
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	//verifies the token servers on other hosts (the probe transports skip the verification)
+	//with the CA bundle (if it's configured) or with the system root CAs
+	tokenTransport http.RoundTripper
+}
+
+func newProbeAuth(cfg config.HTTPProbeAuth) (*probeAuth, error) {
+	auth := &probeAuth{cfg: cfg}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate error: %v", err)
+		}
+
+		auth.certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CACert != "" {
+		data, err := ioutil.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("CA bundle error: %v", err)
+		}
+
+		auth.rootCAs = x509.NewCertPool()
+		if !auth.rootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("CA bundle error: no certificates in %s", cfg.CACert)
+		}
+	}
+
+	if cfg.OAuth2 != nil {
+		auth.tokenTransport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			MaxIdleConns:    10,
+			IdleConnTimeout: 30 * time.Second,
+			TLSClientConfig: &tls.Config{
+				Certificates: auth.certificates,
+				RootCAs:      auth.rootCAs,
+			},
+		}
+	}
+
+	return auth, nil
+}
+
+// authFor returns the auth settings for the command
+// (nil command auth means the global auth settings)
+func (p *CustomProbe) authFor(cmdAuth *config.HTTPProbeAuth) (*probeAuth, error) {
+	p.authMu.Lock()
+	defer p.authMu.Unlock()
+
+	if auth, found := p.auths[cmdAuth]; found {
+		return auth, nil
+	}
+
+	var auth *probeAuth
+	if cfg := p.opts.Auth.Merge(cmdAuth); !cfg.IsEmpty() {
+		var err error
+		if auth, err = newProbeAuth(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.auths == nil {
+		p.auths = map[*config.HTTPProbeAuth]*probeAuth{}
+	}
+
+	p.auths[cmdAuth] = auth
+	return auth, nil
+}
+
+// httpClient returns the HTTP client for the protocol
+// with the global (nil command auth) or the command auth settings
+func (p *CustomProbe) httpClient(proto string, cmdAuth *config.HTTPProbeAuth) (*http.Client, error) {
+	auth, err := p.authFor(cmdAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	return getHTTPClient(proto, auth)
+}
+
+// configureTLS adds the client certificates and the CA bundle verification
+// (the target host names are not verified because the probes use the mapped host ports)
+func (a *probeAuth) configureTLS(tlsConfig *tls.Config) {
+	if a == nil {
+		return
+	}
+
+	tlsConfig.Certificates = a.certificates
+	if a.rootCAs == nil {
+		return
+	}
+
+	rootCAs := a.rootCAs
+	tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("no target certificates")
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         rootCAs,
+			Intermediates: x509.NewCertPool(),
+		}
+
+		var leaf *x509.Certificate
+		for idx, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+
+			if idx == 0 {
+				leaf = cert
+			} else {
+				opts.Intermediates.AddCert(cert)
+			}
+		}
+
+		_, err := leaf.Verify(opts)
+		return err
+	}
+}
+
+// transport wraps the transport to add the bearer token to the requests
+// (the requests with the 'Authorization' header are not changed)
+func (a *probeAuth) transport(base http.RoundTripper) http.RoundTripper {
+	if a == nil || (a.cfg.BearerToken == "" && a.cfg.OAuth2 == nil) {
+		return base
+	}
+
+	return &authTransport{auth: a, base: base}
+}
+
+type authTransport struct {
+	auth *probeAuth
+	base http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	token, err := t.auth.bearerToken(req.URL, t.base)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+func (a *probeAuth) bearerToken(target *url.URL, base http.RoundTripper) (string, error) {
+	if a.cfg.OAuth2 == nil {
+		return a.cfg.BearerToken, nil
+	}
+
+	a.tokenMu.Lock()
+	defer a.tokenMu.Unlock()
+
+	if a.token != "" && (a.tokenExpiry.IsZero() || time.Now().Before(a.tokenExpiry)) {
+		return a.token, nil
+	}
+
+	token, expiresIn, err := fetchOAuth2Token(a.cfg.OAuth2, target, base, a.tokenTransport)
+	if err != nil {
+		return "", err
+	}
+
+	a.token = token
+	a.tokenExpiry = oauth2TokenExpiry(time.Now(), expiresIn)
+	return a.token, nil
+}
+
+// oauth2TokenExpiry returns the token refresh time (zero if the token doesn't expire).
+// The expiry margin is limited to a half of the token lifetime for the short-lived tokens.
+func oauth2TokenExpiry(now time.Time, expiresIn int) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+
+	lifetime := time.Duration(expiresIn) * time.Second
+	margin := oauth2TokenExpiryMargin
+	if margin > lifetime/2 {
+		margin = lifetime / 2
+	}
+
+	return now.Add(lifetime - margin)
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// fetchOAuth2Token runs the OAuth2 client credentials flow (RFC 6749, section 4.4)
+// (the probe transport is used only for the token URLs on the target host,
+// the other token servers are verified with the CA bundle or the system root CAs)
+func fetchOAuth2Token(cfg *config.HTTPProbeOAuth2, target *url.URL, base, verified http.RoundTripper) (string, int, error) {
+	tokenURL, err := target.Parse(cfg.TokenURL)
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2: bad token URL (%s): %v", cfg.TokenURL, err)
+	}
+
+	transport := base
+	if !strings.EqualFold(tokenURL.Host, target.Host) {
+		transport = verified
+	}
+
+	form := url.Values{}
+	for k, v := range cfg.Params {
+		form.Set(k, v)
+	}
+
+	form.Set("grant_type", "client_credentials")
+	if len(cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+
+	if cfg.CredentialsInBody {
+		form.Set("client_id", cfg.ClientID)
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tokenURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !cfg.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	client := &http.Client{
+		Timeout:   oauth2TokenTimeout,
+		Transport: transport,
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2: token request error: %v", err)
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxOAuth2TokenRespSize))
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2: token response error: %v", err)
+	}
+
+	var tokenRes oauth2TokenResponse
+	if err := json.Unmarshal(data, &tokenRes); err != nil && res.StatusCode == http.StatusOK {
+		return "", 0, fmt.Errorf("oauth2: malformed token response: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK || tokenRes.AccessToken == "" {
+		reason := tokenRes.Error
+		if tokenRes.ErrorDescription != "" {
+			reason = fmt.Sprintf("%s (%s)", reason, tokenRes.ErrorDescription)
+		}
+
+		return "", 0, fmt.Errorf("oauth2: token request failed: status=%d error=%s", res.StatusCode, reason)
+	}
+
+	return tokenRes.AccessToken, tokenRes.ExpiresIn, nil
+}
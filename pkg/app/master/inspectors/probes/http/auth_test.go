@@ -0,0 +1,251 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestProbeAuthOAuth2(t *testing.T) {
+	tokenCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			tokenCalls++
+			id, secret, _ := r.BasicAuth()
+			r.ParseForm()
+			if id != "probe" || secret != "s3cret" ||
+				r.Form.Get("grant_type") != "client_credentials" ||
+				r.Form.Get("scope") != "read write" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid_client"}`)
+				return
+			}
+
+			fmt.Fprint(w, `{"access_token":"t0ken","token_type":"bearer","expires_in":3600}`)
+		default:
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}
+	}))
+	defer srv.Close()
+
+	auth, err := newProbeAuth(config.HTTPProbeAuth{
+		OAuth2: &config.HTTPProbeOAuth2{
+			TokenURL:     "/oauth/token",
+			ClientID:     "probe",
+			ClientSecret: "s3cret",
+			Scopes:       []string{"read", "write"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := getHTTPClient(config.ProtoHTTP, auth)
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(srv.URL + "/api")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(data) != "Bearer t0ken" {
+			t.Errorf("unexpected Authorization header: %q", data)
+		}
+	}
+
+	if tokenCalls != 1 {
+		t.Errorf("expected one token request (cached token), got %d", tokenCalls)
+	}
+
+	//explicit request auth is not replaced
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api", nil)
+	req.SetBasicAuth("user", "pass")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(data) == "Bearer t0ken" {
+		t.Error("request Authorization header is replaced")
+	}
+
+	auth.cfg.OAuth2.ClientSecret = "bad"
+	auth.token = ""
+	if _, err := client.Get(srv.URL + "/api"); err == nil {
+		t.Error("expected token request error")
+	}
+}
+
+func TestProbeAuthOAuth2TokenServerVerification(t *testing.T) {
+	tokenCalls := 0
+	tokenSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		fmt.Fprint(w, `{"access_token":"t0ken","token_type":"bearer"}`)
+	}))
+	defer tokenSrv.Close()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	auth, err := newProbeAuth(config.HTTPProbeAuth{
+		OAuth2: &config.HTTPProbeOAuth2{
+			TokenURL:     tokenSrv.URL + "/oauth/token",
+			ClientID:     "probe",
+			ClientSecret: "s3cret",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the probe client skips the target verification,
+	//but the token server on another host is not in the system root CAs
+	client, _ := getHTTPClient(config.ProtoHTTPS, auth)
+	if _, err := client.Get(srv.URL + "/api"); err == nil {
+		t.Error("expected token server verification error")
+	}
+
+	if tokenCalls != 0 {
+		t.Errorf("unexpected token requests: %d", tokenCalls)
+	}
+}
+
+func TestProbeAuthOAuth2TokenServerCABundle(t *testing.T) {
+	tokenSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"t0ken","token_type":"bearer"}`)
+	}))
+	defer tokenSrv.Close()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	//the CA bundle has both the target and the token server certificates
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	caData = append(caData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tokenSrv.Certificate().Raw})...)
+	if err := ioutil.WriteFile(caFile, caData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := newProbeAuth(config.HTTPProbeAuth{
+		CACert: caFile,
+		OAuth2: &config.HTTPProbeOAuth2{
+			TokenURL:     tokenSrv.URL + "/oauth/token",
+			ClientID:     "probe",
+			ClientSecret: "s3cret",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := getHTTPClient(config.ProtoHTTPS, auth)
+	res, err := client.Get(srv.URL + "/api")
+	if err != nil {
+		t.Fatalf("unexpected error with the token server CA: %v", err)
+	}
+
+	data, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(data) != "Bearer t0ken" {
+		t.Errorf("unexpected Authorization header: %q", data)
+	}
+}
+
+func TestOAuth2TokenExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		expiresIn int
+		expected  time.Time
+	}{
+		{expiresIn: 0, expected: time.Time{}},
+		{expiresIn: 3600, expected: now.Add(3600*time.Second - oauth2TokenExpiryMargin)},
+		//the short-lived tokens are not expired right away
+		{expiresIn: 20, expected: now.Add(10 * time.Second)},
+	}
+
+	for _, test := range tests {
+		if expiry := oauth2TokenExpiry(now, test.expiresIn); !expiry.Equal(test.expected) {
+			t.Errorf("unexpected expiry for expires_in=%d: %v (expected %v)", test.expiresIn, expiry, test.expected)
+		}
+	}
+}
+
+func TestProbeAuthCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, caData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := newProbeAuth(config.HTTPProbeAuth{CACert: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := getHTTPClient(config.ProtoHTTPS, auth)
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error with the target CA: %v", err)
+	}
+	res.Body.Close()
+
+	//CA bundle without the target certificate
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "other-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	otherCA, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherFile := filepath.Join(t.TempDir(), "other.pem")
+	otherData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: otherCA})
+	if err := ioutil.WriteFile(otherFile, otherData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err = newProbeAuth(config.HTTPProbeAuth{CACert: otherFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ = getHTTPClient(config.ProtoHTTPS, auth)
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("expected certificate verification error")
+	}
+}
@@ -1,9 +1,18 @@
 package http
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gocolly/colly/v2"
@@ -17,14 +26,21 @@ const (
 	defaultCrawlMaxPageCount     = 1000
 	defaultCrawlConcurrency      = 10
 	defaultMaxConcurrentCrawlers = 1
+
+	robotsTxtPath  = "/robots.txt"
+	sitemapXMLPath = "/sitemap.xml"
 )
 
-func (p *CustomProbe) crawl(proto, domain, addr string) {
+func (p *CustomProbe) crawl(proto, domain, addr string, cmdAuth *config.HTTPProbeAuth) {
+	auth, err := p.authFor(cmdAuth)
+	if err != nil {
+		p.xc.Out.Error("HTTP probe - auth settings error - %v", err.Error())
+		return
+	}
 
 	var httpClient *http.Client
-	if strings.HasPrefix(proto, config.ProtoHTTP2) {
-		var err error
-		if httpClient, err = getHTTPClient(proto); err != nil {
+	if strings.HasPrefix(proto, config.ProtoHTTP2) || auth != nil {
+		if httpClient, err = getHTTPClient(proto, auth); err != nil {
 			p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 			return
 		}
@@ -52,6 +68,16 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 
 		var pageCount int
 
+		baseURL, err := url.Parse(addr)
+		if err != nil {
+			log.Debugf("http.CustomProbe.crawl - bad target address (%v): %v", addr, err)
+			return
+		}
+
+		//query param variants of the same URL are visited only once
+		var variantsMu sync.Mutex
+		variants := map[string]struct{}{}
+
 		c := colly.NewCollector()
 		c.UserAgent = "ds.crawler"
 		c.IgnoreRobotsTxt = true
@@ -108,14 +134,130 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 			e.Request.Visit(e.Attr("src"))
 		})
 
-		c.OnHTML("source[srcset]", func(e *colly.HTMLElement) {
+		c.OnHTML("video[src], audio[src], track[src], embed[src], iframe[src], input[type=image][src], video[poster], object[data]", func(e *colly.HTMLElement) {
+			if p.opts.CrawlMaxPageCount > 0 &&
+				pageCount > p.opts.CrawlMaxPageCount {
+				log.Debugf("http.CustomProbe.crawl.OnHTML(media) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+				return
+			}
+
+			for _, attr := range []string{"src", "poster", "data"} {
+				if link := e.Attr(attr); link != "" {
+					e.Request.Visit(link)
+				}
+			}
+		})
+
+		c.OnHTML("source[srcset], img[srcset]", func(e *colly.HTMLElement) {
 			if p.opts.CrawlMaxPageCount > 0 &&
 				pageCount > p.opts.CrawlMaxPageCount {
-				log.Debugf("http.CustomProbe.crawl.OnHTML(source[srcset]) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+				log.Debugf("http.CustomProbe.crawl.OnHTML(srcset) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
 				return
 			}
 
-			e.Request.Visit(e.Attr("srcset"))
+			for _, link := range srcsetURLs(e.Attr("srcset")) {
+				e.Request.Visit(link)
+			}
+		})
+
+		c.OnHTML("style, [style]", func(e *colly.HTMLElement) {
+			if p.opts.CrawlMaxPageCount > 0 &&
+				pageCount > p.opts.CrawlMaxPageCount {
+				log.Debugf("http.CustomProbe.crawl.OnHTML(style) - reached max page count, ignoring link (%v)", p.opts.CrawlMaxPageCount)
+				return
+			}
+
+			css := e.Attr("style")
+			if e.Name == "style" {
+				css = e.Text
+			}
+
+			for _, link := range cssURLs(css) {
+				e.Request.Visit(link)
+			}
+		})
+
+		if p.opts.CrawlForms || p.opts.CrawlPostForms {
+			c.OnHTML("form", func(e *colly.HTMLElement) {
+				if p.opts.CrawlMaxPageCount > 0 &&
+					pageCount > p.opts.CrawlMaxPageCount {
+					log.Debugf("http.CustomProbe.crawl.OnHTML(form) - reached max page count, ignoring form (%v)", p.opts.CrawlMaxPageCount)
+					return
+				}
+
+				action := e.Request.AbsoluteURL(e.Attr("action"))
+				if action == "" {
+					return
+				}
+
+				values := formValues(e)
+				switch strings.ToUpper(e.Attr("method")) {
+				case "", http.MethodGet:
+					if !p.opts.CrawlForms {
+						return
+					}
+
+					formURL, err := url.Parse(action)
+					if err != nil {
+						return
+					}
+
+					query := url.Values{}
+					for name, value := range values {
+						query.Set(name, value)
+					}
+
+					formURL.RawQuery = query.Encode()
+					e.Request.Visit(formURL.String())
+				case http.MethodPost:
+					if !p.opts.CrawlPostForms {
+						return
+					}
+
+					if strings.HasPrefix(strings.ToLower(e.Attr("enctype")), "multipart/") {
+						data := map[string][]byte{}
+						for name, value := range values {
+							data[name] = []byte(value)
+						}
+
+						e.Request.PostMultipart(action, data)
+					} else {
+						e.Request.Post(action, values)
+					}
+				}
+			})
+		}
+
+		c.OnResponse(func(r *colly.Response) {
+			contentType := strings.ToLower(r.Headers.Get("Content-Type"))
+			switch {
+			case !p.opts.CrawlSitemap:
+				//the robots.txt and sitemap seeds are used only if the sitemap crawling is enabled
+			case r.Request.URL.Path == robotsTxtPath:
+				paths, sitemaps := parseRobotsTxt(r.Body)
+				for _, path := range paths {
+					c.Visit(crawlTargetURL(baseURL, path))
+				}
+
+				for _, sitemap := range sitemaps {
+					c.Visit(crawlTargetURL(baseURL, sitemap))
+				}
+			case strings.Contains(contentType, "xml") ||
+				strings.HasSuffix(r.Request.URL.Path, ".xml") ||
+				strings.HasSuffix(r.Request.URL.Path, ".xml.gz"):
+				//the sitemap pages are the crawler seeds (same as the start page)
+				for _, loc := range parseSitemap(r.Body) {
+					c.Visit(crawlTargetURL(baseURL, loc))
+				}
+			}
+
+			switch {
+			case strings.Contains(contentType, "text/css") ||
+				strings.HasSuffix(r.Request.URL.Path, ".css"):
+				for _, link := range cssURLs(string(r.Body)) {
+					r.Request.Visit(link)
+				}
+			}
 		})
 
 		c.OnHTML("[data-src]", func(e *colly.HTMLElement) {
@@ -129,6 +271,19 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 		})
 
 		c.OnRequest(func(r *colly.Request) {
+			if key := crawlVariantKey(r.Method, r.URL); key != "" {
+				variantsMu.Lock()
+				_, found := variants[key]
+				variants[key] = struct{}{}
+				variantsMu.Unlock()
+
+				if found {
+					log.Debugf("http.CustomProbe.crawl.OnRequest - skipping query param variant (%v)", r.URL)
+					r.Abort()
+					return
+				}
+			}
+
 			p.xc.Out.Info("http.probe.crawler",
 				ovars{
 					"page": pageCount,
@@ -155,6 +310,11 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 		})
 
 		c.Visit(addr)
+		if p.opts.CrawlSitemap {
+			c.Visit(crawlTargetURL(baseURL, robotsTxtPath))
+			c.Visit(crawlTargetURL(baseURL, sitemapXMLPath))
+		}
+
 		c.Wait()
 		p.xc.Out.Info("probe.crawler.done",
 			ovars{
@@ -162,3 +322,234 @@ func (p *CustomProbe) crawl(proto, domain, addr string) {
 			})
 	}()
 }
+
+// crawlTargetURL returns the URL for the crawler target
+// (the absolute URLs are rewritten to use the target host)
+func crawlTargetURL(base *url.URL, link string) string {
+	linkURL, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+
+	linkURL.Scheme = ""
+	linkURL.Host = ""
+	linkURL.User = nil
+	linkURL.Fragment = ""
+	return base.ResolveReference(linkURL).String()
+}
+
+// crawlVariantKey returns the key for the URLs with query params and the form posts
+// (the URLs with the same path and the same param names have the same key)
+func crawlVariantKey(method string, u *url.URL) string {
+	if u.RawQuery == "" && method == http.MethodGet {
+		return ""
+	}
+
+	var names []string
+	for name := range u.Query() {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return method + " " + u.Scheme + "://" + u.Host + u.Path + "?" + strings.Join(names, "&")
+}
+
+// parseRobotsTxt returns the 'Allow'/'Disallow' paths and the 'Sitemap' URLs
+// (the path patterns are truncated at the first wildcard)
+func parseRobotsTxt(data []byte) ([]string, []string) {
+	var paths, sitemaps []string
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx > -1 {
+			line = line[:idx]
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "allow", "disallow":
+			if idx := strings.IndexAny(value, "*$"); idx > -1 {
+				value = value[:idx]
+			}
+
+			if !strings.HasPrefix(value, "/") || value == "/" {
+				continue
+			}
+
+			if _, found := seen[value]; !found {
+				seen[value] = struct{}{}
+				paths = append(paths, value)
+			}
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+	}
+
+	return paths, sitemaps
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+type sitemapDoc struct {
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+// parseSitemap returns the page and the nested sitemap URLs
+// from the sitemap (or sitemap index) document (optionally gzipped)
+func parseSitemap(data []byte) []string {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+
+		if data, err = ioutil.ReadAll(reader); err != nil {
+			return nil
+		}
+	}
+
+	var doc sitemapDoc
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		log.Tracef("http.parseSitemap - error=%v", err)
+		return nil
+	}
+
+	var locs []string
+	for _, info := range append(doc.Sitemaps, doc.URLs...) {
+		if loc := strings.TrimSpace(info.Loc); loc != "" {
+			locs = append(locs, loc)
+		}
+	}
+
+	return locs
+}
+
+var (
+	cssURLPattern    = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+	cssImportPattern = regexp.MustCompile(`@import\s+['"]([^'"]+)['"]`)
+)
+
+// cssURLs returns the resource URLs referenced in the CSS data
+// (fonts, images and imported stylesheets)
+func cssURLs(css string) []string {
+	var links []string
+	for _, pattern := range []*regexp.Regexp{cssURLPattern, cssImportPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(css, -1) {
+			link := strings.TrimSpace(match[1])
+			if link == "" ||
+				strings.HasPrefix(link, "data:") ||
+				strings.HasPrefix(link, "#") {
+				continue
+			}
+
+			links = append(links, link)
+		}
+	}
+
+	return links
+}
+
+// srcsetURLs returns the image candidate URLs from the 'srcset' attribute
+func srcsetURLs(srcset string) []string {
+	var links []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "data:") {
+			links = append(links, fields[0])
+		}
+	}
+
+	return links
+}
+
+// formValues returns the form field values
+// (the synthetic values are used for the empty fields)
+func formValues(e *colly.HTMLElement) map[string]string {
+	values := map[string]string{}
+	e.ForEach("input[name]", func(_ int, field *colly.HTMLElement) {
+		name := field.Attr("name")
+		inputType := strings.ToLower(field.Attr("type"))
+		switch inputType {
+		case "file", "image", "reset", "button", "submit":
+			return
+		case "checkbox":
+			if _, checked := field.DOM.Attr("checked"); checked {
+				values[name] = formValue(field.Attr("value"), "on")
+			}
+			return
+		case "radio":
+			_, checked := field.DOM.Attr("checked")
+			if _, found := values[name]; !found || checked {
+				values[name] = formValue(field.Attr("value"), "on")
+			}
+			return
+		}
+
+		values[name] = formValue(field.Attr("value"), syntheticInputValue(inputType))
+	})
+
+	e.ForEach("select[name]", func(_ int, field *colly.HTMLElement) {
+		value := field.ChildAttr("option[selected]", "value")
+		if value == "" {
+			value = field.ChildAttr("option", "value")
+		}
+
+		values[field.Attr("name")] = value
+	})
+
+	e.ForEach("textarea[name]", func(_ int, field *colly.HTMLElement) {
+		values[field.Attr("name")] = formValue(field.Text, "probe")
+	})
+
+	return values
+}
+
+func formValue(value, defaultValue string) string {
+	if value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+func syntheticInputValue(inputType string) string {
+	switch inputType {
+	case "hidden":
+		return ""
+	case "email":
+		return "probe@example.com"
+	case "number", "range":
+		return "1"
+	case "tel":
+		return "5555555555"
+	case "url":
+		return "http://example.com"
+	case "password":
+		return "Probe-123"
+	case "date":
+		return "2020-01-01"
+	case "datetime-local":
+		return "2020-01-01T12:00"
+	case "time":
+		return "12:00"
+	case "month":
+		return "2020-01"
+	case "week":
+		return "2020-W01"
+	case "color":
+		return "#000000"
+	default:
+		return "probe"
+	}
+}
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestParseRobotsTxt(t *testing.T) {
+	data := []byte(`User-agent: *
+Disallow: /admin/ # admin pages
+Disallow: /search*
+Allow: /public$
+Disallow: /
+Disallow:
+Disallow: /admin/
+Sitemap: https://www.example.com/sitemap_index.xml
+`)
+
+	paths, sitemaps := parseRobotsTxt(data)
+	if expected := []string{"/admin/", "/search", "/public"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+
+	if expected := []string{"https://www.example.com/sitemap_index.xml"}; !reflect.DeepEqual(sitemaps, expected) {
+		t.Errorf("unexpected sitemaps: %v", sitemaps)
+	}
+}
+
+func TestParseSitemap(t *testing.T) {
+	index := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://www.example.com/sitemap-pages.xml.gz</loc></sitemap>
+</sitemapindex>`)
+
+	if locs := parseSitemap(index); !reflect.DeepEqual(locs, []string{"https://www.example.com/sitemap-pages.xml.gz"}) {
+		t.Errorf("unexpected sitemap index locs: %v", locs)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://www.example.com/about </loc></url>
+  <url><loc>https://www.example.com/blog?page=2</loc></url>
+</urlset>`))
+	zw.Close()
+
+	expected := []string{"https://www.example.com/about", "https://www.example.com/blog?page=2"}
+	if locs := parseSitemap(buf.Bytes()); !reflect.DeepEqual(locs, expected) {
+		t.Errorf("unexpected sitemap locs: %v", locs)
+	}
+}
+
+func TestCrawlTargetURL(t *testing.T) {
+	base, _ := url.Parse("http://127.0.0.1:32768/")
+	tt := map[string]string{
+		"https://www.example.com/blog?page=2#top": "http://127.0.0.1:32768/blog?page=2",
+		"/admin/":         "http://127.0.0.1:32768/admin/",
+		"docs/index.html": "http://127.0.0.1:32768/docs/index.html",
+	}
+
+	for link, expected := range tt {
+		if got := crawlTargetURL(base, link); got != expected {
+			t.Errorf("%s: expected %s, got %s", link, expected, got)
+		}
+	}
+}
+
+func TestCrawlVariantKey(t *testing.T) {
+	key := func(method, link string) string {
+		u, _ := url.Parse(link)
+		return crawlVariantKey(method, u)
+	}
+
+	if key("GET", "http://host/items") != "" {
+		t.Error("expected no key for the URL without query params")
+	}
+
+	if key("GET", "http://host/items?page=1&sort=asc") != key("GET", "http://host/items?sort=desc&page=7") {
+		t.Error("expected the same key for the query param variants")
+	}
+
+	if key("GET", "http://host/items?page=1") == key("GET", "http://host/items?page=1&sort=asc") {
+		t.Error("expected different keys for different query param names")
+	}
+
+	if key("POST", "http://host/login") == "" {
+		t.Error("expected key for the form post")
+	}
+}
+
+func TestCSSURLs(t *testing.T) {
+	css := `@import "theme.css";
+@font-face { src: url('/fonts/app.woff2') format('woff2'), url(/fonts/app.woff); }
+.logo { background: url( "img/logo.png" ) no-repeat; }
+.icon { background: url(data:image/png;base64,AAAA); }`
+
+	expected := []string{"/fonts/app.woff2", "/fonts/app.woff", "img/logo.png", "theme.css"}
+	if got := cssURLs(css); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected CSS URLs: %v", got)
+	}
+}
+
+func TestSrcsetURLs(t *testing.T) {
+	expected := []string{"/img/small.jpg", "/img/large.jpg"}
+	if got := srcsetURLs("/img/small.jpg 480w, /img/large.jpg 1080w"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected srcset URLs: %v", got)
+	}
+}
+
+const testCrawlPage = `<html>
+<head><link rel="stylesheet" href="/static/app.css"></head>
+<body>
+<a href="/robots.txt">robots</a>
+<form action="/search">
+  <input type="text" name="q">
+  <input type="email" name="email">
+  <input type="checkbox" name="exact">
+  <select name="sort"><option value="name">Name</option><option value="date" selected>Date</option></select>
+  <input type="submit" name="go" value="Search">
+</form>
+</body>
+</html>`
+
+func crawlTestServer(t *testing.T) (*httptest.Server, func() map[string]bool) {
+	var mu sync.Mutex
+	visited := map[string]bool{}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		visited[r.URL.RequestURI()] = true
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, testCrawlPage)
+		case "/static/app.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, `@import "more.css"; body { background: url('/img/bg.png'); }`)
+		case "/robots.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "User-agent: *\nDisallow: /private/\nSitemap: %s/sitemap_index.xml\n", srv.URL)
+		case "/sitemap_index.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/sitemap-pages.xml</loc></sitemap></sitemapindex>`, srv.URL)
+		case "/sitemap-pages.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<urlset><url><loc>/about</loc></url></urlset>`)
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "ok")
+		}
+	}))
+
+	return srv, func() map[string]bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return visited
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	formQuery := url.Values{
+		"q":     {"probe"},
+		"email": {"probe@example.com"},
+		"sort":  {"date"},
+	}.Encode()
+
+	tests := []struct {
+		name       string
+		opts       config.HTTPProbeOptions
+		visited    []string
+		notVisited []string
+	}{
+		{
+			name: "forms and sitemaps",
+			opts: config.HTTPProbeOptions{CrawlForms: true, CrawlSitemap: true},
+			visited: []string{
+				"/search?" + formQuery,
+				"/static/app.css",
+				"/static/more.css",
+				"/img/bg.png",
+				"/robots.txt",
+				"/private/",
+				"/sitemap_index.xml",
+				"/sitemap-pages.xml",
+				"/about",
+			},
+		},
+		{
+			//robots.txt is visited as a regular link, but it's not used as a seed
+			name: "no forms and sitemaps",
+			visited: []string{
+				"/static/app.css",
+				"/img/bg.png",
+				"/robots.txt",
+			},
+			notVisited: []string{
+				"/search?" + formQuery,
+				"/private/",
+				"/sitemap_index.xml",
+				"/sitemap-pages.xml",
+				"/about",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		srv, visited := crawlTestServer(t)
+		u, _ := url.Parse(srv.URL)
+		host, port, _ := net.SplitHostPort(u.Host)
+
+		probe := newCustomProbe(app.NewExecutionContext("test", "text"), host, test.opts, false)
+		probe.ports = []string{port}
+		probe.crawl("http", host, srv.URL+"/", nil)
+		probe.workers.Wait()
+		srv.Close()
+
+		pages := visited()
+		for _, page := range test.visited {
+			if !pages[page] {
+				t.Errorf("%s: page not visited: %s (visited: %v)", test.name, page, pages)
+			}
+		}
+
+		for _, page := range test.notVisited {
+			if pages[page] {
+				t.Errorf("%s: unexpected page visit: %s", test.name, page)
+			}
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/config"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/pod"
+	"github.com/docker-slim/docker-slim/pkg/report"
 )
 
 const (
@@ -30,6 +31,10 @@ const (
 	defaultFastCGIPortStr = "9000"
 )
 
+var (
+	ErrNoContainerNetwork = errors.New("HTTP probes are not supported for the containers without network (--network none)")
+)
+
 type ovars = app.OutVars
 
 // CustomProbe is a custom HTTP probe
@@ -40,20 +45,68 @@ type CustomProbe struct {
 
 	ports      []string
 	targetHost string
+	tcpTargets []tcpProbeTarget
+
+	//readiness signals (nil if not available):
+	//the image health check and the sensor 'listen()' signal
+	//(known is false until the sensor streams the monitor stats)
+	healthcheck  readyCheckFunc
+	appListening func() (listening bool, known bool)
+	appExited    func() bool
 
-	APISpecProbes []apiSpecInfo
+	APISpecProbes   []apiSpecInfo
+	APISpecCoverage []report.APISpecOpCoverage
 
 	printState bool
 
+	//set after the first successful HTTP call
+	//(the API spec and GraphQL endpoints are probed then)
+	httpCallOk    bool
+	graphQLProbed bool
+
 	CallCount uint64
 	ErrCount  uint64
 	OkCount   uint64
 
+	GRPCCallCount uint64
+	GRPCErrCount  uint64
+	GRPCOkCount   uint64
+
+	AssertFailCount   uint64
+	ScenarioFailCount uint64
+
+	TCPCallCount uint64
+	TCPErrCount  uint64
+	TCPOkCount   uint64
+
+	authMu sync.Mutex
+	auths  map[*config.HTTPProbeAuth]*probeAuth
+
 	doneChan           chan struct{}
 	workers            sync.WaitGroup
 	concurrentCrawlers chan struct{}
 }
 
+// retrySettings returns the max call attempt count and the retry wait times
+// (in seconds) for the 'target not ready', web and other call errors
+func (p *CustomProbe) retrySettings() (maxRetryCount int, notReadyErrorWait, webErrorWait, otherErrorWait time.Duration) {
+	maxRetryCount = probeRetryCount
+	if p.opts.RetryCount > 0 {
+		maxRetryCount = p.opts.RetryCount
+	}
+
+	notReadyErrorWait = time.Duration(16)
+	webErrorWait = time.Duration(8)
+	otherErrorWait = time.Duration(4)
+	if p.opts.RetryWait > 0 {
+		webErrorWait = time.Duration(p.opts.RetryWait)
+		notReadyErrorWait = time.Duration(p.opts.RetryWait * 2)
+		otherErrorWait = time.Duration(p.opts.RetryWait / 2)
+	}
+
+	return maxRetryCount, notReadyErrorWait, webErrorWait, otherErrorWait
+}
+
 // NewContainerProbe creates a new custom HTTP probe
 func NewContainerProbe(
 	xc *app.ExecutionContext,
@@ -61,7 +114,48 @@ func NewContainerProbe(
 	opts config.HTTPProbeOptions,
 	printState bool,
 ) (*CustomProbe, error) {
+	if inspector.IsNetworkNone() {
+		//the published ports don't exist without the container network
+		return nil, ErrNoContainerNetwork
+	}
+
 	probe := newCustomProbe(xc, inspector.TargetHost, opts, printState)
+	probe.ports = containerProbePorts(inspector, opts)
+	if opts.ReadyHealthcheck && len(inspector.HealthcheckCmd()) > 0 {
+		probe.healthcheck = inspector.RunHealthcheck
+	}
+
+	probe.appListening = func() (bool, bool) {
+		activity := inspector.MonitorActivity()
+		return activity.ListenCount > 0, activity.IsStreamingStats
+	}
+
+	probe.appExited = inspector.HasExited
+
+	probe.setTCPTargets(func(containerPort uint16) string {
+		pspec := dockerapi.Port(fmt.Sprintf("%v/tcp", containerPort))
+		if port, ok := inspector.AvailablePorts[pspec]; ok {
+			if inspector.SensorIPCMode == container.SensorIPCModeDirect {
+				return fmt.Sprintf("%d", containerPort)
+			}
+
+			return port.HostPort
+		}
+
+		return ""
+	})
+
+	if len(probe.opts.APISpecFiles) > 0 {
+		probe.loadAPISpecFiles()
+	}
+
+	return probe, nil
+}
+
+// containerProbePorts returns the target container ports to probe
+// (the ports are ordered based on the order of the 'EXPOSE' instructions)
+func containerProbePorts(inspector *container.Inspector, opts config.HTTPProbeOptions) []string {
+	var ports []string
 
 	availableHostPorts := map[string]string{}
 	for nsPortKey, nsPortData := range inspector.AvailablePorts {
@@ -82,20 +176,20 @@ func NewContainerProbe(
 
 	log.Debugf("HTTP probe - available host ports => %+v", availableHostPorts)
 
-	if len(probe.opts.Ports) > 0 {
-		for _, pnum := range probe.opts.Ports {
+	if len(opts.Ports) > 0 {
+		for _, pnum := range opts.Ports {
 			pspec := dockerapi.Port(fmt.Sprintf("%v/tcp", pnum))
 			if _, ok := inspector.AvailablePorts[pspec]; ok {
 				if inspector.SensorIPCMode == container.SensorIPCModeDirect {
-					probe.ports = append(probe.ports, fmt.Sprintf("%d", pnum))
+					ports = append(ports, fmt.Sprintf("%d", pnum))
 				} else {
-					probe.ports = append(probe.ports, inspector.AvailablePorts[pspec].HostPort)
+					ports = append(ports, inspector.AvailablePorts[pspec].HostPort)
 				}
 			} else {
 				log.Debugf("HTTP probe - ignoring port => %v", pspec)
 			}
 		}
-		log.Debugf("HTTP probe - filtered ports => %+v", probe.ports)
+		log.Debugf("HTTP probe - filtered ports => %+v", ports)
 	} else {
 		//order the port list based on the order of the 'EXPOSE' instructions
 		if len(inspector.ImageInspector.DockerfileInfo.ExposedPorts) > 0 {
@@ -111,12 +205,12 @@ func NewContainerProbe(
 					hostPort := inspector.AvailablePorts[pspec].HostPort
 					if inspector.SensorIPCMode == container.SensorIPCModeDirect {
 						if containerPort := availableHostPorts[hostPort]; containerPort != "" {
-							probe.ports = append(probe.ports, containerPort)
+							ports = append(ports, containerPort)
 						} else {
 							log.Debugf("HTTP probe - could not find container port from host port => %v", hostPort)
 						}
 					} else {
-						probe.ports = append(probe.ports, hostPort)
+						ports = append(ports, hostPort)
 					}
 
 					if _, ok := availableHostPorts[hostPort]; ok {
@@ -131,20 +225,16 @@ func NewContainerProbe(
 
 		for hostPort, containerPort := range availableHostPorts {
 			if inspector.SensorIPCMode == container.SensorIPCModeDirect {
-				probe.ports = append(probe.ports, containerPort)
+				ports = append(ports, containerPort)
 			} else {
-				probe.ports = append(probe.ports, hostPort)
+				ports = append(ports, hostPort)
 			}
 		}
 
-		log.Debugf("HTTP probe - probe.Ports => %+v", probe.ports)
-	}
-
-	if len(probe.opts.APISpecFiles) > 0 {
-		probe.loadAPISpecFiles()
+		log.Debugf("HTTP probe - probe.Ports => %+v", ports)
 	}
 
-	return probe, nil
+	return ports
 }
 
 func NewPodProbe(
@@ -182,6 +272,15 @@ func NewPodProbe(
 		log.Debugf("HTTP probe - probe.Ports => %+v", probe.ports)
 	}
 
+	probe.setTCPTargets(func(containerPort uint16) string {
+		pspec := dockerapi.Port(fmt.Sprintf("%v/tcp", containerPort))
+		if port, ok := inspector.AvailablePorts()[pspec]; ok {
+			return port.HostPort
+		}
+
+		return ""
+	})
+
 	if len(probe.opts.APISpecFiles) > 0 {
 		probe.loadAPISpecFiles()
 	}
@@ -217,6 +316,10 @@ func newCustomProbe(
 		opts.CrawlConcurrencyMax = defaultMaxConcurrentCrawlers
 	}
 
+	if opts.ReadyTimeout == 0 {
+		opts.ReadyTimeout = defaultReadyTimeout
+	}
+
 	probe := &CustomProbe{
 		xc:         xc,
 		opts:       opts,
@@ -232,8 +335,14 @@ func newCustomProbe(
 	return probe
 }
 
+// Ports returns the target ports (including the TCP probe ports)
 func (p *CustomProbe) Ports() []string {
-	return p.ports
+	ports := append([]string{}, p.ports...)
+	for _, target := range p.tcpTargets {
+		ports = append(ports, target.port)
+	}
+
+	return ports
 }
 
 // Start starts the HTTP probe instance execution
@@ -246,8 +355,16 @@ func (p *CustomProbe) Start() {
 	}
 
 	go func() {
-		//TODO: need to do a better job figuring out if the target app is ready to accept connections
-		time.Sleep(9 * time.Second) //base start wait time
+		if !p.waitForReady() {
+			//nothing to probe when the target container stopped
+			if p.printState {
+				p.xc.Out.State("http.probe.done", ovars{"warning": "target.stopped"})
+			}
+
+			close(p.doneChan)
+			return
+		}
+
 		if p.opts.StartWait > 0 {
 			if p.printState {
 				p.xc.Out.State("http.probe.start.wait", ovars{"time": p.opts.StartWait})
@@ -313,10 +430,14 @@ func (p *CustomProbe) Start() {
 
 		for _, port := range p.ports {
 			//If it's ok stop after the first successful probe pass
-			if p.OkCount > 0 && !p.opts.Full {
+			if (p.OkCount > 0 || p.GRPCOkCount > 0) && !p.opts.Full {
 				break
 			}
 
+			if p.opts.GRPC {
+				p.probeGRPCServices(port)
+			}
+
 			for _, cmd := range p.opts.Cmds {
 				var reqBody io.Reader
 				var rbSeeker io.Seeker
@@ -381,18 +502,11 @@ func (p *CustomProbe) Start() {
 				}
 
 				for _, proto := range protocols {
-					maxRetryCount := probeRetryCount
-					if p.opts.RetryCount > 0 {
-						maxRetryCount = p.opts.RetryCount
-					}
+					maxRetryCount, notReadyErrorWait, webErrorWait, otherErrorWait := p.retrySettings()
 
-					notReadyErrorWait := time.Duration(16)
-					webErrorWait := time.Duration(8)
-					otherErrorWait := time.Duration(4)
-					if p.opts.RetryWait > 0 {
-						webErrorWait = time.Duration(p.opts.RetryWait)
-						notReadyErrorWait = time.Duration(p.opts.RetryWait * 2)
-						otherErrorWait = time.Duration(p.opts.RetryWait / 2)
+					if IsValidGRPCProto(proto) {
+						p.runGRPCCmd(cmd, proto, port, maxRetryCount, notReadyErrorWait)
+						continue
 					}
 
 					if IsValidWSProto(proto) {
@@ -467,7 +581,7 @@ func (p *CustomProbe) Start() {
 						client = getFastCGIClient(cmd.FastCGI)
 					default:
 						var err error
-						if client, err = getHTTPClient(proto); err != nil {
+						if client, err = p.httpClient(proto, cmd.Auth); err != nil {
 							p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 							continue
 						}
@@ -519,19 +633,28 @@ func (p *CustomProbe) Start() {
 						if err == nil {
 							p.OkCount++
 
-							if p.OkCount == 1 {
+							if !p.httpCallOk {
+								p.httpCallOk = true
 								if len(p.opts.APISpecs) != 0 && len(p.opts.APISpecFiles) != 0 && cmd.FastCGI != nil {
 									p.xc.Out.Info("HTTP probe - API spec probing not implemented for fastcgi")
 								} else {
 									p.probeAPISpecs(proto, p.targetHost, port)
 								}
+
+								if len(p.opts.GraphQLEndpoints) > 0 {
+									if cmd.FastCGI != nil {
+										p.xc.Out.Info("HTTP probe - GraphQL probing not implemented for fastcgi")
+									} else {
+										p.probeGraphQL(proto, p.targetHost, port)
+									}
+								}
 							}
 
 							if cmd.Crawl {
 								if cmd.FastCGI != nil {
 									p.xc.Out.Info("HTTP probe - crawling not implemented for fastcgi")
 								} else {
-									p.crawl(proto, p.targetHost, addr)
+									p.crawl(proto, p.targetHost, addr, cmd.Auth)
 								}
 							}
 							break
@@ -557,6 +680,31 @@ func (p *CustomProbe) Start() {
 					}
 				}
 			}
+
+			//the GraphQL endpoints are probed even if no probe command succeeded
+			//(e.g., the app serves only the GraphQL endpoint)
+			if len(p.opts.GraphQLEndpoints) > 0 && !p.graphQLProbed && port != defaultFastCGIPortStr {
+				proto := config.ProtoHTTP
+				if port == defaultHTTPSPortStr {
+					proto = config.ProtoHTTPS
+				}
+
+				p.probeGraphQL(proto, p.targetHost, port)
+			}
+
+			if len(p.opts.Scenarios) > 0 {
+				maxRetryCount, _, webErrorWait, _ := p.retrySettings()
+				p.probeScenarios(port, maxRetryCount, webErrorWait)
+			}
+		}
+
+		//the gRPC calls are included in the totals when the probing is done
+		p.CallCount += p.GRPCCallCount
+		p.ErrCount += p.GRPCErrCount
+		p.OkCount += p.GRPCOkCount
+
+		if len(p.tcpTargets) > 0 {
+			p.probeTCPTargets()
 		}
 
 		log.Info("HTTP probe done.")
@@ -569,6 +717,33 @@ func (p *CustomProbe) Start() {
 					"successful": p.OkCount,
 				})
 
+			if p.GRPCCallCount > 0 {
+				p.xc.Out.Info("grpc.probe.summary",
+					ovars{
+						"total":      p.GRPCCallCount,
+						"failures":   p.GRPCErrCount,
+						"successful": p.GRPCOkCount,
+					})
+			}
+
+			if p.TCPCallCount > 0 {
+				p.xc.Out.Info("tcp.probe.summary",
+					ovars{
+						"total":      p.TCPCallCount,
+						"failures":   p.TCPErrCount,
+						"successful": p.TCPOkCount,
+					})
+			}
+
+			if len(p.opts.Scenarios) > 0 {
+				p.xc.Out.Info("http.probe.scenario.summary",
+					ovars{
+						"scenarios":         len(p.opts.Scenarios),
+						"failed":            p.ScenarioFailCount,
+						"failed.assertions": p.AssertFailCount,
+					})
+			}
+
 			outVars := ovars{}
 			//warning := ""
 			switch {
@@ -578,6 +753,8 @@ func (p *CustomProbe) Start() {
 			case p.OkCount == 0:
 				//warning = "warning=no.successful.calls"
 				outVars["warning"] = "no.successful.calls"
+			case p.AssertFailCount > 0:
+				outVars["warning"] = "failed.assertions"
 			}
 
 			p.xc.Out.State("http.probe.done", outVars)
@@ -617,6 +794,27 @@ func (p *CustomProbe) DoneChan() <-chan struct{} {
 	return p.doneChan
 }
 
+// IsDone returns true if the probe is done
+// (the probe counters are final then)
+func (p *CustomProbe) IsDone() bool {
+	select {
+	case <-p.doneChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// APISpecCoverageReport returns the API spec operation coverage
+// for the command report (nil if the probe is still running)
+func (p *CustomProbe) APISpecCoverageReport() []report.APISpecOpCoverage {
+	if !p.IsDone() {
+		return nil
+	}
+
+	return p.APISpecCoverage
+}
+
 func newHTTPRequestFromCmd(cmd config.HTTPProbeCmd, addr string, reqBody io.Reader) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(context.Background(), cmd.Method, addr, reqBody)
 	if err != nil {
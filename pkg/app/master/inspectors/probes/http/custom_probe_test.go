@@ -0,0 +1,20 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
+)
+
+func TestNewContainerProbeNoNetwork(t *testing.T) {
+	inspector := &container.Inspector{
+		Overrides: &config.ContainerOverrides{Network: "none"},
+	}
+
+	probe, err := NewContainerProbe(app.NewExecutionContext("test", "text"), inspector, config.HTTPProbeOptions{}, false)
+	if err != ErrNoContainerNetwork {
+		t.Errorf("expected the no container network error, got: %v (probe=%v)", err, probe)
+	}
+}
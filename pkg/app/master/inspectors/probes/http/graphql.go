@@ -0,0 +1,555 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const graphQLIntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        args { name defaultValue type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+      inputFields { name defaultValue type { ...TypeRef } }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }
+}`
+
+// Max selection set nesting level for the generated queries
+// (also protects from the recursive types)
+const maxGraphQLSelectionDepth = 2
+
+// GraphQL type kinds
+const (
+	gqlKindScalar      = "SCALAR"
+	gqlKindObject      = "OBJECT"
+	gqlKindInterface   = "INTERFACE"
+	gqlKindUnion       = "UNION"
+	gqlKindEnum        = "ENUM"
+	gqlKindInputObject = "INPUT_OBJECT"
+	gqlKindList        = "LIST"
+	gqlKindNonNull     = "NON_NULL"
+)
+
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+// String returns the type reference in the GraphQL syntax (e.g., "[String!]!")
+func (t *gqlTypeRef) String() string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Kind == gqlKindNonNull:
+		return t.OfType.String() + "!"
+	case t.Kind == gqlKindList:
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// named returns the named type (unwrapping the list and non-null types)
+func (t *gqlTypeRef) named() *gqlTypeRef {
+	for t != nil && (t.Kind == gqlKindNonNull || t.Kind == gqlKindList) {
+		t = t.OfType
+	}
+
+	return t
+}
+
+type gqlInputValue struct {
+	Name         string      `json:"name"`
+	DefaultValue *string     `json:"defaultValue"`
+	Type         *gqlTypeRef `json:"type"`
+}
+
+type gqlField struct {
+	Name string          `json:"name"`
+	Args []gqlInputValue `json:"args"`
+	Type *gqlTypeRef     `json:"type"`
+}
+
+type gqlType struct {
+	Kind        string          `json:"kind"`
+	Name        string          `json:"name"`
+	Fields      []gqlField      `json:"fields"`
+	InputFields []gqlInputValue `json:"inputFields"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+type gqlSchema struct {
+	QueryType *struct {
+		Name string `json:"name"`
+	} `json:"queryType"`
+	MutationType *struct {
+		Name string `json:"name"`
+	} `json:"mutationType"`
+	Types []gqlType `json:"types"`
+
+	types map[string]*gqlType
+}
+
+func (s *gqlSchema) typeByName(name string) *gqlType {
+	if s.types == nil {
+		s.types = map[string]*gqlType{}
+		for i := range s.Types {
+			s.types[s.Types[i].Name] = &s.Types[i]
+		}
+	}
+
+	return s.types[name]
+}
+
+type gqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage   `json:"data"`
+	Errors []json.RawMessage `json:"errors"`
+}
+
+// gqlOperation is a generated GraphQL operation for a top-level field
+type gqlOperation struct {
+	Type  string //query or mutation
+	Field string
+	gqlRequest
+}
+
+// GraphQLVariables are the user provided argument values for the top-level fields
+// (keyed by the field name or by the type qualified field name, e.g., "Mutation.createUser")
+type GraphQLVariables map[string]map[string]interface{}
+
+func (vars GraphQLVariables) lookup(typeName, fieldName string) map[string]interface{} {
+	if values, found := vars[typeName+"."+fieldName]; found {
+		return values
+	}
+
+	return vars[fieldName]
+}
+
+// graphQLOperations generates the operations for all top-level Query fields
+// and for the allowed top-level Mutation fields
+func graphQLOperations(schema *gqlSchema, vars GraphQLVariables, mutations []string) []*gqlOperation {
+	var ops []*gqlOperation
+	if schema.QueryType != nil {
+		if qt := schema.typeByName(schema.QueryType.Name); qt != nil {
+			for _, field := range qt.Fields {
+				if strings.HasPrefix(field.Name, "__") {
+					continue
+				}
+
+				ops = append(ops, newGraphQLOperation(schema, "query", qt.Name, field, vars))
+			}
+		}
+	}
+
+	if schema.MutationType != nil && len(mutations) > 0 {
+		allowed := map[string]struct{}{}
+		for _, name := range mutations {
+			allowed[name] = struct{}{}
+		}
+
+		if mt := schema.typeByName(schema.MutationType.Name); mt != nil {
+			for _, field := range mt.Fields {
+				if _, found := allowed[field.Name]; !found {
+					continue
+				}
+
+				ops = append(ops, newGraphQLOperation(schema, "mutation", mt.Name, field, vars))
+			}
+		}
+	}
+
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].Type != ops[j].Type {
+			//queries first
+			return ops[i].Type > ops[j].Type
+		}
+
+		return ops[i].Field < ops[j].Field
+	})
+
+	return ops
+}
+
+func newGraphQLOperation(schema *gqlSchema, opType, typeName string, field gqlField, vars GraphQLVariables) *gqlOperation {
+	op := &gqlOperation{
+		Type:  opType,
+		Field: field.Name,
+	}
+
+	op.OperationName = fmt.Sprintf("Probe_%s", field.Name)
+	userValues := vars.lookup(typeName, field.Name)
+
+	var varDefs []string
+	var args []string
+	for _, arg := range field.Args {
+		value, found := userValues[arg.Name]
+		if !found {
+			if arg.DefaultValue != nil || arg.Type.Kind != gqlKindNonNull {
+				//the server uses the default value (or null)
+				continue
+			}
+
+			value = graphQLValue(schema, arg.Type, 0)
+		}
+
+		if op.Variables == nil {
+			op.Variables = map[string]interface{}{}
+		}
+
+		op.Variables[arg.Name] = value
+		varDefs = append(varDefs, fmt.Sprintf("$%s: %s", arg.Name, arg.Type))
+		args = append(args, fmt.Sprintf("%s: $%s", arg.Name, arg.Name))
+	}
+
+	var query strings.Builder
+	query.WriteString(opType)
+	query.WriteString(" ")
+	query.WriteString(op.OperationName)
+	if len(varDefs) > 0 {
+		query.WriteString("(" + strings.Join(varDefs, ", ") + ")")
+	}
+
+	query.WriteString(" { ")
+	query.WriteString(field.Name)
+	if len(args) > 0 {
+		query.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+
+	if selection := graphQLSelection(schema, field.Type, 0); selection != "" {
+		query.WriteString(" " + selection)
+	}
+
+	query.WriteString(" }")
+	op.Query = query.String()
+	return op
+}
+
+// graphQLSelection returns the selection set for the type (empty for the leaf types).
+// The fields with the required arguments are not selected.
+func graphQLSelection(schema *gqlSchema, typeRef *gqlTypeRef, depth int) string {
+	named := typeRef.named()
+	if named == nil {
+		return ""
+	}
+
+	t := schema.typeByName(named.Name)
+	if t == nil || (t.Kind != gqlKindObject && t.Kind != gqlKindInterface && t.Kind != gqlKindUnion) {
+		return ""
+	}
+
+	var fields []string
+	if t.Kind != gqlKindUnion && depth < maxGraphQLSelectionDepth {
+		for _, field := range t.Fields {
+			if hasRequiredArgs(field) {
+				continue
+			}
+
+			ft := field.Type.named()
+			if ft == nil {
+				continue
+			}
+
+			switch ft.Kind {
+			case gqlKindScalar, gqlKindEnum:
+				fields = append(fields, field.Name)
+			default:
+				if selection := graphQLSelection(schema, field.Type, depth+1); selection != "" {
+					fields = append(fields, field.Name+" "+selection)
+				}
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		if depth > 0 {
+			//skipping the nested objects without any leaf fields
+			return ""
+		}
+
+		fields = append(fields, "__typename")
+	}
+
+	return "{ " + strings.Join(fields, " ") + " }"
+}
+
+func hasRequiredArgs(field gqlField) bool {
+	for _, arg := range field.Args {
+		if arg.Type.Kind == gqlKindNonNull && arg.DefaultValue == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// graphQLValue generates a synthetic value for the input type
+func graphQLValue(schema *gqlSchema, typeRef *gqlTypeRef, depth int) interface{} {
+	switch {
+	case typeRef == nil:
+		return nil
+	case typeRef.Kind == gqlKindNonNull:
+		return graphQLValue(schema, typeRef.OfType, depth)
+	case typeRef.Kind == gqlKindList:
+		return []interface{}{graphQLValue(schema, typeRef.OfType, depth)}
+	}
+
+	switch typeRef.Name {
+	case "Int":
+		return 1
+	case "Float":
+		return 1.0
+	case "Boolean":
+		return true
+	case "ID":
+		return "1"
+	case "String":
+		return "string"
+	}
+
+	t := schema.typeByName(typeRef.Name)
+	if t == nil {
+		return "string"
+	}
+
+	switch t.Kind {
+	case gqlKindEnum:
+		if len(t.EnumValues) > 0 {
+			return t.EnumValues[0].Name
+		}
+	case gqlKindInputObject:
+		value := map[string]interface{}{}
+		if depth >= maxGraphQLSelectionDepth {
+			return value
+		}
+
+		for _, field := range t.InputFields {
+			if field.Type.Kind == gqlKindNonNull && field.DefaultValue == nil {
+				value[field.Name] = graphQLValue(schema, field.Type, depth+1)
+			}
+		}
+
+		return value
+	}
+
+	//custom scalars
+	return "string"
+}
+
+func graphQLCall(client *http.Client, endpoint string, req *gqlRequest) (int, *gqlResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	hreq, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	hreq.Header.Set("Content-Type", "application/json")
+	hreq.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(hreq)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	rdata, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, nil, err
+	}
+
+	var gres gqlResponse
+	if err := json.Unmarshal(rdata, &gres); err != nil {
+		//not a GraphQL response
+		log.Debugf("http.CustomProbe.graphQLCall - response json.Unmarshal error=%v", err)
+		return res.StatusCode, nil, nil
+	}
+
+	return res.StatusCode, &gres, nil
+}
+
+func (p *CustomProbe) probeGraphQL(proto, targetHost, port string) {
+	p.graphQLProbed = true
+	client, err := p.httpClient(proto, nil)
+	if err != nil {
+		p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
+		return
+	}
+
+	addr := getHTTPAddr(proto, targetHost, port)
+	for _, gqlPath := range p.opts.GraphQLEndpoints {
+		endpoint := fmt.Sprintf("%s%s", addr, gqlPath)
+		p.probeGraphQLEndpoint(client, endpoint)
+	}
+}
+
+// graphQLIntrospect sends the introspection query retrying the failed calls
+// (the GraphQL endpoint might not be ready yet). The GraphQL error responses
+// are not retried (e.g., the introspection is disabled).
+func (p *CustomProbe) graphQLIntrospect(client *http.Client, endpoint string) (int, *gqlResponse, error) {
+	maxRetryCount, notReadyErrorWait, webErrorWait, _ := p.retrySettings()
+
+	var statusCode int
+	var res *gqlResponse
+	var err error
+	for i := 0; i < maxRetryCount; i++ {
+		statusCode, res, err = graphQLCall(client, endpoint, &gqlRequest{
+			Query:         graphQLIntrospectionQuery,
+			OperationName: "IntrospectionQuery",
+		})
+		p.CallCount++
+
+		if err == nil && res != nil {
+			return statusCode, res, nil
+		}
+
+		p.ErrCount++
+		if i == maxRetryCount-1 {
+			break
+		}
+
+		if err != nil {
+			log.Debugf("HTTP probe - GraphQL endpoint not ready yet (retry again later)...")
+			time.Sleep(notReadyErrorWait * time.Second)
+		} else {
+			log.Debugf("HTTP probe - GraphQL endpoint web error (status=%d)... retry again later...", statusCode)
+			time.Sleep(webErrorWait * time.Second)
+		}
+	}
+
+	return statusCode, res, err
+}
+
+func (p *CustomProbe) probeGraphQLEndpoint(client *http.Client, endpoint string) {
+	statusCode, res, err := p.graphQLIntrospect(client, endpoint)
+	if err != nil || res == nil || len(res.Data) == 0 {
+		if err == nil && res != nil {
+			//the calls without the GraphQL response are counted by graphQLIntrospect
+			p.ErrCount++
+		}
+
+		message := fmt.Sprintf("no introspection data (status=%d)", statusCode)
+		if err != nil {
+			message = err.Error()
+		}
+
+		p.xc.Out.Info("http.probe.graphql.error",
+			ovars{
+				"endpoint": endpoint,
+				"message":  "introspection query failed",
+				"error":    message,
+			})
+		return
+	}
+
+	p.OkCount++
+
+	var data struct {
+		Schema gqlSchema `json:"__schema"`
+	}
+
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		p.xc.Out.Info("http.probe.graphql.error",
+			ovars{
+				"endpoint": endpoint,
+				"message":  "malformed introspection data",
+				"error":    err,
+			})
+		return
+	}
+
+	ops := graphQLOperations(&data.Schema, p.opts.GraphQLVariables, p.opts.GraphQLMutations)
+	if p.printState {
+		p.xc.Out.State("http.probe.graphql.probe.starting",
+			ovars{
+				"endpoint":   endpoint,
+				"operations": len(ops),
+			})
+	}
+
+	var okCount, gqlErrorCount, callErrorCount int
+	for _, op := range ops {
+		statusCode, res, err := graphQLCall(client, endpoint, &op.gqlRequest)
+		p.CallCount++
+
+		status := "error"
+		callErrorStr := "none"
+		errorCount := 0
+		if err != nil {
+			p.ErrCount++
+			callErrorCount++
+			callErrorStr = err.Error()
+		} else {
+			p.OkCount++
+			status = fmt.Sprintf("%v", statusCode)
+
+			if res != nil {
+				errorCount = len(res.Errors)
+			}
+
+			if errorCount > 0 {
+				gqlErrorCount++
+			} else {
+				okCount++
+			}
+		}
+
+		if p.printState {
+			p.xc.Out.Info("http.probe.graphql.call",
+				ovars{
+					"status":   status,
+					"type":     op.Type,
+					"field":    op.Field,
+					"endpoint": endpoint,
+					"errors":   errorCount,
+					"error":    callErrorStr,
+					"time":     time.Now().UTC().Format(time.RFC3339),
+				})
+		}
+	}
+
+	if p.printState {
+		p.xc.Out.Info("http.probe.graphql.summary",
+			ovars{
+				"endpoint":    endpoint,
+				"operations":  len(ops),
+				"ok":          okCount,
+				"with.errors": gqlErrorCount,
+				"call.errors": callErrorCount,
+			})
+	}
+}
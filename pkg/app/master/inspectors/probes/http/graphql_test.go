@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const testGraphQLSchema = `{
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "user", "args": [
+        {"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+        {"name": "verbose", "type": {"kind": "SCALAR", "name": "Boolean"}}
+      ], "type": {"kind": "OBJECT", "name": "User"}},
+      {"name": "users", "args": [
+        {"name": "role", "defaultValue": "ADMIN", "type": {"kind": "NON_NULL", "ofType": {"kind": "ENUM", "name": "Role"}}}
+      ], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "User"}}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "createUser", "args": [
+        {"name": "input", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "UserInput"}}}
+      ], "type": {"kind": "OBJECT", "name": "User"}},
+      {"name": "deleteUser", "args": [], "type": {"kind": "SCALAR", "name": "Boolean"}}
+    ]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+      {"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}},
+      {"name": "friends", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "User"}}},
+      {"name": "posts", "args": [
+        {"name": "first", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}}
+      ], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "Post"}}}
+    ]},
+    {"kind": "ENUM", "name": "Role", "enumValues": [{"name": "ADMIN"}, {"name": "USER"}]},
+    {"kind": "INPUT_OBJECT", "name": "UserInput", "inputFields": [
+      {"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+      {"name": "roles", "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "ENUM", "name": "Role"}}}},
+      {"name": "age", "type": {"kind": "SCALAR", "name": "Int"}}
+    ]}
+  ]
+}`
+
+func TestGraphQLOperations(t *testing.T) {
+	var schema gqlSchema
+	if err := json.Unmarshal([]byte(testGraphQLSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	vars := GraphQLVariables{"user": {"id": "42"}}
+	ops := graphQLOperations(&schema, vars, []string{"createUser"})
+	if len(ops) != 3 {
+		t.Fatalf("unexpected operation count: %d", len(ops))
+	}
+
+	user := ops[0]
+	expected := "query Probe_user($id: ID!) { user(id: $id) { id role friends { id role } } }"
+	if user.Field != "user" || user.Query != expected {
+		t.Errorf("unexpected query: %s", user.Query)
+	}
+
+	if user.Variables["id"] != "42" {
+		t.Errorf("unexpected variables: %v", user.Variables)
+	}
+
+	//the argument with the default value is omitted
+	users := ops[1]
+	if users.Query != "query Probe_users { users { id role friends { id role } } }" || users.Variables != nil {
+		t.Errorf("unexpected query: %s (%v)", users.Query, users.Variables)
+	}
+
+	create := ops[2]
+	if create.Type != "mutation" || create.Field != "createUser" {
+		t.Fatalf("unexpected operation: %s %s", create.Type, create.Field)
+	}
+
+	data, err := json.Marshal(create.Variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"input":{"name":"string","roles":["ADMIN"]}}` {
+		t.Errorf("unexpected variables: %s", data)
+	}
+}
+
+func TestProbeGraphQLEndpointRetry(t *testing.T) {
+	var introspectionCount int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req gqlRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.OperationName != "IntrospectionQuery" {
+			w.Write([]byte(`{"data":{}}`))
+			return
+		}
+
+		//the GraphQL endpoint is not ready on the first call
+		if atomic.AddInt32(&introspectionCount, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("not ready"))
+			return
+		}
+
+		w.Write([]byte(`{"data":{"__schema":` + testGraphQLSchema + `}}`))
+	}))
+	defer srv.Close()
+
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), "127.0.0.1",
+		config.HTTPProbeOptions{RetryCount: 2, RetryWait: 1}, false)
+
+	probe.probeGraphQLEndpoint(srv.Client(), srv.URL+"/graphql")
+
+	if introspectionCount != 2 {
+		t.Fatalf("unexpected introspection call count: %d", introspectionCount)
+	}
+
+	//1 failed introspection call + 1 successful introspection call + the schema operations
+	if probe.ErrCount != 1 || probe.CallCount <= 2 || probe.OkCount != probe.CallCount-1 {
+		t.Errorf("unexpected call counts: calls=%d ok=%d errors=%d",
+			probe.CallCount, probe.OkCount, probe.ErrCount)
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	grpcprobe "github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/grpc"
+)
+
+func IsValidGRPCProto(proto string) bool {
+	switch proto {
+	case config.ProtoGRPC, config.ProtoGRPCS:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *CustomProbe) newGRPCClient(proto, port string) (*grpcprobe.Client, error) {
+	addr := net.JoinHostPort(p.targetHost, port)
+	return grpcprobe.NewClient(addr, proto == config.ProtoGRPCS, p.opts.GRPCProtosets)
+}
+
+// probeGRPCServices calls all unary methods of the discovered gRPC services
+// (with the example messages) trying the plaintext connections first.
+// The protocol is selected only if the connection is established
+// (the services are not discovered over the wire with the protoset files).
+func (p *CustomProbe) probeGRPCServices(port string) {
+	for _, proto := range []string{config.ProtoGRPC, config.ProtoGRPCS} {
+		client, err := p.newGRPCClient(proto, port)
+		if err != nil {
+			log.Debugf("gRPC probe - connect error (proto=%s port=%s) - %v", proto, port, err)
+			continue
+		}
+
+		if err := client.WaitReady(context.Background()); err != nil {
+			log.Debugf("gRPC probe - connect error (proto=%s port=%s) - %v", proto, port, err)
+			client.Close()
+			continue
+		}
+
+		methods, err := client.UnaryMethods(context.Background())
+		if err != nil {
+			log.Debugf("gRPC probe - service discovery error (proto=%s port=%s) - %v", proto, port, err)
+			client.Close()
+			continue
+		}
+
+		if p.printState {
+			p.xc.Out.Info("grpc.probe.services",
+				ovars{
+					"target":  client.Addr,
+					"proto":   proto,
+					"methods": len(methods),
+				})
+		}
+
+		for _, method := range methods {
+			p.grpcCall(client, method, "", 1)
+		}
+
+		client.Close()
+		return
+	}
+
+	log.Debugf("gRPC probe - no gRPC services discovered (port=%s)", port)
+}
+
+// runGRPCCmd makes the gRPC call defined in the probe command
+// (the resource is the full method name and the body is the JSON request message)
+func (p *CustomProbe) runGRPCCmd(cmd config.HTTPProbeCmd, proto, port string, maxRetryCount int, retryWait time.Duration) {
+	for i := 0; i < maxRetryCount; i++ {
+		client, err := p.newGRPCClient(proto, port)
+		if err != nil {
+			p.GRPCCallCount++
+			p.GRPCErrCount++
+
+			if p.printState {
+				p.xc.Out.Info("grpc.probe.call",
+					ovars{
+						"status":  "error",
+						"method":  cmd.Resource,
+						"target":  net.JoinHostPort(p.targetHost, port),
+						"attempt": i + 1,
+						"error":   err.Error(),
+						"time":    time.Now().UTC().Format(time.RFC3339),
+					})
+			}
+
+			log.Debugf("gRPC probe - target not ready yet (retry again later)...")
+			time.Sleep(retryWait * time.Second)
+			continue
+		}
+
+		code, err := p.grpcCall(client, cmd.Resource, cmd.Body, i+1)
+		client.Close()
+		if err == nil || (code != codes.Unavailable && code != codes.DeadlineExceeded) {
+			//retrying only if the call didn't reach the service
+			return
+		}
+
+		time.Sleep(retryWait * time.Second)
+	}
+}
+
+// grpcCall makes the gRPC call (the gRPC calls are counted separately
+// and added to the totals when the probing is done)
+func (p *CustomProbe) grpcCall(client *grpcprobe.Client, method, body string, attempt int) (codes.Code, error) {
+	code, err := client.Call(context.Background(), method, body)
+	p.GRPCCallCount++
+
+	callErrorStr := "none"
+	if err != nil {
+		callErrorStr = err.Error()
+	}
+
+	if isGRPCCallOk(code, err, body == "") {
+		p.GRPCOkCount++
+	} else {
+		p.GRPCErrCount++
+	}
+
+	if p.printState {
+		p.xc.Out.Info("grpc.probe.call",
+			ovars{
+				"status":  fmt.Sprintf("%v", code),
+				"method":  method,
+				"target":  client.Addr,
+				"attempt": attempt,
+				"error":   callErrorStr,
+				"time":    time.Now().UTC().Format(time.RFC3339),
+			})
+	}
+
+	return code, err
+}
+
+// isGRPCCallOk returns true if the service handled the call.
+// The generated example messages are not always valid requests,
+// so the invalid argument errors are ok for them.
+func isGRPCCallOk(code codes.Code, err error, isExample bool) bool {
+	if err != nil {
+		return false
+	}
+
+	switch code {
+	case codes.OK:
+		return true
+	case codes.InvalidArgument:
+		return isExample
+	default:
+		return false
+	}
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestIsGRPCCallOk(t *testing.T) {
+	tests := []struct {
+		code      codes.Code
+		isExample bool
+		ok        bool
+	}{
+		{code: codes.OK, ok: true},
+		{code: codes.InvalidArgument, isExample: true, ok: true},
+		{code: codes.InvalidArgument},
+		{code: codes.NotFound, isExample: true},
+		{code: codes.Internal},
+		{code: codes.Unimplemented},
+		{code: codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+		if ok := isGRPCCallOk(test.code, nil, test.isExample); ok != test.ok {
+			t.Errorf("unexpected result for code=%v example=%v: %v", test.code, test.isExample, ok)
+		}
+	}
+}
+
+func TestProbeGRPCServices(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("svc", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), host, config.HTTPProbeOptions{}, false)
+
+	//the example message for the health check is for an unknown service (NotFound)
+	probe.probeGRPCServices(port)
+	if probe.GRPCCallCount != 1 || probe.GRPCOkCount != 0 || probe.GRPCErrCount != 1 {
+		t.Errorf("unexpected call counts: calls=%d ok=%d errors=%d",
+			probe.GRPCCallCount, probe.GRPCOkCount, probe.GRPCErrCount)
+	}
+
+	cmd := config.HTTPProbeCmd{
+		Resource: "/grpc.health.v1.Health/Check",
+		Body:     `{"service":"svc"}`,
+	}
+
+	probe.runGRPCCmd(cmd, config.ProtoGRPC, port, 1, 0)
+	if probe.GRPCCallCount != 2 || probe.GRPCOkCount != 1 || probe.GRPCErrCount != 1 {
+		t.Errorf("unexpected call counts: calls=%d ok=%d errors=%d",
+			probe.GRPCCallCount, probe.GRPCOkCount, probe.GRPCErrCount)
+	}
+}
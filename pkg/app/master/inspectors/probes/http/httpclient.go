@@ -13,31 +13,37 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/http/internal"
 )
 
-func getHTTP1Client() *http.Client {
+func getHTTP1Client(auth *probeAuth) *http.Client {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+	auth.configureTLS(tlsConfig)
+
 	client := &http.Client{
 		Timeout: time.Second * 30,
-		Transport: &http.Transport{
+		Transport: auth.transport(&http.Transport{
 			MaxIdleConns:    10,
 			IdleConnTimeout: 30 * time.Second,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
+			TLSClientConfig: tlsConfig,
+		}),
 	}
 
 	return client
 }
 
-func getHTTP2Client(h2c bool) *http.Client {
+func getHTTP2Client(h2c bool, auth *probeAuth) *http.Client {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+	auth.configureTLS(tlsConfig)
+
 	transport := &http2.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
+		TLSClientConfig: tlsConfig,
 	}
 
 	client := &http.Client{
 		Timeout:   time.Second * 30,
-		Transport: transport,
+		Transport: auth.transport(transport),
 	}
 
 	if h2c {
@@ -50,14 +56,16 @@ func getHTTP2Client(h2c bool) *http.Client {
 	return client
 }
 
-func getHTTPClient(proto string) (*http.Client, error) {
+// getHTTPClient returns the HTTP client for the protocol
+// (auth can be nil if there are no auth settings)
+func getHTTPClient(proto string, auth *probeAuth) (*http.Client, error) {
 	switch proto {
 	case config.ProtoHTTP2:
-		return getHTTP2Client(false), nil
+		return getHTTP2Client(false, auth), nil
 	case config.ProtoHTTP2C:
-		return getHTTP2Client(true), nil
+		return getHTTP2Client(true, auth), nil
 	default:
-		return getHTTP1Client(), nil
+		return getHTTP1Client(auth), nil
 	}
 
 	return nil, fmt.Errorf("unsupported HTTP-family protocol %s", proto)
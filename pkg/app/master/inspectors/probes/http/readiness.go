@@ -0,0 +1,273 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+const (
+	defaultReadyTimeout      = 120
+	readyPollInterval        = 500 * time.Millisecond
+	readyHealthcheckInterval = 2 * time.Second
+	readyDialTimeout         = 2 * time.Second
+	readyReadTimeout         = 300 * time.Millisecond
+	readyRequestTimeout      = 5 * time.Second
+	maxReadyRespSize         = 64 * 1024
+
+	//the base start wait time when there are no readiness signals
+	baseStartWait = 9 * time.Second
+)
+
+// Readiness detection methods
+const (
+	readyMethodURL         = "url"
+	readyMethodHealthcheck = "healthcheck"
+	readyMethodPorts       = "ports"
+)
+
+var (
+	errAppNotListening = errors.New("app is not listening yet")
+)
+
+type readyCheckFunc func() error
+
+// readinessCheck selects the readiness detection method
+// (the user readiness URL, then the image health check and then the probe ports
+// combined with the sensor 'listen()' signal when it's available)
+func (p *CustomProbe) readinessCheck() (string, readyCheckFunc, error) {
+	switch {
+	case p.opts.ReadyURL != "":
+		check, err := p.readyURLCheck()
+		return readyMethodURL, check, err
+	case p.healthcheck != nil:
+		return readyMethodHealthcheck, p.healthcheck, nil
+	case len(p.readyPorts()) > 0:
+		return readyMethodPorts, p.checkReadyPorts, nil
+	}
+
+	return "", nil, nil
+}
+
+// waitForReady waits until the target app is ready to accept connections
+// (the probes start anyway when the readiness timeout expires).
+// It returns false if the target container stopped while waiting.
+func (p *CustomProbe) waitForReady() bool {
+	method, check, err := p.readinessCheck()
+	if err != nil {
+		p.xc.Out.Error("http.probe.ready.error", err.Error())
+	}
+
+	if check == nil {
+		log.Debug("HTTP probe - no readiness signals (using the base start wait time)")
+		time.Sleep(baseStartWait)
+		return true
+	}
+
+	if p.printState {
+		p.xc.Out.State("http.probe.ready.wait",
+			ovars{
+				"method":  method,
+				"timeout": p.opts.ReadyTimeout,
+			})
+	}
+
+	interval := readyPollInterval
+	if method == readyMethodHealthcheck {
+		interval = readyHealthcheckInterval
+	}
+
+	startTime := time.Now()
+	deadline := startTime.Add(time.Duration(p.opts.ReadyTimeout) * time.Second)
+	for {
+		err := check()
+		if err == nil {
+			if p.printState {
+				p.xc.Out.State("http.probe.ready",
+					ovars{
+						"method": method,
+						"time":   time.Since(startTime).Round(time.Millisecond).String(),
+					})
+			}
+
+			return true
+		}
+
+		if p.appExited != nil && p.appExited() {
+			if p.printState {
+				p.xc.Out.Info("http.probe.ready.error",
+					ovars{
+						"method":  method,
+						"message": "target container stopped",
+					})
+			}
+
+			return false
+		}
+
+		log.Debugf("HTTP probe - target app is not ready yet (method=%s): %v", method, err)
+		if time.Now().After(deadline) {
+			if p.printState {
+				p.xc.Out.Info("http.probe.ready.timeout",
+					ovars{
+						"method": method,
+						"error":  err.Error(),
+					})
+			}
+
+			return true
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// readyPorts returns the ports used to detect the app readiness
+// (the HTTP probe ports or the TCP probe ports if there are no HTTP probe ports)
+func (p *CustomProbe) readyPorts() []string {
+	if len(p.ports) > 0 {
+		return p.ports
+	}
+
+	return p.Ports()
+}
+
+// checkReadyPorts checks if the app is listening (based on the sensor events, if available)
+// and if the main readiness port (the first HTTP probe port) accepts connections.
+// The other exposed ports are not checked because they are not always used by the app
+// (or they are opened before the main port). Without the HTTP probe ports
+// all TCP probe ports must accept connections.
+func (p *CustomProbe) checkReadyPorts() error {
+	if p.appListening != nil {
+		if listening, known := p.appListening(); known && !listening {
+			return errAppNotListening
+		}
+	}
+
+	if len(p.ports) > 0 {
+		return checkPortReady(net.JoinHostPort(p.targetHost, p.ports[0]))
+	}
+
+	for _, port := range p.readyPorts() {
+		if err := checkPortReady(net.JoinHostPort(p.targetHost, port)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPortReady connects to the target port to check if the app accepts connections
+// (the connections closed right away don't count because the Docker userland proxy
+// accepts the connections to the host ports even if the app is not listening yet)
+func checkPortReady(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, readyDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(readyReadTimeout))
+	var buf [1]byte
+	if _, err := conn.Read(buf[:]); err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			//the app is waiting for the request
+			return nil
+		}
+
+		return fmt.Errorf("connection closed (%v)", err)
+	}
+
+	//the app sent a greeting/banner
+	return nil
+}
+
+type readyTarget struct {
+	addr   string
+	client *http.Client
+}
+
+// readyURLCheck creates the readiness URL check
+// (a readiness path is checked using HTTP and HTTPS on the first probe port)
+func (p *CustomProbe) readyURLCheck() (readyCheckFunc, error) {
+	readyURL, err := url.Parse(p.opts.ReadyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var protocols []string
+	var addrs []string
+	if readyURL.IsAbs() {
+		protocols = []string{readyURL.Scheme}
+		addrs = []string{readyURL.String()}
+	} else {
+		if len(p.ports) == 0 {
+			return nil, fmt.Errorf("no probe ports for the readiness path (%s)", p.opts.ReadyURL)
+		}
+
+		for _, proto := range []string{config.ProtoHTTP, config.ProtoHTTPS} {
+			protocols = append(protocols, proto)
+			addrs = append(addrs, getHTTPAddr(proto, p.targetHost, p.ports[0])+readyURL.RequestURI())
+		}
+	}
+
+	var targets []readyTarget
+	for idx, proto := range protocols {
+		client, err := p.httpClient(proto, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		client.Timeout = readyRequestTimeout
+		targets = append(targets, readyTarget{addr: addrs[idx], client: client})
+	}
+
+	return func() error {
+		var lastErr error
+		for _, target := range targets {
+			statusCode, err := readyURLStatus(target.client, target.addr)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			if isReadyStatus(statusCode, p.opts.ReadyStatus) {
+				return nil
+			}
+
+			lastErr = fmt.Errorf("unexpected status code %d (%s)", statusCode, target.addr)
+		}
+
+		return lastErr
+	}, nil
+}
+
+func readyURLStatus(client *http.Client, addr string) (int, error) {
+	res, err := client.Get(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxReadyRespSize))
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+// isReadyStatus checks the readiness URL status code
+// (any non-5xx status is accepted if there's no expected status code)
+func isReadyStatus(statusCode, expected int) bool {
+	if expected == 0 {
+		return statusCode < http.StatusInternalServerError
+	}
+
+	return statusCode == expected
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app"
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestCheckPortReady(t *testing.T) {
+	listen := func(handler func(conn net.Conn)) string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				handler(conn)
+			}
+		}()
+
+		return ln.Addr().String()
+	}
+
+	waiting := listen(func(conn net.Conn) {
+		go func() {
+			defer conn.Close()
+			io.Copy(ioutil.Discard, conn)
+		}()
+	})
+	if err := checkPortReady(waiting); err != nil {
+		t.Errorf("unexpected error for the waiting app: %v", err)
+	}
+
+	//the Docker userland proxy closes the connections if the app is not listening
+	closing := listen(func(conn net.Conn) { conn.Close() })
+	if err := checkPortReady(closing); err == nil {
+		t.Error("expected error for the closed connection")
+	}
+
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	notListening := ln.Addr().String()
+	ln.Close()
+	if err := checkPortReady(notListening); err == nil {
+		t.Error("expected error for the port without listener")
+	}
+}
+
+func TestReadinessURL(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/healthz" || calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), host,
+		config.HTTPProbeOptions{
+			ReadyURL:    "/healthz",
+			ReadyStatus: http.StatusNoContent,
+		}, false)
+	probe.ports = []string{port}
+
+	method, check, err := probe.readinessCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != readyMethodURL {
+		t.Errorf("unexpected readiness method: %s", method)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := check(); err == nil {
+			t.Fatalf("expected error on attempt %d", i+1)
+		}
+	}
+
+	probe.waitForReady()
+	if calls != 3 {
+		t.Errorf("expected 3 readiness URL calls, got %d", calls)
+	}
+}
+
+func TestReadinessPortsWithListenSignal(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(ioutil.Discard, conn)
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), host, config.HTTPProbeOptions{}, false)
+	probe.ports = []string{port}
+
+	listening, known := false, true
+	probe.appListening = func() (bool, bool) { return listening, known }
+
+	method, check, _ := probe.readinessCheck()
+	if method != readyMethodPorts {
+		t.Errorf("unexpected readiness method: %s", method)
+	}
+
+	if err := check(); err != errAppNotListening {
+		t.Errorf("expected the app not listening error, got: %v", err)
+	}
+
+	listening = true
+	if err := check(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	//no sensor stats (the ports are checked)
+	listening, known = false, false
+	if err := check(); err != nil {
+		t.Errorf("unexpected error without the sensor stats: %v", err)
+	}
+}
+
+func TestReadinessPortsWithDeadPort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(ioutil.Discard, conn)
+			}()
+		}
+	}()
+
+	//exposed port not used by the app
+	dead, _ := net.Listen("tcp", "127.0.0.1:0")
+	_, deadPort, _ := net.SplitHostPort(dead.Addr().String())
+	dead.Close()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), host, config.HTTPProbeOptions{}, false)
+	probe.ports = []string{port, deadPort}
+
+	_, check, _ := probe.readinessCheck()
+	if err := check(); err != nil {
+		t.Errorf("unexpected error with the ready main port: %v", err)
+	}
+
+	//the other ready ports don't make the app ready
+	probe.ports = []string{deadPort, port}
+	if err := check(); err == nil {
+		t.Error("expected error without the ready main port")
+	}
+
+	probe.ports = []string{deadPort}
+	if err := check(); err == nil {
+		t.Error("expected error without ready ports")
+	}
+}
+
+func TestReadinessTargetStopped(t *testing.T) {
+	dead, _ := net.Listen("tcp", "127.0.0.1:0")
+	host, deadPort, _ := net.SplitHostPort(dead.Addr().String())
+	dead.Close()
+
+	probe := newCustomProbe(app.NewExecutionContext("test", "text"), host,
+		config.HTTPProbeOptions{ReadyTimeout: 60}, false)
+	probe.ports = []string{deadPort}
+	probe.appExited = func() bool { return true }
+
+	start := time.Now()
+	if probe.waitForReady() {
+		t.Error("expected the readiness wait to fail for the stopped target")
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("the readiness wait is not aborted: %v", elapsed)
+	}
+}
@@ -0,0 +1,272 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/container"
+)
+
+// DefaultRecordFileName is the default name for the recorded HTTP probe command file
+const DefaultRecordFileName = "http-probe-recorded-cmds.json"
+
+// Max request body size saved in the recorded commands
+const maxRecordBodySize = 1024 * 1024
+
+// Default recording proxy listen address (only the local clients are accepted)
+const defaultRecordHost = "127.0.0.1"
+
+// Request headers that are not saved in the recorded commands
+var recordSkipHeaders = map[string]struct{}{
+	"Host":                {},
+	"Content-Length":      {},
+	"Connection":          {},
+	"Keep-Alive":          {},
+	"Proxy-Connection":    {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Accept-Encoding":     {},
+	"X-Forwarded-For":     {},
+	"X-Forwarded-Host":    {},
+	"X-Forwarded-Proto":   {},
+	"Proxy-Authorization": {},
+}
+
+// Request headers that are saved in the recorded commands
+// only if the auth header recording is enabled
+var recordAuthHeaders = map[string]struct{}{
+	"Authorization": {},
+	"Cookie":        {},
+}
+
+// RecordingProxy is a reverse proxy for the target app
+// that records the client requests as HTTP probe commands
+type RecordingProxy struct {
+	Target string
+	// Recorded target container port (set for the container recording proxies)
+	TargetPort string
+	// Record the Authorization and Cookie headers
+	KeepAuthHeaders bool
+
+	listener net.Listener
+	server   *http.Server
+
+	mu   sync.Mutex
+	cmds []config.HTTPProbeCmd
+}
+
+// NewContainerRecordingProxy creates a recording proxy for the first target container port
+// (the same port selection as in the HTTP probe)
+func NewContainerRecordingProxy(
+	inspector *container.Inspector,
+	opts config.HTTPProbeOptions) (*RecordingProxy, error) {
+	ports := containerProbePorts(inspector, opts)
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no target ports")
+	}
+
+	proto := config.ProtoHTTP
+	if ports[0] == defaultHTTPSPortStr {
+		proto = config.ProtoHTTPS
+	}
+
+	host := opts.RecordHost
+	if host == "" {
+		host = defaultRecordHost
+	}
+
+	recorder, err := NewRecordingProxy(
+		getHTTPAddr(proto, inspector.TargetHost, ports[0]),
+		net.JoinHostPort(host, strconv.Itoa(opts.RecordPort)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ports) > 1 {
+		log.Debugf("http.NewContainerRecordingProxy - recording port %s (ports: %v)", ports[0], ports)
+	}
+
+	recorder.TargetPort = ports[0]
+	recorder.KeepAuthHeaders = opts.RecordAuth
+	return recorder, nil
+}
+
+// NewRecordingProxy creates a recording proxy listening on the provided address
+func NewRecordingProxy(target, listenAddr string) (*RecordingProxy, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	proxy.Transport = getHTTP1Client(nil).Transport
+
+	r := &RecordingProxy{
+		Target:   target,
+		listener: listener,
+	}
+
+	r.server = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r.record(req)
+			proxy.ServeHTTP(w, req)
+		}),
+	}
+
+	return r, nil
+}
+
+// Addr returns the proxy listener address
+func (r *RecordingProxy) Addr() string {
+	return r.listener.Addr().String()
+}
+
+// Port returns the proxy listener port
+func (r *RecordingProxy) Port() int {
+	return r.listener.Addr().(*net.TCPAddr).Port
+}
+
+// URL returns the proxy URL for the clients
+// (with the local host name if the proxy listens on all addresses)
+func (r *RecordingProxy) URL() string {
+	addr := r.listener.Addr().(*net.TCPAddr)
+	host := addr.IP.String()
+	if addr.IP.IsUnspecified() {
+		host = "localhost"
+	}
+
+	return fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(addr.Port)))
+}
+
+// ListensOnAllAddrs returns true if the proxy listens on all host addresses
+func (r *RecordingProxy) ListensOnAllAddrs() bool {
+	return r.listener.Addr().(*net.TCPAddr).IP.IsUnspecified()
+}
+
+// Start starts serving the client requests
+func (r *RecordingProxy) Start() {
+	go func() {
+		if err := r.server.Serve(r.listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("http.RecordingProxy - serve error: %v", err)
+		}
+	}()
+}
+
+// Stop stops the proxy (waiting for the active requests to finish)
+func (r *RecordingProxy) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.server.Shutdown(ctx); err != nil {
+		log.Debugf("http.RecordingProxy - shutdown error: %v", err)
+	}
+}
+
+// Cmds returns the recorded HTTP probe commands (in the request order)
+func (r *RecordingProxy) Cmds() []config.HTTPProbeCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmds := make([]config.HTTPProbeCmd, len(r.cmds))
+	copy(cmds, r.cmds)
+	return cmds
+}
+
+// Save saves the recorded commands in the HTTP probe command file format
+// (can be used with the '--http-probe-cmd-file' flag).
+// The file is readable only by its owner because the recorded requests can include secrets.
+func (r *RecordingProxy) Save(filePath string) error {
+	data, err := json.MarshalIndent(config.HTTPProbeCmds{Commands: r.Cmds()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filePath, data, 0600); err != nil {
+		return err
+	}
+
+	//the permissions are not changed by WriteFile for the existing files
+	return os.Chmod(filePath, 0600)
+}
+
+func (r *RecordingProxy) record(req *http.Request) {
+	switch req.Method {
+	case "HEAD", "GET", "POST", "PUT", "DELETE", "PATCH":
+	default:
+		log.Debugf("http.RecordingProxy - not recording unsupported method: %s %s", req.Method, req.URL)
+		return
+	}
+
+	cmd := config.HTTPProbeCmd{
+		Method:   req.Method,
+		Resource: req.URL.RequestURI(),
+	}
+
+	var names []string
+	for name := range req.Header {
+		if _, found := recordSkipHeaders[name]; found {
+			continue
+		}
+
+		if _, found := recordAuthHeaders[name]; found && !r.KeepAuthHeaders {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			cmd.Headers = append(cmd.Headers, fmt.Sprintf("%s: %s", name, value))
+		}
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		//the body is restored for the proxied request
+		data, err := ioutil.ReadAll(io.LimitReader(req.Body, maxRecordBodySize+1))
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+
+		switch {
+		case err != nil:
+			log.Debugf("http.RecordingProxy - body read error (%s %s): %v", req.Method, req.URL, err)
+		case len(data) > maxRecordBodySize:
+			log.Debugf("http.RecordingProxy - not recording large body (%s %s)", req.Method, req.URL)
+		case !utf8.Valid(data):
+			log.Debugf("http.RecordingProxy - not recording binary body (%s %s)", req.Method, req.URL)
+		default:
+			cmd.Body = string(data)
+		}
+	}
+
+	r.mu.Lock()
+	r.cmds = append(r.cmds, cmd)
+	r.mu.Unlock()
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestRecordingProxy(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		received = string(data)
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	recorder, err := NewRecordingProxy(srv.URL, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.Start()
+	defer recorder.Stop()
+
+	addr := fmt.Sprintf("http://127.0.0.1:%d", recorder.Port())
+	res, err := http.Get(addr + "/api/items?limit=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, addr+"/api/items", strings.NewReader(`{"name":"one"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=123")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if received != `{"name":"one"}` {
+		t.Errorf("unexpected proxied body: %s", received)
+	}
+
+	cmds := recorder.Cmds()
+	if len(cmds) != 2 {
+		t.Fatalf("unexpected recorded commands: %+v", cmds)
+	}
+
+	if cmds[0].Method != "GET" || cmds[0].Resource != "/api/items?limit=5" {
+		t.Errorf("unexpected command: %+v", cmds[0])
+	}
+
+	if cmds[1].Method != "POST" || cmds[1].Body != `{"name":"one"}` {
+		t.Errorf("unexpected command: %+v", cmds[1])
+	}
+
+	found := false
+	for _, header := range cmds[1].Headers {
+		if header == "Content-Type: application/json" {
+			found = true
+		}
+
+		if strings.HasPrefix(header, "Authorization:") || strings.HasPrefix(header, "Cookie:") {
+			t.Errorf("unexpected auth header: %s", header)
+		}
+	}
+
+	if !found {
+		t.Errorf("missing content type header: %v", cmds[1].Headers)
+	}
+
+	cmdFile := filepath.Join(t.TempDir(), DefaultRecordFileName)
+	if err := recorder.Save(cmdFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(cmdFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected saved command file permissions: %v (%v)", info.Mode(), err)
+	}
+
+	data, err := ioutil.ReadFile(cmdFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var saved config.HTTPProbeCmds
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved.Commands) != 2 {
+		t.Errorf("unexpected saved commands: %s (%v)", data, err)
+	}
+}
+
+func TestRecordingProxyKeepAuthHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	recorder, err := NewRecordingProxy(srv.URL, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.KeepAuthHeaders = true
+	recorder.Start()
+	defer recorder.Stop()
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/", recorder.Addr()), nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=123")
+	req.Header.Set("Proxy-Authorization", "Basic cHJveHk6cHJveHk=")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	cmds := recorder.Cmds()
+	if len(cmds) != 1 {
+		t.Fatalf("unexpected recorded commands: %+v", cmds)
+	}
+
+	expected := []string{"Authorization: Bearer secret", "Cookie: session=123"}
+	var headers []string
+	for _, header := range cmds[0].Headers {
+		if !strings.HasPrefix(header, "User-Agent:") {
+			headers = append(headers, header)
+		}
+	}
+
+	if strings.Join(headers, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected recorded headers: %v", cmds[0].Headers)
+	}
+}
+
+func TestRecordingProxyURL(t *testing.T) {
+	recorder, err := NewRecordingProxy("http://127.0.0.1:8080", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.listener.Close()
+
+	if expected := fmt.Sprintf("http://127.0.0.1:%d", recorder.Port()); recorder.URL() != expected {
+		t.Errorf("unexpected URL: %s (expected %s)", recorder.URL(), expected)
+	}
+
+	if recorder.ListensOnAllAddrs() {
+		t.Error("unexpected all addresses listener")
+	}
+
+	allRecorder, err := NewRecordingProxy("http://127.0.0.1:8080", "0.0.0.0:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer allRecorder.listener.Close()
+
+	if expected := fmt.Sprintf("http://localhost:%d", allRecorder.Port()); allRecorder.URL() != expected {
+		t.Errorf("unexpected URL: %s (expected %s)", allRecorder.URL(), expected)
+	}
+
+	if !allRecorder.ListensOnAllAddrs() {
+		t.Error("expected all addresses listener")
+	}
+}
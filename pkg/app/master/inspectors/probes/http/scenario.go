@@ -0,0 +1,458 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+// Max number of step executions in one scenario run
+// (protects from the 'goto' loops)
+const maxScenarioStepRuns = 1000
+
+// Max response body size used for the captures and the assertions
+const maxScenarioBodySize = 4 * 1024 * 1024
+
+// The status code of the last step response is always available as a variable
+const scenarioStatusVar = "status"
+
+var scenarioVarPattern = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// expandScenarioVars replaces the variable references ("{{name}}")
+// (the unknown variables are replaced with empty strings)
+func expandScenarioVars(value string, vars map[string]string) string {
+	return scenarioVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := scenarioVarPattern.FindStringSubmatch(ref)[1]
+		val, found := vars[name]
+		if !found {
+			log.Debugf("http.probe.scenario - unknown variable: %s", name)
+		}
+
+		return val
+	})
+}
+
+// evalScenarioCondition evaluates the condition expression:
+// "<a> == <b>", "<a> != <b>" or "<a>" (true if not empty, "0" or "false")
+func evalScenarioCondition(cond string, vars map[string]string) bool {
+	for _, op := range []string{"!=", "=="} {
+		if parts := strings.SplitN(cond, op, 2); len(parts) == 2 {
+			left := strings.TrimSpace(expandScenarioVars(parts[0], vars))
+			right := strings.TrimSpace(expandScenarioVars(parts[1], vars))
+			return (left == right) == (op == "==")
+		}
+	}
+
+	switch strings.TrimSpace(expandScenarioVars(cond, vars)) {
+	case "", "0", "false":
+		return false
+	default:
+		return true
+	}
+}
+
+// jsonPathValue returns the value for the dot separated path
+// (the array elements are selected using their indexes, e.g., "items.0.id")
+func jsonPathValue(data interface{}, path string) (string, bool) {
+	current := data
+	if path != "" && path != "." {
+		for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+			switch val := current.(type) {
+			case map[string]interface{}:
+				next, found := val[key]
+				if !found {
+					return "", false
+				}
+
+				current = next
+			case []interface{}:
+				idx, err := strconv.Atoi(key)
+				if err != nil || idx < 0 || idx >= len(val) {
+					return "", false
+				}
+
+				current = val[idx]
+			default:
+				return "", false
+			}
+		}
+	}
+
+	switch val := current.(type) {
+	case nil:
+		return "", true
+	case string:
+		return val, true
+	default:
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return "", false
+		}
+
+		return string(raw), true
+	}
+}
+
+type scenarioResponse struct {
+	res     *http.Response
+	body    []byte
+	json    interface{}
+	latency time.Duration
+}
+
+func (r *scenarioResponse) jsonValue(path string) (string, bool) {
+	if r.json == nil {
+		if err := json.Unmarshal(r.body, &r.json); err != nil {
+			return "", false
+		}
+	}
+
+	return jsonPathValue(r.json, path)
+}
+
+func (r *scenarioResponse) capture(c config.HTTPProbeCapture) (string, bool) {
+	switch {
+	case c.JSON != "":
+		return r.jsonValue(c.JSON)
+	case c.Header != "":
+		val := r.res.Header.Get(c.Header)
+		return val, val != ""
+	case c.Cookie != "":
+		for _, cookie := range r.res.Cookies() {
+			if cookie.Name == c.Cookie {
+				return cookie.Value, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// check returns the failed assertion descriptions
+func (r *scenarioResponse) check(a *config.HTTPProbeAssert, vars map[string]string) []string {
+	var failures []string
+	if a == nil {
+		return failures
+	}
+
+	if len(a.Status) > 0 {
+		found := false
+		for _, status := range a.Status {
+			if status == r.res.StatusCode {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			failures = append(failures, fmt.Sprintf("status=%d (expected %v)", r.res.StatusCode, a.Status))
+		}
+	}
+
+	if a.BodyContains != "" {
+		expected := expandScenarioVars(a.BodyContains, vars)
+		if !strings.Contains(string(r.body), expected) {
+			failures = append(failures, fmt.Sprintf("body does not contain '%s'", expected))
+		}
+	}
+
+	for path, expected := range a.JSON {
+		expected = expandScenarioVars(expected, vars)
+		if val, found := r.jsonValue(path); !found || val != expected {
+			failures = append(failures, fmt.Sprintf("json[%s]='%s' (expected '%s')", path, val, expected))
+		}
+	}
+
+	if a.MaxLatency > 0 && r.latency > time.Duration(a.MaxLatency)*time.Millisecond {
+		failures = append(failures, fmt.Sprintf("latency=%v (max %vms)", r.latency, a.MaxLatency))
+	}
+
+	return failures
+}
+
+func newScenarioRequest(step config.HTTPProbeStep, baseAddr string, vars map[string]string) (*http.Request, error) {
+	var body io.Reader
+	if step.Body != "" {
+		body = strings.NewReader(expandScenarioVars(step.Body, vars))
+	}
+
+	addr := baseAddr + expandScenarioVars(step.Resource, vars)
+	req, err := http.NewRequestWithContext(context.Background(), step.Method, addr, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hline := range step.Headers {
+		hparts := strings.SplitN(expandScenarioVars(hline, vars), ":", 2)
+		if len(hparts) != 2 {
+			log.Debugf("ignoring malformed header (%v)", hline)
+			continue
+		}
+
+		req.Header.Add(strings.TrimSpace(hparts[0]), strings.TrimSpace(hparts[1]))
+	}
+
+	if (step.Username != "") || (step.Password != "") {
+		req.SetBasicAuth(expandScenarioVars(step.Username, vars), expandScenarioVars(step.Password, vars))
+	}
+
+	return req, nil
+}
+
+func scenarioCall(client *http.Client, req *http.Request) (*scenarioResponse, error) {
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxScenarioBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &scenarioResponse{
+		res:     res,
+		body:    body,
+		latency: time.Since(start),
+	}, nil
+}
+
+// probeScenarios runs the scenarios for the target port
+func (p *CustomProbe) probeScenarios(port string, maxRetryCount int, retryWait time.Duration) {
+	for _, scenario := range p.opts.Scenarios {
+		var protocols []string
+		if scenario.Protocol == "" {
+			switch port {
+			case defaultHTTPPortStr:
+				protocols = []string{config.ProtoHTTP}
+			case defaultHTTPSPortStr:
+				protocols = []string{config.ProtoHTTPS}
+			default:
+				protocols = []string{config.ProtoHTTP, config.ProtoHTTPS}
+			}
+		} else {
+			protocols = []string{scenario.Protocol}
+		}
+
+		for _, proto := range protocols {
+			if p.runScenario(scenario, proto, port, maxRetryCount, retryWait) {
+				break
+			}
+		}
+	}
+}
+
+// runScenario runs the scenario steps and returns false
+// if the target didn't respond to the scenario requests
+func (p *CustomProbe) runScenario(
+	scenario config.HTTPProbeScenario,
+	proto string,
+	port string,
+	maxRetryCount int,
+	retryWait time.Duration) bool {
+	client, err := p.httpClient(proto, nil)
+	if err != nil {
+		p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
+		return false
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		p.xc.Out.Error("HTTP probe - construct cookie jar error - %v", err.Error())
+		return false
+	}
+
+	//the scenario steps share the session cookies
+	client.Jar = jar
+
+	vars := map[string]string{}
+	for k, v := range scenario.Vars {
+		vars[k] = v
+	}
+
+	stepIdx := map[string]int{}
+	for idx, step := range scenario.Steps {
+		if step.Name != "" {
+			stepIdx[step.Name] = idx
+		}
+	}
+
+	baseAddr := getHTTPAddr(proto, p.targetHost, port)
+	status := "ok"
+	var failedStep string
+	var failures []string
+	responded := false
+	stepRuns := 0
+	for idx := 0; idx < len(scenario.Steps) && failedStep == ""; {
+		step := scenario.Steps[idx]
+		stepName := step.Name
+		if stepName == "" {
+			stepName = fmt.Sprintf("%d", idx+1)
+		}
+
+		if step.When != "" && !evalScenarioCondition(step.When, vars) {
+			log.Debugf("http.probe.scenario - skipping step '%s' (%s)", stepName, step.When)
+			idx++
+			continue
+		}
+
+		repeat := step.Repeat
+		if repeat < 1 {
+			repeat = 1
+		}
+
+		for run := 0; run < repeat; run++ {
+			stepRuns++
+			if stepRuns > maxScenarioStepRuns {
+				status = "error"
+				failedStep = stepName
+				failures = []string{"too many step runs"}
+				break
+			}
+
+			sres, err := p.scenarioStepCall(client, scenario, step, stepName, baseAddr, vars, maxRetryCount, retryWait)
+			if err != nil {
+				status = "error"
+				failedStep = stepName
+				failures = []string{err.Error()}
+				break
+			}
+
+			responded = true
+			vars[scenarioStatusVar] = fmt.Sprintf("%d", sres.res.StatusCode)
+			for _, c := range step.Capture {
+				if val, found := sres.capture(c); found {
+					vars[c.Var] = val
+				} else {
+					log.Debugf("http.probe.scenario - no value captured for '%s' (step '%s')", c.Var, stepName)
+				}
+			}
+
+			if failures = sres.check(step.Assert, vars); len(failures) > 0 {
+				p.ErrCount++
+				p.AssertFailCount++
+				status = "failed"
+				failedStep = stepName
+				break
+			}
+
+			p.OkCount++
+
+			if step.Until != "" && evalScenarioCondition(step.Until, vars) {
+				break
+			}
+		}
+
+		if failedStep != "" {
+			break
+		}
+
+		if step.Goto != "" {
+			next, found := stepIdx[step.Goto]
+			if !found {
+				status = "error"
+				failedStep = stepName
+				failures = []string{fmt.Sprintf("unknown goto step '%s'", step.Goto)}
+				break
+			}
+
+			idx = next
+			continue
+		}
+
+		idx++
+	}
+
+	if !responded && len(scenario.Steps) > 0 {
+		return false
+	}
+
+	if status != "ok" {
+		p.ScenarioFailCount++
+	}
+
+	if p.printState {
+		p.xc.Out.Info("http.probe.scenario",
+			ovars{
+				"name":     scenario.Name,
+				"status":   status,
+				"target":   baseAddr,
+				"step":     failedStep,
+				"failures": strings.Join(failures, "; "),
+			})
+	}
+
+	return true
+}
+
+func (p *CustomProbe) scenarioStepCall(
+	client *http.Client,
+	scenario config.HTTPProbeScenario,
+	step config.HTTPProbeStep,
+	stepName string,
+	baseAddr string,
+	vars map[string]string,
+	maxRetryCount int,
+	retryWait time.Duration) (*scenarioResponse, error) {
+	var sres *scenarioResponse
+	var err error
+	for i := 0; i < maxRetryCount; i++ {
+		var req *http.Request
+		req, err = newScenarioRequest(step, baseAddr, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		sres, err = scenarioCall(client, req)
+		p.CallCount++
+
+		statusCode := "error"
+		callErrorStr := "none"
+		var latency time.Duration
+		if err == nil {
+			statusCode = fmt.Sprintf("%v", sres.res.StatusCode)
+			latency = sres.latency
+		} else {
+			callErrorStr = err.Error()
+			p.ErrCount++
+		}
+
+		if p.printState {
+			p.xc.Out.Info("http.probe.scenario.step",
+				ovars{
+					"scenario": scenario.Name,
+					"step":     stepName,
+					"status":   statusCode,
+					"method":   step.Method,
+					"target":   req.URL.String(),
+					"attempt":  i + 1,
+					"latency":  latency.Round(time.Millisecond),
+					"error":    callErrorStr,
+					"time":     time.Now().UTC().Format(time.RFC3339),
+				})
+		}
+
+		if err == nil {
+			return sres, nil
+		}
+
+		log.Debugf("http.probe.scenario - call error... retry again later...")
+		time.Sleep(retryWait * time.Second)
+	}
+
+	return nil, err
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+)
+
+func TestRunScenario(t *testing.T) {
+	var pollCount int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		fmt.Fprintf(w, `{"data": {"token": "t-%s"}}`, r.FormValue("user"))
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "s1" || r.Header.Get("Authorization") != "Bearer t-admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprint(w, `{"user": "admin", "roles": ["a", "b"]}`)
+	})
+	mux.HandleFunc("/poll", func(w http.ResponseWriter, r *http.Request) {
+		pollCount++
+		if pollCount < 3 {
+			fmt.Fprint(w, `{"state": "running"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"state": "done"}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenario := config.HTTPProbeScenario{
+		Name: "login",
+		Vars: map[string]string{"user": "admin"},
+		Steps: []config.HTTPProbeStep{
+			{
+				Method:   "GET",
+				Resource: "/login?user={{user}}",
+				Capture:  []config.HTTPProbeCapture{{Var: "token", JSON: "data.token"}},
+			},
+			{
+				Method:   "GET",
+				Resource: "/profile",
+				Headers:  []string{"Authorization: Bearer {{token}}"},
+				Capture:  []config.HTTPProbeCapture{{Var: "role", JSON: "roles.1"}},
+				Assert: &config.HTTPProbeAssert{
+					Status: []int{200},
+					JSON:   map[string]string{"user": "{{user}}"},
+				},
+			},
+			{
+				Method:   "GET",
+				Resource: "/poll",
+				Capture:  []config.HTTPProbeCapture{{Var: "state", JSON: "state"}},
+				Repeat:   5,
+				Until:    "{{state}} == done",
+			},
+			{
+				Method:   "GET",
+				Resource: "/profile",
+				When:     "{{role}} != b",
+			},
+		},
+	}
+
+	p := newCustomProbe(nil, host, config.HTTPProbeOptions{}, false)
+	if !p.runScenario(scenario, config.ProtoHTTP, port, 1, 0) {
+		t.Fatal("expected response")
+	}
+
+	if p.ScenarioFailCount != 0 || p.AssertFailCount != 0 {
+		t.Errorf("unexpected failures: scenarios=%d assertions=%d", p.ScenarioFailCount, p.AssertFailCount)
+	}
+
+	//the last step is skipped
+	if pollCount != 3 || p.CallCount != 5 || p.OkCount != 5 {
+		t.Errorf("unexpected calls: poll=%d calls=%d ok=%d", pollCount, p.CallCount, p.OkCount)
+	}
+
+	//no auth token
+	scenario.Steps = scenario.Steps[1:2]
+	p.runScenario(scenario, config.ProtoHTTP, port, 1, 0)
+	if p.ScenarioFailCount != 1 || p.AssertFailCount != 1 {
+		t.Errorf("unexpected failures: scenarios=%d assertions=%d", p.ScenarioFailCount, p.AssertFailCount)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
 )
 
 type apiSpecInfo struct {
@@ -202,7 +205,7 @@ func apiSpecPrefix(spec *openapi3.T) (string, error) {
 func (p *CustomProbe) loadAPISpecs(proto, targetHost, port string) {
 
 	baseAddr := getHTTPAddr(proto, targetHost, port)
-	client, err := getHTTPClient(proto)
+	client, err := p.httpClient(proto, nil)
 	if err != nil {
 		p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 		return
@@ -271,6 +274,22 @@ func addPathOp(m *map[string]*openapi3.Operation, op *openapi3.Operation, name s
 	}
 }
 
+// apiSpecCallResult returns the operation call result category
+func apiSpecCallResult(statusCode int) string {
+	switch {
+	case statusCode == 0:
+		return "error"
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return "unauthorized"
+	case statusCode >= 500:
+		return "server.error"
+	case statusCode >= 400:
+		return "client.error"
+	default:
+		return "ok"
+	}
+}
+
 func (p *CustomProbe) probeAPISpecEndpoints(proto, targetHost, port, prefix string, spec *openapi3.T) {
 	addr := getHTTPAddr(proto, targetHost, port)
 
@@ -283,57 +302,107 @@ func (p *CustomProbe) probeAPISpecEndpoints(proto, targetHost, port, prefix stri
 			})
 	}
 
-	httpClient, err := getHTTPClient(proto)
+	httpClient, err := p.httpClient(proto, nil)
 	if err != nil {
 		p.xc.Out.Error("HTTP probe - construct client error - %v", err.Error())
 		return
 	}
 
-	for apiPath, pathInfo := range spec.Paths {
-		//very primitive way to set the path params (will break for numeric values)
-		if strings.Contains(apiPath, "{") {
-			apiPath = strings.ReplaceAll(apiPath, "{", "")
+	var apiPaths []string
+	for apiPath := range spec.Paths {
+		apiPaths = append(apiPaths, apiPath)
+	}
 
-			if strings.Contains(apiPath, "}") {
-				apiPath = strings.ReplaceAll(apiPath, "}", "")
-			}
-		}
+	//making the call order (and the coverage report) stable
+	sort.Strings(apiPaths)
 
-		endpoint := fmt.Sprintf("%s%s%s", addr, prefix, apiPath)
+	var coverage []*report.APISpecOpCoverage
+	for _, apiPath := range apiPaths {
+		pathInfo := spec.Paths[apiPath]
 		ops := pathOps(pathInfo)
+
+		var apiMethods []string
 		for apiMethod := range ops {
-			//make a call (no params for now)
-			p.apiSpecEndpointCall(httpClient, endpoint, apiMethod)
+			apiMethods = append(apiMethods, apiMethod)
+		}
+
+		sort.Strings(apiMethods)
+		for _, apiMethod := range apiMethods {
+			req := newAPISpecRequest(
+				spec,
+				fmt.Sprintf("%s%s", addr, prefix),
+				apiPath,
+				apiMethod,
+				pathInfo,
+				ops[apiMethod],
+				p.opts.APISpecCredentials)
+
+			coverage = append(coverage, p.apiSpecEndpointCall(httpClient, req))
 		}
 	}
+
+	for _, info := range coverage {
+		p.APISpecCoverage = append(p.APISpecCoverage, *info)
+	}
+
+	if p.printState {
+		p.printAPISpecCoverage(coverage)
+	}
 }
 
-func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method string) {
-	maxRetryCount := probeRetryCount
-	if p.opts.RetryCount > 0 {
-		maxRetryCount = p.opts.RetryCount
+func (p *CustomProbe) printAPISpecCoverage(coverage []*report.APISpecOpCoverage) {
+	counts := map[string]int{}
+	for _, info := range coverage {
+		counts[info.Result]++
+
+		status := "error"
+		if info.StatusCode != 0 {
+			status = fmt.Sprintf("%v", info.StatusCode)
+		}
+
+		p.xc.Out.Info("http.probe.api-spec.coverage.operation",
+			ovars{
+				"method":    info.Method,
+				"path":      info.Path,
+				"operation": info.OperationID,
+				"status":    status,
+				"result":    info.Result,
+			})
 	}
 
-	notReadyErrorWait := time.Duration(16)
-	webErrorWait := time.Duration(8)
-	otherErrorWait := time.Duration(4)
-	if p.opts.RetryWait > 0 {
-		webErrorWait = time.Duration(p.opts.RetryWait)
-		notReadyErrorWait = time.Duration(p.opts.RetryWait * 2)
-		otherErrorWait = time.Duration(p.opts.RetryWait / 2)
+	p.xc.Out.Info("http.probe.api-spec.coverage",
+		ovars{
+			"operations":    len(coverage),
+			"ok":            counts["ok"],
+			"unauthorized":  counts["unauthorized"],
+			"client.errors": counts["client.error"],
+			"server.errors": counts["server.error"],
+			"call.errors":   counts["error"],
+		})
+}
+
+func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, apiReq *apiSpecRequest) *report.APISpecOpCoverage {
+	coverage := &report.APISpecOpCoverage{
+		Method:      apiReq.method,
+		Path:        apiReq.path,
+		OperationID: apiReq.operationID,
+		Endpoint:    apiReq.endpoint,
 	}
 
-	method = strings.ToUpper(method)
+	maxRetryCount, notReadyErrorWait, webErrorWait, otherErrorWait := p.retrySettings()
+
 	for i := 0; i < maxRetryCount; i++ {
-		req, err := http.NewRequest(method, endpoint, nil)
+		req, err := apiReq.newHTTPRequest()
 		if err != nil {
 			p.xc.Out.Error("HTTP probe - construct request error - %v", err.Error())
+			coverage.Error = err.Error()
 			// Break since the same args are passed to NewRequest() on each loop.
 			break
 		}
-		//no body, no request headers and no credentials for now
+
 		res, err := client.Do(req)
 		p.CallCount++
+		coverage.Attempts++
 
 		if res != nil {
 			if res.Body != nil {
@@ -347,16 +416,19 @@ func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method
 		callErrorStr := "none"
 		if err == nil {
 			statusCode = fmt.Sprintf("%v", res.StatusCode)
+			coverage.StatusCode = res.StatusCode
+			coverage.Error = ""
 		} else {
 			callErrorStr = err.Error()
+			coverage.Error = callErrorStr
 		}
 
 		if p.printState {
 			p.xc.Out.Info("http.probe.api-spec.probe.endpoint.call",
 				ovars{
 					"status":   statusCode,
-					"method":   method,
-					"endpoint": endpoint,
+					"method":   apiReq.method,
+					"endpoint": apiReq.endpoint,
 					"attempt":  i + 1,
 					"error":    callErrorStr,
 					"time":     time.Now().UTC().Format(time.RFC3339),
@@ -385,4 +457,7 @@ func (p *CustomProbe) apiSpecEndpointCall(client *http.Client, endpoint, method
 		}
 
 	}
+
+	coverage.Result = apiSpecCallResult(coverage.StatusCode)
+	return coverage
 }
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/master/config"
+	"github.com/docker-slim/docker-slim/pkg/app/master/inspectors/probes/tcp"
+)
+
+type tcpProbeTarget struct {
+	port string //target port (host or container port)
+	cmd  config.TCPProbeCmd
+}
+
+// setTCPTargets maps the TCP probe container ports to the target ports
+// (the TCP probe ports are removed from the HTTP probe ports)
+func (p *CustomProbe) setTCPTargets(targetPort func(containerPort uint16) string) {
+	for _, cmd := range p.opts.TCPProbes {
+		port := targetPort(cmd.Port)
+		if port == "" {
+			log.Debugf("HTTP probe - ignoring TCP probe port => %v", cmd.Port)
+			continue
+		}
+
+		p.tcpTargets = append(p.tcpTargets, tcpProbeTarget{port: port, cmd: cmd})
+
+		var httpPorts []string
+		for _, httpPort := range p.ports {
+			if httpPort != port {
+				httpPorts = append(httpPorts, httpPort)
+			}
+		}
+
+		p.ports = httpPorts
+	}
+
+	log.Debugf("HTTP probe - TCP probe targets => %+v", p.tcpTargets)
+}
+
+func (p *CustomProbe) probeTCPTargets() {
+	maxRetryCount, notReadyErrorWait, _, otherErrorWait := p.retrySettings()
+	for _, target := range p.tcpTargets {
+		driver, err := tcp.GetDriver(target.cmd.Driver)
+		if err != nil {
+			p.xc.Out.Error("tcp.probe.error", err.Error())
+			continue
+		}
+
+		addr := net.JoinHostPort(p.targetHost, target.port)
+		for i := 0; i < maxRetryCount; i++ {
+			err := tcp.Probe(addr, driver, target.cmd.Params)
+			p.TCPCallCount++
+
+			if p.printState {
+				statusCode := "ok"
+				callErrorStr := "none"
+				if err != nil {
+					statusCode = "error"
+					callErrorStr = err.Error()
+				}
+
+				p.xc.Out.Info("tcp.probe.call",
+					ovars{
+						"status":  statusCode,
+						"driver":  target.cmd.Driver,
+						"target":  addr,
+						"port":    fmt.Sprintf("%d", target.cmd.Port),
+						"attempt": i + 1,
+						"error":   callErrorStr,
+						"time":    time.Now().UTC().Format(time.RFC3339),
+					})
+			}
+
+			if err == nil {
+				p.TCPOkCount++
+				break
+			}
+
+			p.TCPErrCount++
+			if tcp.IsConnError(err) {
+				log.Debugf("HTTP probe - TCP target not ready yet (retry again later)...")
+				time.Sleep(notReadyErrorWait * time.Second)
+			} else {
+				log.Debugf("HTTP probe - TCP probe error (%v)... retry again later...", err)
+				time.Sleep(otherErrorWait * time.Second)
+			}
+		}
+	}
+
+	p.CallCount += p.TCPCallCount
+	p.ErrCount += p.TCPErrCount
+	p.OkCount += p.TCPOkCount
+}
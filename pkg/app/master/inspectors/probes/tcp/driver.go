@@ -0,0 +1,127 @@
+// Package tcp provides the non-HTTP TCP protocol probe drivers
+// (databases, caches and message brokers).
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	dialTimeout  = 10 * time.Second
+	probeTimeout = 30 * time.Second
+)
+
+// Driver is a TCP protocol probe driver.
+// Probe runs the protocol handshake and a basic command set
+// using the connection to the target port.
+type Driver interface {
+	Probe(conn net.Conn, params map[string]string) error
+}
+
+// DriverFunc is a Driver implemented as a function
+type DriverFunc func(conn net.Conn, params map[string]string) error
+
+// Probe calls the driver function
+func (fn DriverFunc) Probe(conn net.Conn, params map[string]string) error {
+	return fn(conn, params)
+}
+
+var ErrUnknownDriver = errors.New("unknown TCP probe driver")
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+// Register makes the driver available by the provided name
+// (registering the same name again replaces the driver)
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	drivers[name] = driver
+}
+
+// GetDriver returns the registered driver
+func GetDriver(name string) (Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	driver, found := drivers[name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, name)
+	}
+
+	return driver, nil
+}
+
+// DriverNames returns the registered driver names
+func DriverNames() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	var names []string
+	for name := range drivers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Dial connects to the target address
+func Dial(addr string) (net.Conn, error) {
+	return net.DialTimeout("tcp", addr, dialTimeout)
+}
+
+// Probe connects to the target address and runs the driver
+func Probe(addr string, driver Driver, params map[string]string) error {
+	conn, err := Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(probeTimeout)); err != nil {
+		return err
+	}
+
+	return driver.Probe(conn, params)
+}
+
+// IsConnError returns true if the probe failed because the target
+// is not ready yet (the connection failed or it was closed by the target).
+// The read/write timeouts are the probe failures (the target accepted
+// the connection, but it didn't reply in time).
+func IsConnError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func init() {
+	Register("redis", DriverFunc(probeRedis))
+	Register("postgres", DriverFunc(probePostgres))
+	Register("mysql", DriverFunc(probeMySQL))
+	Register("memcached", DriverFunc(probeMemcached))
+	Register("nats", DriverFunc(probeNATS))
+	Register("script", DriverFunc(probeScript))
+}
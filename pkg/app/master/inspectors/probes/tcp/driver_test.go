@@ -0,0 +1,631 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve runs the handler for the connections to the test listener
+func serve(t *testing.T, handler func(conn net.Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestProbeRedis(t *testing.T) {
+	var cmds []string
+	addr := serve(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		store := map[string]string{}
+		for {
+			reply, err := readRedisReply(r)
+			if err != nil {
+				return
+			}
+
+			args := strings.Fields(reply)
+			cmds = append(cmds, args[0])
+			switch args[0] {
+			case "PING":
+				fmt.Fprint(conn, "+PONG\r\n")
+			case "SET":
+				store[args[1]] = args[2]
+				fmt.Fprint(conn, "+OK\r\n")
+			case "GET":
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(store[args[1]]), store[args[1]])
+			case "DEL":
+				fmt.Fprint(conn, ":1\r\n")
+			case "INFO":
+				fmt.Fprint(conn, "$13\r\nredis_version\r\n")
+			default:
+				fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+			}
+		}
+	})
+
+	driver, err := GetDriver("redis")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Probe(addr, driver, nil); err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+
+	//read-only by default
+	if got := strings.Join(cmds, ","); got != "PING,GET,INFO" {
+		t.Errorf("unexpected commands: %s", got)
+	}
+
+	cmds = nil
+	if err := Probe(addr, driver, map[string]string{"write": "true"}); err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+
+	if got := strings.Join(cmds, ","); got != "PING,SET,GET,DEL,INFO" {
+		t.Errorf("unexpected commands: %s", got)
+	}
+
+	if err := Probe(addr, driver, map[string]string{"password": "secret"}); err == nil ||
+		!strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("expected AUTH error, got: %v", err)
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	//nothing listens on the closed listener port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closedAddr := ln.Addr().String()
+	ln.Close()
+
+	driver := DriverFunc(func(conn net.Conn, params map[string]string) error {
+		_, err := conn.Read(make([]byte, 1))
+		return err
+	})
+
+	if err := Probe(closedAddr, driver, nil); !IsConnError(err) {
+		t.Errorf("expected connection error for the dial error: %v", err)
+	}
+
+	closingAddr := serve(t, func(conn net.Conn) {})
+	if err := Probe(closingAddr, driver, nil); !IsConnError(err) {
+		t.Errorf("expected connection error for the closed connection: %v", err)
+	}
+
+	silentAddr := serve(t, func(conn net.Conn) {
+		time.Sleep(time.Second)
+	})
+
+	timeoutDriver := DriverFunc(func(conn net.Conn, params map[string]string) error {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		_, err := conn.Read(make([]byte, 1))
+		return err
+	})
+
+	if err := Probe(silentAddr, timeoutDriver, nil); err == nil || IsConnError(err) {
+		t.Errorf("expected probe failure for the read timeout: %v", err)
+	}
+}
+
+func TestProbeScript(t *testing.T) {
+	addr := serve(t, func(conn net.Conn) {
+		fmt.Fprint(conn, "220 ready\r\n")
+		line, err := readLine(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+
+		fmt.Fprintf(conn, "250 hello %s\r\n", strings.TrimPrefix(line, "HELO "))
+	})
+
+	script := "# SMTP greeting\n" +
+		"expect 220\n" +
+		`send "HELO probe\r\n"` + "\n" +
+		`expect-re ^ ready\r\n250 hello \w+` + "\n"
+
+	path := filepath.Join(t.TempDir(), "smtp.script")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	driver, _ := GetDriver("script")
+	if err := Probe(addr, driver, map[string]string{"file": path}); err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	tt := []struct {
+		script string
+		err    string
+	}{
+		{script: "send ping\nexpect pong\nsleep 1s"},
+		{script: "# comment only", err: "empty script"},
+		{script: "send ping\nread pong", err: "line 2: unknown operation: read"},
+		{script: "expect-re (", err: "line 1: error parsing regexp"},
+		{script: "sleep forever", err: "line 1: time: invalid duration"},
+	}
+
+	for _, test := range tt {
+		_, err := ParseScript(test.script)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", test.script, err)
+		case test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)):
+			t.Errorf("%q: expected error %q, got: %v", test.script, test.err, err)
+		}
+	}
+}
+
+func TestPBKDF2SHA256(t *testing.T) {
+	//RFC 7914 (section 11) test vector (the first 32 bytes)
+	expected := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"
+	if got := hex.EncodeToString(pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1)); got != expected {
+		t.Errorf("unexpected key: %s", got)
+	}
+}
+
+// serveOnce runs the handler like serve, but the returned channel
+// is signaled when the handler returns (so the recorded data can be checked)
+func serveOnce(t *testing.T, handler func(conn net.Conn)) (string, <-chan struct{}) {
+	handled := make(chan struct{}, 10)
+	addr := serve(t, func(conn net.Conn) {
+		defer func() { handled <- struct{}{} }()
+		handler(conn)
+	})
+
+	return addr, handled
+}
+
+func waitHandled(t *testing.T, handled <-chan struct{}) {
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake server handler timeout")
+	}
+}
+
+func writePGMessage(conn net.Conn, msgType byte, payload []byte) {
+	var buf bytes.Buffer
+	buf.WriteByte(msgType)
+	binary.Write(&buf, binary.BigEndian, int32(len(payload)+4))
+	buf.Write(payload)
+	conn.Write(buf.Bytes())
+}
+
+func readPGMessage(r *bufio.Reader, typed bool) (byte, []byte, error) {
+	var msgType byte
+	if typed {
+		var err error
+		if msgType, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, size-4)
+	_, err := io.ReadFull(r, payload)
+	return msgType, payload, err
+}
+
+func pgAuthMessage(authType uint32, data string) []byte {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, authType)
+	return append(payload, data...)
+}
+
+// pgServer is a fake postgres server ("md5" or "scram" authentication)
+// that accepts the "secret" password
+func pgServer(t *testing.T, method string, queries *[]string) (string, <-chan struct{}) {
+	const password = "secret"
+
+	return serveOnce(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		_, startup, err := readPGMessage(r, false)
+		if err != nil {
+			return
+		}
+
+		params := bytes.Split(startup[4:], []byte{0})
+		user := string(params[1])
+
+		authError := func() {
+			writePGMessage(conn, 'E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00"))
+		}
+
+		switch method {
+		case "md5":
+			salt := "s4lt"
+			writePGMessage(conn, 'R', pgAuthMessage(pgAuthMD5, salt))
+			_, payload, err := readPGMessage(r, true)
+			if err != nil {
+				return
+			}
+
+			inner := md5.Sum([]byte(password + user))
+			outer := md5.Sum([]byte(hex.EncodeToString(inner[:]) + salt))
+			if string(bytes.TrimRight(payload, "\x00")) != "md5"+hex.EncodeToString(outer[:]) {
+				authError()
+				return
+			}
+		case "scram":
+			writePGMessage(conn, 'R', pgAuthMessage(pgAuthSASL, pgSCRAMSHA256+"\x00\x00"))
+			_, payload, err := readPGMessage(r, true)
+			if err != nil {
+				return
+			}
+
+			mechEnd := bytes.IndexByte(payload, 0)
+			if string(payload[:mechEnd]) != pgSCRAMSHA256 {
+				authError()
+				return
+			}
+
+			clientFirst := string(payload[mechEnd+1+4:])
+			clientFirstBare := strings.TrimPrefix(clientFirst, "n,,")
+			nonce := strings.TrimPrefix(clientFirstBare, "n=,r=") + "server"
+			salt := []byte("pgsalt")
+			serverFirst := fmt.Sprintf("r=%s,s=%s,i=16", nonce, base64.StdEncoding.EncodeToString(salt))
+			writePGMessage(conn, 'R', pgAuthMessage(pgAuthSASLContinue, serverFirst))
+
+			_, payload, err = readPGMessage(r, true)
+			if err != nil {
+				return
+			}
+
+			clientFinal := string(payload)
+			proofPos := strings.LastIndex(clientFinal, ",p=")
+			proof, _ := base64.StdEncoding.DecodeString(clientFinal[proofPos+3:])
+			authMessage := clientFirstBare + "," + serverFirst + "," + clientFinal[:proofPos]
+
+			//the server verifies the proof using the stored key
+			saltedPassword := pbkdf2SHA256([]byte(password), salt, 16)
+			storedKey := sha256.Sum256(hmacSHA256(saltedPassword, []byte("Client Key")))
+			clientSignature := hmacSHA256(storedKey[:], []byte(authMessage))
+			if len(proof) != len(clientSignature) {
+				authError()
+				return
+			}
+
+			clientKey := make([]byte, len(proof))
+			for i := range proof {
+				clientKey[i] = proof[i] ^ clientSignature[i]
+			}
+
+			if sha256.Sum256(clientKey) != storedKey {
+				authError()
+				return
+			}
+
+			serverSignature := hmacSHA256(hmacSHA256(saltedPassword, []byte("Server Key")), []byte(authMessage))
+			writePGMessage(conn, 'R', pgAuthMessage(pgAuthSASLFinal, "v="+base64.StdEncoding.EncodeToString(serverSignature)))
+		}
+
+		writePGMessage(conn, 'R', pgAuthMessage(pgAuthOK, ""))
+		writePGMessage(conn, 'S', []byte("server_version\x0015.0\x00"))
+		writePGMessage(conn, 'Z', []byte("I"))
+
+		for {
+			msgType, payload, err := readPGMessage(r, true)
+			if err != nil || msgType == 'X' {
+				return
+			}
+
+			*queries = append(*queries, string(bytes.TrimRight(payload, "\x00")))
+			writePGMessage(conn, 'T', []byte("\x00\x01?column?\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x17\x00\x04\xff\xff\xff\xff\x00\x00"))
+			writePGMessage(conn, 'D', []byte("\x00\x01\x00\x00\x00\x011"))
+			writePGMessage(conn, 'C', []byte("SELECT 1\x00"))
+			writePGMessage(conn, 'Z', []byte("I"))
+		}
+	})
+}
+
+func TestProbePostgres(t *testing.T) {
+	driver, err := GetDriver("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{"md5", "scram"} {
+		var queries []string
+		addr, handled := pgServer(t, method, &queries)
+
+		if err := Probe(addr, driver, map[string]string{"user": "app", "password": "secret"}); err != nil {
+			t.Errorf("%s: unexpected probe error: %v", method, err)
+		}
+
+		waitHandled(t, handled)
+		if len(queries) != 1 || queries[0] != pgProbeQuery {
+			t.Errorf("%s: unexpected queries: %q", method, queries)
+		}
+
+		err := Probe(addr, driver, map[string]string{"user": "app", "password": "wrong"})
+		if err == nil || !strings.Contains(err.Error(), "password authentication failed") {
+			t.Errorf("%s: expected auth error, got: %v", method, err)
+		}
+	}
+}
+
+func writeMySQLPacket(conn net.Conn, seq byte, payload []byte) {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	conn.Write(append(header, payload...))
+}
+
+func readMySQLPacket(r *bufio.Reader) (byte, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, err := io.ReadFull(r, payload)
+	return header[3], payload, err
+}
+
+// mysqlServer is a fake MySQL server (using the provided auth plugin)
+// that accepts the "secret" password
+func mysqlServer(t *testing.T, plugin string, queries *[]string) (string, <-chan struct{}) {
+	const password = "secret"
+	salt := []byte("0123456789abcdefghij")
+
+	return serveOnce(t, func(conn net.Conn) {
+		var greeting bytes.Buffer
+		greeting.WriteByte(10)
+		greeting.WriteString("8.0.32\x00")
+		greeting.Write([]byte{1, 0, 0, 0})
+		greeting.Write(salt[:8])
+		greeting.WriteByte(0)
+		greeting.Write([]byte{0xff, 0xf7, mysqlCharsetUTF8, 2, 0, 0xff, 0xdf})
+		greeting.WriteByte(byte(len(salt) + 1))
+		greeting.Write(make([]byte, 10))
+		greeting.Write(salt[8:])
+		greeting.WriteByte(0)
+		greeting.WriteString(plugin + "\x00")
+		writeMySQLPacket(conn, 0, greeting.Bytes())
+
+		r := bufio.NewReader(conn)
+		_, resp, err := readMySQLPacket(r)
+		if err != nil {
+			return
+		}
+
+		userEnd := 32 + bytes.IndexByte(resp[32:], 0)
+		authLen := int(resp[userEnd+1])
+		authData := resp[userEnd+2 : userEnd+2+authLen]
+
+		//the server verifies the scramble using the stored password hash
+		valid := false
+		switch plugin {
+		case mysqlNativePassword:
+			hash1 := sha1.Sum([]byte(password))
+			stored := sha1.Sum(hash1[:])
+			h := sha1.New()
+			h.Write(salt)
+			h.Write(stored[:])
+			if len(authData) == sha1.Size {
+				candidate := sha1.Sum(xorBytes(authData, h.Sum(nil)))
+				valid = candidate == stored
+			}
+		case mysqlCachingSHA2:
+			hash1 := sha256.Sum256([]byte(password))
+			stored := sha256.Sum256(hash1[:])
+			h := sha256.New()
+			h.Write(stored[:])
+			h.Write(salt)
+			if len(authData) == sha256.Size {
+				candidate := sha256.Sum256(xorBytes(authData, h.Sum(nil)))
+				valid = candidate == stored
+			}
+		}
+
+		seq := byte(2)
+		if !valid {
+			writeMySQLPacket(conn, seq, []byte("\xff\x15\x04#28000Access denied for user"))
+			return
+		}
+
+		if plugin == mysqlCachingSHA2 {
+			writeMySQLPacket(conn, seq, []byte{mysqlPacketAuthMore, mysqlFastAuthSuccess})
+			seq++
+		}
+
+		writeMySQLPacket(conn, seq, []byte{mysqlPacketOK, 0, 0, 2, 0, 0, 0})
+
+		for {
+			_, cmd, err := readMySQLPacket(r)
+			if err != nil || len(cmd) == 0 || cmd[0] == mysqlComQuit {
+				return
+			}
+
+			*queries = append(*queries, string(cmd[1:]))
+			writeMySQLPacket(conn, 1, []byte{1})
+			writeMySQLPacket(conn, 2, []byte("\x03def\x00\x00\x00\x011\x00\x0c\x3f\x00\x01\x00\x00\x00\x08\x81\x00\x00\x00\x00"))
+			writeMySQLPacket(conn, 3, []byte{mysqlPacketEOF, 0, 0, 2, 0})
+			writeMySQLPacket(conn, 4, []byte("\x011"))
+			writeMySQLPacket(conn, 5, []byte{mysqlPacketEOF, 0, 0, 2, 0})
+		}
+	})
+}
+
+func TestProbeMySQL(t *testing.T) {
+	driver, err := GetDriver("mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plugin := range []string{mysqlNativePassword, mysqlCachingSHA2} {
+		var queries []string
+		addr, handled := mysqlServer(t, plugin, &queries)
+
+		if err := Probe(addr, driver, map[string]string{"user": "app", "password": "secret"}); err != nil {
+			t.Errorf("%s: unexpected probe error: %v", plugin, err)
+		}
+
+		waitHandled(t, handled)
+		if len(queries) != 1 || queries[0] != mysqlProbeQuery {
+			t.Errorf("%s: unexpected queries: %q", plugin, queries)
+		}
+
+		err := Probe(addr, driver, map[string]string{"user": "app", "password": "wrong"})
+		if err == nil || !strings.Contains(err.Error(), "Access denied") {
+			t.Errorf("%s: expected auth error, got: %v", plugin, err)
+		}
+	}
+}
+
+func TestProbeMemcached(t *testing.T) {
+	var cmds []string
+	addr, handled := serveOnce(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		store := map[string]string{}
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return
+			}
+
+			args := strings.Fields(line)
+			cmds = append(cmds, args[0])
+			switch args[0] {
+			case "version":
+				fmt.Fprint(conn, "VERSION 1.6.21\r\n")
+			case "set":
+				value, err := readLine(r)
+				if err != nil {
+					return
+				}
+
+				store[args[1]] = value
+				fmt.Fprint(conn, "STORED\r\n")
+			case "get":
+				if value, found := store[args[1]]; found {
+					fmt.Fprintf(conn, "VALUE %s 0 %d\r\n%s\r\n", args[1], len(value), value)
+				}
+
+				fmt.Fprint(conn, "END\r\n")
+			case "delete":
+				delete(store, args[1])
+				fmt.Fprint(conn, "DELETED\r\n")
+			case "stats":
+				fmt.Fprint(conn, "STAT pid 1\r\nSTAT uptime 10\r\nEND\r\n")
+			default:
+				fmt.Fprint(conn, "ERROR\r\n")
+			}
+		}
+	})
+
+	driver, err := GetDriver("memcached")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Probe(addr, driver, nil); err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+
+	waitHandled(t, handled)
+	if got := strings.Join(cmds, ","); got != "version,set,get,delete,stats" {
+		t.Errorf("unexpected commands: %s", got)
+	}
+}
+
+func TestProbeNATS(t *testing.T) {
+	var cmds []string
+	addr, handled := serveOnce(t, func(conn net.Conn) {
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"auth_required\":true}\r\n")
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return
+			}
+
+			op := strings.Fields(line)[0]
+			cmds = append(cmds, op)
+			switch op {
+			case "CONNECT":
+				var opts map[string]interface{}
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &opts)
+				if opts["auth_token"] != "secret" {
+					fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+					return
+				}
+
+				//the client answers the server PINGs
+				fmt.Fprint(conn, "PING\r\n")
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB":
+				args := strings.Fields(line)
+				payload, err := readLine(r)
+				if err != nil {
+					return
+				}
+
+				fmt.Fprintf(conn, "MSG %s 1 %s\r\n%s\r\n", args[1], args[2], payload)
+			}
+		}
+	})
+
+	driver, err := GetDriver("nats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Probe(addr, driver, map[string]string{"token": "secret"}); err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+
+	waitHandled(t, handled)
+	if got := strings.Join(cmds, ","); got != "CONNECT,PING,PONG,SUB,PUB,PING,UNSUB" {
+		t.Errorf("unexpected protocol ops: %s", got)
+	}
+
+	if err := Probe(addr, driver, map[string]string{"token": "wrong"}); err == nil ||
+		!strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("expected auth error, got: %v", err)
+	}
+}
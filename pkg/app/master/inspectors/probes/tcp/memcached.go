@@ -0,0 +1,61 @@
+package tcp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// probeMemcached runs version, set, get, delete and stats
+// (using the text protocol)
+func probeMemcached(conn net.Conn, params map[string]string) error {
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	key := strings.Replace(probeKey, ":", "_", -1)
+	cmds := []struct {
+		cmd string
+		end string
+	}{
+		{cmd: "version", end: "VERSION"},
+		{cmd: fmt.Sprintf("set %s 0 60 %d\r\n%s", key, len(probeValue), probeValue), end: "STORED"},
+		{cmd: fmt.Sprintf("get %s", key), end: "END"},
+		{cmd: fmt.Sprintf("delete %s", key), end: "DELETED"},
+		{cmd: "stats", end: "END"},
+	}
+
+	for _, c := range cmds {
+		if _, err := fmt.Fprintf(rw, "%s\r\n", c.cmd); err != nil {
+			return err
+		}
+
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		if err := readMemcachedReply(rw.Reader, c.end); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readMemcachedReply reads the reply lines until the line with the expected prefix
+func readMemcachedReply(r *bufio.Reader, end string) error {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(line, end):
+			return nil
+		case line == "ERROR",
+			strings.HasPrefix(line, "CLIENT_ERROR"),
+			strings.HasPrefix(line, "SERVER_ERROR"):
+			return fmt.Errorf("memcached: %s", line)
+		}
+	}
+}
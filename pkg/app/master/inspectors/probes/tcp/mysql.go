@@ -0,0 +1,300 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net"
+)
+
+const (
+	mysqlClientLongPassword     = 0x00000001
+	mysqlClientConnectWithDB    = 0x00000008
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientTransactions     = 0x00002000
+	mysqlClientSecureConnection = 0x00008000
+	mysqlClientPluginAuth       = 0x00080000
+
+	mysqlCharsetUTF8     = 33
+	mysqlDefaultUser     = "root"
+	mysqlProbeQuery      = "SELECT 1"
+	mysqlNativePassword  = "mysql_native_password"
+	mysqlCachingSHA2     = "caching_sha2_password"
+	mysqlComQuit         = 0x01
+	mysqlComQuery        = 0x03
+	mysqlPacketOK        = 0x00
+	mysqlPacketAuthMore  = 0x01
+	mysqlPacketEOF       = 0xfe
+	mysqlPacketErr       = 0xff
+	mysqlFastAuthSuccess = 0x03
+	mysqlFullAuth        = 0x04
+)
+
+type mysqlConn struct {
+	r   *bufio.Reader
+	w   io.Writer
+	seq byte
+}
+
+func (c *mysqlConn) send(payload []byte) error {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), c.seq}
+	c.seq++
+
+	_, err := c.w.Write(append(header, payload...))
+	return err
+}
+
+func (c *mysqlConn) receive() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	size := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	c.seq = header[3] + 1
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return nil, err
+	}
+
+	if len(payload) > 0 && payload[0] == mysqlPacketErr {
+		return nil, mysqlError(payload)
+	}
+
+	return payload, nil
+}
+
+// mysqlError returns the error with the ERR packet message
+func mysqlError(payload []byte) error {
+	if len(payload) < 3 {
+		return fmt.Errorf("mysql: malformed error packet")
+	}
+
+	code := int(payload[1]) | int(payload[2])<<8
+	message := payload[3:]
+	if len(message) > 0 && message[0] == '#' && len(message) >= 6 {
+		//skip the SQL state marker and value
+		message = message[6:]
+	}
+
+	return fmt.Errorf("mysql: %s (code=%d)", message, code)
+}
+
+// probeMySQL runs the connection handshake and a simple query
+// (params: "user", "password" and "database")
+func probeMySQL(conn net.Conn, params map[string]string) error {
+	user := params["user"]
+	if user == "" {
+		user = mysqlDefaultUser
+	}
+
+	password := params["password"]
+	database := params["database"]
+
+	c := &mysqlConn{r: bufio.NewReader(conn), w: conn}
+
+	greeting, err := c.receive()
+	if err != nil {
+		return err
+	}
+
+	salt, plugin, err := parseMySQLHandshake(greeting)
+	if err != nil {
+		return err
+	}
+
+	if plugin != mysqlCachingSHA2 {
+		plugin = mysqlNativePassword
+	}
+
+	caps := uint32(mysqlClientLongPassword |
+		mysqlClientProtocol41 |
+		mysqlClientTransactions |
+		mysqlClientSecureConnection |
+		mysqlClientPluginAuth)
+	if database != "" {
+		caps |= mysqlClientConnectWithDB
+	}
+
+	authData := mysqlScramble(plugin, password, salt)
+
+	var resp bytes.Buffer
+	resp.Write([]byte{byte(caps), byte(caps >> 8), byte(caps >> 16), byte(caps >> 24)})
+	resp.Write([]byte{0xff, 0xff, 0xff, 0})
+	resp.WriteByte(mysqlCharsetUTF8)
+	resp.Write(make([]byte, 23))
+	resp.WriteString(user)
+	resp.WriteByte(0)
+	resp.WriteByte(byte(len(authData)))
+	resp.Write(authData)
+	if database != "" {
+		resp.WriteString(database)
+		resp.WriteByte(0)
+	}
+	resp.WriteString(plugin)
+	resp.WriteByte(0)
+
+	if err := c.send(resp.Bytes()); err != nil {
+		return err
+	}
+
+	if err := mysqlAuthResult(c, plugin, password); err != nil {
+		return err
+	}
+
+	c.seq = 0
+	if err := c.send(append([]byte{mysqlComQuery}, mysqlProbeQuery...)); err != nil {
+		return err
+	}
+
+	//the result set ends with the second EOF packet
+	//(the column definitions end with the first one)
+	for idx, eofCount := 0, 0; eofCount < 2; idx++ {
+		packet, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		if len(packet) == 0 {
+			continue
+		}
+
+		if idx == 0 && packet[0] == mysqlPacketOK {
+			//no result set
+			break
+		}
+
+		if packet[0] == mysqlPacketEOF && len(packet) < 9 {
+			eofCount++
+		}
+	}
+
+	c.seq = 0
+	return c.send([]byte{mysqlComQuit})
+}
+
+// parseMySQLHandshake returns the auth salt and plugin name
+// from the protocol v10 handshake packet
+func parseMySQLHandshake(data []byte) ([]byte, string, error) {
+	if len(data) == 0 || data[0] != 10 {
+		return nil, "", fmt.Errorf("mysql: unsupported handshake packet")
+	}
+
+	pos := bytes.IndexByte(data[1:], 0)
+	if pos < 0 {
+		return nil, "", fmt.Errorf("mysql: malformed handshake packet")
+	}
+
+	//skip the server version, connection id
+	pos += 1 + 1 + 4
+	if len(data) < pos+8+1+2+1+2+2+1+10 {
+		return nil, "", fmt.Errorf("mysql: malformed handshake packet")
+	}
+
+	salt := append([]byte{}, data[pos:pos+8]...)
+	pos += 8 + 1 + 2 + 1 + 2 + 2
+	authDataLen := int(data[pos])
+	pos += 1 + 10
+
+	//the second salt part is (at least 13 bytes) null terminated
+	partLen := authDataLen - 8
+	if partLen < 13 {
+		partLen = 13
+	}
+
+	if len(data) < pos+partLen {
+		return salt, "", nil
+	}
+
+	salt = append(salt, bytes.TrimRight(data[pos:pos+partLen], "\x00")...)
+	pos += partLen
+
+	plugin := string(bytes.TrimRight(data[pos:], "\x00"))
+	return salt, plugin, nil
+}
+
+// mysqlAuthResult handles the auth switch and "more data" packets
+// until the final OK packet
+func mysqlAuthResult(c *mysqlConn, plugin, password string) error {
+	for {
+		packet, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		if len(packet) == 0 {
+			return fmt.Errorf("mysql: empty auth response")
+		}
+
+		switch packet[0] {
+		case mysqlPacketOK:
+			return nil
+		case mysqlPacketEOF:
+			//auth switch request
+			data := packet[1:]
+			pos := bytes.IndexByte(data, 0)
+			if pos < 0 {
+				return fmt.Errorf("mysql: malformed auth switch request")
+			}
+
+			plugin = string(data[:pos])
+			salt := bytes.TrimRight(data[pos+1:], "\x00")
+			if plugin != mysqlNativePassword && plugin != mysqlCachingSHA2 {
+				return fmt.Errorf("mysql: unsupported auth plugin: %s", plugin)
+			}
+
+			if err := c.send(mysqlScramble(plugin, password, salt)); err != nil {
+				return err
+			}
+		case mysqlPacketAuthMore:
+			if len(packet) > 1 && packet[1] == mysqlFastAuthSuccess {
+				continue
+			}
+
+			if len(packet) > 1 && packet[1] == mysqlFullAuth {
+				return fmt.Errorf("mysql: full %s authentication requires a secure connection", mysqlCachingSHA2)
+			}
+
+			return fmt.Errorf("mysql: unexpected auth data")
+		default:
+			return fmt.Errorf("mysql: unexpected auth response: 0x%02x", packet[0])
+		}
+	}
+}
+
+func mysqlScramble(plugin, password string, salt []byte) []byte {
+	if password == "" {
+		return nil
+	}
+
+	if plugin == mysqlCachingSHA2 {
+		//XOR(SHA256(password), SHA256(SHA256(SHA256(password)), salt))
+		hash1 := sha256.Sum256([]byte(password))
+		hash2 := sha256.Sum256(hash1[:])
+		h := sha256.New()
+		h.Write(hash2[:])
+		h.Write(salt)
+		return xorBytes(hash1[:], h.Sum(nil))
+	}
+
+	//XOR(SHA1(password), SHA1(salt, SHA1(SHA1(password))))
+	hash1 := sha1.Sum([]byte(password))
+	hash2 := sha1.Sum(hash1[:])
+	h := sha1.New()
+	h.Write(salt)
+	h.Write(hash2[:])
+	return xorBytes(hash1[:], h.Sum(nil))
+}
+
+func xorBytes(a, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const natsProbeSubject = "slim.probe"
+
+// probeNATS runs the client handshake (INFO/CONNECT) and PING,
+// then it subscribes to a subject and publishes a message to it
+// (params: "user", "password" and "token")
+func probeNATS(conn net.Conn, params map[string]string) error {
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("nats: unexpected server greeting: %s", line)
+	}
+
+	connectOpts := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "slim-probe",
+		"lang":     "go",
+	}
+
+	if user := params["user"]; user != "" {
+		connectOpts["user"] = user
+		connectOpts["pass"] = params["password"]
+	}
+
+	if token := params["token"]; token != "" {
+		connectOpts["auth_token"] = token
+	}
+
+	data, err := json.Marshal(connectOpts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rw, "CONNECT %s\r\nPING\r\n", data)
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	if err := readNATSUntil(rw, "PONG"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rw, "SUB %s 1\r\nPUB %s %d\r\n%s\r\nPING\r\n",
+		natsProbeSubject, natsProbeSubject, len(probeValue), probeValue)
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	//the published message is usually delivered before the PONG
+	//(the delivery is not verified)
+	if err := readNATSUntil(rw, "PONG"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rw, "UNSUB 1\r\n")
+	return rw.Flush()
+}
+
+// readNATSUntil reads the protocol lines until the expected line
+// (the server PINGs are answered)
+func readNATSUntil(rw *bufio.ReadWriter, expected string) error {
+	for {
+		line, err := readLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == expected:
+			return nil
+		case line == "PING":
+			fmt.Fprintf(rw, "PONG\r\n")
+			if err := rw.Flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	pgProtocolVersion = 196608 //3.0
+
+	pgAuthOK            = 0
+	pgAuthCleartext     = 3
+	pgAuthMD5           = 5
+	pgAuthSASL          = 10
+	pgAuthSASLContinue  = 11
+	pgAuthSASLFinal     = 12
+	pgSCRAMSHA256       = "SCRAM-SHA-256"
+	pgDefaultUser       = "postgres"
+	pgProbeQuery        = "SELECT 1"
+	maxPGMessageSize    = 16 * 1024 * 1024
+	scramClientNonceLen = 18
+)
+
+type pgConn struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func (c *pgConn) send(msgType byte, payload []byte) error {
+	var buf bytes.Buffer
+	if msgType != 0 {
+		buf.WriteByte(msgType)
+	}
+
+	binary.Write(&buf, binary.BigEndian, int32(len(payload)+4))
+	buf.Write(payload)
+
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+func (c *pgConn) receive() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	size := int(binary.BigEndian.Uint32(header[1:])) - 4
+	if size < 0 || size > maxPGMessageSize {
+		return 0, nil, fmt.Errorf("postgres: malformed message (type=%c size=%d)", header[0], size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if header[0] == 'E' {
+		return 0, nil, pgError(payload)
+	}
+
+	return header[0], payload, nil
+}
+
+// pgError returns the error with the ErrorResponse message
+func pgError(payload []byte) error {
+	var code, message string
+	for _, field := range bytes.Split(payload, []byte{0}) {
+		if len(field) < 2 {
+			continue
+		}
+
+		switch field[0] {
+		case 'C':
+			code = string(field[1:])
+		case 'M':
+			message = string(field[1:])
+		}
+	}
+
+	return fmt.Errorf("postgres: %s (code=%s)", message, code)
+}
+
+func pgCString(values ...string) []byte {
+	var buf bytes.Buffer
+	for _, value := range values {
+		buf.WriteString(value)
+		buf.WriteByte(0)
+	}
+
+	return buf.Bytes()
+}
+
+// probePostgres runs the startup/authentication flow and a simple query
+// (params: "user", "password" and "database")
+func probePostgres(conn net.Conn, params map[string]string) error {
+	user := params["user"]
+	if user == "" {
+		user = pgDefaultUser
+	}
+
+	database := params["database"]
+	if database == "" {
+		database = user
+	}
+
+	password := params["password"]
+
+	c := &pgConn{r: bufio.NewReader(conn), w: conn}
+
+	var startup bytes.Buffer
+	binary.Write(&startup, binary.BigEndian, int32(pgProtocolVersion))
+	startup.Write(pgCString("user", user, "database", database, "application_name", "slim-probe"))
+	startup.WriteByte(0)
+	if err := c.send(0, startup.Bytes()); err != nil {
+		return err
+	}
+
+	var scram *scramClient
+	queried := false
+	for {
+		msgType, payload, err := c.receive()
+		if err != nil {
+			return err
+		}
+
+		switch msgType {
+		case 'R':
+			if len(payload) < 4 {
+				return fmt.Errorf("postgres: malformed authentication message")
+			}
+
+			authType := binary.BigEndian.Uint32(payload)
+			data := payload[4:]
+			switch authType {
+			case pgAuthOK:
+			case pgAuthCleartext:
+				err = c.send('p', pgCString(password))
+			case pgAuthMD5:
+				if len(data) < 4 {
+					return fmt.Errorf("postgres: malformed md5 salt")
+				}
+
+				err = c.send('p', pgCString(pgMD5Password(user, password, data[:4])))
+			case pgAuthSASL:
+				if !bytes.Contains(data, []byte(pgSCRAMSHA256)) {
+					return fmt.Errorf("postgres: unsupported SASL mechanisms: %q", data)
+				}
+
+				scram, err = newSCRAMClient(password)
+				if err != nil {
+					return err
+				}
+
+				first := scram.clientFirst()
+				var msg bytes.Buffer
+				msg.Write(pgCString(pgSCRAMSHA256))
+				binary.Write(&msg, binary.BigEndian, int32(len(first)))
+				msg.WriteString(first)
+				err = c.send('p', msg.Bytes())
+			case pgAuthSASLContinue:
+				if scram == nil {
+					return fmt.Errorf("postgres: unexpected SASL message")
+				}
+
+				var final string
+				if final, err = scram.clientFinal(string(data)); err == nil {
+					err = c.send('p', []byte(final))
+				}
+			case pgAuthSASLFinal:
+				if scram == nil || !scram.verifyServerFinal(string(data)) {
+					return fmt.Errorf("postgres: invalid SASL server signature")
+				}
+			default:
+				return fmt.Errorf("postgres: unsupported authentication method: %d", authType)
+			}
+
+			if err != nil {
+				return err
+			}
+		case 'Z':
+			//ReadyForQuery
+			if queried {
+				return c.send('X', nil)
+			}
+
+			queried = true
+			if err := c.send('Q', pgCString(pgProbeQuery)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func pgMD5Password(user, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user))
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	return "md5" + hex.EncodeToString(outer[:])
+}
+
+// scramClient implements the SCRAM-SHA-256 client (RFC 7677) without channel binding
+type scramClient struct {
+	password        string
+	clientNonce     string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+func newSCRAMClient(password string) (*scramClient, error) {
+	nonce := make([]byte, scramClientNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &scramClient{
+		password:    password,
+		clientNonce: base64.RawStdEncoding.EncodeToString(nonce),
+	}, nil
+}
+
+func (s *scramClient) clientFirst() string {
+	//the user name is taken from the startup message
+	s.clientFirstBare = "n=,r=" + s.clientNonce
+	return "n,," + s.clientFirstBare
+}
+
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(serverFirst, ",") {
+		if len(attr) < 2 || attr[1] != '=' {
+			continue
+		}
+
+		switch attr[0] {
+		case 'r':
+			nonce = attr[2:]
+		case 's':
+			salt = attr[2:]
+		case 'i':
+			iterations, _ = strconv.Atoi(attr[2:])
+		}
+	}
+
+	if !strings.HasPrefix(nonce, s.clientNonce) || iterations < 1 {
+		return "", fmt.Errorf("postgres: malformed SCRAM server message")
+	}
+
+	saltData, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return "", fmt.Errorf("postgres: malformed SCRAM salt: %v", err)
+	}
+
+	saltedPassword := pbkdf2SHA256([]byte(s.password), saltData, iterations)
+	clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := hmacSHA256(saltedPassword, []byte("Server Key"))
+
+	clientFinalNoProof := "c=biws,r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + clientFinalNoProof
+
+	clientSignature := hmacSHA256(storedKey[:], []byte(authMessage))
+	proof := make([]byte, len(clientKey))
+	for i := range clientKey {
+		proof[i] = clientKey[i] ^ clientSignature[i]
+	}
+
+	s.serverSignature = hmacSHA256(serverKey, []byte(authMessage))
+	return clientFinalNoProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+func (s *scramClient) verifyServerFinal(serverFinal string) bool {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(serverFinal, "v="))
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(signature, s.serverSignature)
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// pbkdf2SHA256 derives the key with PBKDF2 (RFC 8018)
+// using HMAC-SHA-256 (the key length is the hash size)
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+
+	result := make([]byte, len(u))
+	copy(result, u)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+
+	return result
+}
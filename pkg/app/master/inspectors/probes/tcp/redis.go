@@ -0,0 +1,145 @@
+package tcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const probeKey = "slim:probe"
+const probeValue = "probe"
+
+// probeRedis runs PING, GET (read-only) and INFO
+// (params: "user" and "password" for AUTH and "write=true"
+// to run SET, GET and DEL with a probe key instead of the read-only GET)
+func probeRedis(conn net.Conn, params map[string]string) error {
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	if password := params["password"]; password != "" {
+		args := []string{"AUTH", password}
+		if user := params["user"]; user != "" {
+			args = []string{"AUTH", user, password}
+		}
+
+		if _, err := redisCmd(rw, args...); err != nil {
+			return err
+		}
+	}
+
+	reply, err := redisCmd(rw, "PING")
+	if err != nil {
+		return err
+	}
+
+	if reply != "PONG" {
+		return fmt.Errorf("redis: unexpected PING reply: %s", reply)
+	}
+
+	if params["write"] == "true" {
+		if _, err := redisCmd(rw, "SET", probeKey, probeValue); err != nil {
+			return err
+		}
+
+		reply, err = redisCmd(rw, "GET", probeKey)
+		if err != nil {
+			return err
+		}
+
+		if reply != probeValue {
+			return fmt.Errorf("redis: unexpected GET reply: %s", reply)
+		}
+
+		if _, err := redisCmd(rw, "DEL", probeKey); err != nil {
+			return err
+		}
+	} else {
+		//the probe key value is not checked (it's not created)
+		if _, err := redisCmd(rw, "GET", probeKey); err != nil {
+			return err
+		}
+	}
+
+	_, err = redisCmd(rw, "INFO")
+	return err
+}
+
+func redisCmd(rw *bufio.ReadWriter, args ...string) (string, error) {
+	fmt.Fprintf(rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if err := rw.Flush(); err != nil {
+		return "", err
+	}
+
+	return readRedisReply(rw.Reader)
+}
+
+// readRedisReply reads the RESP reply
+// (the array replies are returned as space separated values)
+func readRedisReply(r *bufio.Reader) (string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return "", err
+	}
+
+	if line == "" {
+		return "", fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("redis: malformed bulk reply: %s", line)
+		}
+
+		if size < 0 {
+			return "", nil
+		}
+
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return "", err
+		}
+
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("redis: malformed array reply: %s", line)
+		}
+
+		var values []string
+		for i := 0; i < count; i++ {
+			value, err := readRedisReply(r)
+			if err != nil {
+				return "", err
+			}
+
+			values = append(values, value)
+		}
+
+		return strings.Join(values, " "), nil
+	}
+
+	return "", fmt.Errorf("redis: unexpected reply: %s", line)
+}
+
+// readLine reads a CRLF (or LF) terminated line
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
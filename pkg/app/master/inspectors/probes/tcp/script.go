@@ -0,0 +1,175 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Script operations
+const (
+	ScriptOpSend     = "send"
+	ScriptOpExpect   = "expect"
+	ScriptOpExpectRE = "expect-re"
+	ScriptOpSleep    = "sleep"
+)
+
+const maxExpectBufferSize = 1024 * 1024
+
+// ScriptStep is one send/expect script operation
+type ScriptStep struct {
+	Op    string
+	Data  []byte
+	Re    *regexp.Regexp
+	Delay time.Duration
+	Line  int
+}
+
+// LoadScript reads and parses the send/expect script file
+func LoadScript(path string) ([]ScriptStep, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseScript(string(data))
+}
+
+// ParseScript parses the send/expect script.
+// Each line is an operation: "send <text>", "expect <text>",
+// "expect-re <regex>" or "sleep <duration>".
+// The text can be a quoted Go string (to use escape sequences like "\r\n").
+// Empty lines and lines starting with '#' are ignored.
+func ParseScript(script string) ([]ScriptStep, error) {
+	var steps []ScriptStep
+	for idx, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		op, arg := line, ""
+		if pos := strings.IndexAny(line, " \t"); pos > -1 {
+			op = line[:pos]
+			arg = strings.TrimSpace(line[pos+1:])
+		}
+
+		step := ScriptStep{Op: op, Line: idx + 1}
+		switch op {
+		case ScriptOpSend, ScriptOpExpect:
+			text, err := scriptText(arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", step.Line, err)
+			}
+
+			if op == ScriptOpExpect && text == "" {
+				return nil, fmt.Errorf("line %d: empty expect text", step.Line)
+			}
+
+			step.Data = []byte(text)
+		case ScriptOpExpectRE:
+			expr, err := scriptText(arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", step.Line, err)
+			}
+
+			step.Re, err = regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", step.Line, err)
+			}
+		case ScriptOpSleep:
+			delay, err := time.ParseDuration(arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", step.Line, err)
+			}
+
+			step.Delay = delay
+		default:
+			return nil, fmt.Errorf("line %d: unknown operation: %s", step.Line, op)
+		}
+
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("empty script")
+	}
+
+	return steps, nil
+}
+
+func scriptText(arg string) (string, error) {
+	if strings.HasPrefix(arg, `"`) || strings.HasPrefix(arg, "`") {
+		return strconv.Unquote(arg)
+	}
+
+	return arg, nil
+}
+
+// RunScript runs the send/expect script steps using the connection
+func RunScript(conn net.Conn, steps []ScriptStep) error {
+	r := bufio.NewReader(conn)
+	var buf []byte
+	for _, step := range steps {
+		switch step.Op {
+		case ScriptOpSend:
+			if _, err := conn.Write(step.Data); err != nil {
+				return err
+			}
+		case ScriptOpExpect, ScriptOpExpectRE:
+			for {
+				var matched bool
+				var end int
+				if step.Re != nil {
+					if loc := step.Re.FindIndex(buf); loc != nil {
+						matched, end = true, loc[1]
+					}
+				} else if pos := bytes.Index(buf, step.Data); pos > -1 {
+					matched, end = true, pos+len(step.Data)
+				}
+
+				if matched {
+					//the next expect continues after the matched data
+					buf = buf[end:]
+					break
+				}
+
+				if len(buf) > maxExpectBufferSize {
+					return fmt.Errorf("script: line %d: expected data not found", step.Line)
+				}
+
+				chunk := make([]byte, 4096)
+				n, err := r.Read(chunk)
+				buf = append(buf, chunk[:n]...)
+				if err != nil && n == 0 {
+					return fmt.Errorf("script: line %d: %w", step.Line, err)
+				}
+			}
+		case ScriptOpSleep:
+			time.Sleep(step.Delay)
+		}
+	}
+
+	return nil
+}
+
+// probeScript runs the send/expect script (param: "file")
+func probeScript(conn net.Conn, params map[string]string) error {
+	path := params["file"]
+	if path == "" {
+		return fmt.Errorf("script: missing 'file' param")
+	}
+
+	steps, err := LoadScript(path)
+	if err != nil {
+		return fmt.Errorf("script: %v", err)
+	}
+
+	return RunScript(conn, steps)
+}
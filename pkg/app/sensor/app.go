@@ -9,6 +9,7 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -17,12 +18,16 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/artifacts"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/controlled"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/execution"
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/metrics"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors"
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/sink"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/standalone"
+	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
 	"github.com/docker-slim/docker-slim/pkg/ipc/event"
 	"github.com/docker-slim/docker-slim/pkg/sysenv"
 	"github.com/docker-slim/docker-slim/pkg/system"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	"github.com/docker-slim/docker-slim/pkg/version"
 )
 
@@ -47,10 +52,13 @@ const (
 	sensorModeFlagUsage   = "set the sensor execution mode ('controlled' when sensor expect the driver docker-slim app to manipulate its lifecycle; or 'standalone' when sensor depends on nothing but the target app"
 	sensorModeFlagDefault = sensorModeControlled
 
-	commandsFileFlagUsage   = "provide a JSONL-encoded file with one ore more sensor commands (standalone mode only)"
+	commandsFileFlagUsage   = "provide a JSONL-encoded (or a YAML .yaml/.yml) file with one ore more sensor commands (standalone mode only; the command fields can be also set with the " + execution.CommandEnvVarPrefix + "<FIELD> env vars)"
 	commandsFileFlagDefault = "/opt/_slim/commands.json"
 
-	lifecycleHookCommandFlagUsage   = "set path to an executable that'll be invoked at various sensor lifecycle events (post-start, pre-shutdown, etc)"
+	printDefaultCommandsFlagUsage   = "print a commented YAML commands file template (with all supported command fields) and exit"
+	printDefaultCommandsFlagDefault = false
+
+	lifecycleHookCommandFlagUsage   = "set path to an executable that'll be invoked at various sensor lifecycle events (post-start, pre-shutdown, etc); the hook gets the event context as a JSON document on stdin, it can return extra 'includes' (JSON on stdout) and abort the monitor start (exit code 3 in 'monitor-pre-start')"
 	lifecycleHookCommandFlagDefault = ""
 
 	// Should stopSignal and stopGracePeriod become StartMonitor
@@ -64,6 +72,21 @@ const (
 
 	artifactsDirFlagUsage   = "output director for all sensor artifacts"
 	artifactsDirFlagDefault = app.DefaultArtifactsDirPath
+
+	reportSinkFlagUsage   = "export the sensor events, reports and artifacts to an HTTP endpoint (http[s]://...) or to an OTLP/HTTP logs endpoint (otlp+http[s]://...) (standalone mode only)"
+	reportSinkFlagDefault = ""
+
+	reportSinkIntervalFlagUsage   = "set the interval for the periodic sensor event export to the report sink"
+	reportSinkIntervalFlagDefault = sink.DefaultInterval
+
+	ipcKeyFileFlagUsage   = "provide a file with the IPC authentication key (controlled mode only; the key can also be passed with the " + channel.AuthKeyEnvVar + " env var)"
+	ipcKeyFileFlagDefault = ""
+
+	ipcSocketDirFlagUsage   = "use the Unix domain sockets in this directory for the IPC channels instead of the TCP ports (controlled mode only)"
+	ipcSocketDirFlagDefault = ""
+
+	metricsAddrFlagUsage   = "serve the sensor metrics (/metrics, Prometheus text format) and the Go profiler (/debug/pprof) on a loopback address (e.g., 127.0.0.1:9191) or on a Unix socket (unix:/path/to/socket)"
+	metricsAddrFlagDefault = ""
 )
 
 var (
@@ -77,6 +100,12 @@ var (
 	lifecycleHookCommand *string        = flag.String("lifecycle-hook", lifecycleHookCommandFlagDefault, lifecycleHookCommandFlagUsage)
 	stopSignal           *string        = flag.String("stop-signal", stopSignalFlagDefault, stopSignalFlagUsage)
 	stopGracePeriod      *time.Duration = flag.Duration("stop-grace-period", stopGracePeriodFlagDefault, stopGracePeriodFlagUsage)
+	reportSink           *string        = flag.String("report-sink", reportSinkFlagDefault, reportSinkFlagUsage)
+	reportSinkInterval   *time.Duration = flag.Duration("report-sink-interval", reportSinkIntervalFlagDefault, reportSinkIntervalFlagUsage)
+	ipcKeyFile           *string        = flag.String("ipc-key-file", ipcKeyFileFlagDefault, ipcKeyFileFlagUsage)
+	ipcSocketDir         *string        = flag.String("ipc-socket-dir", ipcSocketDirFlagDefault, ipcSocketDirFlagUsage)
+	printDefaultCommands *bool          = flag.Bool("print-default-commands", printDefaultCommandsFlagDefault, printDefaultCommandsFlagUsage)
+	metricsAddr          *string        = flag.String("metrics-addr", metricsAddrFlagDefault, metricsAddrFlagUsage)
 
 	errUnknownMode = errors.New("unknown sensor mode")
 )
@@ -92,12 +121,18 @@ func init() {
 	flag.StringVar(lifecycleHookCommand, "a", lifecycleHookCommandFlagDefault, lifecycleHookCommandFlagUsage)
 	flag.StringVar(stopSignal, "s", stopSignalFlagDefault, stopSignalFlagUsage)
 	flag.DurationVar(stopGracePeriod, "w", stopGracePeriodFlagDefault, stopGracePeriodFlagUsage)
+	flag.StringVar(reportSink, "k", reportSinkFlagDefault, reportSinkFlagUsage)
 }
 
 // Run starts the sensor app
 func Run() {
 	flag.Parse()
 
+	if *printDefaultCommands {
+		errutil.FailOn(execution.PrintDefaultCommands(os.Stdout))
+		return
+	}
+
 	errutil.FailOn(configureLogger(*enableDebug, *logLevel, *logFormat, *logFile))
 
 	activeCaps, maxCaps, err := sysenv.Capabilities(0)
@@ -112,8 +147,17 @@ func Run() {
 	log.Tracef("sensor: sysinfo => %#v", system.GetSystemInfo())
 	log.Tracef("sensor: kernel flags => %#v", system.DefaultKernelFeatures.Raw)
 
+	msrv := newMetricsServer(*metricsAddr)
+	if msrv != nil {
+		msrv.Start()
+	}
+
 	var artifactsExtra []string
-	if len(*commandsFile) > 0 {
+	//the commands file is loaded only in the standalone mode
+	//and it's optional when the command is set with the env vars
+	if *sensorMode == sensorModeStandalone &&
+		len(*commandsFile) > 0 &&
+		fsutil.Exists(*commandsFile) {
 		artifactsExtra = append(artifactsExtra, *commandsFile)
 	}
 	if len(*logFile) > 0 {
@@ -122,18 +166,27 @@ func Run() {
 	artifactor := artifacts.NewArtifactor(*artifactsDir, artifactsExtra)
 
 	ctx := context.Background()
+	eventsFile := filepath.Join(*artifactsDir, "events.json")
 	exe, err := newExecution(
 		ctx,
 		*sensorMode,
 		*commandsFile,
-		filepath.Join(*artifactsDir, "events.json"),
+		eventsFile,
 		*lifecycleHookCommand,
+		ipcAuthKey(),
+		*ipcSocketDir,
+		*artifactsDir,
 	)
 	if err != nil {
 		errutil.WarnOn(artifactor.Archive())
 		errutil.FailOn(err) // calls os.Exit(1)
 	}
 
+	rsink := newReportSink(*sensorMode, *reportSink, *artifactsDir)
+	if rsink != nil {
+		rsink.Start(ctx, eventsFile, *reportSinkInterval)
+	}
+
 	// There is a number of errutil.FailOn() below, so no way to rely on defer:
 	// We need to make sure `exe` is closed before archiving - otherwise some
 	// artifacts might be missing due to the non-flushed buffers!
@@ -162,11 +215,20 @@ func Run() {
 	exe.Close()
 	errutil.WarnOn(artifactor.Archive())
 
+	if rsink != nil {
+		errutil.WarnOn(rsink.Finish(ctx, eventsFile))
+	}
+
 	// We have to "stop" the execution and dump the artifacts
 	// before calling the pre-shutdown hook (that may want to
 	// upload the artifacts somewhere).
 	// Not ideal calling it after exe.Close() but should be safe.
 	exe.HookSensorPreShutdown()
+
+	if msrv != nil {
+		errutil.WarnOn(msrv.Close())
+	}
+
 	log.Info("sensor: exiting...")
 }
 
@@ -176,22 +238,77 @@ func newExecution(
 	commandsFile string,
 	eventsFile string,
 	lifecycleHookCommand string,
+	ipcAuthKey string,
+	ipcSocketDir string,
+	artifactsDir string,
 ) (execution.Interface, error) {
 	switch mode {
 	case sensorModeControlled:
-		return execution.NewControlled(ctx, lifecycleHookCommand)
+		return execution.NewControlled(ctx, lifecycleHookCommand, ipcAuthKey, ipcSocketDir, artifactsDir)
 	case sensorModeStandalone:
 		return execution.NewStandalone(
 			ctx,
 			commandsFile,
 			eventsFile,
 			lifecycleHookCommand,
+			artifactsDir,
 		)
 	}
 
 	return nil, errUnknownMode
 }
 
+// ipcAuthKey returns the IPC authentication key (from the key file or from the env var).
+// The env var is removed not to leak the key to the target app.
+func ipcAuthKey() string {
+	key := os.Getenv(channel.AuthKeyEnvVar)
+	os.Unsetenv(channel.AuthKeyEnvVar)
+
+	if len(*ipcKeyFile) > 0 {
+		data, err := os.ReadFile(*ipcKeyFile)
+		if err != nil {
+			log.WithError(err).Warn("sensor: failed to read IPC key file")
+		} else {
+			key = strings.TrimSpace(string(data))
+		}
+	}
+
+	return key
+}
+
+func newReportSink(mode string, sinkURL string, artifactsDir string) *sink.Sink {
+	if sinkURL == "" {
+		return nil
+	}
+
+	if mode != sensorModeStandalone {
+		log.Warn("sensor: report sink is supported only in the standalone mode (ignoring)")
+		return nil
+	}
+
+	s, err := sink.New(sinkURL, artifactsDir)
+	if err != nil {
+		log.WithError(err).Warn("sensor: failed to create report sink (ignoring)")
+		return nil
+	}
+
+	return s
+}
+
+func newMetricsServer(addr string) *metrics.Server {
+	if addr == "" {
+		return nil
+	}
+
+	s, err := metrics.NewServer(addr)
+	if err != nil {
+		log.WithError(err).Warn("sensor: failed to create metrics server (ignoring)")
+		return nil
+	}
+
+	return s
+}
+
 type sensor interface {
 	Run() error
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/detectors/binfile"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/inspectors/sodeps"
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/metrics"
 	"github.com/docker-slim/docker-slim/pkg/artifact"
 	"github.com/docker-slim/docker-slim/pkg/certdiscover"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
@@ -39,10 +40,12 @@ const (
 	varRunDir        = "/var/run/"
 	fileTypeCmdName  = "file"
 	filesArchiveName = "files.tar"
-	runArchiveName   = "run.tar"
 	preservedDirName = "preserved"
 )
 
+// RunArchiveName is the sensor artifacts archive file name (see Archive())
+const RunArchiveName = "run.tar"
+
 //TODO: extract these app, framework and language specific login into separate packages
 
 // Nginx related consts
@@ -228,6 +231,7 @@ type Artifactor interface {
 		peReport *report.PeMonitorReport,
 		fanReport *report.FanMonitorReport,
 		ptReport *report.PtMonitorReport,
+		resourceReport *report.ResourceUsageReport,
 	) error
 
 	// Archives commands.json, creport.json, events.json, sensor.log, etc
@@ -387,6 +391,7 @@ func (a *artifactor) ProcessReports(
 	peReport *report.PeMonitorReport,
 	fanReport *report.FanMonitorReport,
 	ptReport *report.PtMonitorReport,
+	resourceReport *report.ResourceUsageReport,
 ) error {
 	//TODO: when peReport is available filter file events from fanReport
 
@@ -404,8 +409,62 @@ func (a *artifactor) ProcessReports(
 	}
 
 	log.Debugf("sensor: processReports(): len(fanReport.ProcessFiles)=%v / fileCount=%v", len(fanReport.ProcessFiles), fileCount)
+	if len(cmd.ExcludePhases) > 0 {
+		fileList = filterFilesByPhase(fileList, fanReport, cmd.ExcludePhases)
+	}
+
 	allFilesMap := findSymlinks(fileList, mountPoint, cmd.Excludes)
-	return saveResults(a.origPathMap, a.artifactsDirName, cmd, allFilesMap, fanReport, ptReport, peReport)
+	return saveResults(a.origPathMap, a.artifactsDirName, cmd, allFilesMap, fanReport, ptReport, peReport, resourceReport)
+}
+
+// filterFilesByPhase removes the files accessed only during the excluded monitoring phases
+// (the files without the phase info are kept and nothing is removed
+// if all files would be excluded, e.g., when there's no other phase)
+func filterFilesByPhase(fileList []string, fanReport *report.FanMonitorReport, excludePhases []string) []string {
+	excluded := map[string]struct{}{}
+	for _, phase := range excludePhases {
+		excluded[phase] = struct{}{}
+	}
+
+	filePhases := map[string][]string{}
+	for _, processFileMap := range fanReport.ProcessFiles {
+		for fpath, finfo := range processFileMap {
+			filePhases[fpath] = append(filePhases[fpath], finfo.Phases...)
+		}
+	}
+
+	isExcluded := func(fpath string) bool {
+		phases := filePhases[fpath]
+		if len(phases) == 0 {
+			return false
+		}
+
+		for _, phase := range phases {
+			if _, ok := excluded[phase]; !ok {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var filtered []string
+	for _, fpath := range fileList {
+		if isExcluded(fpath) {
+			log.Debugf("sensor: filterFilesByPhase - excluding %s (phases: %v)", fpath, filePhases[fpath])
+			continue
+		}
+
+		filtered = append(filtered, fpath)
+	}
+
+	if len(filtered) == 0 && len(fileList) > 0 {
+		log.Warnf("sensor: all files are accessed only during the excluded phases (%v) - ignoring the phase filter", excludePhases)
+		return fileList
+	}
+
+	log.Debugf("sensor: filterFilesByPhase - excluded %d file(s)", len(fileList)-len(filtered))
+	return filtered
 }
 
 func (a *artifactor) Archive() error {
@@ -439,7 +498,7 @@ func (a *artifactor) Archive() error {
 		toArchiveList = append(toArchiveList, name)
 	}
 	return fsutil.ArchiveFiles(
-		filepath.Join(a.artifactsDirName, runArchiveName), toArchiveList, false, "")
+		filepath.Join(a.artifactsDirName, RunArchiveName), toArchiveList, false, "")
 }
 
 func saveResults(
@@ -450,10 +509,12 @@ func saveResults(
 	fanMonReport *report.FanMonitorReport,
 	ptMonReport *report.PtMonitorReport,
 	peReport *report.PeMonitorReport,
+	resourceReport *report.ResourceUsageReport,
 ) error {
 	log.Debugf("saveResults(%v,...)", len(fileNames))
 
 	artifactStore := newArtifactStore(origPathMap, artifactsDirName, fileNames, fanMonReport, ptMonReport, peReport, cmd)
+	artifactStore.resourceReport = resourceReport
 	artifactStore.prepareArtifacts()
 	artifactStore.saveArtifacts()
 	artifactStore.enumerateArtifacts()
@@ -475,6 +536,9 @@ type artifactStore struct {
 	saFileMap     map[string]*report.ArtifactProps
 	cmd           *command.StartMonitor
 	appStacks     map[string]*appStackInfo
+
+	// Optional (nil if not available)
+	resourceReport *report.ResourceUsageReport
 }
 
 func newArtifactStore(
@@ -504,6 +568,24 @@ func newArtifactStore(
 	return store
 }
 
+// getArtifactPhases returns the monitoring phases when the artifact was accessed
+func (p *artifactStore) getArtifactPhases(artifactFileName string) []string {
+	var phases []string
+	seen := map[string]bool{}
+	for _, processFileMap := range p.fanMonReport.ProcessFiles {
+		if finfo, ok := processFileMap[artifactFileName]; ok {
+			for _, phase := range finfo.Phases {
+				if !seen[phase] {
+					seen[phase] = true
+					phases = append(phases, phase)
+				}
+			}
+		}
+	}
+
+	return phases
+}
+
 func (p *artifactStore) getArtifactFlags(artifactFileName string) map[string]bool {
 	flags := map[string]bool{}
 	for _, processFileMap := range p.fanMonReport.ProcessFiles {
@@ -546,6 +628,7 @@ func (p *artifactStore) prepareArtifact(artifactFileName string) {
 	}
 
 	props.Flags = p.getArtifactFlags(artifactFileName)
+	props.Phases = p.getArtifactPhases(artifactFileName)
 
 	log.Tracef("prepareArtifact - file mode:%v", srcLinkFileInfo.Mode())
 	switch {
@@ -632,12 +715,14 @@ func (p *artifactStore) prepareArtifacts() {
 			artifactInfo, found := p.rawNames[artifactFileName]
 			if found {
 				artifactInfo.FSActivity = fsaInfo
+				setFileRanges(artifactInfo, fsaInfo)
 			} else {
 				log.Debugf("prepareArtifacts - fsa artifact => %v", artifactFileName)
 				p.prepareArtifact(artifactFileName)
 				artifactInfo, found := p.rawNames[artifactFileName]
 				if found {
 					artifactInfo.FSActivity = fsaInfo
+					setFileRanges(artifactInfo, fsaInfo)
 				} else {
 					log.Debugf("[warn] prepareArtifacts - fsa artifact - missing in rawNames => %v", artifactFileName)
 				}
@@ -690,6 +775,7 @@ func (p *artifactStore) prepareArtifacts() {
 			}
 
 			bprops.Flags = p.getArtifactFlags(bpath)
+			bprops.Phases = p.getArtifactPhases(bpath)
 
 			fsType := "unknown"
 			switch {
@@ -716,6 +802,14 @@ func (p *artifactStore) prepareArtifacts() {
 	p.resolveLinks()
 }
 
+// setFileRanges copies the recorded file read/mmap ranges (if any) to the artifact
+func setFileRanges(artifactInfo *report.ArtifactProps, fsaInfo *report.FSActivityInfo) {
+	artifactInfo.BytesAccessed = fsaInfo.BytesAccessed
+	artifactInfo.BytesMapped = fsaInfo.BytesMapped
+	artifactInfo.ReadRanges = fsaInfo.Ranges
+	artifactInfo.MappedRanges = fsaInfo.MappedRanges
+}
+
 func (p *artifactStore) resolveLinks() {
 	//note:
 	//the links should be resolved in findSymlinks, but
@@ -2228,6 +2322,9 @@ func (p *artifactStore) saveReport() error {
 			Pt:  p.ptMonReport,
 			Fan: p.fanMonReport,
 		},
+		SensorMetrics: metrics.Summary(),
+		ResourceUsage: p.resourceReport,
+		Warnings:      coverageWarnings(p.fanMonReport, p.ptMonReport),
 	}
 
 	sinfo := system.GetSystemInfo()
@@ -2268,6 +2365,27 @@ func (p *artifactStore) saveReport() error {
 	return ioutil.WriteFile(reportFilePath, reportData.Bytes(), 0644)
 }
 
+func coverageWarnings(fanReport *report.FanMonitorReport, ptReport *report.PtMonitorReport) []string {
+	if fanReport == nil || !fanReport.HasLostCoverage() {
+		return nil
+	}
+
+	warning := fmt.Sprintf("%s: fanotify lost file events (queue overflows: %d, event errors: %d, permission errors: %d)",
+		report.CoverageIncompleteWarning,
+		fanReport.OverflowCount,
+		fanReport.ErrorCount,
+		fanReport.PermissionErrorCount)
+
+	if ptReport != nil && ptReport.Enabled {
+		warning += fmt.Sprintf(" - %d file(s) recovered from the ptrace data", len(fanReport.RecoveredFiles))
+	} else {
+		warning += " - enable the ptrace runtime analysis source to recover the lost files"
+	}
+
+	log.Warnf("sensor: %s", warning)
+	return []string{warning}
+}
+
 func getFileHash(artifactFileName string) (string, error) {
 	fileData, err := ioutil.ReadFile(artifactFileName)
 	if err != nil {
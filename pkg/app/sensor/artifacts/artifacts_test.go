@@ -0,0 +1,82 @@
+package artifacts
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+func TestFilterFilesByPhase(t *testing.T) {
+	fanReport := &report.FanMonitorReport{
+		ProcessFiles: map[string]map[string]*report.FileInfo{
+			"1": {
+				"/usr/bin/app":        {Phases: []string{"startup"}},
+				"/usr/bin/migrate":    {Phases: []string{"startup"}},
+				"/etc/app/config.yml": {Phases: []string{"startup", "probe"}},
+				"/var/lib/app/data":   {},
+			},
+			"2": {
+				"/usr/bin/app":      {Phases: []string{"probe"}},
+				"/srv/static/index": {Phases: []string{"probe"}},
+			},
+		},
+	}
+
+	fileList := []string{
+		"/usr/bin/app",
+		"/usr/bin/migrate",
+		"/etc/app/config.yml",
+		"/var/lib/app/data",
+		"/srv/static/index",
+	}
+
+	tests := []struct {
+		exclude  []string
+		expected []string
+	}{
+		{
+			exclude: []string{"startup"},
+			expected: []string{
+				"/etc/app/config.yml",
+				"/srv/static/index",
+				"/usr/bin/app",
+				"/var/lib/app/data",
+			},
+		},
+		{
+			exclude: []string{"probe"},
+			expected: []string{
+				"/etc/app/config.yml",
+				"/usr/bin/app",
+				"/usr/bin/migrate",
+				"/var/lib/app/data",
+			},
+		},
+		{
+			exclude: []string{"warmup"},
+			expected: []string{
+				"/etc/app/config.yml",
+				"/srv/static/index",
+				"/usr/bin/app",
+				"/usr/bin/migrate",
+				"/var/lib/app/data",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		filtered := filterFilesByPhase(fileList, fanReport, test.exclude)
+		sort.Strings(filtered)
+		if !reflect.DeepEqual(filtered, test.expected) {
+			t.Errorf("exclude %v: unexpected files %v", test.exclude, filtered)
+		}
+	}
+
+	//nothing is excluded if all files would be excluded
+	filtered := filterFilesByPhase([]string{"/usr/bin/migrate"}, fanReport, []string{"startup"})
+	if len(filtered) != 1 {
+		t.Errorf("unexpected files: %v", filtered)
+	}
+}
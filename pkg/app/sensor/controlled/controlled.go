@@ -57,6 +57,7 @@ func NewSensor(
 //   - (II) Monitor is running
 //     -> StopMonitor command arrives    => stop the mon, dump the report, and go to state I.
 //     -> ShutdownSensor command arrives => cancel monitoring, grumble, and exit
+//     -> MarkPhase, Snapshot or ResetCounters command arrives => update/report the mon state and keep waiting
 //     -> Any other command              => grumble but keep waiting
 func (s *Sensor) Run() error {
 	log.Info("sensor: waiting for commands...")
@@ -76,12 +77,13 @@ func (s *Sensor) Run() error {
 
 		s.exe.PubEvent(event.StartMonitorDone)
 
-		if err := s.runWithMonitor(mon); err != nil {
+		report, err := s.runWithMonitor(mon)
+		if err != nil {
 			s.exe.PubEvent(event.ShutdownSensorDone)
 			return fmt.Errorf("run sensor with monitor failed: %w", err)
 		}
 
-		s.exe.HookMonitorPostShutdown()
+		s.exe.HookMonitorPostShutdown(execution.NewReportSummary(report.FanReport, report.PtReport))
 		s.exe.PubEvent(event.StopMonitorDone)
 	}
 }
@@ -121,7 +123,9 @@ func (s *Sensor) startMonitor(cmd *command.StartMonitor) (monitors.CompositeMoni
 		return nil, fmt.Errorf("failed to enumerate current paths: %w", err)
 	}
 
-	s.exe.HookMonitorPreStart()
+	if err := s.exe.HookMonitorPreStart(cmd); err != nil {
+		return nil, err
+	}
 
 	mon, err := s.newMonitor(
 		s.ctx,
@@ -149,29 +153,54 @@ func (s *Sensor) startMonitor(cmd *command.StartMonitor) (monitors.CompositeMoni
 	return mon, nil
 }
 
-func (s *Sensor) runWithMonitor(mon monitors.CompositeMonitor) error {
+func (s *Sensor) runWithMonitor(mon monitors.CompositeMonitor) (*monitors.CompositeReport, error) {
 	log.Debug("sensor: monitor.worker - waiting to stop monitoring...")
 	log.Debug("sensor: error collector - waiting for errors...")
 
 	// Only two ways out of this: either StopMonitor or ShutdownSensor.
 	stopCommandReceived := false
 
+	streamer := monitors.NewActivityStreamer(s.exe.PubEvent)
+	flushTicker := time.NewTicker(monitors.ActivityFlushInterval)
+	defer flushTicker.Stop()
+
 loop:
 	for {
 		select {
 		case <-mon.Done():
 			break loop
 
+		case a := <-mon.Activity():
+			streamer.Add(a)
+
+		case <-flushTicker.C:
+			streamer.Flush()
+			if mon.StartCommand().StreamEvents {
+				streamer.UpdateStats(mon.LiveStats())
+			}
+
 		case cmd := <-s.exe.Commands():
-			switch cmd.(type) {
+			switch typedCmd := cmd.(type) {
 			case *command.StopMonitor:
 				stopCommandReceived = true
 				mon.Cancel()
 				break loop
 
+			case *command.MarkPhase:
+				streamer.Flush()
+				mon.MarkPhase(typedCmd.Name)
+
+			case *command.Snapshot:
+				streamer.Flush()
+				s.exe.PubEvent(event.SnapshotDone, mon.Snapshot())
+
+			case *command.ResetCounters:
+				streamer.ResetTotals()
+				mon.ResetCounters()
+
 			case *command.ShutdownSensor:
 				mon.Cancel() // Dirty exit - abandoning the results.
-				return ErrPrematureShutdown
+				return nil, ErrPrematureShutdown
 
 			default:
 				log.Info("sensor: ignoring unknown or unexpected command => ", cmd)
@@ -190,14 +219,17 @@ loop:
 		// Monitor can finish before the stop command is received.
 		// In such case, we have to await the explicit stop.
 		if cmd, ok := (<-s.exe.Commands()).(*command.StopMonitor); !ok {
-			return fmt.Errorf("sensor received unepxected command: %#+v", cmd)
+			return nil, fmt.Errorf("sensor received unepxected command: %#+v", cmd)
 		}
 	}
 
-	return s.processMonitoringResults(mon)
+	return s.processMonitoringResults(mon, streamer)
 }
 
-func (s *Sensor) processMonitoringResults(mon monitors.CompositeMonitor) error {
+func (s *Sensor) processMonitoringResults(
+	mon monitors.CompositeMonitor,
+	streamer *monitors.ActivityStreamer,
+) (*monitors.CompositeReport, error) {
 	// A bit of code duplication to avoid starting a goroutine
 	// for error event handling - keeping the control flow
 	// "single-threaded" keeps reasoning about the logic.
@@ -206,12 +238,14 @@ func (s *Sensor) processMonitoringResults(mon monitors.CompositeMonitor) error {
 		s.exe.PubEvent(event.Error, monitors.NonCriticalError(err).Error())
 	}
 
+	streamer.Drain(mon.Activity())
+
 	log.Info("sensor: composite monitor is done, checking status...")
 
 	report, err := mon.Status()
 	if err != nil {
 		log.WithError(err).Error("sensor: composite monitor failed")
-		return fmt.Errorf("composite monitor failed: %w", err)
+		return nil, fmt.Errorf("composite monitor failed: %w", err)
 	}
 
 	if err := s.artifactor.ProcessReports(
@@ -220,11 +254,12 @@ func (s *Sensor) processMonitoringResults(mon monitors.CompositeMonitor) error {
 		report.PeReport,
 		report.FanReport,
 		report.PtReport,
+		report.ResourceReport,
 	); err != nil {
 		log.WithError(err).Error("sensor: artifacts.ProcessReports() failed")
-		return fmt.Errorf("saving reports failed: %w", err)
+		return nil, fmt.Errorf("saving reports failed: %w", err)
 	}
-	return nil // Clean exit
+	return report, nil // Clean exit
 }
 
 func nonCriticalError(err error) error {
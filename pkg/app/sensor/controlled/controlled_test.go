@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"os"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/fanotify"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/ptrace"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+	"github.com/docker-slim/docker-slim/pkg/ipc/event"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/test/stub/sensor/execution"
 	stubmonitor "github.com/docker-slim/docker-slim/pkg/test/stub/sensor/monitor"
@@ -75,6 +78,7 @@ func (a *artifactorStub) ProcessReports(
 	peReport *report.PeMonitorReport,
 	fanReport *report.FanMonitorReport,
 	ptReport *report.PtMonitorReport,
+	resourceReport *report.ResourceUsageReport,
 ) error {
 	return nil
 }
@@ -168,3 +172,150 @@ func TestStopNonStartedMonitor(t *testing.T) {
 		t.Fatal("Unexpected sensor run error:", err)
 	}
 }
+
+// phasedFanMonitor is a fanotify monitor stub tracking the file phases
+// (the files are "accessed" when the phases are marked)
+type phasedFanMonitor struct {
+	*stubmonitor.FanMonitorStub
+
+	mu         sync.Mutex
+	phase      string
+	eventCount uint32
+	files      map[string]*report.FileInfo
+}
+
+func newPhasedFanMonitor(ctx context.Context) *phasedFanMonitor {
+	m := &phasedFanMonitor{
+		FanMonitorStub: stubmonitor.NewFanMonitor(ctx),
+		phase:          command.PhaseStartup,
+		files:          map[string]*report.FileInfo{},
+	}
+
+	m.access("/bin/app")
+	return m
+}
+
+func (m *phasedFanMonitor) access(fpath string) {
+	m.eventCount++
+	finfo, ok := m.files[fpath]
+	if !ok {
+		finfo = &report.FileInfo{Name: fpath}
+		m.files[fpath] = finfo
+	}
+
+	finfo.EventCount++
+	finfo.Phases = append(finfo.Phases, m.phase)
+}
+
+func (m *phasedFanMonitor) MarkPhase(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.phase = name
+	m.access("/bin/app")
+	m.access("/etc/app.conf")
+}
+
+func (m *phasedFanMonitor) Snapshot() *report.FanMonitorReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	files := map[string]*report.FileInfo{}
+	for fpath, finfo := range m.files {
+		fcopy := *finfo
+		files[fpath] = &fcopy
+	}
+
+	return &report.FanMonitorReport{
+		EventCount:   m.eventCount,
+		Processes:    map[string]*report.ProcessInfo{"1": {Pid: 1}},
+		ProcessFiles: map[string]map[string]*report.FileInfo{"1": files},
+	}
+}
+
+func (m *phasedFanMonitor) ResetCounters() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.eventCount = 0
+}
+
+type countingPtMonitor struct {
+	*stubmonitor.PtMonitorStub
+}
+
+func (m *countingPtMonitor) LiveStats() ptrace.LiveStats {
+	return ptrace.LiveStats{SyscallCount: 100, SyscallNum: 10}
+}
+
+func TestMidSessionControlCommands(t *testing.T) {
+	ctx := context.Background()
+	exe := execution.NewExecution()
+	sen := controlled.NewSensor(
+		ctx,
+		exe,
+		newStubMonitorFunc(ctx,
+			newPhasedFanMonitor(ctx),
+			&countingPtMonitor{PtMonitorStub: stubmonitor.NewPtMonitor(ctx)}),
+		&artifactorStub{},
+		"", "",
+	)
+
+	go func() {
+		exe.SendCommand(&command.StartMonitor{Phase: command.PhaseStartup})
+		exe.SendCommand(&command.MarkPhase{Name: "warmup"})
+		exe.SendCommand(&command.Snapshot{})
+		exe.SendCommand(&command.ResetCounters{})
+		exe.SendCommand(&command.Snapshot{})
+		exe.SendCommand(&command.StopMonitor{})
+		exe.SendCommand(&command.ShutdownSensor{})
+	}()
+
+	if err := sen.Run(); err != nil {
+		t.Fatal("Unexpected sensor run error:", err)
+	}
+
+	var snapshots []*event.SnapshotInfo
+	for _, evt := range exe.PubEvents() {
+		if evt.Type != event.SnapshotDone {
+			continue
+		}
+
+		if len(evt.Data) != 1 {
+			t.Fatalf("unexpected snapshot event data: %+v", evt.Data)
+		}
+
+		snapshot, ok := evt.Data[0].(*event.SnapshotInfo)
+		if !ok {
+			t.Fatalf("unexpected snapshot event data type: %T", evt.Data[0])
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(snapshots))
+	}
+
+	first, second := snapshots[0], snapshots[1]
+	if first.Phase != "warmup" || first.EventCount != 3 || first.SyscallCount != 100 || first.Processes != 1 {
+		t.Errorf("unexpected snapshot: %+v", first)
+	}
+
+	expectedFiles := []event.SnapshotFileInfo{
+		{Path: "/bin/app", EventCount: 2, Phases: []string{command.PhaseStartup, "warmup"}},
+		{Path: "/etc/app.conf", EventCount: 1, Phases: []string{"warmup"}},
+	}
+	if !reflect.DeepEqual(first.Files, expectedFiles) {
+		t.Errorf("unexpected snapshot files: %+v", first.Files)
+	}
+
+	//the counters are reset, but the collected files are kept
+	if second.EventCount != 0 || second.SyscallCount != 0 {
+		t.Errorf("unexpected counters after reset: events=%d syscalls=%d", second.EventCount, second.SyscallCount)
+	}
+
+	if !reflect.DeepEqual(second.Files, expectedFiles) {
+		t.Errorf("unexpected snapshot files after reset: %+v", second.Files)
+	}
+}
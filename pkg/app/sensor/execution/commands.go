@@ -0,0 +1,320 @@
+package execution
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+)
+
+// CommandEnvVarPrefix is the prefix for the env vars mapped onto
+// the StartMonitor command fields (e.g., SLIM_SENSOR_APP_NAME => app_name)
+const CommandEnvVarPrefix = "SLIM_SENSOR_"
+
+var (
+	ErrNoCommand = errors.New("no target app in start command (set app_name or app_entrypoint/app_cmd in the command file or with the " + CommandEnvVarPrefix + "* env vars)")
+)
+
+// Field descriptions for the commands file template (see PrintDefaultCommands)
+var commandFieldDescriptions = map[string]string{
+	"obfuscate_metadata":      "Obfuscate the package metadata in the minified image",
+	"rta_source_ptrace":       "Use the ptrace monitor as the runtime analysis data source",
+	"app_name":                "Target app executable (use app_name+app_args or app_entrypoint+app_cmd)",
+	"app_args":                "Target app arguments",
+	"app_entrypoint":          "Target app ENTRYPOINT (as in Dockerfile)",
+	"app_cmd":                 "Target app CMD (as in Dockerfile; overridden by the args after the '--' sensor flag separator)",
+	"app_user":                "Run the target app as this user",
+	"app_stdout_to_file":      "Save the target app stdout in the artifacts dir",
+	"app_stderr_to_file":      "Save the target app stderr in the artifacts dir",
+	"run_tas_user":            "Run the target app as the app_user user",
+	"report_on_main_pid_exit": "Stop monitoring when the main target app process exits",
+	"keep_perms":              "Keep the artifact permissions",
+	"perms":                   "Artifact permissions (path => access info)",
+	"excludes":                "Path patterns to exclude",
+	"preserves":               "Paths to preserve (path => access info)",
+	"includes":                "Paths to include (path => access info)",
+	"include_bins":            "Binaries to include (with their dependencies)",
+	"include_exes":            "Executables to include (with their dependencies)",
+	"include_shell":           "Include a basic shell",
+	"include_workdir":         "Include the app working directory",
+	"include_cert_all":        "Include all certificate files",
+	"include_cert_bundles":    "Include the certificate bundles",
+	"include_cert_dirs":       "Include the certificate directories",
+	"include_cert_pk_all":     "Include all private key files",
+	"include_cert_pk_dirs":    "Include the private key directories",
+	"include_new":             "Include the new files created by the target app",
+	"include_oslibs_net":      "Include the OS networking libraries",
+	"include_app_nuxt_dir":    "Include the Nuxt.js app dir",
+	"include_app_nuxt_build":  "Include the Nuxt.js build dir",
+	"include_app_nuxt_dist":   "Include the Nuxt.js dist dir",
+	"include_app_nuxt_static": "Include the Nuxt.js static dir",
+	"include_app_nuxt_nm":     "Include the Nuxt.js node_modules dir",
+	"include_app_next_dir":    "Include the Next.js app dir",
+	"include_app_next_build":  "Include the Next.js build dir",
+	"include_app_next_dist":   "Include the Next.js dist dir",
+	"include_app_next_static": "Include the Next.js static dir",
+	"include_app_next_nm":     "Include the Next.js node_modules dir",
+	"include_node_packages":   "Node.js packages to include",
+	"stream_events":           "Stream the file access and process events (controlled mode only)",
+	"attach_pid":              "Attach to the running process instead of starting the app (controlled mode only)",
+	"phase":                   "Initial monitoring phase name (the accessed files are tagged with it)",
+	"record_file_ranges":      "Record the file read/mmap ranges (ptrace monitor; analysis only)",
+	"exclude_phases":          "Exclude the files accessed only during these monitoring phases",
+}
+
+// loadCommand reads the start monitor command from the JSONL (first line)
+// or YAML (.yaml/.yml) commands file and applies the SLIM_SENSOR_* env var overrides.
+// The commands file is optional if the command is defined with the env vars.
+func loadCommand(filename string) (command.StartMonitor, error) {
+	var cmd command.StartMonitor
+
+	data, err := os.ReadFile(filename)
+	if err != nil && !(os.IsNotExist(err) && hasCommandEnvVars(os.Environ())) {
+		return cmd, fmt.Errorf("could not read command file %q: %w", filename, err)
+	}
+
+	if err := decodeCommand(data, isYAMLFile(filename), &cmd); err != nil {
+		return cmd, fmt.Errorf("invalid command file %q: %w", filename, err)
+	}
+
+	if err := applyCommandEnvVars(os.Environ(), &cmd); err != nil {
+		return cmd, err
+	}
+
+	return cmd, nil
+}
+
+func isYAMLFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// decodeCommand strictly decodes the command data
+// (the unknown and mistyped fields are reported as errors)
+func decodeCommand(data []byte, isYAML bool, cmd *command.StartMonitor) error {
+	if isYAML {
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return fmt.Errorf("malformed YAML: %w", err)
+		}
+
+		data = jsonData
+	} else {
+		data = bytes.Split(data, []byte("\n"))[0]
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cmd); err != nil {
+		return describeDecodeError(err)
+	}
+
+	return nil
+}
+
+func describeDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("field '%s' - expected %s value (got %s)", typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+
+	// The json package doesn't have a dedicated unknown field error type.
+	if msg := err.Error(); strings.HasPrefix(msg, "json: unknown field ") {
+		return fmt.Errorf("unknown field %s (see --print-default-commands for the supported fields)",
+			strings.TrimPrefix(msg, "json: unknown field "))
+	}
+
+	return err
+}
+
+func hasCommandEnvVars(environ []string) bool {
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, CommandEnvVarPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyCommandEnvVars maps the SLIM_SENSOR_* env vars onto the command fields.
+// The list values are comma-separated (or JSON-encoded) and the path maps
+// are comma-separated path lists (or JSON-encoded objects).
+func applyCommandEnvVars(environ []string, cmd *command.StartMonitor) error {
+	fields := commandFieldKinds()
+	overrides := map[string]json.RawMessage{}
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, CommandEnvVarPrefix) {
+			continue
+		}
+
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimPrefix(parts[0], CommandEnvVarPrefix))
+		kind, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown command env var %s (no '%s' command field)", parts[0], name)
+		}
+
+		value, err := envValueToJSON(kind, parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid command env var %s: %w", parts[0], err)
+		}
+
+		overrides[name] = value
+	}
+
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return fmt.Errorf("invalid command env vars (malformed JSON value): %w", err)
+	}
+
+	if err := decodeCommand(data, false, cmd); err != nil {
+		return fmt.Errorf("invalid command env vars: %w", err)
+	}
+
+	return nil
+}
+
+func envValueToJSON(kind reflect.Kind, value string) (json.RawMessage, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected bool value (got %q)", value)
+		}
+
+		return json.Marshal(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected int value (got %q)", value)
+		}
+
+		return json.Marshal(n)
+	case reflect.Slice:
+		if strings.HasPrefix(value, "[") {
+			return json.RawMessage(value), nil
+		}
+
+		return json.Marshal(splitList(value))
+	case reflect.Map:
+		if strings.HasPrefix(value, "{") {
+			return json.RawMessage(value), nil
+		}
+
+		paths := map[string]interface{}{}
+		for _, p := range splitList(value) {
+			paths[p] = nil
+		}
+
+		return json.Marshal(paths)
+	default:
+		return json.Marshal(value)
+	}
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+func commandFieldKinds() map[string]reflect.Kind {
+	kinds := map[string]reflect.Kind{}
+	t := reflect.TypeOf(command.StartMonitor{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			kinds[name] = t.Field(i).Type.Kind()
+		}
+	}
+
+	return kinds
+}
+
+// PrintDefaultCommands outputs a commented YAML commands file template
+// with all supported StartMonitor fields (set to their default values)
+func PrintDefaultCommands(w io.Writer) error {
+	fields := commandFieldKinds()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Slim sensor commands file (standalone mode).\n")
+	b.WriteString("# Save it with the .yaml (or .yml) extension and pass it with --command-file.\n")
+	b.WriteString("# The JSON-encoded (JSONL) commands files are supported too.\n")
+	fmt.Fprintf(&b, "# Every field can be also set with a %s<FIELD> env var (e.g., %sAPP_NAME=/bin/app).\n",
+		CommandEnvVarPrefix, CommandEnvVarPrefix)
+	b.WriteString("# List fields in env vars are comma-separated.\n")
+
+	for _, name := range names {
+		desc := commandFieldDescriptions[name]
+		if desc == "" {
+			desc = "(no description)"
+		}
+
+		fmt.Fprintf(&b, "\n# %s (%s)\n%s: %s\n", desc, fieldTypeName(fields[name]), name, defaultYAMLValue(fields[name]))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fieldTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Slice:
+		return "list"
+	case reflect.Map:
+		return "path map"
+	default:
+		return kind.String()
+	}
+}
+
+func defaultYAMLValue(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "false"
+	case reflect.Int:
+		return "0"
+	case reflect.Slice:
+		return "[]"
+	case reflect.Map:
+		return "{}"
+	default:
+		return `""`
+	}
+}
@@ -0,0 +1,88 @@
+package execution
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+)
+
+func TestDecodeCommand_YAML(t *testing.T) {
+	data := []byte("app_name: /bin/app\napp_args: [\"-v\"]\ninclude_shell: true\nincludes:\n  /etc/app.conf:\n")
+
+	var cmd command.StartMonitor
+	if err := decodeCommand(data, true, &cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	if cmd.AppName != "/bin/app" || len(cmd.AppArgs) != 1 || !cmd.IncludeShell {
+		t.Errorf("unexpected command: %+v", cmd)
+	}
+
+	if _, ok := cmd.Includes["/etc/app.conf"]; !ok {
+		t.Errorf("expected includes: %+v", cmd.Includes)
+	}
+}
+
+func TestDecodeCommand_Errors(t *testing.T) {
+	tests := []struct {
+		data   string
+		isYAML bool
+		errMsg string
+	}{
+		{data: "app_name: /bin/app\nincude_shell: true\n", isYAML: true, errMsg: `unknown field "incude_shell"`},
+		{data: "include_shell: \"yes\"\n", isYAML: true, errMsg: "field 'include_shell' - expected bool"},
+		{data: `{"app_args":"-v"}`, errMsg: "field 'app_args' - expected []string"},
+	}
+
+	for _, test := range tests {
+		var cmd command.StartMonitor
+		err := decodeCommand([]byte(test.data), test.isYAML, &cmd)
+		if err == nil || !strings.Contains(err.Error(), test.errMsg) {
+			t.Errorf("%q: expected error with %q, got %v", test.data, test.errMsg, err)
+		}
+	}
+}
+
+func TestApplyCommandEnvVars(t *testing.T) {
+	cmd := command.StartMonitor{AppName: "/bin/app"}
+	environ := []string{
+		"PATH=/bin",
+		"SLIM_SENSOR_APP_ARGS=-v, --port=80",
+		"SLIM_SENSOR_INCLUDE_NEW=true",
+		"SLIM_SENSOR_INCLUDES=/etc/a,/etc/b",
+	}
+
+	if err := applyCommandEnvVars(environ, &cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	if cmd.AppName != "/bin/app" || len(cmd.AppArgs) != 2 || cmd.AppArgs[1] != "--port=80" || !cmd.IncludeNew || len(cmd.Includes) != 2 {
+		t.Errorf("unexpected command: %+v", cmd)
+	}
+
+	if err := applyCommandEnvVars([]string{"SLIM_SENSOR_APP_NAMES=/bin/app"}, &cmd); err == nil {
+		t.Error("expected an unknown env var error")
+	}
+
+	if err := applyCommandEnvVars([]string{"SLIM_SENSOR_KEEP_PERMS=maybe"}, &cmd); err == nil {
+		t.Error("expected a mistyped env var error")
+	}
+}
+
+func TestPrintDefaultCommands(t *testing.T) {
+	var out bytes.Buffer
+	if err := PrintDefaultCommands(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out.String(), "(no description)") {
+		t.Errorf("expected descriptions for all fields:\n%s", out.String())
+	}
+
+	var cmd command.StartMonitor
+	if err := decodeCommand(out.Bytes(), true, &cmd); err != nil {
+		t.Fatalf("expected a valid template: %v", err)
+	}
+}
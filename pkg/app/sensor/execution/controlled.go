@@ -19,10 +19,13 @@ type controlledExe struct {
 func NewControlled(
 	ctx context.Context,
 	lifecycleHookCommand string,
+	ipcAuthKey string,
+	ipcSocketDir string,
+	artifactsDir string,
 ) (Interface, error) {
 	log.Debug("sensor: starting IPC server...")
 
-	ipcServer, err := ipc.NewServer(ctx.Done())
+	ipcServer, err := ipc.NewServer(ctx.Done(), ipcAuthKey, ipcSocketDir)
 	if err != nil {
 		return nil, err
 	}
@@ -33,8 +36,9 @@ func NewControlled(
 
 	return &controlledExe{
 		hookExecutor: hookExecutor{
-			ctx: ctx,
-			cmd: lifecycleHookCommand,
+			ctx:          ctx,
+			cmd:          lifecycleHookCommand,
+			artifactsDir: artifactsDir,
 		},
 		ipcServer: ipcServer,
 	}, nil
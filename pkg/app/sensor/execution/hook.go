@@ -1,10 +1,17 @@
 package execution
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"os/exec"
+	"strings"
 
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,48 +25,200 @@ const (
 	monitorFailed       kind = "monitor-failed"
 )
 
+// Lifecycle hook protocol:
+// The hook command is invoked with the hook kind as its only argument
+// and with a JSON document (HookInput) on stdin. The hook can return
+// a JSON document (HookOutput) on stdout. The 'monitor-pre-start' hook
+// can add extra include paths and it can abort the monitor start
+// by exiting with HookExitCodeAbort. The other non-zero exit codes
+// are reported as warnings (the sensor keeps going).
+
+// HookExitCodeAbort is the hook exit code to abort the monitor start
+const HookExitCodeAbort = 3
+
+var (
+	ErrHookAbort = errors.New("lifecycle hook aborted monitor start")
+)
+
+// HookInput is the lifecycle hook input (passed on stdin).
+// The app PID is known only after the target app is done, so it's set
+// for the 'monitor-post-shutdown' and 'sensor-pre-shutdown' hooks
+// (the other hooks run before the app is started or when it failed to start).
+type HookInput struct {
+	Kind         string                `json:"kind"`
+	ArtifactsDir string                `json:"artifacts_dir,omitempty"`
+	AppPid       int32                 `json:"app_pid,omitempty"`
+	Command      *command.StartMonitor `json:"command,omitempty"`
+	Report       *ReportSummary        `json:"report,omitempty"`
+}
+
+// HookOutput is the (optional) lifecycle hook output (read from stdout)
+type HookOutput struct {
+	Includes []string `json:"includes,omitempty"`
+}
+
+// ReportSummary is the monitoring report summary
+// (passed to the 'monitor-post-shutdown' hook)
+type ReportSummary struct {
+	AppPid       int32  `json:"app_pid,omitempty"`
+	EventCount   uint32 `json:"event_count"`
+	Processes    int    `json:"processes"`
+	Files        int    `json:"files"`
+	SyscallCount uint64 `json:"syscall_count"`
+	SyscallNum   uint32 `json:"syscall_num"`
+}
+
+// NewReportSummary creates a summary for the monitor reports (any of them can be nil)
+func NewReportSummary(fanReport *report.FanMonitorReport, ptReport *report.PtMonitorReport) *ReportSummary {
+	summary := &ReportSummary{}
+	if fanReport != nil {
+		if fanReport.MainProcess != nil {
+			summary.AppPid = fanReport.MainProcess.Pid
+		}
+
+		summary.EventCount = fanReport.EventCount
+		summary.Processes = len(fanReport.Processes)
+
+		files := map[string]struct{}{}
+		for _, processFiles := range fanReport.ProcessFiles {
+			for name := range processFiles {
+				files[name] = struct{}{}
+			}
+		}
+
+		summary.Files = len(files)
+	}
+
+	if ptReport != nil {
+		summary.SyscallCount = ptReport.SyscallCount
+		summary.SyscallNum = ptReport.SyscallNum
+	}
+
+	return summary
+}
+
 type hookExecutor struct {
-	ctx context.Context
-	cmd string
+	ctx          context.Context
+	cmd          string
+	artifactsDir string
+
+	// the current start monitor command (set by the 'monitor-pre-start' hook)
+	startCmd *command.StartMonitor
+	// the target app PID (set by the 'monitor-post-shutdown' hook)
+	appPid int32
 }
 
 func (h *hookExecutor) HookSensorPostStart() {
-	h.doHook(sensorPostStart)
+	h.doHook(sensorPostStart, nil)
 }
 
 func (h *hookExecutor) HookSensorPreShutdown() {
-	h.doHook(sensorPreShutdown)
+	h.doHook(sensorPreShutdown, nil)
 }
 
-func (h *hookExecutor) HookMonitorPreStart() {
-	h.doHook(monitorPreStart)
+func (h *hookExecutor) HookMonitorPreStart(cmd *command.StartMonitor) error {
+	h.startCmd = cmd
+
+	out, err := h.doHook(monitorPreStart, nil)
+	if err != nil {
+		return err
+	}
+
+	if out != nil && len(out.Includes) > 0 {
+		log.Debugf("sensor: %s hook - extra includes => %v", monitorPreStart, out.Includes)
+		if cmd.Includes == nil {
+			cmd.Includes = map[string]*fsutil.AccessInfo{}
+		}
+
+		for _, p := range out.Includes {
+			if _, ok := cmd.Includes[p]; !ok {
+				cmd.Includes[p] = nil
+			}
+		}
+	}
+
+	return nil
 }
 
-func (h *hookExecutor) HookMonitorPostShutdown() {
-	h.doHook(monitorPostShutdown)
+func (h *hookExecutor) HookMonitorPostShutdown(summary *ReportSummary) {
+	if summary != nil {
+		h.appPid = summary.AppPid
+	}
+
+	h.doHook(monitorPostShutdown, summary)
 }
 
 func (h *hookExecutor) HookMonitorFailed() {
-	h.doHook(monitorFailed)
+	h.doHook(monitorFailed, nil)
 }
 
-func (h *hookExecutor) doHook(k kind) {
+func (h *hookExecutor) doHook(k kind, summary *ReportSummary) (*HookOutput, error) {
 	if len(h.cmd) == 0 {
-		return
+		return nil, nil
+	}
+
+	input := HookInput{
+		Kind:         string(k),
+		ArtifactsDir: h.artifactsDir,
+		AppPid:       h.appPid,
+		Command:      h.startCmd,
+		Report:       summary,
+	}
+
+	inputData, err := json.Marshal(&input)
+	if err != nil {
+		return nil, err
 	}
 
+	var stdout, stderr bytes.Buffer
 	cmd := exec.CommandContext(h.ctx, h.cmd, string(k))
-	out, err := cmd.CombinedOutput()
+	cmd.Stdin = bytes.NewReader(inputData)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
 
 	logger := log.
 		WithField("command", h.cmd).
 		WithField("exit_code", cmd.ProcessState.ExitCode()).
-		WithField("output", string(out))
+		WithField("output", stdout.String()+stderr.String())
 
 	// Some lifecycle hooks are really fast - hence, the IsNoChildProcesses() check.
-	if err == nil || errutil.IsNoChildProcesses(err) {
-		logger.Debugf("sensor: %s hook succeeded", k)
-	} else {
+	if err != nil && !errutil.IsNoChildProcesses(err) {
+		var exitErr *exec.ExitError
+		if k == monitorPreStart && errors.As(err, &exitErr) && exitErr.ExitCode() == HookExitCodeAbort {
+			logger.Errorf("sensor: %s hook aborted monitor start", k)
+			return nil, ErrHookAbort
+		}
+
 		logger.WithError(err).Warnf("sensor: %s hook failed", k)
+		return nil, nil
+	}
+
+	logger.Debugf("sensor: %s hook succeeded", k)
+	return parseHookOutput(k, stdout.Bytes()), nil
+}
+
+// parseHookOutput decodes the hook output if it's a JSON document
+// (any other output is just logged)
+func parseHookOutput(k kind, data []byte) *HookOutput {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return nil
 	}
+
+	var out HookOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		log.WithError(err).Warnf("sensor: %s hook - malformed output", k)
+		return nil
+	}
+
+	var includes []string
+	for _, p := range out.Includes {
+		if p = strings.TrimSpace(p); p != "" {
+			includes = append(includes, p)
+		}
+	}
+
+	out.Includes = includes
+	return &out
 }
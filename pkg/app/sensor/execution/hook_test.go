@@ -0,0 +1,114 @@
+package execution
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+)
+
+func TestHookMonitorPreStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		err      error
+		includes []string
+	}{
+		{
+			name:     "extra includes",
+			script:   `cat > /dev/null; echo '{"includes":["/etc/app.conf"," ",""]}'`,
+			includes: []string{"/etc/app.conf"},
+		},
+		{
+			name:   "abort",
+			script: `grep -q '"kind":"monitor-pre-start"' && exit 3`,
+			err:    ErrHookAbort,
+		},
+		{
+			name:   "failure",
+			script: `exit 1`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hookPath := filepath.Join(t.TempDir(), "hook.sh")
+			if err := os.WriteFile(hookPath, []byte("#!/bin/sh\n"+tc.script+"\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			h := hookExecutor{ctx: context.Background(), cmd: hookPath}
+			cmd := &command.StartMonitor{AppName: "/bin/app"}
+			if err := h.HookMonitorPreStart(cmd); !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: %v (expected %v)", err, tc.err)
+			}
+
+			if len(cmd.Includes) != len(tc.includes) {
+				t.Fatalf("unexpected includes: %v", cmd.Includes)
+			}
+
+			for _, p := range tc.includes {
+				if _, ok := cmd.Includes[p]; !ok {
+					t.Errorf("missing include %q", p)
+				}
+			}
+		})
+	}
+}
+
+func TestHookInputAppPid(t *testing.T) {
+	dir := t.TempDir()
+	inputsPath := filepath.Join(dir, "inputs.jsonl")
+	hookPath := filepath.Join(dir, "hook.sh")
+	script := "#!/bin/sh\ncat >> " + inputsPath + "\necho >> " + inputsPath + "\n"
+	if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	h := hookExecutor{ctx: context.Background(), cmd: hookPath}
+	h.HookSensorPostStart()
+	if err := h.HookMonitorPreStart(&command.StartMonitor{AppName: "/bin/app"}); err != nil {
+		t.Fatal(err)
+	}
+	h.HookMonitorPostShutdown(&ReportSummary{AppPid: 42})
+	h.HookSensorPreShutdown()
+
+	f, err := os.Open(inputsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pids := map[string]int32{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var input HookInput
+		if err := json.Unmarshal(scanner.Bytes(), &input); err != nil {
+			t.Fatal(err)
+		}
+
+		pids[input.Kind] = input.AppPid
+	}
+
+	expected := map[string]int32{
+		string(sensorPostStart):     0,
+		string(monitorPreStart):     0,
+		string(monitorPostShutdown): 42,
+		string(sensorPreShutdown):   42,
+	}
+
+	if len(pids) != len(expected) {
+		t.Fatalf("unexpected hook inputs: %v", pids)
+	}
+
+	for k, pid := range expected {
+		if pids[k] != pid {
+			t.Errorf("unexpected app pid for the %s hook: %d (expected %d)", k, pids[k], pid)
+		}
+	}
+}
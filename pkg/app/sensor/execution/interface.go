@@ -13,7 +13,7 @@ type Interface interface {
 	// Lifecycle hooks (extension points)
 	HookSensorPostStart()
 	HookSensorPreShutdown()
-	HookMonitorPreStart()
-	HookMonitorPostShutdown()
+	HookMonitorPreStart(cmd *command.StartMonitor) error // can abort the start or add includes to the command
+	HookMonitorPostShutdown(summary *ReportSummary)
 	HookMonitorFailed()
 }
@@ -1,7 +1,6 @@
 package execution
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -29,6 +28,7 @@ func NewStandalone(
 	commandFileName string,
 	eventFileName string,
 	lifecycleHookCommand string,
+	artifactsDir string,
 ) (Interface, error) {
 	// fsutil.Touch() creates (potentially missing) folder(s).
 	if err := fsutil.Touch(eventFileName); err != nil {
@@ -59,8 +59,9 @@ func NewStandalone(
 
 	return &standaloneExe{
 		hookExecutor: hookExecutor{
-			ctx: ctx,
-			cmd: lifecycleHookCommand,
+			ctx:          ctx,
+			cmd:          lifecycleHookCommand,
+			artifactsDir: artifactsDir,
 		},
 		commandCh: commandCh,
 		eventFile: eventFile,
@@ -87,16 +88,9 @@ func (e *standaloneExe) PubEvent(name event.Type, data ...interface{}) {
 
 // TODO: Make this function return a list of commands.
 func readCommandFile(filename string) (command.StartMonitor, error) {
-	var cmd command.StartMonitor
-
-	data, err := os.ReadFile(filename)
+	cmd, err := loadCommand(filename)
 	if err != nil {
-		return cmd, fmt.Errorf("could not read command file %q: %w", filename, err)
-	}
-	data = bytes.Split(data, []byte("\n"))[0]
-
-	if err := json.Unmarshal(data, &cmd); err != nil {
-		return cmd, fmt.Errorf("could not decode command %q: %w", string(data), err)
+		return cmd, err
 	}
 
 	// The instrumented image will always have the ENTRYPOINT overwritten
@@ -144,5 +138,9 @@ func readCommandFile(filename string) (command.StartMonitor, error) {
 		}
 	}
 
+	if cmd.AppName == "" {
+		return cmd, ErrNoCommand
+	}
+
 	return cmd, nil
 }
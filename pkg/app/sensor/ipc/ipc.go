@@ -3,25 +3,41 @@ package ipc
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
 	"github.com/docker-slim/docker-slim/pkg/ipc/event"
+	"github.com/docker-slim/docker-slim/pkg/version"
 )
 
 type Server struct {
+	authKey    []byte
+	socketDir  string
 	evtChannel *channel.EventServer
 	cmdChannel *channel.CommandServer
 	cmdChan    chan command.Message
 	doneChan   <-chan struct{}
+
+	//the master handshake (nil until the master sends it)
+	hsMu      sync.Mutex
+	handshake *command.Handshake
 }
 
-func NewServer(doneChan <-chan struct{}) (*Server, error) {
+// NewServer creates the sensor IPC server. If the auth key is not empty
+// the server accepts only the peers authenticated with the same key.
+// If the socket dir is not empty the server listens on the Unix domain sockets
+// in that directory instead of the TCP ports.
+func NewServer(doneChan <-chan struct{}, authKey string, socketDir string) (*Server, error) {
 	server := Server{
-		doneChan: doneChan,
-		cmdChan:  make(chan command.Message, 10),
+		authKey:   []byte(authKey),
+		socketDir: socketDir,
+		doneChan:  doneChan,
+		cmdChan:   make(chan command.Message, 10),
 	}
 
 	if err := server.initChannels(); err != nil {
@@ -34,11 +50,21 @@ func NewServer(doneChan <-chan struct{}) (*Server, error) {
 
 func (s *Server) initChannels() error {
 	evtChannelAddr := fmt.Sprintf("0.0.0.0:%d", channel.EvtPort)
-	evtChannel := channel.NewEventServer(evtChannelAddr)
+	cmdChannelAddr := fmt.Sprintf("0.0.0.0:%d", channel.CmdPort)
+	if s.socketDir != "" {
+		//the per-run socket directory in the sensor volume
+		if err := os.MkdirAll(s.socketDir, 0755); err != nil {
+			return err
+		}
+
+		evtChannelAddr = channel.UnixAddr(filepath.Join(s.socketDir, channel.EvtSocketName))
+		cmdChannelAddr = channel.UnixAddr(filepath.Join(s.socketDir, channel.CmdSocketName))
+	}
+
+	evtChannel := channel.NewEventServer(evtChannelAddr, s.authKey)
 	s.evtChannel = evtChannel
 
-	cmdChannelAddr := fmt.Sprintf("0.0.0.0:%d", channel.CmdPort)
-	cmdChannel := channel.NewCommandServer(cmdChannelAddr, s)
+	cmdChannel := channel.NewCommandServer(cmdChannelAddr, s.authKey, s)
 	s.cmdChannel = cmdChannel
 
 	return nil
@@ -72,8 +98,42 @@ func (s *Server) OnRequest(data []byte) ([]byte, error) {
 	}
 
 	if cmd, err := command.Decode(data); err == nil {
-		s.cmdChan <- cmd
-		resp.Status = command.ResponseStatusOk
+		switch cmd := cmd.(type) {
+		case *command.Handshake:
+			//the handshake is handled here (it doesn't change the sensor state)
+			log.Debugf("ipc.Server.OnRequest: handshake - master version='%s' protocol=%d",
+				cmd.MasterVersion, cmd.ProtocolVersion)
+			if err := command.CheckMaster(cmd); err != nil {
+				log.Errorf("ipc.Server.OnRequest: %v", err)
+				resp.Error = err.Error()
+				break
+			}
+
+			s.hsMu.Lock()
+			s.handshake = cmd
+			s.hsMu.Unlock()
+
+			resp.Handshake = command.NewHandshakeInfo(version.Current())
+			resp.Status = command.ResponseStatusOk
+		case *command.StartMonitor:
+			//the masters without the handshake don't know the sensor protocol changes
+			s.hsMu.Lock()
+			hs := s.handshake
+			s.hsMu.Unlock()
+
+			if err := command.CheckMaster(hs); err != nil {
+				log.Errorf("ipc.Server.OnRequest: rejecting start monitor command - %v", err)
+				resp.Error = err.Error()
+				s.TryPublishEvt(&event.Message{Name: event.StartMonitorFailed, Data: err.Error()}, 3)
+				break
+			}
+
+			s.cmdChan <- cmd
+			resp.Status = command.ResponseStatusOk
+		default:
+			s.cmdChan <- cmd
+			resp.Status = command.ResponseStatusOk
+		}
 	} else {
 		log.Errorf("ipc.Server.OnRequest: error decoding request = %v", err)
 	}
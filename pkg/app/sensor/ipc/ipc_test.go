@@ -0,0 +1,83 @@
+package ipc
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/ipc/channel"
+	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+)
+
+func TestServerMasterProtocolVersion(t *testing.T) {
+	key, err := channel.GenerateAuthKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	server, err := NewServer(make(chan struct{}), key, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	if err := server.evtChannel.Start(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := server.cmdChannel.Start(true); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := channel.NewCommandClient(channel.UnixAddr(filepath.Join(dir, channel.CmdSocketName)), 0, 0, 0, 0, []byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	call := func(cmd command.Message) *command.Response {
+		data, err := command.Encode(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := client.Call(data, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var resp command.Response
+		if err := json.Unmarshal(out, &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		return &resp
+	}
+
+	//the masters without the handshake support send the start command right away
+	resp := call(&command.StartMonitor{AppName: "/bin/app"})
+	if resp.Status != command.ResponseStatusError || !strings.Contains(resp.Error, command.ErrIncompatibleMaster.Error()) {
+		t.Errorf("expected the start command to be rejected: %+v", resp)
+	}
+
+	resp = call(&command.Handshake{ProtocolVersion: command.LegacyProtocolVersion})
+	if resp.Status != command.ResponseStatusError || resp.Handshake != nil || resp.Error == "" {
+		t.Errorf("expected the old master handshake to be rejected: %+v", resp)
+	}
+
+	resp = call(&command.Handshake{ProtocolVersion: command.ProtocolVersion})
+	if resp.Status != command.ResponseStatusOk || resp.Handshake == nil {
+		t.Fatalf("unexpected handshake response: %+v", resp)
+	}
+
+	resp = call(&command.StartMonitor{AppName: "/bin/app"})
+	if resp.Status != command.ResponseStatusOk {
+		t.Errorf("unexpected start command response: %+v", resp)
+	}
+
+	if cmd := <-server.CommandChan(); cmd.GetName() != command.StartMonitorName {
+		t.Errorf("unexpected command: %v", cmd.GetName())
+	}
+}
@@ -0,0 +1,225 @@
+// Package metrics provides the sensor self-observability metrics
+// (exposed in the Prometheus text format and summarized in the container report).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const namePrefix = "slim_sensor_"
+
+// Counter is a monotonically increasing metric
+type Counter struct {
+	v uint64
+}
+
+func (c *Counter) Inc() {
+	atomic.AddUint64(&c.v, 1)
+}
+
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.v, n)
+}
+
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.v)
+}
+
+// Gauge is a metric that can go up and down (it also tracks its max value)
+type Gauge struct {
+	v   int64
+	max int64
+}
+
+func (g *Gauge) Set(v int64) {
+	atomic.StoreInt64(&g.v, v)
+	g.updateMax(v)
+}
+
+func (g *Gauge) Add(n int64) {
+	g.updateMax(atomic.AddInt64(&g.v, n))
+}
+
+func (g *Gauge) Value() int64 {
+	return atomic.LoadInt64(&g.v)
+}
+
+func (g *Gauge) Max() int64 {
+	return atomic.LoadInt64(&g.max)
+}
+
+func (g *Gauge) updateMax(v int64) {
+	for {
+		max := atomic.LoadInt64(&g.max)
+		if v <= max || atomic.CompareAndSwapInt64(&g.max, max, v) {
+			return
+		}
+	}
+}
+
+// Sensor metrics (updated by the monitors)
+var (
+	FanEvents        Counter // all fanotify events read from the kernel
+	FanDroppedEvents Counter // fanotify queue overflows (the kernel dropped events)
+	FanErrors        Counter // fanotify event read errors
+	FanQueueDepth    Gauge   // fanotify events waiting for processing
+	ActivityDropped  Counter // activity stream records dropped (slow consumer)
+	ReportFiles      Gauge   // file records in the fanotify report
+	ReportProcesses  Gauge   // process records in the fanotify report
+)
+
+var (
+	startedAt = time.Now()
+
+	syscallMu     sync.Mutex
+	syscallSource func() uint64
+	syscallRate   float64
+	syscallSample struct {
+		count uint64
+		at    time.Time
+	}
+)
+
+// SetSyscallSource sets the syscall counter source (the active ptrace monitor)
+func SetSyscallSource(source func() uint64) {
+	syscallMu.Lock()
+	defer syscallMu.Unlock()
+
+	syscallSource = source
+	syscallSample.count = 0
+	syscallSample.at = time.Now()
+}
+
+func syscalls() uint64 {
+	syscallMu.Lock()
+	source := syscallSource
+	syscallMu.Unlock()
+
+	if source == nil {
+		return 0
+	}
+
+	return source()
+}
+
+// sampleSyscallRate updates the syscalls/sec rate (since the previous sample)
+func sampleSyscallRate() float64 {
+	count := syscalls()
+
+	syscallMu.Lock()
+	defer syscallMu.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(syscallSample.at).Seconds(); elapsed > 0 && count >= syscallSample.count {
+		syscallRate = float64(count-syscallSample.count) / elapsed
+	}
+
+	syscallSample.count = count
+	syscallSample.at = now
+	return syscallRate
+}
+
+func currentSyscallRate() float64 {
+	syscallMu.Lock()
+	defer syscallMu.Unlock()
+
+	return syscallRate
+}
+
+type metricType string
+
+const (
+	counterType metricType = "counter"
+	gaugeType   metricType = "gauge"
+)
+
+type metric struct {
+	name  string
+	help  string
+	typ   metricType
+	value func() float64
+}
+
+func metricList() []metric {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return []metric{
+		{"fanotify_events_total", "Number of fanotify events read from the kernel.", counterType, counterValue(&FanEvents)},
+		{"fanotify_dropped_events_total", "Number of fanotify event queue overflows (events dropped by the kernel).", counterType, counterValue(&FanDroppedEvents)},
+		{"fanotify_errors_total", "Number of fanotify event read errors.", counterType, counterValue(&FanErrors)},
+		{"fanotify_queue_depth", "Number of fanotify events waiting for processing.", gaugeType, gaugeValue(&FanQueueDepth)},
+		{"activity_dropped_records_total", "Number of activity stream records dropped because of a slow consumer.", counterType, counterValue(&ActivityDropped)},
+		{"ptrace_syscalls_total", "Number of syscalls observed by the ptrace monitor.", counterType, func() float64 { return float64(syscalls()) }},
+		{"ptrace_syscalls_per_second", "Observed syscall rate (since the previous sample).", gaugeType, currentSyscallRate},
+		{"report_files", "Number of file records in the monitor report.", gaugeType, gaugeValue(&ReportFiles)},
+		{"report_processes", "Number of process records in the monitor report.", gaugeType, gaugeValue(&ReportProcesses)},
+		{"heap_alloc_bytes", "Sensor heap memory in use (including the report data).", gaugeType, func() float64 { return float64(mem.HeapAlloc) }},
+		{"goroutines", "Number of sensor goroutines.", gaugeType, func() float64 { return float64(runtime.NumGoroutine()) }},
+		{"uptime_seconds", "Sensor uptime.", gaugeType, func() float64 { return time.Since(startedAt).Seconds() }},
+	}
+}
+
+func counterValue(c *Counter) func() float64 {
+	return func() float64 { return float64(c.Value()) }
+}
+
+func gaugeValue(g *Gauge) func() float64 {
+	return func() float64 { return float64(g.Value()) }
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format
+func WriteText(w io.Writer) error {
+	for _, m := range metricList() {
+		name := namePrefix + m.name
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n",
+			name, m.help, name, m.typ, name, formatValue(m.value())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Summary returns the metrics summary for the container report
+func Summary() *report.SensorMetricsReport {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	summary := &report.SensorMetricsReport{
+		Duration:         time.Since(startedAt).Round(time.Millisecond).String(),
+		FanEvents:        FanEvents.Value(),
+		FanDroppedEvents: FanDroppedEvents.Value(),
+		FanErrors:        FanErrors.Value(),
+		FanMaxQueueDepth: FanQueueDepth.Max(),
+		ActivityDropped:  ActivityDropped.Value(),
+		Syscalls:         syscalls(),
+		ReportFiles:      ReportFiles.Value(),
+		ReportProcesses:  ReportProcesses.Value(),
+		HeapAllocBytes:   mem.HeapAlloc,
+		HeapSysBytes:     mem.HeapSys,
+	}
+
+	if elapsed := time.Since(startedAt).Seconds(); elapsed > 0 {
+		summary.AvgSyscallsPerSecond = math.Round(float64(summary.Syscalls)/elapsed*100) / 100
+	}
+
+	return summary
+}
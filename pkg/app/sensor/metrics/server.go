@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	unixAddrPrefix = "unix:"
+
+	sampleInterval  = time.Second
+	shutdownTimeout = 2 * time.Second
+)
+
+var (
+	ErrNonLoopbackAddr = errors.New("metrics address must be a loopback address or a Unix socket")
+)
+
+// Server serves the sensor metrics (/metrics) and the Go profiler (/debug/pprof)
+type Server struct {
+	addr     string
+	listener net.Listener
+	server   *http.Server
+	doneCh   chan struct{}
+}
+
+// NewServer creates a metrics server listening on a loopback address
+// (e.g., 127.0.0.1:9191) or on a Unix socket (unix:/path/to/socket)
+func NewServer(addr string) (*Server, error) {
+	listener, err := listen(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handleMetrics)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &Server{
+		addr:     addr,
+		listener: listener,
+		server:   &http.Server{Handler: mux},
+		doneCh:   make(chan struct{}),
+	}, nil
+}
+
+func listen(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, unixAddrPrefix) {
+		path := strings.TrimPrefix(addr, unixAddrPrefix)
+		if path == "" {
+			return nil, fmt.Errorf("malformed metrics address %q (no socket path)", addr)
+		}
+
+		os.Remove(path) // a left-over from a previous run
+		return net.Listen("unix", path)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("malformed metrics address %q: %w", addr, err)
+	}
+
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("%w (got %q)", ErrNonLoopbackAddr, addr)
+		}
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+// Addr returns the server listener address
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Start serves the metrics in the background
+func (s *Server) Start() {
+	log.Infof("sensor: serving metrics and pprof on %s", s.addr)
+
+	go func() {
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.doneCh:
+				return
+			case <-ticker.C:
+				sampleSyscallRate()
+			}
+		}
+	}()
+
+	go func() {
+		if err := s.server.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Warn("sensor: metrics server failed")
+		}
+	}()
+}
+
+// Close stops the metrics server
+func (s *Server) Close() error {
+	close(s.doneCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := WriteText(w); err != nil {
+		log.WithError(err).Debug("sensor: failed to write metrics")
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	if _, err := NewServer("0.0.0.0:0"); !errors.Is(err, ErrNonLoopbackAddr) {
+		t.Fatalf("expected non-loopback address to be rejected: %v", err)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "metrics.sock")
+	s, err := NewServer(unixAddrPrefix + socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	defer s.Close()
+
+	FanEvents.Add(3)
+	FanQueueDepth.Set(7)
+	FanQueueDepth.Set(2)
+	SetSyscallSource(func() uint64 { return 42 })
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	body := get(t, client, "http://sensor/metrics")
+	for _, expected := range []string{
+		"# TYPE slim_sensor_fanotify_events_total counter\nslim_sensor_fanotify_events_total 3\n",
+		"slim_sensor_fanotify_queue_depth 2\n",
+		"slim_sensor_ptrace_syscalls_total 42\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("missing %q in metrics:\n%s", expected, body)
+		}
+	}
+
+	if body := get(t, client, "http://sensor/debug/pprof/"); !strings.Contains(body, "goroutine") {
+		t.Errorf("unexpected pprof index:\n%s", body)
+	}
+
+	summary := Summary()
+	if summary.FanEvents != 3 || summary.FanMaxQueueDepth != 7 || summary.Syscalls != 42 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s - unexpected status %d", url, resp.StatusCode)
+	}
+
+	return string(data)
+}
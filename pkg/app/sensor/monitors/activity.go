@@ -0,0 +1,117 @@
+package monitors
+
+import (
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/fanotify"
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/ptrace"
+	"github.com/docker-slim/docker-slim/pkg/ipc/event"
+)
+
+const (
+	ActivityFlushInterval = 1 * time.Second
+
+	activityMaxBatchSize = 200
+)
+
+type PubEventFunc func(etype event.Type, data ...interface{})
+
+// ActivityStreamer batches the monitor activity records
+// and publishes them as FileAccess and ProcessExec events.
+// It's not thread-safe (it's used from the sensor's main loop).
+type ActivityStreamer struct {
+	pub PubEventFunc
+
+	seq        uint64
+	totalFiles uint64
+	totalProcs uint64
+	stats      ptrace.LiveStats
+
+	files []event.FileAccessInfo
+	procs []event.ProcessExecInfo
+}
+
+func NewActivityStreamer(pub PubEventFunc) *ActivityStreamer {
+	return &ActivityStreamer{pub: pub}
+}
+
+func (s *ActivityStreamer) Add(a fanotify.Activity) {
+	if a.File != nil {
+		s.files = append(s.files, *a.File)
+		s.totalFiles++
+	}
+
+	if a.Exec != nil {
+		s.procs = append(s.procs, *a.Exec)
+		s.totalProcs++
+	}
+
+	if len(s.files) >= activityMaxBatchSize || len(s.procs) >= activityMaxBatchSize {
+		s.Flush()
+	}
+}
+
+func (s *ActivityStreamer) Flush() {
+	if len(s.procs) > 0 {
+		s.seq++
+		s.pub(event.ProcessExec, &event.ProcessExecBatch{
+			Seq:       s.seq,
+			Processes: s.procs,
+			Total:     s.totalProcs,
+		})
+		s.procs = nil
+	}
+
+	if len(s.files) > 0 {
+		s.seq++
+		s.pub(event.FileAccess, &event.FileAccessBatch{
+			Seq:   s.seq,
+			Files: s.files,
+			Total: s.totalFiles,
+		})
+		s.files = nil
+	}
+}
+
+// UpdateStats publishes the monitor counters (if they changed)
+func (s *ActivityStreamer) UpdateStats(stats ptrace.LiveStats) {
+	if stats == s.stats {
+		return
+	}
+
+	s.stats = stats
+	s.seq++
+	s.pub(event.MonitorStats, &event.MonitorStatsInfo{
+		Seq:          s.seq,
+		SyscallCount: stats.SyscallCount,
+		SyscallNum:   stats.SyscallNum,
+		ListenCount:  stats.ListenCount,
+	})
+}
+
+// ResetTotals restarts the published activity totals
+// (the pending records are flushed first)
+func (s *ActivityStreamer) ResetTotals() {
+	s.Flush()
+	s.totalFiles = 0
+	s.totalProcs = 0
+	s.stats = ptrace.LiveStats{}
+}
+
+// Drain reads the left-over activity records (after the monitor is done)
+// and publishes the final batches.
+func (s *ActivityStreamer) Drain(activityCh <-chan fanotify.Activity) {
+	if activityCh != nil {
+	drain:
+		for {
+			select {
+			case a := <-activityCh:
+				s.Add(a)
+			default:
+				break drain
+			}
+		}
+	}
+
+	s.Flush()
+}
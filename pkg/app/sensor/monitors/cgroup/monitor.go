@@ -0,0 +1,327 @@
+// Package cgroup provides the container resource usage monitor
+// (based on the cgroup v2 resource accounting files).
+package cgroup
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+const (
+	defaultRoot    = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+	sampleInterval = time.Second
+)
+
+// cgroup v2 resource accounting files
+const (
+	fileControllers   = "cgroup.controllers"
+	fileMemoryCurrent = "memory.current"
+	fileMemoryPeak    = "memory.peak" //kernel 5.19+
+	fileMemoryMax     = "memory.max"
+	fileCPUStat       = "cpu.stat"
+	fileIOStat        = "io.stat"
+	filePidsCurrent   = "pids.current"
+	filePidsPeak      = "pids.peak" //kernel 6.1+
+	filePidsMax       = "pids.max"
+)
+
+var ErrNoCgroupV2 = errors.New("cgroup v2 is not available")
+
+// Monitor samples the resource usage of the sensor's (container's) cgroup
+// while the target app is running. The counters (CPU time and I/O bytes)
+// are reported as the deltas since the monitor start.
+type Monitor struct {
+	ctx context.Context
+
+	root           string
+	procCgroupPath string
+	dir            string
+
+	startedAt time.Time
+	stoppedAt time.Time
+
+	cpuBase map[string]uint64
+	ioBase  ioStat
+
+	mu         sync.Mutex
+	samples    int
+	memoryPeak uint64
+	pidsPeak   uint64
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewMonitor(ctx context.Context) *Monitor {
+	return &Monitor{
+		ctx:            ctx,
+		root:           defaultRoot,
+		procCgroupPath: procSelfCgroup,
+	}
+}
+
+// Start() is not reentrant!
+func (m *Monitor) Start() error {
+	dir, err := findCgroupDir(m.root, m.procCgroupPath)
+	if err != nil {
+		return err
+	}
+
+	m.dir = dir
+	m.cpuBase, _ = readKeyValues(filepath.Join(dir, fileCPUStat))
+	m.ioBase, _ = readIOStat(filepath.Join(dir, fileIOStat))
+	m.startedAt = time.Now()
+	m.sample()
+
+	m.stopCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+
+	go func() {
+		defer close(m.doneCh)
+
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.sample()
+			case <-m.stopCh:
+				return
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	log.Debugf("sensor: cgroup monitor - started (dir=%s)", dir)
+	return nil
+}
+
+// Stop() ends the sampling (it's reentrant)
+func (m *Monitor) Stop() {
+	if m.stopCh == nil {
+		return
+	}
+
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+		<-m.doneCh
+
+		m.sample()
+		m.stoppedAt = time.Now()
+		log.Debug("sensor: cgroup monitor - stopped")
+	})
+}
+
+func (m *Monitor) sample() {
+	memoryCurrent, memErr := readUint(filepath.Join(m.dir, fileMemoryCurrent))
+	pidsCurrent, pidsErr := readUint(filepath.Join(m.dir, filePidsCurrent))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples++
+	if memErr == nil && memoryCurrent > m.memoryPeak {
+		m.memoryPeak = memoryCurrent
+	}
+
+	if pidsErr == nil && pidsCurrent > m.pidsPeak {
+		m.pidsPeak = pidsCurrent
+	}
+}
+
+// Report returns the resource usage summary
+// (nil if the monitor wasn't started or stopped)
+func (m *Monitor) Report() *report.ResourceUsageReport {
+	if m == nil || m.stoppedAt.IsZero() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	duration := m.stoppedAt.Sub(m.startedAt)
+	result := &report.ResourceUsageReport{
+		CgroupPath:      m.dir,
+		Duration:        duration.Round(time.Millisecond).String(),
+		Samples:         m.samples,
+		MemoryPeakBytes: m.memoryPeak,
+		PidsPeak:        m.pidsPeak,
+	}
+
+	// The kernel tracked peak values are more accurate than the sampled ones
+	// (but they also include the usage before the app started).
+	if peak, err := readUint(filepath.Join(m.dir, fileMemoryPeak)); err == nil && peak > result.MemoryPeakBytes {
+		result.MemoryPeakBytes = peak
+	}
+
+	if peak, err := readUint(filepath.Join(m.dir, filePidsPeak)); err == nil && peak > result.PidsPeak {
+		result.PidsPeak = peak
+	}
+
+	result.MemoryLimitBytes, _ = readUint(filepath.Join(m.dir, fileMemoryMax))
+	result.PidsLimit, _ = readUint(filepath.Join(m.dir, filePidsMax))
+
+	if cpu, err := readKeyValues(filepath.Join(m.dir, fileCPUStat)); err == nil {
+		result.CPUUsageUsec = delta(cpu, m.cpuBase, "usage_usec")
+		result.CPUUserUsec = delta(cpu, m.cpuBase, "user_usec")
+		result.CPUSystemUsec = delta(cpu, m.cpuBase, "system_usec")
+		result.CPUThrottledUsec = delta(cpu, m.cpuBase, "throttled_usec")
+	}
+
+	if usec := float64(duration.Microseconds()); usec > 0 {
+		result.AvgCPUCores = math.Round(float64(result.CPUUsageUsec)/usec*1000) / 1000
+	}
+
+	if io, err := readIOStat(filepath.Join(m.dir, fileIOStat)); err == nil {
+		if io.readBytes >= m.ioBase.readBytes {
+			result.IOReadBytes = io.readBytes - m.ioBase.readBytes
+		}
+
+		if io.writeBytes >= m.ioBase.writeBytes {
+			result.IOWriteBytes = io.writeBytes - m.ioBase.writeBytes
+		}
+	}
+
+	return result
+}
+
+func delta(current, base map[string]uint64, key string) uint64 {
+	if current[key] < base[key] {
+		return 0
+	}
+
+	return current[key] - base[key]
+}
+
+// findCgroupDir returns the cgroup v2 directory for the current process.
+// With the cgroup namespaces (the default for cgroup v2) the process cgroup
+// is the root of the mounted cgroup file system.
+func findCgroupDir(root, procCgroupPath string) (string, error) {
+	if _, err := os.Stat(filepath.Join(root, fileControllers)); err != nil {
+		return "", ErrNoCgroupV2
+	}
+
+	data, err := os.ReadFile(procCgroupPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		// The cgroup v2 (unified hierarchy) entry format: "0::/path"
+		if !strings.HasPrefix(line, "0::") {
+			continue
+		}
+
+		dir := filepath.Join(root, strings.TrimPrefix(line, "0::"))
+		if _, err := os.Stat(filepath.Join(dir, fileControllers)); err == nil {
+			return dir, nil
+		}
+
+		// The host cgroup path is not visible in the container mount
+		return root, nil
+	}
+
+	return "", ErrNoCgroupV2
+}
+
+// readUint reads a single value file (the "max" value is reported as 0)
+func readUint(name string) (uint64, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// readKeyValues reads a flat keyed file (e.g., cpu.stat)
+func readKeyValues(name string) (map[string]uint64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			result[fields[0]] = value
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+type ioStat struct {
+	readBytes  uint64
+	writeBytes uint64
+}
+
+// readIOStat reads the io.stat file summing up the bytes for all devices
+// (the line format: "8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=5 dios=6")
+func readIOStat(name string) (ioStat, error) {
+	var result ioStat
+
+	f, err := os.Open(name)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return result, fmt.Errorf("malformed io.stat field %q: %w", field, err)
+			}
+
+			switch key {
+			case "rbytes":
+				result.readBytes += n
+			case "wbytes":
+				result.writeBytes += n
+			}
+		}
+	}
+
+	return result, scanner.Err()
+}
@@ -0,0 +1,97 @@
+package cgroup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMonitorReport(t *testing.T) {
+	root := t.TempDir()
+	procCgroup := filepath.Join(t.TempDir(), "cgroup")
+	writeFiles(t, root, map[string]string{
+		fileControllers:   "cpu io memory pids\n",
+		fileMemoryCurrent: "1000\n",
+		fileMemoryMax:     "max\n",
+		fileCPUStat:       "usage_usec 100\nuser_usec 60\nsystem_usec 40\n",
+		fileIOStat:        "8:0 rbytes=10 wbytes=20 rios=1 wios=2\n",
+		filePidsCurrent:   "2\n",
+		filePidsMax:       "100\n",
+	})
+
+	// The host cgroup path is not visible in the container mount.
+	if err := os.WriteFile(procCgroup, []byte("0::/system.slice/docker-1234.scope\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mon := NewMonitor(context.Background())
+	mon.root = root
+	mon.procCgroupPath = procCgroup
+
+	if mon.Report() != nil {
+		t.Fatal("expected no report before start")
+	}
+
+	if err := mon.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	writeFiles(t, root, map[string]string{
+		fileMemoryCurrent: "500\n",
+		fileCPUStat:       "usage_usec 1100\nuser_usec 660\nsystem_usec 440\n",
+		fileIOStat:        "8:0 rbytes=110 wbytes=20 rios=2 wios=2\n8:16 rbytes=5 wbytes=7\n",
+		filePidsCurrent:   "5\n",
+		filePidsPeak:      "7\n",
+	})
+
+	mon.Stop()
+	mon.Stop()
+
+	r := mon.Report()
+	if r == nil {
+		t.Fatal("expected report")
+	}
+
+	if r.CgroupPath != root {
+		t.Errorf("unexpected cgroup path: %s", r.CgroupPath)
+	}
+
+	if r.MemoryPeakBytes != 1000 || r.MemoryLimitBytes != 0 {
+		t.Errorf("unexpected memory usage: peak=%d limit=%d", r.MemoryPeakBytes, r.MemoryLimitBytes)
+	}
+
+	if r.CPUUsageUsec != 1000 || r.CPUUserUsec != 600 || r.CPUSystemUsec != 400 {
+		t.Errorf("unexpected cpu usage: %+v", r)
+	}
+
+	if r.IOReadBytes != 105 || r.IOWriteBytes != 7 {
+		t.Errorf("unexpected io usage: read=%d write=%d", r.IOReadBytes, r.IOWriteBytes)
+	}
+
+	if r.PidsPeak != 7 || r.PidsLimit != 100 {
+		t.Errorf("unexpected pids usage: peak=%d limit=%d", r.PidsPeak, r.PidsLimit)
+	}
+}
+
+func TestMonitorNoCgroupV2(t *testing.T) {
+	mon := NewMonitor(context.Background())
+	mon.root = t.TempDir()
+
+	if err := mon.Start(); err != ErrNoCgroupV2 {
+		t.Fatalf("expected ErrNoCgroupV2, got: %v", err)
+	}
+
+	mon.Stop()
+	if mon.Report() != nil {
+		t.Fatal("expected no report")
+	}
+}
@@ -7,15 +7,19 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/metrics"
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/cgroup"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/fanotify"
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/monitors/ptrace"
 	"github.com/docker-slim/docker-slim/pkg/ipc/command"
+	"github.com/docker-slim/docker-slim/pkg/ipc/event"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 )
@@ -24,6 +28,8 @@ const (
 	errorChanBufSize   = 100
 	errorChanDrainTime = 200 * time.Millisecond
 
+	activityChanBufSize = 1000
+
 	// Some monitors are passive. If the driving monitor
 	// is too fast, the passive ones may not have a chance
 	// to track all the needed events (used to happen often
@@ -39,6 +45,8 @@ type CompositeReport struct {
 	PeReport  *report.PeMonitorReport
 	FanReport *report.FanMonitorReport
 	PtReport  *report.PtMonitorReport
+	// Optional (nil if the cgroup v2 resource accounting is not available)
+	ResourceReport *report.ResourceUsageReport
 }
 
 type CompositeMonitor interface {
@@ -65,6 +73,23 @@ type CompositeMonitor interface {
 	// is done.
 	DrainErrors() []error
 
+	// Activity() streams the new file access and process records observed
+	// by the sub-monitors (only if StartMonitor.StreamEvents is set;
+	// otherwise the channel is nil). The method is reentrant.
+	// The records are best effort - they are dropped if the channel is full.
+	Activity() <-chan fanotify.Activity
+
+	// Live syscall counters (from the ptrace monitor).
+	LiveStats() ptrace.LiveStats
+
+	// Mid-session control (not reentrant - used from the sensor's main loop):
+	// MarkPhase() tags the subsequent file events with the phase name,
+	// Snapshot() returns the current partial report without stopping the monitors
+	// and ResetCounters() restarts the event and syscall counters.
+	MarkPhase(name string)
+	Snapshot() *event.SnapshotInfo
+	ResetCounters()
+
 	// TODO: Consider adding the Files() method for the incremental
 	//       report storing.
 	// Files() <-chan *ArtifactProp
@@ -85,6 +110,7 @@ type monitor struct {
 	// peMon  *pevent.Monitor
 	fanMon fanotify.Monitor
 	ptMon  ptrace.Monitor
+	resMon *cgroup.Monitor
 
 	// Inspired by os/exec.Cmd
 	closeAfterDone []io.Closer
@@ -93,6 +119,11 @@ type monitor struct {
 	doneOnce sync.Once
 
 	errorCh chan error
+
+	activityCh chan fanotify.Activity
+
+	phase     string
+	statsBase ptrace.LiveStats
 }
 
 type NewCompositeMonitorFunc func(
@@ -118,12 +149,19 @@ func NewCompositeMonitor(
 
 	errorCh := make(chan error, errorChanBufSize)
 
+	var activityCh chan fanotify.Activity
+	if cmd.StreamEvents {
+		activityCh = make(chan fanotify.Activity, activityChanBufSize)
+	}
+
 	fanMon := fanotify.NewMonitor(
 		ctx,
 		mountPoint,
 		cmd.IncludeNew,
 		origPaths,
 		errorCh,
+		activityCh,
+		cmd.Phase,
 	)
 
 	var closeAfterDone []io.Closer
@@ -149,27 +187,43 @@ func NewCompositeMonitor(
 		appStderr = sink
 	}
 
-	ptMon := ptrace.NewMonitor(
-		ctx,
-		ptrace.AppRunOpt{
-			Cmd:                 cmd.AppName,
-			Args:                cmd.AppArgs,
-			AppStdout:           appStdout,
-			AppStderr:           appStderr,
-			WorkDir:             workDir,
-			User:                cmd.AppUser,
-			RunAsUser:           cmd.RunTargetAsUser,
-			RTASourcePT:         cmd.RTASourcePT,
-			ReportOnMainPidExit: cmd.ReportOnMainPidExit,
-		},
-		cmd.IncludeNew,
-		origPaths,
-		signalChan,
-		errorCh,
-	)
+	var ptMon ptrace.Monitor
+	if cmd.AttachPid > 0 {
+		// Attaching to an already running app (e.g., a Kubernetes pod)
+		// instead of starting it.
+		ptMon = ptrace.NewAttachedMonitor(
+			ctx,
+			cmd.AttachPid,
+			cmd.IncludeNew,
+			origPaths,
+			errorCh,
+		)
+	} else {
+		ptMon = ptrace.NewMonitor(
+			ctx,
+			ptrace.AppRunOpt{
+				Cmd:                 cmd.AppName,
+				Args:                cmd.AppArgs,
+				AppStdout:           appStdout,
+				AppStderr:           appStderr,
+				WorkDir:             workDir,
+				User:                cmd.AppUser,
+				RunAsUser:           cmd.RunTargetAsUser,
+				RTASourcePT:         cmd.RTASourcePT,
+				ReportOnMainPidExit: cmd.ReportOnMainPidExit,
+				RecordFileRanges:    cmd.RecordFileRanges,
+			},
+			cmd.IncludeNew,
+			origPaths,
+			signalChan,
+			errorCh,
+		)
+	}
 
 	m := Compose(cmd, fanMon, ptMon, errorCh)
 	m.closeAfterDone = closeAfterDone
+	m.activityCh = activityCh
+	m.resMon = cgroup.NewMonitor(ctx)
 
 	return m, nil
 }
@@ -188,6 +242,8 @@ func Compose(
 		ptMon:  ptMon,
 
 		errorCh: errorCh,
+
+		phase: cmd.Phase,
 	}
 }
 
@@ -221,7 +277,18 @@ func (m *monitor) Start() error {
 		return err
 	}
 
+	// The resource usage reporting is best effort.
+	if m.resMon != nil {
+		if err := m.resMon.Start(); err != nil {
+			log.WithError(err).Info("sensor: composite monitor - resource usage is not available")
+			m.resMon = nil
+		}
+	}
+
 	m.startedAt = time.Now()
+	metrics.SetSyscallSource(func() uint64 {
+		return m.ptMon.LiveStats().SyscallCount
+	})
 
 	return nil
 }
@@ -248,6 +315,11 @@ func (m *monitor) Done() <-chan struct{} {
 			<-m.ptMon.Done()
 			log.Debug("sensor: composite monitor - ptmon is done")
 
+			// The app is done, so its resource usage is final.
+			if m.resMon != nil {
+				m.resMon.Stop()
+			}
+
 			// This code smells... If the ptrace monitor finished too quickly,
 			// we have to give the other monitors more time to increase their
 			// chances to track the needed events. But we only do this if the
@@ -292,6 +364,85 @@ func (m *monitor) DrainErrors() (errors []error) {
 	}
 }
 
+func (m *monitor) Activity() <-chan fanotify.Activity {
+	return m.activityCh
+}
+
+func (m *monitor) LiveStats() ptrace.LiveStats {
+	stats := m.ptMon.LiveStats()
+	if stats.SyscallCount >= m.statsBase.SyscallCount {
+		stats.SyscallCount -= m.statsBase.SyscallCount
+	}
+
+	return stats
+}
+
+func (m *monitor) MarkPhase(name string) {
+	log.Infof("sensor: composite monitor - phase '%s'", name)
+	m.phase = name
+	m.fanMon.MarkPhase(name)
+}
+
+func (m *monitor) Snapshot() *event.SnapshotInfo {
+	info := &event.SnapshotInfo{
+		Phase:        m.phase,
+		SyscallCount: m.LiveStats().SyscallCount,
+		Files:        []event.SnapshotFileInfo{},
+	}
+
+	fanReport := m.fanMon.Snapshot()
+	if fanReport == nil {
+		return info
+	}
+
+	info.EventCount = fanReport.EventCount
+	info.Processes = len(fanReport.Processes)
+
+	files := map[string]*event.SnapshotFileInfo{}
+	for _, processFiles := range fanReport.ProcessFiles {
+		for name, finfo := range processFiles {
+			sfinfo, ok := files[name]
+			if !ok {
+				sfinfo = &event.SnapshotFileInfo{Path: name}
+				files[name] = sfinfo
+			}
+
+			sfinfo.EventCount += finfo.EventCount
+			for _, phase := range finfo.Phases {
+				if !hasString(sfinfo.Phases, phase) {
+					sfinfo.Phases = append(sfinfo.Phases, phase)
+				}
+			}
+		}
+	}
+
+	for _, sfinfo := range files {
+		info.Files = append(info.Files, *sfinfo)
+	}
+
+	sort.Slice(info.Files, func(i, j int) bool {
+		return info.Files[i].Path < info.Files[j].Path
+	})
+
+	return info
+}
+
+func (m *monitor) ResetCounters() {
+	log.Info("sensor: composite monitor - resetting counters")
+	m.statsBase = m.ptMon.LiveStats()
+	m.fanMon.ResetCounters()
+}
+
+func hasString(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (m *monitor) Status() (*CompositeReport, error) {
 	// peReport, peErr := m.peMon.Status()
 	fanReport, fanErr := m.fanMon.Status()
@@ -304,10 +455,13 @@ func (m *monitor) Status() (*CompositeReport, error) {
 		)
 	}
 
+	recoverLostFiles(fanReport, ptReport)
+
 	return &CompositeReport{
 		// PeReport: peReport,
-		FanReport: fanReport,
-		PtReport:  ptReport,
+		FanReport:      fanReport,
+		PtReport:       ptReport,
+		ResourceReport: m.resMon.Report(),
 	}, nil
 }
 
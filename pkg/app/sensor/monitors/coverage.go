@@ -0,0 +1,80 @@
+package monitors
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// The ptrace file activity timestamps are the event processing times
+// (not the actual syscall times), so the lost coverage ranges are extended.
+const lostCoverageSlack = time.Second
+
+// recoverLostFiles adds the files the ptrace monitor observed during
+// the fanotify lost coverage time ranges (and missing in the fanotify report)
+// to the fanotify report. The recovered file list is saved in the report too.
+func recoverLostFiles(fanReport *report.FanMonitorReport, ptReport *report.PtMonitorReport) {
+	if fanReport == nil || len(fanReport.LostCoverage) == 0 ||
+		ptReport == nil || len(ptReport.FSActivity) == 0 {
+		return
+	}
+
+	known := map[string]struct{}{}
+	for _, files := range fanReport.ProcessFiles {
+		for name := range files {
+			known[name] = struct{}{}
+		}
+	}
+
+	var recovered []string
+	for name, fsa := range ptReport.FSActivity {
+		if _, ok := known[name]; ok || fsa.IsSubdir || fsa.FirstOpTime.IsZero() {
+			continue
+		}
+
+		opRange := report.TimeRange{From: fsa.FirstOpTime, To: fsa.LastOpTime}
+		for _, lost := range fanReport.LostCoverage {
+			if lost.Overlaps(opRange, lostCoverageSlack) {
+				addRecoveredFile(fanReport, name, fsa)
+				recovered = append(recovered, name)
+				break
+			}
+		}
+	}
+
+	if len(recovered) == 0 {
+		return
+	}
+
+	sort.Strings(recovered)
+	log.Infof("sensor: composite monitor - recovered %d file(s) from the ptrace data (lost fanotify coverage)", len(recovered))
+	fanReport.RecoveredFiles = append(fanReport.RecoveredFiles, recovered...)
+}
+
+func addRecoveredFile(fanReport *report.FanMonitorReport, name string, fsa *report.FSActivityInfo) {
+	// Using the lowest pid to keep the report stable.
+	pid := -1
+	for p := range fsa.Pids {
+		if pid == -1 || p < pid {
+			pid = p
+		}
+	}
+
+	key := strconv.Itoa(pid)
+	if fanReport.ProcessFiles == nil {
+		fanReport.ProcessFiles = map[string]map[string]*report.FileInfo{}
+	}
+
+	if _, ok := fanReport.ProcessFiles[key]; !ok {
+		fanReport.ProcessFiles[key] = map[string]*report.FileInfo{}
+	}
+
+	fanReport.ProcessFiles[key][name] = &report.FileInfo{
+		EventCount: uint32(fsa.OpsAll),
+		Name:       name,
+	}
+}
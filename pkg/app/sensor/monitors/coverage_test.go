@@ -0,0 +1,64 @@
+package monitors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+func TestRecoverLostFiles(t *testing.T) {
+	start := time.Now()
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	fanReport := &report.FanMonitorReport{
+		ProcessFiles: map[string]map[string]*report.FileInfo{
+			"1": {"/app/known": &report.FileInfo{Name: "/app/known", EventCount: 1}},
+		},
+		OverflowCount: 1,
+		LostCoverage:  []report.TimeRange{{From: at(10), To: at(20)}},
+	}
+
+	fsa := func(pid, from, to int) *report.FSActivityInfo {
+		return &report.FSActivityInfo{
+			OpsAll:      1,
+			Pids:        map[int]struct{}{pid: {}},
+			FirstOpTime: at(from),
+			LastOpTime:  at(to),
+		}
+	}
+
+	ptReport := &report.PtMonitorReport{
+		Enabled: true,
+		FSActivity: map[string]*report.FSActivityInfo{
+			"/app/known":  fsa(1, 12, 12),
+			"/app/lost":   fsa(2, 15, 15),
+			"/app/edge":   fsa(1, 0, 20),
+			"/app/before": fsa(1, 1, 5),
+			"/app/after":  fsa(1, 30, 40),
+		},
+	}
+
+	recoverLostFiles(fanReport, ptReport)
+
+	expected := []string{"/app/edge", "/app/lost"}
+	if len(fanReport.RecoveredFiles) != len(expected) {
+		t.Fatalf("unexpected recovered files: %v", fanReport.RecoveredFiles)
+	}
+
+	for i, name := range expected {
+		if fanReport.RecoveredFiles[i] != name {
+			t.Errorf("unexpected recovered files: %v", fanReport.RecoveredFiles)
+		}
+	}
+
+	if _, ok := fanReport.ProcessFiles["2"]["/app/lost"]; !ok {
+		t.Errorf("recovered file is missing in the process files: %v", fanReport.ProcessFiles)
+	}
+
+	// The recovery is idempotent.
+	recoverLostFiles(fanReport, ptReport)
+	if len(fanReport.RecoveredFiles) != len(expected) {
+		t.Errorf("unexpected recovered files after the second pass: %v", fanReport.RecoveredFiles)
+	}
+}
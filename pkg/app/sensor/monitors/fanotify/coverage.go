@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+package fanotify
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// coverageTracker tracks the lost file events: the queue overflows
+// (the kernel drops the events when the app generates them faster
+// than the sensor reads them) and the event read/resolve errors.
+// It's updated by the event collector and it's copied to the report
+// by the event processor (hence, the lock).
+type coverageTracker struct {
+	mu sync.Mutex
+
+	lastEventAt      time.Time
+	lastOverflowAt   time.Time
+	overflows        uint32
+	errors           uint32
+	permissionErrors uint32
+	lost             []report.TimeRange
+}
+
+func newCoverageTracker() *coverageTracker {
+	now := time.Now()
+	return &coverageTracker{lastEventAt: now, lastOverflowAt: now}
+}
+
+// event records the time of the last successfully received event
+func (t *coverageTracker) event(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastEventAt = at
+}
+
+// overflow records a queue overflow and returns the overflow count.
+// The kernel drops the events while the queue is full, so the lost events
+// happened before the (already queued) events received just before
+// the overflow notification. The event receive times don't tell when
+// the queue backed up, so the lost time range starts at the previous
+// overflow (or at the monitor start).
+func (t *coverageTracker) overflow(at time.Time) uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.overflows++
+	t.addLost(report.TimeRange{From: t.lastOverflowAt, To: at})
+	t.lastOverflowAt = at
+	return t.overflows
+}
+
+// failure records an event read or resolve error
+func (t *coverageTracker) failure(at time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.errors++
+	if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) {
+		t.permissionErrors++
+	}
+
+	t.addLost(report.TimeRange{From: t.lastEventAt, To: at})
+}
+
+// addLost adds the time range merging it with the last one if they overlap
+func (t *coverageTracker) addLost(r report.TimeRange) {
+	if n := len(t.lost); n > 0 && t.lost[n-1].Overlaps(r, 0) {
+		if r.To.After(t.lost[n-1].To) {
+			t.lost[n-1].To = r.To
+		}
+
+		return
+	}
+
+	t.lost = append(t.lost, r)
+}
+
+func (t *coverageTracker) apply(fanReport *report.FanMonitorReport) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fanReport.OverflowCount = t.overflows
+	fanReport.ErrorCount = t.errors
+	fanReport.PermissionErrorCount = t.permissionErrors
+	fanReport.LostCoverage = append([]report.TimeRange(nil), t.lost...)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package fanotify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+func TestCoverageTrackerOverflow(t *testing.T) {
+	start := time.Now()
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	tracker := &coverageTracker{lastEventAt: start, lastOverflowAt: start}
+
+	// The queue backs up at 5s and the kernel drops an open at 10s,
+	// but the queued events are received (drained) until 20s,
+	// so the dropped open happened before the last received event.
+	tracker.event(at(1))
+	tracker.event(at(20))
+	if count := tracker.overflow(at(21)); count != 1 {
+		t.Fatalf("unexpected overflow count: %v", count)
+	}
+
+	// The next overflow loses the events after the previous one only.
+	tracker.event(at(40))
+	tracker.overflow(at(50))
+
+	var fanReport report.FanMonitorReport
+	tracker.apply(&fanReport)
+
+	if fanReport.OverflowCount != 2 {
+		t.Errorf("unexpected overflow count in the report: %v", fanReport.OverflowCount)
+	}
+
+	dropped := report.TimeRange{From: at(10), To: at(10)}
+	var covered bool
+	for _, lost := range fanReport.LostCoverage {
+		if lost.Overlaps(dropped, 0) {
+			covered = true
+		}
+	}
+
+	if !covered {
+		t.Errorf("the dropped event time is not in the lost coverage: %+v", fanReport.LostCoverage)
+	}
+
+	if len(fanReport.LostCoverage) != 1 ||
+		!fanReport.LostCoverage[0].From.Equal(start) ||
+		!fanReport.LostCoverage[0].To.Equal(at(50)) {
+		t.Errorf("unexpected lost coverage: %+v", fanReport.LostCoverage)
+	}
+}
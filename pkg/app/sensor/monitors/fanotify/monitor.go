@@ -10,10 +10,13 @@ import (
 	"io/ioutil"
 	"os"
 	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/metrics"
 	"github.com/docker-slim/docker-slim/pkg/errors"
+	"github.com/docker-slim/docker-slim/pkg/ipc/event"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	fanapi "github.com/docker-slim/docker-slim/pkg/third_party/madmo/fanotify"
 )
@@ -23,8 +26,11 @@ const (
 	eventBufSize   = 1000
 	procFsFdInfo   = "/proc/self/fd/%d"
 	procFsFilePath = "/proc/%v/%v"
+	nsScanInterval = 500 * time.Millisecond
 )
 
+const fanEventMask = fanapi.FAN_MODIFY | fanapi.FAN_ACCESS | fanapi.FAN_OPEN
+
 // Event is file operation event
 type Event struct {
 	ID      uint32
@@ -34,6 +40,13 @@ type Event struct {
 	IsWrite bool
 }
 
+// Activity is a monitored app activity record
+// (a new file access or a new process)
+type Activity struct {
+	File *event.FileAccessInfo
+	Exec *event.ProcessExecInfo
+}
+
 type Monitor interface {
 	// Starts the long running monitoring. The method itself is not
 	// blocking and not reentrant!
@@ -50,6 +63,16 @@ type Monitor interface {
 	Done() <-chan struct{}
 
 	Status() (*report.FanMonitorReport, error)
+
+	// Tags the subsequent file events with the phase name.
+	MarkPhase(name string)
+
+	// Returns a copy of the current (partial) report without stopping
+	// the monitor (or the final report if the monitor is done).
+	Snapshot() *report.FanMonitorReport
+
+	// Resets the event counters (the collected files are kept).
+	ResetCounters()
 }
 
 type status struct {
@@ -71,6 +94,16 @@ type monitor struct {
 	doneCh  chan struct{}
 	errorCh chan<- error
 
+	// optional (nil if the activity is not streamed)
+	activityCh chan<- Activity
+	seenFiles  map[string]struct{}
+
+	// the report control requests (executed by the event processor)
+	ctrlCh chan func(*report.FanMonitorReport)
+	phase  string
+
+	coverage *coverageTracker
+
 	logger *log.Entry
 }
 
@@ -80,6 +113,8 @@ func NewMonitor(
 	includeNew bool,
 	origPaths map[string]struct{},
 	errorCh chan<- error,
+	activityCh chan<- Activity,
+	phase string,
 ) Monitor {
 	logger := log.WithFields(log.Fields{
 		"app": "sensor",
@@ -95,9 +130,14 @@ func NewMonitor(
 		includeNew: includeNew,
 		origPaths:  origPaths,
 
-		doneCh:  make(chan struct{}),
-		errorCh: errorCh,
-		logger:  logger,
+		doneCh:     make(chan struct{}),
+		errorCh:    errorCh,
+		activityCh: activityCh,
+		seenFiles:  map[string]struct{}{},
+		ctrlCh:     make(chan func(*report.FanMonitorReport)),
+		phase:      phase,
+		coverage:   newCoverageTracker(),
+		logger:     logger,
 	}
 }
 
@@ -113,12 +153,37 @@ func (m *monitor) Start() error {
 
 	if err = nd.Mark(
 		fanapi.FAN_MARK_ADD|fanapi.FAN_MARK_MOUNT,
-		fanapi.FAN_MODIFY|fanapi.FAN_ACCESS|fanapi.FAN_OPEN,
+		fanEventMask,
 		-1, m.mountPoint,
 	); err != nil {
 		return errors.SE("sensor.fanotify.Run/nd.Mark", "call.error", err)
 	}
 
+	//the app processes can fork into their own mount namespaces
+	//(or chroot into a subdirectory), so these mounts need to be marked too
+	nsTracker := newNSTracker(func(path string) error {
+		return nd.Mark(fanapi.FAN_MARK_ADD|fanapi.FAN_MARK_MOUNT, fanEventMask, -1, path)
+	}, m.logger)
+
+	go func() {
+		logger := m.logger.WithField("op", "nswatcher")
+		logger.Debug("call")
+		defer logger.Debug("exit")
+
+		ticker := time.NewTicker(nsScanInterval)
+		defer ticker.Stop()
+
+		for {
+			nsTracker.scan()
+
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
 	// Sync part of the start was successful.
 	// Tracking the completetion of the monitor....
 
@@ -141,6 +206,9 @@ func (m *monitor) Start() error {
 				Processes:        map[string]*report.ProcessInfo{},
 			}
 
+			metrics.ReportFiles.Set(0)
+			metrics.ReportProcesses.Set(0)
+
 		process:
 			for {
 				select {
@@ -149,7 +217,11 @@ func (m *monitor) Start() error {
 					break process
 
 				case e := <-eventCh:
+					metrics.FanQueueDepth.Set(int64(len(eventCh)))
 					m.processEvent(e, fanReport)
+
+				case fn := <-m.ctrlCh:
+					fn(fanReport)
 				}
 			}
 
@@ -167,6 +239,13 @@ func (m *monitor) Start() error {
 				}
 			}
 
+			fanReport.MountNamespaces = nsTracker.report()
+			m.coverage.apply(fanReport)
+			if fanReport.HasLostCoverage() {
+				logger.Warnf("coverage may be incomplete (overflows=%d errors=%d)",
+					fanReport.OverflowCount, fanReport.ErrorCount)
+			}
+
 			logger.Debugf("sending report (processed %v events)...", fanReport.EventCount)
 			m.status.report = fanReport
 			close(m.doneCh)
@@ -184,13 +263,20 @@ func (m *monitor) Start() error {
 			//TODO: enhance FA Notify to return the original file handle too
 			data, err := nd.GetEvent()
 			if err != nil {
+				metrics.FanErrors.Inc()
+				m.coverage.failure(time.Now(), err)
 				m.errorCh <- errors.SE("sensor.fanotify.Run/nd.GetEvent", "call.error", err)
 				continue
 			}
 			logger.Debugf("data.Mask => %x", data.Mask)
+			metrics.FanEvents.Inc()
 
 			if (data.Mask & fanapi.FAN_Q_OVERFLOW) == fanapi.FAN_Q_OVERFLOW {
 				logger.Debug("overflow event")
+				metrics.FanDroppedEvents.Inc()
+				if m.coverage.overflow(time.Now()) == 1 {
+					logger.Warn("event queue overflow - some file events are lost")
+				}
 				continue
 			}
 
@@ -219,19 +305,24 @@ func (m *monitor) Start() error {
 			// Probably, the fanotify events should be read as quick as just possible.
 			path, err := os.Readlink(fmt.Sprintf(procFsFdInfo, data.File.Fd()))
 			if err != nil {
+				metrics.FanErrors.Inc()
+				m.coverage.failure(time.Now(), err)
 				m.errorCh <- errors.SE("sensor.fanotify.Run/os.Readlink", "call.error", err)
 				continue
 			}
 
+			path = nsTracker.imagePath(int(data.Pid), path, data.File)
 			logger.Debugf("file path => %v", path)
 
 			data.File.Close()
+			m.coverage.event(time.Now())
 			if doNotify {
 				eventID++
 				e := Event{ID: eventID, Pid: data.Pid, File: path, IsRead: isRead, IsWrite: isWrite}
 
 				select {
 				case eventCh <- e:
+					metrics.FanQueueDepth.Set(int64(len(eventCh)))
 				case <-m.ctx.Done():
 					logger.Info("stopping....")
 					return
@@ -255,6 +346,71 @@ func (m *monitor) Status() (*report.FanMonitorReport, error) {
 	return m.status.report, m.status.err
 }
 
+// control executes the report control function in the event processor
+// (or with the final report if the monitor is already done)
+func (m *monitor) control(fn func(*report.FanMonitorReport)) {
+	select {
+	case m.ctrlCh <- fn:
+	case <-m.doneCh:
+		if m.status.report != nil {
+			fn(m.status.report)
+		}
+	}
+}
+
+func (m *monitor) MarkPhase(name string) {
+	m.control(func(*report.FanMonitorReport) {
+		m.phase = name
+		// The files are streamed again (once) in the new phase.
+		m.seenFiles = map[string]struct{}{}
+	})
+}
+
+func (m *monitor) Snapshot() *report.FanMonitorReport {
+	var snapshot *report.FanMonitorReport
+	m.control(func(fanReport *report.FanMonitorReport) {
+		snapshot = copyReport(fanReport)
+		m.coverage.apply(snapshot)
+	})
+
+	return snapshot
+}
+
+func (m *monitor) ResetCounters() {
+	m.control(func(fanReport *report.FanMonitorReport) {
+		fanReport.EventCount = 0
+		m.seenFiles = map[string]struct{}{}
+	})
+}
+
+func copyReport(fanReport *report.FanMonitorReport) *report.FanMonitorReport {
+	snapshot := *fanReport
+	snapshot.Processes = make(map[string]*report.ProcessInfo, len(fanReport.Processes))
+	for pid, pinfo := range fanReport.Processes {
+		pcopy := *pinfo
+		snapshot.Processes[pid] = &pcopy
+	}
+
+	if fanReport.MainProcess != nil {
+		mainProcess := *fanReport.MainProcess
+		snapshot.MainProcess = &mainProcess
+	}
+
+	snapshot.ProcessFiles = make(map[string]map[string]*report.FileInfo, len(fanReport.ProcessFiles))
+	for pid, files := range fanReport.ProcessFiles {
+		fcopies := make(map[string]*report.FileInfo, len(files))
+		for name, finfo := range files {
+			fcopy := *finfo
+			fcopy.Phases = append([]string(nil), finfo.Phases...)
+			fcopies[name] = &fcopy
+		}
+
+		snapshot.ProcessFiles[pid] = fcopies
+	}
+
+	return &snapshot
+}
+
 func (m *monitor) processEvent(e Event, fanReport *report.FanMonitorReport) {
 	fanReport.EventCount++
 	logger := m.logger.WithField("op", "processEvent")
@@ -269,6 +425,8 @@ func (m *monitor) processEvent(e Event, fanReport *report.FanMonitorReport) {
 		if pinfo, err := getProcessInfo(e.Pid); (err == nil) && (pinfo != nil) {
 			fanReport.MainProcess = pinfo
 			fanReport.Processes[strconv.Itoa(int(e.Pid))] = pinfo
+			metrics.ReportProcesses.Set(int64(len(fanReport.Processes)))
+			m.pubProcessActivity(pinfo)
 		}
 	} else {
 		if _, ok := fanReport.Processes[strconv.Itoa(int(e.Pid))]; !ok {
@@ -277,10 +435,24 @@ func (m *monitor) processEvent(e Event, fanReport *report.FanMonitorReport) {
 				// But if we consider this probability as too low to care about,
 				// then we should probably start caching getProcessInfo() calls :)
 				fanReport.Processes[strconv.Itoa(int(e.Pid))] = pinfo
+				metrics.ReportProcesses.Set(int64(len(fanReport.Processes)))
+				m.pubProcessActivity(pinfo)
 			}
 		}
 	}
 
+	if _, ok := m.seenFiles[e.File]; !ok {
+		m.seenFiles[e.File] = struct{}{}
+		m.pubActivity(Activity{
+			File: &event.FileAccessInfo{
+				Pid:     e.Pid,
+				Path:    e.File,
+				IsWrite: e.IsWrite,
+				Phase:   m.phase,
+			},
+		})
+	}
+
 	if _, ok := fanReport.ProcessFiles[strconv.Itoa(int(e.Pid))]; !ok {
 		fanReport.ProcessFiles[strconv.Itoa(int(e.Pid))] = map[string]*report.FileInfo{}
 	}
@@ -292,6 +464,10 @@ func (m *monitor) processEvent(e Event, fanReport *report.FanMonitorReport) {
 			FirstEventID: e.ID,
 		}
 
+		if m.phase != "" {
+			fi.Phases = []string{m.phase}
+		}
+
 		if e.IsRead {
 			fi.ReadCount = 1
 		}
@@ -305,9 +481,14 @@ func (m *monitor) processEvent(e Event, fanReport *report.FanMonitorReport) {
 		}
 
 		fanReport.ProcessFiles[strconv.Itoa(int(e.Pid))][e.File] = fi
+		metrics.ReportFiles.Add(1)
 	} else {
 		existingFi.EventCount++
 
+		if m.phase != "" && !hasPhase(existingFi.Phases, m.phase) {
+			existingFi.Phases = append(existingFi.Phases, m.phase)
+		}
+
 		if e.IsRead {
 			existingFi.ReadCount++
 		}
@@ -322,6 +503,42 @@ func (m *monitor) processEvent(e Event, fanReport *report.FanMonitorReport) {
 	}
 }
 
+func hasPhase(phases []string, name string) bool {
+	for _, phase := range phases {
+		if phase == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *monitor) pubProcessActivity(pinfo *report.ProcessInfo) {
+	m.pubActivity(Activity{
+		Exec: &event.ProcessExecInfo{
+			Pid:       pinfo.Pid,
+			ParentPid: pinfo.ParentPid,
+			Path:      pinfo.Path,
+			Cmd:       pinfo.Cmd,
+		},
+	})
+}
+
+// pubActivity never blocks the event processing
+// (the activity records are dropped if the consumer is too slow)
+func (m *monitor) pubActivity(a Activity) {
+	if m.activityCh == nil {
+		return
+	}
+
+	select {
+	case m.activityCh <- a:
+	default:
+		metrics.ActivityDropped.Inc()
+		m.logger.Trace("activity channel is full - dropping record")
+	}
+}
+
 func procFilePath(pid int, key string) string {
 	return fmt.Sprintf(procFsFilePath, pid, key)
 }
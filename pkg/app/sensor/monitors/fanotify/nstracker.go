@@ -0,0 +1,252 @@
+//go:build linux
+// +build linux
+
+package fanotify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+type markFunc func(path string) error
+
+// nsInfoTTL is the max time the process namespace info is reused
+// without checking it again (the processes can chroot or call setns at any time)
+const nsInfoTTL = 1 * time.Second
+
+// nsTracker keeps track of the mount namespaces and chroot-ed roots
+// used by the monitored processes. A single FAN_MARK_MOUNT mark
+// on the sensor's mount point doesn't cover the mount copies created
+// by unshare(CLONE_NEWNS) or the mounts used only by the chroot-ed
+// processes, so every new namespace/root gets its own mark.
+type nsTracker struct {
+	mu sync.Mutex
+
+	selfPid int
+	selfNS  string
+
+	mark markFunc
+
+	// key is "<mount_ns>:<root>"
+	namespaces map[string]*report.MountNamespaceInfo
+	pids       map[int]*trackedPid
+
+	nsInfo func(pid int) (*system.ProcessNSInfo, error)
+	now    func() time.Time
+
+	logger *log.Entry
+}
+
+func newNSTracker(mark markFunc, logger *log.Entry) *nsTracker {
+	selfPid := os.Getpid()
+	selfNS, err := system.MountNamespace(selfPid)
+	if err != nil {
+		logger.Debugf("nsTracker: can't get own mount namespace - %v", err)
+	}
+
+	return &nsTracker{
+		selfPid:    selfPid,
+		selfNS:     selfNS,
+		mark:       mark,
+		namespaces: map[string]*report.MountNamespaceInfo{},
+		pids:       map[int]*trackedPid{},
+		nsInfo:     system.GetProcessNSInfo,
+		now:        time.Now,
+		logger:     logger.WithField("op", "nsTracker"),
+	}
+}
+
+type trackedPid struct {
+	info      *system.ProcessNSInfo
+	checkedAt time.Time
+}
+
+func nsKey(info *system.ProcessNSInfo) string {
+	return fmt.Sprintf("%s:%s", info.MountNS, info.Root)
+}
+
+func (t *nsTracker) isForeign(info *system.ProcessNSInfo) bool {
+	return t.selfNS != "" && info.MountNS != t.selfNS
+}
+
+// track registers the process and returns its namespace info.
+// New namespaces (or roots) are marked right away.
+// The cached process info is checked again when it's older than nsInfoTTL
+// (to catch the chroot/setns calls and the reused pids).
+func (t *nsTracker) track(pid int) *system.ProcessNSInfo {
+	return t.trackWithTTL(pid, nsInfoTTL)
+}
+
+func (t *nsTracker) trackWithTTL(pid int, ttl time.Duration) *system.ProcessNSInfo {
+	if pid == t.selfPid {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if tracked, ok := t.pids[pid]; ok && now.Sub(tracked.checkedAt) < ttl {
+		return tracked.info
+	}
+
+	info, err := t.nsInfo(pid)
+	if err != nil {
+		//the process might be gone already
+		delete(t.pids, pid)
+		return nil
+	}
+
+	if tracked, ok := t.pids[pid]; ok && nsKey(tracked.info) != nsKey(info) {
+		t.logger.Debugf("process namespace/root changed - pid=%d (%s -> %s)", pid, nsKey(tracked.info), nsKey(info))
+	}
+
+	t.pids[pid] = &trackedPid{info: info, checkedAt: now}
+
+	if !t.isForeign(info) && !info.IsChroot() {
+		return info
+	}
+
+	key := nsKey(info)
+	if _, ok := t.namespaces[key]; ok {
+		return info
+	}
+
+	nsInfo := &report.MountNamespaceInfo{
+		MountNS:  info.MountNS,
+		Root:     info.Root,
+		FirstPid: int32(pid),
+	}
+
+	if t.mark != nil {
+		if err := t.mark(system.RootPath(pid)); err != nil {
+			t.logger.Debugf("error marking mount (pid=%d ns=%s root=%s) - %v", pid, info.MountNS, info.Root, err)
+			nsInfo.MarkError = err.Error()
+		} else {
+			nsInfo.IsMarked = true
+		}
+	}
+
+	t.logger.Debugf("new mount namespace/root - pid=%d ns=%s root=%s marked=%v",
+		pid, info.MountNS, info.Root, nsInfo.IsMarked)
+	t.namespaces[key] = nsInfo
+	return info
+}
+
+// scan enumerates the running processes to catch the namespace changes
+// before the processes in the new namespaces generate any file events.
+// The exited processes are removed from the tracked process list.
+func (t *nsTracker) scan() {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		t.logger.Debugf("scan: error reading /proc - %v", err)
+		return
+	}
+
+	running := map[int]struct{}{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		running[pid] = struct{}{}
+		//always checking the running processes again
+		t.trackWithTTL(pid, 0)
+	}
+
+	t.evict(running)
+}
+
+// evict removes the processes that are not running anymore
+func (t *nsTracker) evict(running map[int]struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for pid := range t.pids {
+		if _, ok := running[pid]; !ok {
+			delete(t.pids, pid)
+		}
+	}
+}
+
+// imagePath maps the event file path to the image root when the event
+// comes from a process in a different mount namespace. The file path
+// is resolved by the kernel relative to the root of the namespace where
+// the file is opened, so the file identity (dev/inode) is used
+// to pick the path that's valid in the sensor's namespace.
+func (t *nsTracker) imagePath(pid int, fpath string, file *os.File) string {
+	info := t.track(pid)
+	if info == nil || !t.isForeign(info) {
+		//d_path is relative to the sensor's root (chroot-ed paths are full paths already)
+		return fpath
+	}
+
+	var fst syscall.Stat_t
+	if err := syscall.Fstat(int(file.Fd()), &fst); err != nil {
+		return fpath
+	}
+
+	candidates := []string{fpath}
+	if info.IsChroot() {
+		candidates = append(candidates, filepath.Join(info.Root, fpath))
+	}
+
+	for idx, candidate := range candidates {
+		var cst syscall.Stat_t
+		if err := syscall.Stat(candidate, &cst); err != nil {
+			continue
+		}
+
+		if cst.Dev == fst.Dev && cst.Ino == fst.Ino {
+			if idx > 0 {
+				t.update(info, func(nsInfo *report.MountNamespaceInfo) { nsInfo.MappedFiles++ })
+			}
+
+			return candidate
+		}
+	}
+
+	t.update(info, func(nsInfo *report.MountNamespaceInfo) { nsInfo.UnmappedRefs++ })
+	return fpath
+}
+
+func (t *nsTracker) update(info *system.ProcessNSInfo, fn func(nsInfo *report.MountNamespaceInfo)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if nsInfo, ok := t.namespaces[nsKey(info)]; ok {
+		fn(nsInfo)
+	}
+}
+
+func (t *nsTracker) report() map[string]*report.MountNamespaceInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.namespaces) == 0 {
+		return nil
+	}
+
+	result := make(map[string]*report.MountNamespaceInfo, len(t.namespaces))
+	for k, v := range t.namespaces {
+		nsCopy := *v
+		result[k] = &nsCopy
+	}
+
+	return result
+}
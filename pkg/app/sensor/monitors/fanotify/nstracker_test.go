@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package fanotify
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+func TestNSTrackerRecheck(t *testing.T) {
+	now := time.Now()
+	current := map[int]*system.ProcessNSInfo{
+		100: {Pid: 100, MountNS: "mnt:[1]", Root: "/"},
+	}
+
+	var marked []string
+	tracker := newNSTracker(func(path string) error {
+		marked = append(marked, path)
+		return nil
+	}, log.WithField("test", "nstracker"))
+	tracker.selfNS = "mnt:[1]"
+	tracker.now = func() time.Time { return now }
+	tracker.nsInfo = func(pid int) (*system.ProcessNSInfo, error) {
+		if info, ok := current[pid]; ok {
+			infoCopy := *info
+			return &infoCopy, nil
+		}
+
+		return nil, errors.New("no such process")
+	}
+
+	if info := tracker.track(100); info == nil || info.IsChroot() {
+		t.Fatalf("unexpected process info: %+v", info)
+	}
+
+	//chroot after the first lookup
+	current[100].Root = "/chroot"
+	if info := tracker.track(100); info.Root != "/" {
+		t.Errorf("expected cached root, got %s", info.Root)
+	}
+
+	now = now.Add(nsInfoTTL)
+	if info := tracker.track(100); info.Root != "/chroot" {
+		t.Errorf("expected new root, got %s", info.Root)
+	}
+
+	if len(marked) != 1 || marked[0] != system.RootPath(100) {
+		t.Errorf("expected the chroot-ed root to be marked, got %v", marked)
+	}
+
+	if _, ok := tracker.report()["mnt:[1]:/chroot"]; !ok {
+		t.Errorf("expected new namespace/root in the report: %v", tracker.report())
+	}
+
+	//the process exited
+	delete(current, 100)
+	now = now.Add(nsInfoTTL)
+	if info := tracker.track(100); info != nil {
+		t.Errorf("expected no info for the exited process, got %+v", info)
+	}
+
+	if _, ok := tracker.pids[100]; ok {
+		t.Error("expected the exited process to be removed")
+	}
+}
+
+func TestNSTrackerEvict(t *testing.T) {
+	tracker := newNSTracker(nil, log.WithField("test", "nstracker"))
+	tracker.nsInfo = func(pid int) (*system.ProcessNSInfo, error) {
+		return &system.ProcessNSInfo{Pid: pid, MountNS: tracker.selfNS, Root: "/"}, nil
+	}
+
+	tracker.track(100)
+	tracker.track(200)
+	tracker.evict(map[int]struct{}{200: {}})
+
+	if _, ok := tracker.pids[100]; ok {
+		t.Error("expected pid 100 to be evicted")
+	}
+
+	if _, ok := tracker.pids[200]; !ok {
+		t.Error("expected pid 200 to be tracked")
+	}
+}
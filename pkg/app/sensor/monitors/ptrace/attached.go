@@ -0,0 +1,241 @@
+package ptrace
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+const (
+	attachedScanInterval = 1 * time.Second
+
+	procDeletedSuffix = " (deleted)"
+)
+
+// attachedMonitor is a passive replacement for the ptrace monitor
+// used when the sensor is attached to an already running app.
+// It's too late to trace the app syscalls from the start (and the app
+// is not a child of the sensor), so the monitor only keeps track
+// of the files used by the app processes (executables, mapped libraries
+// and open files) scanning /proc periodically. The files loaded before
+// the sensor started are not visible to the fanotify monitor.
+// The monitor is done when the main app process exits or when it's canceled.
+type attachedMonitor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	pid     int
+	selfPid int
+
+	includeNew bool
+	origPaths  map[string]struct{}
+
+	errorCh chan<- error
+
+	report *report.PtMonitorReport
+	status status
+	doneCh chan struct{}
+
+	logger *log.Entry
+}
+
+func NewAttachedMonitor(
+	ctx context.Context,
+	pid int,
+	includeNew bool,
+	origPaths map[string]struct{},
+	errorCh chan<- error,
+) Monitor {
+	logger := log.WithFields(log.Fields{
+		"app": "sensor",
+		"com": "ptmon.attached",
+	})
+
+	sysInfo := system.GetSystemInfo()
+	archName := system.MachineToArchName(sysInfo.Machine)
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &attachedMonitor{
+		ctx:    ctx,
+		cancel: cancel,
+
+		pid:     pid,
+		selfPid: os.Getpid(),
+
+		includeNew: includeNew,
+		origPaths:  origPaths,
+
+		errorCh: errorCh,
+
+		report: &report.PtMonitorReport{
+			Enabled:      true,
+			ArchName:     string(archName),
+			SyscallStats: map[string]report.SyscallStatInfo{},
+			FSActivity:   map[string]*report.FSActivityInfo{},
+		},
+
+		doneCh: make(chan struct{}),
+		logger: logger,
+	}
+}
+
+func (m *attachedMonitor) Start() error {
+	logger := m.logger.WithField("op", "sensor.pt.attached.Start")
+	logger.Info("call")
+	defer logger.Info("exit")
+
+	if !m.isAlive() {
+		return fmt.Errorf("ptmon: target app process (pid=%d) not found", m.pid)
+	}
+
+	m.scan()
+
+	go func() {
+		logger := m.logger.WithField("op", "sensor.pt.attached.scanner")
+		logger.Info("call")
+		defer logger.Info("exit")
+
+		ticker := time.NewTicker(attachedScanInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				logger.Debug("canceled")
+				m.scan()
+				m.status.report = m.report
+				close(m.doneCh)
+				return
+
+			case <-ticker.C:
+				if !m.isAlive() {
+					logger.Debugf("target app process (pid=%d) exited", m.pid)
+					m.status.report = m.report
+					close(m.doneCh)
+					return
+				}
+
+				m.scan()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (m *attachedMonitor) Cancel() {
+	m.cancel()
+}
+
+func (m *attachedMonitor) Done() <-chan struct{} {
+	return m.doneCh
+}
+
+// No syscall tracing in the attached mode.
+func (m *attachedMonitor) LiveStats() LiveStats {
+	return LiveStats{}
+}
+
+func (m *attachedMonitor) Status() (*report.PtMonitorReport, error) {
+	return m.status.report, m.status.err
+}
+
+func (m *attachedMonitor) isAlive() bool {
+	_, err := os.Stat(fmt.Sprintf("/proc/%d", m.pid))
+	return err == nil
+}
+
+func (m *attachedMonitor) scan() {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		m.logger.Debugf("scan: error reading /proc - %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == m.selfPid {
+			continue
+		}
+
+		m.scanProcess(pid)
+	}
+}
+
+func (m *attachedMonitor) scanProcess(pid int) {
+	if exePath, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		m.addFile(pid, exePath)
+	}
+
+	if mapsFile, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid)); err == nil {
+		scanner := bufio.NewScanner(mapsFile)
+		for scanner.Scan() {
+			//format: address perms offset dev inode pathname
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 6 {
+				continue
+			}
+
+			m.addFile(pid, strings.Join(fields[5:], " "))
+		}
+
+		mapsFile.Close()
+	}
+
+	fdDir := fmt.Sprintf("/proc/%d/fd", pid)
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return
+	}
+
+	for _, fd := range fds {
+		if fpath, err := os.Readlink(fmt.Sprintf("%s/%s", fdDir, fd.Name())); err == nil {
+			m.addFile(pid, fpath)
+		}
+	}
+}
+
+func (m *attachedMonitor) addFile(pid int, fpath string) {
+	if !strings.HasPrefix(fpath, "/") ||
+		strings.HasSuffix(fpath, procDeletedSuffix) ||
+		strings.HasPrefix(fpath, "/proc/") ||
+		strings.HasPrefix(fpath, "/sys/") ||
+		strings.HasPrefix(fpath, "/dev/") {
+		return
+	}
+
+	if _, ok := m.origPaths[fpath]; !ok && !m.includeNew {
+		return
+	}
+
+	now := time.Now()
+	fsa, ok := m.report.FSActivity[fpath]
+	if !ok {
+		fsa = &report.FSActivityInfo{
+			Syscalls:    map[int]struct{}{},
+			Pids:        map[int]struct{}{},
+			FirstOpTime: now,
+		}
+		m.report.FSActivity[fpath] = fsa
+	}
+
+	fsa.LastOpTime = now
+
+	if _, ok := fsa.Pids[pid]; !ok {
+		fsa.Pids[pid] = struct{}{}
+		fsa.OpsAll++
+	}
+}
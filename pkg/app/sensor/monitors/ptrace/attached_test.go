@@ -0,0 +1,91 @@
+package ptrace
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+func TestAttachedMonitorAddFile(t *testing.T) {
+	origPaths := map[string]struct{}{
+		"/usr/bin/app":           {},
+		"/lib/libc.so.6":         {},
+		"/etc/app/config.yaml":   {},
+		"/proc/self/status":      {},
+		"/dev/null":              {},
+		"/var/lib/app (deleted)": {},
+	}
+
+	tests := []struct {
+		name       string
+		includeNew bool
+		fpath      string
+		expected   bool
+	}{
+		{name: "executable", fpath: "/usr/bin/app", expected: true},
+		{name: "library", fpath: "/lib/libc.so.6", expected: true},
+		{name: "new file", fpath: "/tmp/app.pid"},
+		{name: "new file included", includeNew: true, fpath: "/tmp/app.pid", expected: true},
+		{name: "proc file", fpath: "/proc/self/status"},
+		{name: "dev file", fpath: "/dev/null"},
+		{name: "sys file", includeNew: true, fpath: "/sys/kernel/mm"},
+		{name: "deleted file", fpath: "/var/lib/app (deleted)"},
+		{name: "socket", includeNew: true, fpath: "socket:[12345]"},
+		{name: "anonymous mapping", includeNew: true, fpath: "[vdso]"},
+	}
+
+	for _, test := range tests {
+		m := NewAttachedMonitor(context.Background(), 1, test.includeNew, origPaths, nil).(*attachedMonitor)
+		m.addFile(10, test.fpath)
+
+		_, found := m.report.FSActivity[test.fpath]
+		if found != test.expected {
+			t.Errorf("%s: addFile(%q) recorded = %v, expected %v", test.name, test.fpath, found, test.expected)
+		}
+	}
+}
+
+func TestAttachedMonitorAddFilePids(t *testing.T) {
+	origPaths := map[string]struct{}{"/usr/bin/app": {}}
+	m := NewAttachedMonitor(context.Background(), 1, false, origPaths, nil).(*attachedMonitor)
+
+	//the same file is seen on every scan, but the ops are counted once per process
+	m.addFile(10, "/usr/bin/app")
+	m.addFile(10, "/usr/bin/app")
+	m.addFile(11, "/usr/bin/app")
+
+	fsa := m.report.FSActivity["/usr/bin/app"]
+	if fsa == nil {
+		t.Fatal("file activity not recorded")
+	}
+
+	if fsa.OpsAll != 2 {
+		t.Errorf("unexpected ops count: %d", fsa.OpsAll)
+	}
+
+	if len(fsa.Pids) != 2 {
+		t.Errorf("unexpected pids: %v", fsa.Pids)
+	}
+
+	if fsa.LastOpTime.Before(fsa.FirstOpTime) {
+		t.Errorf("unexpected op times: first=%v last=%v", fsa.FirstOpTime, fsa.LastOpTime)
+	}
+}
+
+func TestAttachedMonitorScanSelf(t *testing.T) {
+	exePath, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	if _, err := os.Stat("/proc/self/exe"); err != nil {
+		t.Skip("no procfs")
+	}
+
+	m := NewAttachedMonitor(context.Background(), os.Getpid(), true, nil, nil).(*attachedMonitor)
+	m.scanProcess(os.Getpid())
+
+	if _, found := m.report.FSActivity[exePath]; !found {
+		t.Errorf("process executable (%s) not recorded", exePath)
+	}
+}
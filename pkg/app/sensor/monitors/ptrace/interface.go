@@ -7,6 +7,8 @@ import (
 
 type AppRunOpt = ptrace.AppRunOpt
 
+type LiveStats = ptrace.LiveStats
+
 type Monitor interface {
 	// Starts the long running monitoring. The method itself is not
 	// blocking and not reentrant!
@@ -22,5 +24,8 @@ type Monitor interface {
 	// instance of the channel.
 	Done() <-chan struct{}
 
+	// Live syscall counters. Safe to call while the monitor is running.
+	LiveStats() LiveStats
+
 	Status() (*report.PtMonitorReport, error)
 }
@@ -143,6 +143,14 @@ func (m *monitor) Done() <-chan struct{} {
 	return m.doneCh
 }
 
+func (m *monitor) LiveStats() LiveStats {
+	if m.app == nil {
+		return LiveStats{}
+	}
+
+	return m.app.LiveStats()
+}
+
 func (m *monitor) Status() (*report.PtMonitorReport, error) {
 	return m.status.report, m.status.err
 }
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"runtime"
 	"strconv"
+	"sync/atomic"
 	"syscall"
 
 	log "github.com/sirupsen/logrus"
@@ -62,6 +63,10 @@ type monitor struct {
 
 	status status
 	doneCh chan struct{}
+
+	syscallCount uint64
+	syscallNum   uint32
+	listenCount  uint32
 }
 
 func NewMonitor(
@@ -101,6 +106,7 @@ func (m *monitor) Start() error {
 	sysInfo := system.GetSystemInfo()
 	archName := system.MachineToArchName(sysInfo.Machine)
 	syscallResolver := system.CallNumberResolver(archName)
+	listenCallNum, hasListenCallNum := system.LookupCallNumber("listen")
 
 	appName := m.runOpt.Cmd
 	appArgs := m.runOpt.Args
@@ -288,12 +294,18 @@ func (m *monitor) Start() error {
 				break done
 			case e := <-eventChan:
 				ptReport.SyscallCount++
+				atomic.AddUint64(&m.syscallCount, 1)
 				logger.Tracef("syscall ==> %d", e.callNum)
 
 				if _, ok := syscallStats[e.callNum]; ok {
 					syscallStats[e.callNum]++
 				} else {
 					syscallStats[e.callNum] = 1
+					atomic.AddUint32(&m.syscallNum, 1)
+				}
+
+				if hasListenCallNum && e.callNum == listenCallNum && e.retVal == 0 {
+					atomic.AddUint32(&m.listenCount, 1)
 				}
 			}
 		}
@@ -327,6 +339,14 @@ func (m *monitor) Done() <-chan struct{} {
 	return m.doneCh
 }
 
+func (m *monitor) LiveStats() LiveStats {
+	return LiveStats{
+		SyscallCount: atomic.LoadUint64(&m.syscallCount),
+		SyscallNum:   atomic.LoadUint32(&m.syscallNum),
+		ListenCount:  atomic.LoadUint32(&m.listenCount),
+	}
+}
+
 func (m *monitor) Status() (*report.PtMonitorReport, error) {
 	return m.status.report, m.status.err
 }
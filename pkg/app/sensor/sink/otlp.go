@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/version"
+)
+
+// Minimal OTLP/HTTP (JSON encoding) logs data model.
+// See opentelemetry-proto/opentelemetry/proto/collector/logs/v1/logs_service.proto
+
+const (
+	otlpServiceName = "slim-sensor"
+	otlpScopeName   = "slim.sensor.sink"
+)
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BytesValue  []byte  `json:"bytesValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpLogsRequestData struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+const otlpSeverityInfo = 9
+
+func otlpString(key, val string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &val}}
+}
+
+// otlpLogsRequest wraps the exported data into a single OTLP log record.
+// The binary content (the artifacts archive) is stored as bytes (base64 in JSON).
+func otlpLogsRequest(kind, contentType string, data []byte, hostname string) ([]byte, error) {
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber: otlpSeverityInfo,
+		SeverityText:   "INFO",
+		Attributes: []otlpKeyValue{
+			otlpString("slim.report.kind", kind),
+			otlpString("slim.report.content_type", contentType),
+		},
+	}
+
+	if contentType == ContentTypeTar {
+		record.Body.BytesValue = data
+	} else {
+		body := string(data)
+		record.Body.StringValue = &body
+	}
+
+	resourceAttrs := []otlpKeyValue{otlpString("service.name", otlpServiceName)}
+	if hostname != "" {
+		resourceAttrs = append(resourceAttrs, otlpString("host.name", hostname))
+	}
+
+	return json.Marshal(otlpLogsRequestData{
+		ResourceLogs: []otlpResourceLogs{
+			{
+				Resource: otlpResource{Attributes: resourceAttrs},
+				ScopeLogs: []otlpScopeLogs{
+					{
+						Scope: otlpScope{
+							Name:    otlpScopeName,
+							Version: version.Current(),
+						},
+						LogRecords: []otlpLogRecord{record},
+					},
+				},
+			},
+		},
+	})
+}
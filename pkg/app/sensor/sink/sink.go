@@ -0,0 +1,366 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/artifacts"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+// Report kinds
+const (
+	KindEvents  = "events"
+	KindReport  = "report"
+	KindFiles   = "files"
+	KindArchive = "archive"
+)
+
+const (
+	HeaderReportKind = "X-Slim-Report-Kind"
+	HeaderHost       = "X-Slim-Host"
+
+	ContentTypeJSON   = "application/json"
+	ContentTypeNDJSON = "application/x-ndjson"
+	ContentTypeTar    = "application/x-tar"
+
+	SchemeOTLPHTTP  = "otlp+http"
+	SchemeOTLPHTTPS = "otlp+https"
+
+	DefaultInterval = 30 * time.Second
+
+	defaultMaxRetries = 5
+	defaultMinBackoff = 1 * time.Second
+	defaultMaxBackoff = 30 * time.Second
+	requestTimeout    = 30 * time.Second
+)
+
+var (
+	ErrUnsupportedScheme = errors.New("unsupported report sink scheme")
+)
+
+// Sink exports the sensor reports and artifacts to an HTTP endpoint.
+// The plain HTTP sink gets the raw report content (the report kind is passed
+// in the X-Slim-Report-Kind header), while the OTLP/HTTP sink gets
+// the content wrapped in the OTLP log records (JSON-encoded).
+type Sink struct {
+	endpoint     string
+	isOTLP       bool
+	artifactsDir string
+	hostname     string
+	client       *http.Client
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	eventsOffset int64
+	// The modification times of the exported report artifacts
+	// (the unchanged artifacts are not exported again)
+	exported map[string]time.Time
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	logger *log.Entry
+}
+
+// New creates a report sink. The sink URL format:
+// http[s]://host[:port]/path for the plain HTTP sink and
+// otlp+http[s]://host[:port]/v1/logs for the OTLP/HTTP sink.
+func New(sinkURL string, artifactsDir string) (*Sink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, fmt.Errorf("malformed report sink URL %q: %w", sinkURL, err)
+	}
+
+	s := &Sink{
+		artifactsDir: artifactsDir,
+		client:       &http.Client{Timeout: requestTimeout},
+		maxRetries:   defaultMaxRetries,
+		minBackoff:   defaultMinBackoff,
+		maxBackoff:   defaultMaxBackoff,
+		exported:     map[string]time.Time{},
+		stopCh:       make(chan struct{}),
+		logger:       log.WithField("com", "sensor.sink"),
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	case SchemeOTLPHTTP, SchemeOTLPHTTPS:
+		s.isOTLP = true
+		u.Scheme = u.Scheme[len("otlp+"):]
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedScheme, u.Scheme)
+	}
+
+	s.endpoint = u.String()
+	s.hostname, _ = os.Hostname()
+	return s, nil
+}
+
+// Start periodically exports the new sensor events and the new or updated
+// report artifacts (the container report, its file list and the artifacts archive)
+// until Finish is called. The report artifacts are created when the target app is done,
+// so the periodic export picks them up if the sensor shutdown is not complete yet.
+func (s *Sink) Start(ctx context.Context, eventsFile string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if err := s.ExportEvents(ctx, eventsFile); err != nil {
+					s.logger.WithError(err).Warn("periodic events export failed")
+				}
+
+				if s.hasReport() {
+					if err := s.ExportReports(ctx); err != nil {
+						s.logger.WithError(err).Warn("periodic reports export failed")
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Finish stops the periodic export and exports the left-over events,
+// the container report, its file list and the artifacts archive
+// (unless they were already exported by the periodic export).
+// It's called after the artifacts are archived.
+func (s *Sink) Finish(ctx context.Context, eventsFile string) error {
+	close(s.stopCh)
+	s.wg.Wait()
+
+	var firstErr error
+	keepErr := func(err error) {
+		if err == nil {
+			return
+		}
+
+		s.logger.WithError(err).Warn("export failed")
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	keepErr(s.ExportEvents(ctx, eventsFile))
+	keepErr(s.ExportReports(ctx))
+	return firstErr
+}
+
+// ExportEvents posts the sensor events recorded since the last export.
+func (s *Sink) ExportEvents(ctx context.Context, eventsFile string) error {
+	f, err := os.Open(eventsFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(s.eventsOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	// Only complete records (the event file is still being written).
+	idx := bytes.LastIndexByte(data, '\n')
+	if idx < 0 {
+		return nil
+	}
+	data = data[:idx+1]
+
+	if err := s.post(ctx, KindEvents, ContentTypeNDJSON, data); err != nil {
+		return err
+	}
+
+	s.eventsOffset += int64(len(data))
+	return nil
+}
+
+// ExportReports posts the container report, the list of the files
+// from the report and the artifacts archive (if it exists).
+// The artifacts that didn't change since their last export are skipped.
+func (s *Sink) ExportReports(ctx context.Context) error {
+	reportPath := filepath.Join(s.artifactsDir, report.DefaultContainerReportFileName)
+	reportInfo, err := os.Stat(reportPath)
+	if err != nil {
+		return fmt.Errorf("can't read container report: %w", err)
+	}
+
+	if s.isNew(KindReport, reportInfo) {
+		data, err := os.ReadFile(reportPath)
+		if err != nil {
+			return fmt.Errorf("can't read container report: %w", err)
+		}
+
+		var creport report.ContainerReport
+		if err := json.Unmarshal(data, &creport); err != nil {
+			return fmt.Errorf("can't decode container report: %w", err)
+		}
+
+		if err := s.post(ctx, KindReport, ContentTypeJSON, data); err != nil {
+			return err
+		}
+
+		files := make([]string, 0, len(creport.Image.Files))
+		for _, f := range creport.Image.Files {
+			files = append(files, f.FilePath)
+		}
+
+		fileList, err := json.Marshal(files)
+		if err != nil {
+			return err
+		}
+
+		if err := s.post(ctx, KindFiles, ContentTypeJSON, fileList); err != nil {
+			return err
+		}
+
+		s.exported[KindReport] = reportInfo.ModTime()
+	}
+
+	archivePath := filepath.Join(s.artifactsDir, artifacts.RunArchiveName)
+	archiveInfo, err := os.Stat(archivePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !s.isNew(KindArchive, archiveInfo) {
+		return nil
+	}
+
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		return err
+	}
+
+	if err := s.post(ctx, KindArchive, ContentTypeTar, archive); err != nil {
+		return err
+	}
+
+	s.exported[KindArchive] = archiveInfo.ModTime()
+	return nil
+}
+
+func (s *Sink) hasReport() bool {
+	_, err := os.Stat(filepath.Join(s.artifactsDir, report.DefaultContainerReportFileName))
+	return err == nil
+}
+
+func (s *Sink) isNew(kind string, info os.FileInfo) bool {
+	modTime, ok := s.exported[kind]
+	return !ok || !modTime.Equal(info.ModTime())
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("report sink responded with status %d", e.code)
+}
+
+func isRetryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+
+	// Connection errors, timeouts, etc.
+	return true
+}
+
+func (s *Sink) post(ctx context.Context, kind, contentType string, data []byte) error {
+	if s.isOTLP {
+		body, err := otlpLogsRequest(kind, contentType, data, s.hostname)
+		if err != nil {
+			return err
+		}
+
+		data = body
+		contentType = ContentTypeJSON
+	}
+
+	backoff := s.minBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.send(ctx, kind, contentType, data)
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryable(err) || attempt >= s.maxRetries {
+			return fmt.Errorf("exporting %s failed: %w", kind, err)
+		}
+
+		s.logger.Debugf("exporting %s failed (attempt %d) - %v (retrying in %v)", kind, attempt+1, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+func (s *Sink) send(ctx context.Context, kind, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(HeaderReportKind, kind)
+	if s.hostname != "" {
+		req.Header.Set(HeaderHost, s.hostname)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{code: resp.StatusCode}
+	}
+
+	return nil
+}
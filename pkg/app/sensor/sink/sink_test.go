@@ -0,0 +1,234 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/app/sensor/artifacts"
+	"github.com/docker-slim/docker-slim/pkg/report"
+)
+
+type received struct {
+	kind string
+	body []byte
+}
+
+func newTestServer(t *testing.T, failFirst int) (*httptest.Server, func() []received) {
+	var mu sync.Mutex
+	var calls int
+	var reqs []received
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls++
+		if calls <= failFirst {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+		}
+
+		reqs = append(reqs, received{kind: r.Header.Get(HeaderReportKind), body: body})
+	}))
+
+	return srv, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), reqs...)
+	}
+}
+
+func writeArtifacts(t *testing.T, dir string) {
+	creport := report.ContainerReport{
+		Image: report.ImageReport{
+			Files: []*report.ArtifactProps{{FilePath: "/bin/app"}, {FilePath: "/etc/app.conf"}},
+		},
+	}
+
+	data, err := json.Marshal(&creport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, report.DefaultContainerReportFileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, artifacts.RunArchiveName), []byte("tar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSink_ExportWithRetries(t *testing.T) {
+	srv, requests := newTestServer(t, 2)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeArtifacts(t, dir)
+
+	eventsFile := filepath.Join(dir, "events.json")
+	if err := os.WriteFile(eventsFile, []byte("{\"name\":\"a\"}\n{\"name\":\"b\"}\n{\"name\":"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(srv.URL, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.minBackoff = 10 * time.Millisecond
+
+	if err := s.Finish(context.Background(), eventsFile); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+
+	reqs := requests()
+	if len(reqs) != 4 {
+		t.Fatalf("expected 4 exported items, got %d", len(reqs))
+	}
+
+	if reqs[0].kind != KindEvents || string(reqs[0].body) != "{\"name\":\"a\"}\n{\"name\":\"b\"}\n" {
+		t.Errorf("unexpected events export: %s %q", reqs[0].kind, reqs[0].body)
+	}
+
+	if reqs[1].kind != KindReport {
+		t.Errorf("expected report, got %s", reqs[1].kind)
+	}
+
+	var files []string
+	if err := json.Unmarshal(reqs[2].body, &files); err != nil || reqs[2].kind != KindFiles || len(files) != 2 {
+		t.Errorf("unexpected file list export: %s %q", reqs[2].kind, reqs[2].body)
+	}
+
+	if reqs[3].kind != KindArchive || string(reqs[3].body) != "tar" {
+		t.Errorf("unexpected archive export: %s %q", reqs[3].kind, reqs[3].body)
+	}
+}
+
+func TestSink_PeriodicExport(t *testing.T) {
+	srv, requests := newTestServer(t, 0)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	eventsFile := filepath.Join(dir, "events.json")
+	if err := os.WriteFile(eventsFile, []byte("{\"name\":\"a\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(srv.URL, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := func() map[string]int {
+		counts := map[string]int{}
+		for _, r := range requests() {
+			counts[r.kind]++
+		}
+		return counts
+	}
+
+	waitFor := func(kind string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for kinds()[kind] == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s not exported (exported: %v)", kind, kinds())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	s.Start(context.Background(), eventsFile, 20*time.Millisecond)
+	waitFor(KindEvents)
+
+	//the report artifacts are created when the target app is done
+	writeArtifacts(t, dir)
+	waitFor(KindArchive)
+
+	if err := s.Finish(context.Background(), eventsFile); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+
+	counts := kinds()
+	for _, kind := range []string{KindEvents, KindReport, KindFiles, KindArchive} {
+		if counts[kind] != 1 {
+			t.Errorf("expected a single %s export, got %d", kind, counts[kind])
+		}
+	}
+}
+
+func TestSink_NoRetryOnClientError(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeArtifacts(t, dir)
+
+	s, err := New(srv.URL, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.minBackoff = 10 * time.Millisecond
+
+	if err := s.ExportReports(context.Background()); err == nil {
+		t.Fatal("expected export error")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected a single call, got %d", calls)
+	}
+}
+
+func TestSink_OTLP(t *testing.T) {
+	srv, requests := newTestServer(t, 0)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeArtifacts(t, dir)
+
+	s, err := New("otlp+"+srv.URL, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ExportReports(context.Background()); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+
+	reqs := requests()
+	if len(reqs) != 3 {
+		t.Fatalf("expected 3 exported items, got %d", len(reqs))
+	}
+
+	var logs otlpLogsRequestData
+	if err := json.Unmarshal(reqs[2].body, &logs); err != nil {
+		t.Fatal(err)
+	}
+
+	record := logs.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if string(record.Body.BytesValue) != "tar" {
+		t.Errorf("unexpected archive log record body: %+v", record.Body)
+	}
+}
+
+func TestSink_UnsupportedScheme(t *testing.T) {
+	if _, err := New("ftp://localhost/reports", ""); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -81,7 +81,11 @@ func (s *Sensor) Run() error {
 		return fmt.Errorf("failed to enumerate current paths: %w", err)
 	}
 
-	s.exe.HookMonitorPreStart()
+	if err := s.exe.HookMonitorPreStart(cmd); err != nil {
+		s.exe.HookMonitorFailed()
+		s.exe.PubEvent(event.StartMonitorFailed)
+		return err
+	}
 
 	mon, err := s.newMonitor(
 		s.ctx,
@@ -115,7 +119,7 @@ func (s *Sensor) Run() error {
 		return err
 	}
 	log.Info("sensor: target app is done")
-	s.exe.HookMonitorPostShutdown()
+	s.exe.HookMonitorPostShutdown(execution.NewReportSummary(report.FanReport, report.PtReport))
 	s.exe.PubEvent(event.StopMonitorDone)
 
 	if err := s.artifactor.ProcessReports(
@@ -124,6 +128,7 @@ func (s *Sensor) Run() error {
 		report.PeReport,
 		report.FanReport,
 		report.PtReport,
+		report.ResourceReport,
 	); err != nil {
 		log.WithError(err).Error("sensor: artifacts.ProcessReports() failed")
 		return fmt.Errorf("saving reports failed: %w", err)
@@ -134,12 +139,25 @@ func (s *Sensor) Run() error {
 }
 
 func (s *Sensor) runMonitor(mon monitors.CompositeMonitor) {
+	streamer := monitors.NewActivityStreamer(s.exe.PubEvent)
+	flushTicker := time.NewTicker(monitors.ActivityFlushInterval)
+	defer flushTicker.Stop()
+
 loop:
 	for {
 		select {
 		case <-mon.Done():
 			break loop
 
+		case a := <-mon.Activity():
+			streamer.Add(a)
+
+		case <-flushTicker.C:
+			streamer.Flush()
+			if mon.StartCommand().StreamEvents {
+				streamer.UpdateStats(mon.LiveStats())
+			}
+
 		case err := <-mon.Errors():
 			log.WithError(err).Warn("sensor: non-critical monitor error condition")
 			s.exe.PubEvent(event.Error, monitors.NonCriticalError(err).Error())
@@ -160,6 +178,8 @@ loop:
 		log.WithError(err).Warn("sensor: non-critical monitor error condition (drained)")
 		s.exe.PubEvent(event.Error, monitors.NonCriticalError(err).Error())
 	}
+
+	streamer.Drain(mon.Activity())
 }
 
 func initSignalForwardingChannel(
@@ -0,0 +1,268 @@
+package channel
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Frame authentication:
+// When the channel endpoints share a (per-run) secret key every frame
+// carries an HMAC-SHA256 of its encoded content (without the MAC field).
+// The command server and the event client reject the frames without a valid MAC
+// (protecting the sensor and the master from the other peers on the network)
+// and the event server accepts only the subscribers that send an authenticated
+// control frame right after they connect. An empty key disables the authentication.
+//
+// Replay protection:
+// Each sender picks a random session ID for its connection and numbers its frames.
+// The session ID and the sequence number are covered by the MAC. The receivers reject
+// the frames from another session and the frames with a sequence number that is not
+// greater than the last one, and the servers reject the sessions they have already seen
+// (so the captured frames can't be replayed on a new connection either).
+// The command responses are also bound to the random request TIDs.
+
+// AuthKeyEnvVar is the sensor environment variable with the IPC authentication key
+const AuthKeyEnvVar = "DSLIM_SENSOR_IPC_KEY"
+
+const (
+	authKeySize      = 32
+	sessionIDSize    = 16
+	maxAuthFrameSize = 1024
+)
+
+var (
+	ErrFrameUnauthenticated = errors.New("unauthenticated frame")
+	ErrFrameReplayed        = errors.New("replayed frame")
+)
+
+// GenerateAuthKey generates a new random IPC authentication key (hex-encoded)
+func GenerateAuthKey() (string, error) {
+	key := make([]byte, authKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+func generateSessionID() string {
+	id := make([]byte, sessionIDSize)
+	if _, err := rand.Read(id); err != nil {
+		//should never happen (the TID-based ID is still unique)
+		return GenerateTID()
+	}
+
+	return hex.EncodeToString(id)
+}
+
+func frameMAC(key []byte, frame *Frame) (string, error) {
+	unsigned := *frame
+	unsigned.MAC = ""
+
+	raw, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(raw)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func signFrame(key []byte, frame *Frame) error {
+	if len(key) == 0 {
+		return nil
+	}
+
+	mac, err := frameMAC(key, frame)
+	if err != nil {
+		return err
+	}
+
+	frame.MAC = mac
+	return nil
+}
+
+func verifyFrame(key []byte, frame *Frame) error {
+	if len(key) == 0 {
+		return nil
+	}
+
+	if frame.MAC == "" {
+		return ErrFrameUnauthenticated
+	}
+
+	expected, err := frameMAC(key, frame)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal([]byte(expected), []byte(frame.MAC)) {
+		return ErrFrameUnauthenticated
+	}
+
+	return nil
+}
+
+// frameSigner signs the frames sent on one connection
+type frameSigner struct {
+	key     []byte
+	session string
+
+	mu  sync.Mutex
+	seq uint64
+}
+
+func newFrameSigner(key []byte) *frameSigner {
+	signer := &frameSigner{key: key}
+	if len(key) > 0 {
+		signer.session = generateSessionID()
+	}
+
+	return signer
+}
+
+func (s *frameSigner) sign(frame *Frame) error {
+	if s == nil || len(s.key) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	frame.Session = s.session
+	frame.Seq = s.seq
+	return signFrame(s.key, frame)
+}
+
+// sessionSet keeps the peer session IDs a server has already seen
+type sessionSet struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func newSessionSet() *sessionSet {
+	return &sessionSet{seen: map[string]struct{}{}}
+}
+
+// add returns false if the session ID has been seen before
+func (s *sessionSet) add(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.seen[id]; found {
+		return false
+	}
+
+	s.seen[id] = struct{}{}
+	return true
+}
+
+// frameVerifier verifies the frames received on one connection
+// (not safe for concurrent use; the connection frames are read sequentially)
+type frameVerifier struct {
+	key      []byte
+	sessions *sessionSet //nil for the clients
+
+	session string
+	seq     uint64
+}
+
+func newFrameVerifier(key []byte, sessions *sessionSet) *frameVerifier {
+	return &frameVerifier{
+		key:      key,
+		sessions: sessions,
+	}
+}
+
+func (v *frameVerifier) verify(frame *Frame) error {
+	if v == nil || len(v.key) == 0 {
+		return nil
+	}
+
+	if err := verifyFrame(v.key, frame); err != nil {
+		return err
+	}
+
+	if v.session == "" {
+		if frame.Session == "" {
+			return ErrFrameReplayed
+		}
+
+		if v.sessions != nil && !v.sessions.add(frame.Session) {
+			return ErrFrameReplayed
+		}
+
+		v.session = frame.Session
+	}
+
+	if frame.Session != v.session || frame.Seq <= v.seq {
+		return ErrFrameReplayed
+	}
+
+	v.seq = frame.Seq
+	return nil
+}
+
+// sendAuthFrame sends an authenticated control frame (used by the event clients to subscribe)
+func sendAuthFrame(conn net.Conn, signer *frameSigner) error {
+	frameBytes, err := createFrameBytesFromFields(ControlFrameType, nil, "", signer)
+	if err != nil {
+		return err
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(defaultWriteTimeoutDuration))
+	_, err = conn.Write(frameBytes)
+	conn.SetWriteDeadline(time.Time{})
+	return err
+}
+
+// readAuthFrame reads and verifies the first (control) frame from a new connection
+func readAuthFrame(conn net.Conn, verifier *frameVerifier) error {
+	conn.SetReadDeadline(time.Now().Add(defaultReadTimeoutDuration))
+	defer conn.SetReadDeadline(time.Time{})
+
+	// Reading byte by byte not to consume anything past the control frame.
+	var raw []byte
+	buf := make([]byte, 1)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			return err
+		}
+
+		raw = append(raw, buf[0])
+		if buf[0] == msgEndByte {
+			break
+		}
+
+		if len(raw) > maxAuthFrameSize {
+			return ErrFrameMalformed
+		}
+	}
+
+	frame, err := getFrame(raw)
+	if err != nil {
+		return err
+	}
+
+	if frame.Type != ControlFrameType {
+		return ErrFrameUnexpected
+	}
+
+	if err := verifier.verify(frame); err != nil {
+		log.Debugf("channel.readAuthFrame: %s -> %s - %v", conn.RemoteAddr(), conn.LocalAddr(), err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package channel
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFrameAuth(t *testing.T) {
+	key := []byte("test-key")
+	frame := newFrame(RequestFrameType, []byte(`{"name":"cmd.monitor.start","data":{"app_name":"<app>","app_user":"app"}}`), "")
+
+	raw, err := createFrameBytes(frame, newFrameSigner(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := getFrame(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyFrame(key, received); err != nil {
+		t.Fatalf("expected a valid frame: %v", err)
+	}
+
+	if err := verifyFrame([]byte("other-key"), received); !errors.Is(err, ErrFrameUnauthenticated) {
+		t.Errorf("expected the frame to be rejected with a different key: %v", err)
+	}
+
+	tampered, err := getFrame(bytes.Replace(raw, []byte(`"app_user":"app"`), []byte(`"app_user":"bad"`), 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyFrame(key, tampered); !errors.Is(err, ErrFrameUnauthenticated) {
+		t.Errorf("expected the tampered frame to be rejected: %v", err)
+	}
+
+	unsigned, err := getFrame(mustFrameBytes(t, newFrame(RequestFrameType, nil, ""), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyFrame(key, unsigned); !errors.Is(err, ErrFrameUnauthenticated) {
+		t.Errorf("expected the unsigned frame to be rejected: %v", err)
+	}
+
+	if err := verifyFrame(nil, unsigned); err != nil {
+		t.Errorf("expected no verification without a key: %v", err)
+	}
+}
+
+func mustFrameBytes(t *testing.T, frame *Frame, key []byte) []byte {
+	raw, err := createFrameBytes(frame, newFrameSigner(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func TestFrameReplay(t *testing.T) {
+	key := []byte("test-key")
+	signer := newFrameSigner(key)
+	sessions := newSessionSet()
+
+	first, _ := getFrame(mustSignedFrameBytes(t, signer, newFrame(RequestFrameType, []byte(`{"name":"one"}`), "")))
+	second, _ := getFrame(mustSignedFrameBytes(t, signer, newFrame(RequestFrameType, []byte(`{"name":"two"}`), "")))
+
+	verifier := newFrameVerifier(key, sessions)
+	if err := verifier.verify(first); err != nil {
+		t.Fatalf("expected a valid frame: %v", err)
+	}
+
+	if err := verifier.verify(first); !errors.Is(err, ErrFrameReplayed) {
+		t.Errorf("expected the duplicate frame to be rejected: %v", err)
+	}
+
+	if err := verifier.verify(second); err != nil {
+		t.Fatalf("expected a valid frame: %v", err)
+	}
+
+	if err := verifier.verify(first); !errors.Is(err, ErrFrameReplayed) {
+		t.Errorf("expected the older frame to be rejected: %v", err)
+	}
+
+	//frame from another session on the same connection
+	other, _ := getFrame(mustSignedFrameBytes(t, newFrameSigner(key), newFrame(RequestFrameType, nil, "")))
+	other.Seq = 10
+	other.MAC, _ = frameMAC(key, other)
+	if err := verifier.verify(other); !errors.Is(err, ErrFrameReplayed) {
+		t.Errorf("expected the frame from another session to be rejected: %v", err)
+	}
+
+	//captured frames replayed on a new server connection
+	if err := newFrameVerifier(key, sessions).verify(first); !errors.Is(err, ErrFrameReplayed) {
+		t.Errorf("expected the replayed session to be rejected: %v", err)
+	}
+
+	//the sequence number is covered by the MAC
+	tampered := *first
+	tampered.Seq = 100
+	if err := newFrameVerifier(key, nil).verify(&tampered); !errors.Is(err, ErrFrameUnauthenticated) {
+		t.Errorf("expected the frame with a changed sequence number to be rejected: %v", err)
+	}
+}
+
+func mustSignedFrameBytes(t *testing.T, signer *frameSigner, frame *Frame) []byte {
+	raw, err := createFrameBytes(frame, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
@@ -11,6 +11,7 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,8 +33,17 @@ const (
 	EvtPort = 65502
 )
 
+// Channel Unix domain socket names (in the IPC socket directory)
+const (
+	CmdSocketName = "cmd.sock"
+	EvtSocketName = "evt.sock"
+)
+
 const (
 	proto                         = "tcp"
+	unixProto                     = "unix"
+	unixAddrPrefix                = "unix://"
+	unixSocketPerms               = 0666
 	defaultConnectTimeoutDuration = 11 * time.Second
 	defaultReadTimeoutDuration    = 11 * time.Second
 	defaultWriteTimeoutDuration   = 11 * time.Second
@@ -58,9 +68,12 @@ const (
 )
 
 type Frame struct {
-	TID  string          `json:"tid"`
-	Type FrameType       `json:"type"`
-	Body json.RawMessage `json:"body,omitempty"`
+	TID     string          `json:"tid"`
+	Type    FrameType       `json:"type"`
+	Body    json.RawMessage `json:"body,omitempty"`
+	Session string          `json:"session,omitempty"`
+	Seq     uint64          `json:"seq,omitempty"`
+	MAC     string          `json:"mac,omitempty"`
 }
 
 func newFrame(ftype FrameType, data []byte, tid string) *Frame {
@@ -78,7 +91,11 @@ func newFrame(ftype FrameType, data []byte, tid string) *Frame {
 	return &frame
 }
 
-func createFrameBytes(frame *Frame) ([]byte, error) {
+func createFrameBytes(frame *Frame, signer *frameSigner) ([]byte, error) {
+	if err := signer.sign(frame); err != nil {
+		return nil, err
+	}
+
 	raw, err := json.Marshal(frame)
 	if err != nil {
 		return nil, err
@@ -92,9 +109,9 @@ func createFrameBytes(frame *Frame) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
-func createFrameBytesFromFields(ftype FrameType, data []byte, tid string) ([]byte, error) {
+func createFrameBytesFromFields(ftype FrameType, data []byte, tid string, signer *frameSigner) ([]byte, error) {
 	frame := newFrame(ftype, data, tid)
-	return createFrameBytes(frame)
+	return createFrameBytes(frame, signer)
 }
 
 func GenerateTID() string {
@@ -104,6 +121,21 @@ func GenerateTID() string {
 	return fmt.Sprintf("%d.%s", now, hex.EncodeToString(random))
 }
 
+// UnixAddr returns the channel address for a Unix domain socket path
+func UnixAddr(socketPath string) string {
+	return unixAddrPrefix + socketPath
+}
+
+// netAddr splits the channel address into the network and the network address
+// (the address is either a TCP "host:port" address or a "unix:///path/to/socket" address)
+func netAddr(addr string) (string, string) {
+	if strings.HasPrefix(addr, unixAddrPrefix) {
+		return unixProto, strings.TrimPrefix(addr, unixAddrPrefix)
+	}
+
+	return proto, addr
+}
+
 func getFrame(raw []byte) (*Frame, error) {
 	if len(raw) > (len(frameHeader)+len(frameTrailer)) && bytes.HasPrefix(raw, frameHeader) && bytes.HasSuffix(raw, frameTrailer) {
 		data := raw[len(frameHeader) : len(raw)-len(frameTrailer)]
@@ -125,13 +157,17 @@ type ConnectionHandler interface {
 
 type Server struct {
 	addr     string
+	authKey  []byte
+	sessions *sessionSet
 	listener net.Listener
 	handler  ConnectionHandler
 }
 
-func NewServer(addr string) *Server {
+func NewServer(addr string, authKey []byte) *Server {
 	server := Server{
-		addr: addr,
+		addr:     addr,
+		authKey:  authKey,
+		sessions: newSessionSet(),
 	}
 
 	return &server
@@ -144,12 +180,28 @@ func (s *Server) SetConnHandler(handler ConnectionHandler) {
 func (s *Server) Start(async bool) error {
 	var err error
 	log.Debugf("channel.Server.Start() - addr=%v [time=%v]", s.addr, time.Now().UnixNano())
-	s.listener, err = net.Listen(proto, s.addr)
+	network, addr := netAddr(s.addr)
+	if network == unixProto {
+		//removing the stale socket (if any)
+		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			log.Debugf("channel:Server.Start() - error removing stale socket = %v", err)
+		}
+	}
+
+	s.listener, err = net.Listen(network, addr)
 	if err != nil {
 		log.Debugf("channel:Server.Start() - net.Listen error = %v", err)
 		return err
 	}
 
+	if network == unixProto {
+		//the client might be running as a different user
+		//(the socket dir permissions limit the access)
+		if err := os.Chmod(addr, unixSocketPerms); err != nil {
+			log.Debugf("channel:Server.Start() - error setting socket permissions = %v", err)
+		}
+	}
+
 	loop := func() {
 		log.Debugf("channel.Server.Start.loop()... [time=%v]", time.Now().UnixNano())
 		for {
@@ -189,14 +241,14 @@ type EventServer struct {
 	*Server
 
 	mu    sync.Mutex
-	links []net.Conn
+	links []*eventLink
 
 	pending chan struct{}
 }
 
-func NewEventServer(addr string) *EventServer {
+func NewEventServer(addr string, authKey []byte) *EventServer {
 	server := &EventServer{
-		Server:  NewServer(addr),
+		Server:  NewServer(addr, authKey),
 		pending: make(chan struct{}),
 	}
 
@@ -204,11 +256,39 @@ func NewEventServer(addr string) *EventServer {
 	return server
 }
 
+// eventLink is an event subscriber connection
+// (the events are signed separately for each subscriber connection)
+type eventLink struct {
+	conn   net.Conn
+	signer *frameSigner
+}
+
 func (s *EventServer) OnConnection(conn net.Conn) {
+	if len(s.authKey) == 0 {
+		s.addLink(conn)
+		return
+	}
+
+	// Not blocking the accept loop waiting for the subscriber's auth frame.
+	go func() {
+		if err := readAuthFrame(conn, newFrameVerifier(s.authKey, s.sessions)); err != nil {
+			log.Errorf("channel.EventServer.OnConnection: %s -> %s - rejecting unauthenticated subscriber (%v)", conn.RemoteAddr(), conn.LocalAddr(), err)
+			conn.Close()
+			return
+		}
+
+		s.addLink(conn)
+	}()
+}
+
+func (s *EventServer) addLink(conn net.Conn) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.links = append(s.links, conn)
+	s.links = append(s.links, &eventLink{
+		conn:   conn,
+		signer: newFrameSigner(s.authKey),
+	})
 
 	select {
 	case <-s.pending: // Has already been closed
@@ -227,12 +307,18 @@ func (s *EventServer) Publish(data []byte, retries uint) error {
 		return nil
 	}
 
-	frame, err := createFrameBytesFromFields(EventFrameType, data, "")
-	if err != nil {
-		return err
-	}
+	s.mu.Lock()
+	links := append([]*eventLink(nil), s.links...)
+	s.mu.Unlock()
+
+	tid := GenerateTID()
+	for _, link := range links {
+		frame, err := createFrameBytesFromFields(EventFrameType, data, tid, link.signer)
+		if err != nil {
+			return err
+		}
 
-	for _, conn := range s.links {
+		conn := link.conn
 		timeouts := uint(0)
 		for {
 			conn.SetWriteDeadline(time.Now().Add(defaultWriteTimeoutDuration))
@@ -260,6 +346,9 @@ func (s *EventServer) Publish(data []byte, retries uint) error {
 
 type Client struct {
 	addr         string
+	authKey      []byte
+	signer       *frameSigner
+	verifier     *frameVerifier
 	conn         net.Conn
 	reader       *bufio.Reader
 	readTimeout  time.Duration
@@ -279,7 +368,7 @@ func durationValue(vparam int, vdefault time.Duration) time.Duration {
 	return val
 }
 
-func NewClient(addr string, connectWait, connectTimeout, readTimeout, writeTimeout int) (*Client, error) {
+func NewClient(addr string, connectWait, connectTimeout, readTimeout, writeTimeout int, authKey []byte) (*Client, error) {
 	cwd := durationValue(connectWait, 0)
 	ctd := durationValue(connectTimeout, defaultConnectTimeoutDuration) //todo: use non-timeout net.Dial or net.DialContext
 	rtd := durationValue(readTimeout, defaultReadTimeoutDuration)
@@ -287,6 +376,9 @@ func NewClient(addr string, connectWait, connectTimeout, readTimeout, writeTimeo
 
 	client := Client{
 		addr:         addr,
+		authKey:      authKey,
+		signer:       newFrameSigner(authKey),
+		verifier:     newFrameVerifier(authKey, nil),
 		readTimeout:  rtd,
 		writeTimeout: wtd,
 	}
@@ -297,6 +389,7 @@ func NewClient(addr string, connectWait, connectTimeout, readTimeout, writeTimeo
 		timeout = time.After(cwd)
 	}
 
+	network, netaddr := netAddr(addr)
 	connectStart := time.Now()
 done:
 	for {
@@ -308,11 +401,11 @@ done:
 			start := time.Now()
 			var err error
 			if ctd != 0 {
-				log.Debugf("channel.NewClient: net.DialTimeout(%v,%v,%v) [time=%v]", proto, addr, ctd, time.Now().UnixNano())
-				client.conn, err = net.DialTimeout(proto, addr, ctd)
+				log.Debugf("channel.NewClient: net.DialTimeout(%v,%v,%v) [time=%v]", network, netaddr, ctd, time.Now().UnixNano())
+				client.conn, err = net.DialTimeout(network, netaddr, ctd)
 			} else {
-				log.Debugf("channel.NewClient: net.Dial(%v,%v)", proto, addr)
-				client.conn, err = net.Dial(proto, addr)
+				log.Debugf("channel.NewClient: net.Dial(%v,%v)", network, netaddr)
+				client.conn, err = net.Dial(network, netaddr)
 			}
 
 			if err == nil {
@@ -336,8 +429,8 @@ done:
 }
 
 func (c *Client) Write(frame *Frame, retries uint) (n int, err error) {
-	frameBytes, e := createFrameBytes(frame)
-	if err != nil {
+	frameBytes, e := createFrameBytes(frame, c.signer)
+	if e != nil {
 		return 0, e
 	}
 
@@ -407,6 +500,11 @@ func (c *Client) Read(retries uint) (*Frame, error) {
 		return nil, err
 	}
 
+	if err := c.verifier.verify(frame); err != nil {
+		log.Errorf("channel.Client.Read: %s -> %s - %v", c.conn.RemoteAddr(), c.conn.LocalAddr(), err)
+		return nil, err
+	}
+
 	if frame != nil {
 		log.Debugf("channel.Client.Read: frame data => tid=%s type=%s body='%s'",
 			frame.TID, frame.Type, string(frame.Body))
@@ -425,13 +523,21 @@ type EventClient struct {
 	*Client
 }
 
-func NewEventClient(addr string, connectWait, connectTimeout, readTimeout int) (*EventClient, error) {
-	client, err := NewClient(addr, connectWait, connectTimeout, readTimeout, -1)
+func NewEventClient(addr string, connectWait, connectTimeout, readTimeout int, authKey []byte) (*EventClient, error) {
+	client, err := NewClient(addr, connectWait, connectTimeout, readTimeout, -1, authKey)
 	if err != nil {
 		log.Errorf("channel.NewSubscriber: NewClient error = %v", err)
 		return nil, err
 	}
 
+	if len(authKey) > 0 {
+		if err := sendAuthFrame(client.conn, client.signer); err != nil {
+			log.Errorf("channel.NewSubscriber: sendAuthFrame error = %v", err)
+			client.Close()
+			return nil, err
+		}
+	}
+
 	eventClient := &EventClient{
 		Client: client,
 	}
@@ -457,7 +563,7 @@ type CommandClient struct {
 	*Client
 }
 
-func NewCommandClient(addr string, connectWait, connectTimeout, readTimeout, writeTimeout int) (*CommandClient, error) {
+func NewCommandClient(addr string, connectWait, connectTimeout, readTimeout, writeTimeout int, authKey []byte) (*CommandClient, error) {
 	cwd := durationValue(connectWait, 0)
 	var timeout <-chan time.Time
 	if cwd > 0 {
@@ -471,7 +577,7 @@ func NewCommandClient(addr string, connectWait, connectTimeout, readTimeout, wri
 			log.Debugf("channel.NewCommandClient: connect wait timeout (waited=%v)...", time.Since(connectStart))
 			return nil, ErrWaitTimeout
 		default:
-			client, err := NewClient(addr, connectWait, connectTimeout, readTimeout, writeTimeout)
+			client, err := NewClient(addr, connectWait, connectTimeout, readTimeout, writeTimeout, authKey)
 			if err != nil {
 				log.Errorf("channel.NewCommandClient: NewClient error = %v", err)
 				return nil, err
@@ -486,6 +592,13 @@ func NewCommandClient(addr string, connectWait, connectTimeout, readTimeout, wri
 				return cmdClient, nil
 			}
 
+			if errors.Is(err, ErrFrameUnauthenticated) {
+				//the peer doesn't have the key (or doesn't support the authentication),
+				//so there's no point in trying again
+				client.Close()
+				return nil, err
+			}
+
 			if errors.Is(err, io.EOF) {
 				log.Debug("channel.NewCommandClient: closed connection.")
 			} else {
@@ -596,9 +709,9 @@ type CommandServer struct {
 	pending chan struct{}
 }
 
-func NewCommandServer(addr string, handler RequestHandler) *CommandServer {
+func NewCommandServer(addr string, authKey []byte, handler RequestHandler) *CommandServer {
 	server := &CommandServer{
-		Server:  NewServer(addr),
+		Server:  NewServer(addr, authKey),
 		pending: make(chan struct{}),
 	}
 
@@ -631,6 +744,8 @@ func (s *CommandServer) OnConnection(conn net.Conn) {
 			conn.Close()
 		}()
 
+		signer := newFrameSigner(s.authKey)
+		verifier := newFrameVerifier(s.authKey, s.sessions)
 		reader := bufio.NewReader(conn)
 		for {
 			conn.SetReadDeadline(time.Now().Add(defaultReadTimeoutDuration))
@@ -663,9 +778,14 @@ func (s *CommandServer) OnConnection(conn net.Conn) {
 				log.Debugf("channel.CommandServer.OnConnection.worker: in frame => tid=%v type=%v body='%s'",
 					inFrame.TID, inFrame.Type, string(inFrame.Body))
 
+				if err := verifier.verify(inFrame); err != nil {
+					log.Errorf("channel.CommandServer.OnConnection.worker: %s -> %s - rejecting unauthenticated peer (%v)", conn.RemoteAddr(), conn.LocalAddr(), err)
+					return
+				}
+
 				var outFrame []byte
 				if inFrame.Type == ControlFrameType {
-					outFrame, err = createFrameBytesFromFields(ControlFrameType, nil, inFrame.TID)
+					outFrame, err = createFrameBytesFromFields(ControlFrameType, nil, inFrame.TID, signer)
 					if err != nil {
 						log.Debugf("channel.CommandServer.OnConnection.worker: %s -> %s - error creating control out frame (%v)", conn.RemoteAddr(), conn.LocalAddr(), err)
 						return
@@ -674,13 +794,13 @@ func (s *CommandServer) OnConnection(conn net.Conn) {
 					outData, err := s.handler.OnRequest(inFrame.Body)
 					if err != nil {
 						log.Errorf("channel.CommandServer.OnConnection.worker: handler.OnRequest error => %v", err)
-						outFrame, err = createFrameBytesFromFields(ErrorFrameType, nil, inFrame.TID)
+						outFrame, err = createFrameBytesFromFields(ErrorFrameType, nil, inFrame.TID, signer)
 						if err != nil {
 							log.Errorf("channel.CommandServer.OnConnection.worker: %s -> %s - error creating out frame (%v)", conn.RemoteAddr(), conn.LocalAddr(), err)
 							return
 						}
 					} else {
-						outFrame, err = createFrameBytesFromFields(ResponseFrameType, outData, inFrame.TID)
+						outFrame, err = createFrameBytesFromFields(ResponseFrameType, outData, inFrame.TID, signer)
 						if err != nil {
 							log.Debugf("channel.CommandServer.OnConnection.worker: %s -> %s - error creating out frame (%v)", conn.RemoteAddr(), conn.LocalAddr(), err)
 							return
@@ -0,0 +1,97 @@
+package channel
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type echoHandler struct{}
+
+func (echoHandler) OnRequest(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func TestCommandChannel_UnixSocket(t *testing.T) {
+	key := []byte("test-key")
+	addr := UnixAddr(filepath.Join(t.TempDir(), CmdSocketName))
+
+	server := NewCommandServer(addr, key, echoHandler{})
+	if err := server.Start(true); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	client, err := NewCommandClient(addr, 0, 0, 0, 0, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	out, err := client.Call([]byte(`{"name":"ping"}`), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(out) != `{"name":"ping"}` {
+		t.Errorf("unexpected response: %s", out)
+	}
+}
+
+func TestCommandChannel_Replay(t *testing.T) {
+	key := []byte("test-key")
+	addr := UnixAddr(filepath.Join(t.TempDir(), CmdSocketName))
+
+	server := NewCommandServer(addr, key, echoHandler{})
+	if err := server.Start(true); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	_, path := netAddr(addr)
+	call := func(conn net.Conn, reader *bufio.Reader, raw []byte) (*Frame, error) {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Write(raw); err != nil {
+			return nil, err
+		}
+
+		out, err := reader.ReadString(msgEndByte)
+		if err != nil {
+			return nil, err
+		}
+
+		return getFrame([]byte(out))
+	}
+
+	signer := newFrameSigner(key)
+	captured := mustSignedFrameBytes(t, signer, newFrame(RequestFrameType, []byte(`{"name":"ping"}`), ""))
+
+	conn, err := net.Dial(unixProto, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	if reply, err := call(conn, reader, captured); err != nil || reply.Type != ResponseFrameType {
+		t.Fatalf("unexpected reply: %+v (%v)", reply, err)
+	}
+
+	//the same frame on the same connection
+	if reply, err := call(conn, reader, captured); err == nil {
+		t.Errorf("expected the replayed frame to be rejected: %+v", reply)
+	}
+
+	//the same frame on a new connection
+	replayConn, err := net.Dial(unixProto, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayConn.Close()
+
+	if reply, err := call(replayConn, bufio.NewReader(replayConn), captured); err == nil {
+		t.Errorf("expected the replayed frame to be rejected on a new connection: %+v", reply)
+	}
+}
@@ -20,7 +20,9 @@ const (
 
 // Response contains the command response status information
 type Response struct {
-	Status string `json:"status"`
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Handshake *HandshakeInfo `json:"handshake,omitempty"`
 }
 
 // MessageName is a message ID type
@@ -31,6 +33,16 @@ const (
 	StartMonitorName   MessageName = "cmd.monitor.start"
 	StopMonitorName    MessageName = "cmd.monitor.stop"
 	ShutdownSensorName MessageName = "cmd.sensor.shutdown"
+	HandshakeName      MessageName = "cmd.sensor.handshake"
+	MarkPhaseName      MessageName = "cmd.monitor.phase.mark"
+	SnapshotName       MessageName = "cmd.monitor.snapshot"
+	ResetCountersName  MessageName = "cmd.monitor.counters.reset"
+)
+
+// Common monitoring phase names
+const (
+	PhaseStartup = "startup"
+	PhaseProbe   = "probe"
 )
 
 // Message represents the message interface
@@ -78,6 +90,11 @@ type StartMonitor struct {
 	IncludeAppNextStaticDir      bool                          `json:"include_app_next_static,omitempty"`
 	IncludeAppNextNodeModulesDir bool                          `json:"include_app_next_nm,omitempty"`
 	IncludeNodePackages          []string                      `json:"include_node_packages,omitempty"`
+	StreamEvents                 bool                          `json:"stream_events,omitempty"`
+	AttachPid                    int                           `json:"attach_pid,omitempty"`
+	Phase                        string                        `json:"phase,omitempty"`
+	ExcludePhases                []string                      `json:"exclude_phases,omitempty"`
+	RecordFileRanges             bool                          `json:"record_file_ranges,omitempty"`
 }
 
 // GetName returns the command message ID for the start monitor command
@@ -102,6 +119,47 @@ func (m *ShutdownSensor) GetName() MessageName {
 	return ShutdownSensorName
 }
 
+// Handshake contains the 'handshake' command fields
+// (sent by the master right after it connects to the sensor)
+type Handshake struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	MasterVersion   string `json:"master_version,omitempty"`
+}
+
+// GetName returns the command message ID for the 'handshake' command
+func (m *Handshake) GetName() MessageName {
+	return HandshakeName
+}
+
+// MarkPhase contains the 'mark phase' command fields
+// (the subsequent monitor events are tagged with the phase name)
+type MarkPhase struct {
+	Name string `json:"name"`
+}
+
+// GetName returns the command message ID for the 'mark phase' command
+func (m *MarkPhase) GetName() MessageName {
+	return MarkPhaseName
+}
+
+// Snapshot contains the 'snapshot' command fields
+// (the sensor publishes the current partial report without stopping the monitor)
+type Snapshot struct{}
+
+// GetName returns the command message ID for the 'snapshot' command
+func (m *Snapshot) GetName() MessageName {
+	return SnapshotName
+}
+
+// ResetCounters contains the 'reset counters' command fields
+// (the activity counters start fresh, the collected files are kept)
+type ResetCounters struct{}
+
+// GetName returns the command message ID for the 'reset counters' command
+func (m *ResetCounters) GetName() MessageName {
+	return ResetCountersName
+}
+
 type messageWrapper struct {
 	Name MessageName     `json:"name"`
 	Data json.RawMessage `json:"data,omitempty"`
@@ -123,8 +181,17 @@ func Encode(m Message) ([]byte, error) {
 		}
 
 		obj.Data = b.Bytes()
+	case *Handshake, *MarkPhase:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.Data = data
 	case *StopMonitor:
 	case *ShutdownSensor:
+	case *Snapshot:
+	case *ResetCounters:
 	default:
 		return nil, ErrUnknownMessage
 	}
@@ -151,6 +218,24 @@ func Decode(data []byte) (Message, error) {
 		return &StopMonitor{}, nil
 	case ShutdownSensorName:
 		return &ShutdownSensor{}, nil
+	case HandshakeName:
+		var cmd Handshake
+		if err := json.Unmarshal(wrapper.Data, &cmd); err != nil {
+			return nil, err
+		}
+
+		return &cmd, nil
+	case MarkPhaseName:
+		var cmd MarkPhase
+		if err := json.Unmarshal(wrapper.Data, &cmd); err != nil {
+			return nil, err
+		}
+
+		return &cmd, nil
+	case SnapshotName:
+		return &Snapshot{}, nil
+	case ResetCountersName:
+		return &ResetCounters{}, nil
 	default:
 		return nil, ErrUnknownMessage
 	}
@@ -0,0 +1,225 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// IPC protocol versions:
+// 1 - the original protocol (no handshake)
+// 2 - the handshake with the sensor capabilities
+// 3 - the mid-session monitor control commands (phases, snapshots, counter resets)
+//
+// The master and the sensor reject the peers older than MinProtocolVersion
+// (the peers without the handshake support).
+const (
+	ProtocolVersion       = 3
+	MinProtocolVersion    = 2
+	LegacyProtocolVersion = 1
+)
+
+// Sensor monitors (advertised in the handshake)
+const (
+	MonitorFanotify = "fanotify"
+	MonitorPtrace   = "ptrace"
+	MonitorPevent   = "pevent"
+	MonitorAttach   = "attach"
+)
+
+// SupportedMonitors lists the monitors available in this sensor build
+var SupportedMonitors = []string{
+	MonitorFanotify,
+	MonitorPtrace,
+	MonitorPevent,
+	MonitorAttach,
+}
+
+// Protocol errors
+var (
+	ErrIncompatibleSensor = errors.New("incompatible sensor")
+	ErrIncompatibleMaster = errors.New("incompatible master")
+)
+
+// StartMonitor fields added after the legacy protocol version
+// (the legacy sensors silently ignore them)
+var legacyUnsupportedFields = []string{
+	"stream_events",
+	"attach_pid",
+	"phase",
+	"record_file_ranges",
+	"exclude_phases",
+}
+
+// StartMonitor fields the sensor must support (the command can't be degraded without them)
+var requiredFields = map[string]string{
+	"attach_pid": MonitorAttach,
+}
+
+// HandshakeInfo contains the sensor version and capabilities
+// (the handshake command response data)
+type HandshakeInfo struct {
+	ProtocolVersion    int           `json:"protocol_version"`
+	SensorVersion      string        `json:"sensor_version,omitempty"`
+	Monitors           []string      `json:"monitors,omitempty"`
+	Commands           []MessageName `json:"commands,omitempty"`
+	StartMonitorFields []string      `json:"start_monitor_fields,omitempty"`
+}
+
+// NewHandshakeInfo creates the handshake info for the current sensor build
+func NewHandshakeInfo(sensorVersion string) *HandshakeInfo {
+	return &HandshakeInfo{
+		ProtocolVersion: ProtocolVersion,
+		SensorVersion:   sensorVersion,
+		Monitors:        SupportedMonitors,
+		Commands: []MessageName{
+			StartMonitorName,
+			StopMonitorName,
+			ShutdownSensorName,
+			HandshakeName,
+			MarkPhaseName,
+			SnapshotName,
+			ResetCountersName,
+		},
+		StartMonitorFields: StartMonitorFields(),
+	}
+}
+
+// LegacyHandshakeInfo describes the sensors that don't support the handshake
+func LegacyHandshakeInfo() *HandshakeInfo {
+	var fields []string
+	for _, name := range StartMonitorFields() {
+		if !hasString(legacyUnsupportedFields, name) {
+			fields = append(fields, name)
+		}
+	}
+
+	return &HandshakeInfo{
+		ProtocolVersion:    LegacyProtocolVersion,
+		Monitors:           []string{MonitorFanotify, MonitorPtrace, MonitorPevent},
+		Commands:           []MessageName{StartMonitorName, StopMonitorName, ShutdownSensorName},
+		StartMonitorFields: fields,
+	}
+}
+
+// HasCommand returns true if the sensor supports the command
+func (info *HandshakeInfo) HasCommand(name MessageName) bool {
+	for _, cmd := range info.Commands {
+		if cmd == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasMonitor returns true if the sensor supports the monitor
+func (info *HandshakeInfo) HasMonitor(name string) bool {
+	return hasString(info.Monitors, name)
+}
+
+// Check verifies that the sensor can execute the start monitor command.
+// It fails if the protocol versions are incompatible or if the command
+// depends on the unsupported sensor features. The other unsupported fields
+// are cleared in the command (degrading the related features) and returned.
+func (info *HandshakeInfo) Check(cmd *StartMonitor) ([]string, error) {
+	if info.ProtocolVersion < MinProtocolVersion {
+		return nil, fmt.Errorf("%w: sensor protocol version %d (min supported version - %d)",
+			ErrIncompatibleSensor, info.ProtocolVersion, MinProtocolVersion)
+	}
+
+	var unsupported []string
+	for _, name := range cmd.setFields() {
+		if hasString(info.StartMonitorFields, name) {
+			continue
+		}
+
+		if monitor, ok := requiredFields[name]; ok {
+			return nil, fmt.Errorf("%w: sensor (protocol version %d) doesn't support '%s' (%s monitor)",
+				ErrIncompatibleSensor, info.ProtocolVersion, name, monitor)
+		}
+
+		unsupported = append(unsupported, name)
+	}
+
+	if cmd.RTASourcePT && !info.HasMonitor(MonitorPtrace) {
+		unsupported = append(unsupported, "rta_source_ptrace")
+	}
+
+	cmd.clearFields(unsupported)
+	return unsupported, nil
+}
+
+// CheckMaster verifies that the sensor can work with the master
+// (based on the master handshake command; nil means there was no handshake)
+func CheckMaster(hs *Handshake) error {
+	version := LegacyProtocolVersion
+	if hs != nil {
+		version = hs.ProtocolVersion
+	}
+
+	if version < MinProtocolVersion {
+		return fmt.Errorf("%w: master protocol version %d (min supported version - %d)",
+			ErrIncompatibleMaster, version, MinProtocolVersion)
+	}
+
+	return nil
+}
+
+// StartMonitorFields returns the (JSON) names of the StartMonitor command fields
+func StartMonitorFields() []string {
+	var fields []string
+	t := reflect.TypeOf(StartMonitor{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonFieldName(t.Field(i)); name != "" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// setFields returns the names of the fields with non-zero values
+func (m *StartMonitor) setFields() []string {
+	var fields []string
+	v := reflect.ValueOf(m).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonFieldName(t.Field(i))
+		if name != "" && !v.Field(i).IsZero() {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+func (m *StartMonitor) clearFields(names []string) {
+	v := reflect.ValueOf(m).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if hasString(names, jsonFieldName(t.Field(i))) {
+			v.Field(i).Set(reflect.Zero(t.Field(i).Type))
+		}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+func hasString(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
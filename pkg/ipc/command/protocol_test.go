@@ -0,0 +1,79 @@
+package command
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHandshakeInfo_Check(t *testing.T) {
+	cmd := &StartMonitor{AppName: "/app", StreamEvents: true}
+	unsupported, err := NewHandshakeInfo("test").Check(cmd)
+	if err != nil || len(unsupported) != 0 || !cmd.StreamEvents {
+		t.Fatalf("expected a compatible sensor: %v %v", unsupported, err)
+	}
+
+	//older sensor without the event streaming and the attach monitor
+	older := NewHandshakeInfo("test")
+	older.ProtocolVersion = MinProtocolVersion
+	older.Monitors = []string{MonitorFanotify, MonitorPtrace, MonitorPevent}
+	older.StartMonitorFields = nil
+	for _, name := range StartMonitorFields() {
+		if name != "stream_events" && name != "attach_pid" {
+			older.StartMonitorFields = append(older.StartMonitorFields, name)
+		}
+	}
+
+	unsupported, err = older.Check(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unsupported) != 1 || unsupported[0] != "stream_events" || cmd.StreamEvents {
+		t.Errorf("expected the stream_events field to be dropped: %v %+v", unsupported, cmd)
+	}
+
+	if cmd.AppName != "/app" {
+		t.Errorf("unexpected app name change: %s", cmd.AppName)
+	}
+
+	_, err = older.Check(&StartMonitor{AttachPid: 1})
+	if !errors.Is(err, ErrIncompatibleSensor) {
+		t.Errorf("expected an incompatible sensor error: %v", err)
+	}
+
+	//the sensors without the handshake support are rejected
+	_, err = LegacyHandshakeInfo().Check(&StartMonitor{AppName: "/app"})
+	if !errors.Is(err, ErrIncompatibleSensor) {
+		t.Errorf("expected an incompatible legacy sensor error: %v", err)
+	}
+}
+
+func TestCheckMaster(t *testing.T) {
+	if err := CheckMaster(&Handshake{ProtocolVersion: ProtocolVersion}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := CheckMaster(&Handshake{ProtocolVersion: LegacyProtocolVersion}); !errors.Is(err, ErrIncompatibleMaster) {
+		t.Errorf("expected an incompatible master error: %v", err)
+	}
+
+	if err := CheckMaster(nil); !errors.Is(err, ErrIncompatibleMaster) {
+		t.Errorf("expected an incompatible master error without the handshake: %v", err)
+	}
+}
+
+func TestHandshake_EncodeDecode(t *testing.T) {
+	data, err := Encode(&Handshake{ProtocolVersion: ProtocolVersion, MasterVersion: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hs, ok := msg.(*Handshake); !ok || hs.ProtocolVersion != ProtocolVersion || hs.MasterVersion != "test" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
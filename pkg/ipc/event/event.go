@@ -1,6 +1,7 @@
 package event
 
 import (
+	"bytes"
 	"encoding/json"
 	goerr "errors"
 
@@ -23,8 +24,51 @@ const (
 	StopMonitorDone    Type = "event.monitor.stop.done"
 	ShutdownSensorDone Type = "event.sensor.shutdown.done"
 	Error              Type = "event.error"
+
+	// Monitor activity events (streamed while monitoring, if requested)
+	FileAccess   Type = "event.monitor.file.access"
+	ProcessExec  Type = "event.monitor.process.exec"
+	MonitorStats Type = "event.monitor.stats"
+
+	// Monitor snapshot (published in response to the Snapshot command)
+	SnapshotDone Type = "event.monitor.snapshot.done"
 )
 
+// IsActivity returns true for the monitor activity events
+func (t Type) IsActivity() bool {
+	return t == FileAccess || t == ProcessExec || t == MonitorStats
+}
+
+// FileAccessInfo describes a file accessed by the monitored app for the first time
+type FileAccessInfo struct {
+	Pid     int32  `json:"pid"`
+	Path    string `json:"path"`
+	IsWrite bool   `json:"is_write,omitempty"`
+	Phase   string `json:"phase,omitempty"`
+}
+
+// ProcessExecInfo describes a new process observed in the monitored app
+type ProcessExecInfo struct {
+	Pid       int32  `json:"pid"`
+	ParentPid int32  `json:"ppid"`
+	Path      string `json:"path"`
+	Cmd       string `json:"cmd,omitempty"`
+}
+
+// FileAccessBatch contains the FileAccess event data
+type FileAccessBatch struct {
+	Seq   uint64           `json:"seq"`
+	Files []FileAccessInfo `json:"files"`
+	Total uint64           `json:"total"`
+}
+
+// ProcessExecBatch contains the ProcessExec event data
+type ProcessExecBatch struct {
+	Seq       uint64            `json:"seq"`
+	Processes []ProcessExecInfo `json:"processes"`
+	Total     uint64            `json:"total"`
+}
+
 type Message struct {
 	Name Type        `json:"name"`
 	Data interface{} `json:"data,omitempty"`
@@ -48,6 +92,34 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 			return err
 		}
 
+		m.Data = &data
+	case FileAccess:
+		var data FileAccessBatch
+		if err := unmarshalSingle(tmp.Data, &data); err != nil {
+			return err
+		}
+
+		m.Data = &data
+	case ProcessExec:
+		var data ProcessExecBatch
+		if err := unmarshalSingle(tmp.Data, &data); err != nil {
+			return err
+		}
+
+		m.Data = &data
+	case MonitorStats:
+		var data MonitorStatsInfo
+		if err := unmarshalSingle(tmp.Data, &data); err != nil {
+			return err
+		}
+
+		m.Data = &data
+	case SnapshotDone:
+		var data SnapshotInfo
+		if err := unmarshalSingle(tmp.Data, &data); err != nil {
+			return err
+		}
+
 		m.Data = &data
 	default:
 		if len(tmp.Data) > 0 {
@@ -57,3 +129,50 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// MonitorStatsInfo contains the MonitorStats event data
+// (published periodically when the counters change;
+// ListenCount is the number of the successful listen() calls made by the app)
+type MonitorStatsInfo struct {
+	Seq          uint64 `json:"seq"`
+	SyscallCount uint64 `json:"syscall_count"`
+	SyscallNum   uint32 `json:"syscall_num"`
+	ListenCount  uint32 `json:"listen_count,omitempty"`
+}
+
+// SnapshotFileInfo describes a file in the monitor snapshot
+type SnapshotFileInfo struct {
+	Path       string   `json:"path"`
+	EventCount uint32   `json:"event_count"`
+	Phases     []string `json:"phases,omitempty"`
+}
+
+// SnapshotInfo contains the SnapshotDone event data
+// (the current partial monitor report)
+type SnapshotInfo struct {
+	Phase        string             `json:"phase,omitempty"`
+	EventCount   uint32             `json:"event_count"`
+	SyscallCount uint64             `json:"syscall_count"`
+	Processes    int                `json:"processes"`
+	Files        []SnapshotFileInfo `json:"files"`
+}
+
+// unmarshalSingle decodes the event data object
+// (the sensor publishes the event data as a list of values)
+func unmarshalSingle(raw json.RawMessage, target interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			return nil
+		}
+
+		raw = list[0]
+	}
+
+	return json.Unmarshal(raw, target)
+}
@@ -14,7 +14,9 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/armon/go-radix"
 	log "github.com/sirupsen/logrus"
@@ -112,6 +114,9 @@ type syscallState struct {
 	exiting      bool
 	pathParam    string
 	pathParamErr error
+	rangeOffset  uint64
+	rangeSize    uint64
+	rangeMapped  bool
 }
 
 type App struct {
@@ -127,6 +132,7 @@ type App struct {
 
 	RTASourcePT         bool
 	reportOnMainPidExit bool
+	recordFileRanges    bool
 
 	includeNew bool
 	origPaths  map[string]struct{}
@@ -141,8 +147,21 @@ type App struct {
 	pgid   int
 	Report report.PtMonitorReport
 
+	//live syscall counters (Report is ready only when the app is done)
+	syscallCount uint64
+	syscallNum   uint32
+	listenCount  uint32
+
+	//the listen() syscall number (used to detect when the app is ready to accept connections)
+	listenCallNum    uint32
+	hasListenCallNum bool
+
+	//the syscalls that invalidate the cached process root directories
+	rootChangeCallNums map[uint32]struct{}
+
 	fsActivity      map[string]*report.FSActivityInfo
 	syscallActivity map[uint32]uint64
+	processors      map[int]SyscallProcessor
 	//syscallResolver system.NumberResolverFunc
 
 	eventCh         chan syscallEvent
@@ -159,13 +178,37 @@ func (a *App) PGID() int {
 	return a.pgid
 }
 
+// LiveStats returns the syscall counters observed so far
+// (safe to call while the app is running)
+func (a *App) LiveStats() LiveStats {
+	return LiveStats{
+		SyscallCount: atomic.LoadUint64(&a.syscallCount),
+		SyscallNum:   atomic.LoadUint32(&a.syscallNum),
+		ListenCount:  atomic.LoadUint32(&a.listenCount),
+	}
+}
+
 const eventBufSize = 2000
 
+// processRootTTL is the max time the process root directories are cached
+// (the cached roots are also dropped when the processes exit or change their roots)
+const processRootTTL = 1 * time.Second
+
+// processRoots caches the process root directories used to map
+// the syscall path params to the image paths (see system.ToImagePath)
+var processRoots = system.NewRootDirCache(processRootTTL)
+
+// rootChangeCallNames are the syscalls that can change the process root directory
+var rootChangeCallNames = []string{"chroot", "pivot_root", "setns", "unshare"}
+
 type syscallEvent struct {
-	pid       int
-	callNum   uint32
-	retVal    uint64
-	pathParam string
+	pid         int
+	callNum     uint32
+	retVal      uint64
+	pathParam   string
+	rangeOffset uint64
+	rangeSize   uint64
+	rangeMapped bool
 }
 
 func newApp(
@@ -196,6 +239,7 @@ func newApp(
 
 		RTASourcePT:         runOpt.RTASourcePT,
 		reportOnMainPidExit: runOpt.ReportOnMainPidExit,
+		recordFileRanges:    runOpt.RecordFileRanges,
 
 		includeNew: includeNew,
 		origPaths:  origPaths,
@@ -214,6 +258,7 @@ func newApp(
 
 		fsActivity:      map[string]*report.FSActivityInfo{},
 		syscallActivity: map[uint32]uint64{},
+		processors:      syscallProcessors,
 
 		eventCh:         make(chan syscallEvent, eventBufSize),
 		collectorDoneCh: make(chan int, 2),
@@ -221,6 +266,25 @@ func newApp(
 		logger: logger,
 	}
 
+	a.listenCallNum, a.hasListenCallNum = system.LookupCallNumber("listen")
+	a.rootChangeCallNums = map[uint32]struct{}{}
+	for _, name := range rootChangeCallNames {
+		if num, ok := system.LookupCallNumber(name); ok {
+			a.rootChangeCallNums[num] = struct{}{}
+		}
+	}
+
+	if runOpt.RecordFileRanges {
+		a.processors = map[int]SyscallProcessor{}
+		for num, p := range syscallProcessors {
+			a.processors[num] = p
+		}
+
+		for num, p := range rangeSyscallProcessors() {
+			a.processors[num] = p
+		}
+	}
+
 	return &a, nil
 }
 
@@ -246,18 +310,27 @@ func (app *App) trace() {
 }
 
 func (app *App) processSyscallActivity(e *syscallEvent) {
+	if _, ok := app.syscallActivity[e.callNum]; !ok {
+		atomic.AddUint32(&app.syscallNum, 1)
+	}
+
 	app.syscallActivity[e.callNum]++
 }
 
 func (app *App) processFileActivity(e *syscallEvent) {
 	if e.pathParam != "" {
-		p, found := syscallProcessors[int(e.callNum)]
+		p, found := app.processors[int(e.callNum)]
 		if !found {
 			app.logger.WithField("op", "processFileActivity").Debugf("no syscall processor - %#v", e)
 			//shouldn't happen
 			return
 		}
 
+		if p.SyscallType() == ReadFileType {
+			app.processFileRange(e)
+			return
+		}
+
 		if (p.SyscallType() == CheckFileType ||
 			p.SyscallType() == OpenFileType) &&
 			!p.FailedReturnStatus(e.retVal) {
@@ -267,12 +340,16 @@ func (app *App) processFileActivity(e *syscallEvent) {
 				!strings.HasPrefix(e.pathParam, "/proc/") &&
 				!strings.HasPrefix(e.pathParam, "/sys/") &&
 				!strings.HasPrefix(e.pathParam, "/dev/") {
+				// The (approximate) operation time is used to recover
+				// the file events lost by the fanotify monitor.
+				now := time.Now()
 				if fsa, ok := app.fsActivity[e.pathParam]; ok {
 					fsa.OpsAll++
+					fsa.LastOpTime = now
 					fsa.Pids[e.pid] = struct{}{}
 					fsa.Syscalls[int(e.callNum)] = struct{}{}
 
-					if processor, found := syscallProcessors[int(e.callNum)]; found {
+					if processor, found := app.processors[int(e.callNum)]; found {
 						switch processor.SyscallType() {
 						case CheckFileType:
 							fsa.OpsCheckFile++
@@ -284,6 +361,8 @@ func (app *App) processFileActivity(e *syscallEvent) {
 						OpsCheckFile: 1,
 						Pids:         map[int]struct{}{},
 						Syscalls:     map[int]struct{}{},
+						FirstOpTime:  now,
+						LastOpTime:   now,
 					}
 
 					fsa.Pids[e.pid] = struct{}{}
@@ -296,6 +375,37 @@ func (app *App) processFileActivity(e *syscallEvent) {
 	}
 }
 
+func (app *App) processFileRange(e *syscallEvent) {
+	if e.rangeSize == 0 ||
+		strings.HasPrefix(e.pathParam, "/proc/") ||
+		strings.HasPrefix(e.pathParam, "/sys/") ||
+		strings.HasPrefix(e.pathParam, "/dev/") {
+		return
+	}
+
+	fsa, ok := app.fsActivity[e.pathParam]
+	if !ok {
+		now := time.Now()
+		fsa = &report.FSActivityInfo{
+			Pids:        map[int]struct{}{},
+			Syscalls:    map[int]struct{}{},
+			FirstOpTime: now,
+			LastOpTime:  now,
+		}
+
+		app.fsActivity[e.pathParam] = fsa
+	}
+
+	fsa.Pids[e.pid] = struct{}{}
+	fsa.Syscalls[int(e.callNum)] = struct{}{}
+	if e.rangeMapped {
+		fsa.AddMappedRange(e.rangeOffset, e.rangeSize)
+		return
+	}
+
+	fsa.AddRange(e.rangeOffset, e.rangeSize)
+}
+
 func (app *App) process() {
 	logger := app.logger.WithField("op", "process")
 	logger.Debug("call")
@@ -321,6 +431,7 @@ done:
 
 		case e := <-app.eventCh:
 			app.Report.SyscallCount++
+			atomic.AddUint64(&app.syscallCount, 1)
 			logger.Tracef("event ==> {pid=%v cn=%d}", e.pid, e.callNum)
 
 			app.processSyscallActivity(&e)
@@ -346,6 +457,7 @@ drain:
 		select {
 		case e := <-app.eventCh:
 			app.Report.SyscallCount++
+			atomic.AddUint64(&app.syscallCount, 1)
 			logger.Tracef("event (drained) ==> {pid=%v cn=%d}", e.pid, e.callNum)
 
 			app.processSyscallActivity(&e)
@@ -693,6 +805,7 @@ func (app *App) collect() {
 			}
 
 			delete(pidSyscallState, wpid)
+			processRoots.Forget(wpid)
 			if app.MainPID() == wpid {
 				logger.Debugf("[%d/%d]: wpid(%v) is main PID and terminated...",
 					app.cmd.Process.Pid, app.pgid, wpid)
@@ -733,7 +846,7 @@ func (app *App) collect() {
 			}
 
 			if !cstate.expectReturn {
-				genEvent, err := onSyscall(wpid, cstate)
+				genEvent, err := onSyscall(wpid, cstate, app.processors)
 				if err != nil {
 					logger.Debugf("[%d/%d]: wpid=%v onSyscall error - %v",
 						app.cmd.Process.Pid, app.pgid, wpid, err)
@@ -757,7 +870,7 @@ func (app *App) collect() {
 					}
 				}
 			} else {
-				if err := onSyscallReturn(wpid, cstate); err != nil {
+				if err := onSyscallReturn(wpid, cstate, app.processors); err != nil {
 					logger.Debugf("[%d/%d]: wpid=%v onSyscallReturn error - %v",
 						app.cmd.Process.Pid, app.pgid, wpid, err)
 					continue
@@ -766,16 +879,32 @@ func (app *App) collect() {
 
 			if cstate.gotCallNum && cstate.gotRetVal {
 				evt := syscallEvent{
-					pid:       wpid,
-					callNum:   uint32(cstate.callNum),
-					retVal:    cstate.retVal,
-					pathParam: cstate.pathParam,
+					pid:         wpid,
+					callNum:     uint32(cstate.callNum),
+					retVal:      cstate.retVal,
+					pathParam:   cstate.pathParam,
+					rangeOffset: cstate.rangeOffset,
+					rangeSize:   cstate.rangeSize,
+					rangeMapped: cstate.rangeMapped,
 				}
 
 				cstate.gotCallNum = false
 				cstate.gotRetVal = false
 				cstate.pathParam = ""
 				cstate.pathParamErr = nil
+				cstate.rangeOffset = 0
+				cstate.rangeSize = 0
+				cstate.rangeMapped = false
+
+				if _, ok := app.rootChangeCallNums[evt.callNum]; ok {
+					processRoots.Forget(wpid)
+				}
+
+				if app.hasListenCallNum &&
+					evt.callNum == app.listenCallNum &&
+					evt.retVal == 0 {
+					atomic.AddUint32(&app.listenCount, 1)
+				}
 
 				_, ok := app.origPaths[evt.pathParam]
 				if app.includeNew {
@@ -898,7 +1027,7 @@ func (app *App) startSignalForwarding() context.CancelFunc {
 	return cancel
 }
 
-func onSyscall(pid int, cstate *syscallState) (bool, error) {
+func onSyscall(pid int, cstate *syscallState, processors map[int]SyscallProcessor) (bool, error) {
 	var regs syscall.PtraceRegs
 	if err := syscall.PtraceGetRegs(pid, &regs); err != nil {
 		return false, err
@@ -908,7 +1037,7 @@ func onSyscall(pid int, cstate *syscallState) (bool, error) {
 	cstate.expectReturn = true
 	cstate.gotCallNum = true
 
-	if processor, found := syscallProcessors[int(cstate.callNum)]; found && processor != nil {
+	if processor, found := processors[int(cstate.callNum)]; found && processor != nil {
 		processor.OnCall(pid, regs, cstate)
 		genEvent := processor.EventOnCall()
 		return genEvent, nil
@@ -917,7 +1046,7 @@ func onSyscall(pid int, cstate *syscallState) (bool, error) {
 	return false, nil
 }
 
-func onSyscallReturn(pid int, cstate *syscallState) error {
+func onSyscallReturn(pid int, cstate *syscallState, processors map[int]SyscallProcessor) error {
 	var regs syscall.PtraceRegs
 	if err := syscall.PtraceGetRegs(pid, &regs); err != nil {
 		return err
@@ -927,7 +1056,7 @@ func onSyscallReturn(pid int, cstate *syscallState) error {
 	cstate.expectReturn = false
 	cstate.gotRetVal = true
 
-	if processor, found := syscallProcessors[int(cstate.callNum)]; found {
+	if processor, found := processors[int(cstate.callNum)]; found {
 		processor.OnReturn(pid, regs, cstate)
 	}
 
@@ -1042,6 +1171,10 @@ func (ref *syscallProcessorCore) OnCall(pid int, regs syscall.PtraceRegs, cstate
 		} else if cwd != "" {
 			pth = path.Join(cwd, pth)
 		}
+	} else if len(pth) > 0 {
+		//absolute paths are relative to the process root
+		//(different from the image root for chroot-ed processes)
+		pth = processRoots.ToImagePath(pid, pth)
 	}
 
 	cstate.pathParam = pth
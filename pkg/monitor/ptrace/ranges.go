@@ -0,0 +1,185 @@
+//go:build !arm64
+// +build !arm64
+
+package ptrace
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/system"
+)
+
+// File range recording (optional and analysis only):
+// the read/pread64/mmap syscall processors resolve the file descriptor
+// to the file path and record the accessed data range, so the reports
+// can show the bytes accessed vs the file size (sparse-access candidates).
+// The mmap ranges are recorded separately (as mapped, not accessed)
+// because the app might touch only a few pages of the mapped data.
+
+const ReadFileType SyscallTypeName = "type.readfile"
+
+type paramFunc func(regs syscall.PtraceRegs) uint64
+
+type readFileSyscallProcessor struct {
+	*syscallProcessorCore
+
+	fdParam     paramFunc
+	offsetParam paramFunc // nil if the current file position is used
+	sizeParam   paramFunc // nil if the size is the return value
+	offsetUnit  uint64
+	mapped      bool
+}
+
+func (ref *readFileSyscallProcessor) OnCall(pid int, regs syscall.PtraceRegs, cstate *syscallState) {
+	cstate.rangeOffset = 0
+	cstate.rangeSize = 0
+	cstate.rangeMapped = ref.mapped
+
+	fd := int(int32(ref.fdParam(regs)))
+	if fd < 0 {
+		//anonymous mappings
+		return
+	}
+
+	pth, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, fd))
+	if err != nil || !strings.HasPrefix(pth, "/") {
+		//pipes, sockets, etc
+		return
+	}
+
+	if ref.offsetParam != nil {
+		cstate.rangeOffset = ref.offsetParam(regs) * ref.offsetUnit
+	} else {
+		pos, err := fdPosition(pid, fd)
+		if err != nil {
+			log.Tracef("readFileSyscallProcessor.OnCall[%s]: pid=%d fd=%d - no file position (%v)", ref.Name, pid, fd, err)
+			return
+		}
+
+		cstate.rangeOffset = pos
+	}
+
+	if ref.sizeParam != nil {
+		cstate.rangeSize = ref.sizeParam(regs)
+	}
+
+	cstate.pathParam = processRoots.ToImagePath(pid, pth)
+}
+
+func (ref *readFileSyscallProcessor) OnReturn(pid int, regs syscall.PtraceRegs, cstate *syscallState) {
+	if ref.FailedCall(cstate) {
+		cstate.rangeSize = 0
+		return
+	}
+
+	if ref.sizeParam == nil {
+		cstate.rangeSize = cstate.retVal
+	}
+}
+
+func (ref *readFileSyscallProcessor) FailedCall(cstate *syscallState) bool {
+	return ref.FailedReturnStatus(cstate.retVal)
+}
+
+// The error return values are in the [-4095, -1] range
+// (the large mmap addresses would look negative too).
+func (ref *readFileSyscallProcessor) FailedReturnStatus(retVal uint64) bool {
+	if runtime.GOARCH == "arm" {
+		//the 32-bit return values are not sign-extended
+		retVal = uint64(int64(int32(retVal)))
+	}
+
+	return retVal > ^uint64(4096)
+}
+
+func (ref *readFileSyscallProcessor) EventOnCall() bool {
+	return false
+}
+
+// fdPosition returns the current file position for the process file descriptor
+func fdPosition(pid, fd int) (uint64, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/fdinfo/%d", pid, fd))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value := strings.TrimPrefix(scanner.Text(), "pos:"); value != scanner.Text() {
+			return strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("no file position")
+}
+
+// rangeSyscallProcessors returns the syscall processors for the file range recording
+func rangeSyscallProcessors() map[int]SyscallProcessor {
+	processors := []*readFileSyscallProcessor{
+		//read(int fd, void *buf, size_t count)
+		{
+			syscallProcessorCore: &syscallProcessorCore{Name: "read", Type: ReadFileType},
+			fdParam:              system.CallFirstParam,
+		},
+	}
+
+	//the param positions and the offset units are arch-specific
+	switch runtime.GOARCH {
+	case "amd64":
+		processors = append(processors,
+			//pread64(int fd, void *buf, size_t count, loff_t pos)
+			&readFileSyscallProcessor{
+				syscallProcessorCore: &syscallProcessorCore{Name: "pread64", Type: ReadFileType},
+				fdParam:              system.CallFirstParam,
+				offsetParam:          system.CallFourthParam,
+				offsetUnit:           1,
+			},
+			//mmap(void *addr, size_t length, int prot, int flags, int fd, off_t offset)
+			&readFileSyscallProcessor{
+				syscallProcessorCore: &syscallProcessorCore{Name: "mmap", Type: ReadFileType},
+				fdParam:              system.CallFifthParam,
+				offsetParam:          system.CallSixthParam,
+				sizeParam:            system.CallSecondParam,
+				offsetUnit:           1,
+				mapped:               true,
+			})
+	case "arm":
+		processors = append(processors,
+			//mmap2(void *addr, size_t length, int prot, int flags, int fd, off_t pgoffset)
+			&readFileSyscallProcessor{
+				syscallProcessorCore: &syscallProcessorCore{Name: "mmap2", Type: ReadFileType},
+				fdParam:              system.CallFifthParam,
+				offsetParam:          system.CallSixthParam,
+				sizeParam:            system.CallSecondParam,
+				offsetUnit:           4096,
+				mapped:               true,
+			})
+	}
+
+	result := map[int]SyscallProcessor{}
+	for _, p := range processors {
+		num, found := system.LookupCallNumber(p.SyscallName())
+		if !found {
+			log.Debugf("rangeSyscallProcessors: unknown syscall='%s'", p.SyscallName())
+			continue
+		}
+
+		p.SetSyscallNumber(uint64(num))
+		result[int(num)] = p
+	}
+
+	return result
+}
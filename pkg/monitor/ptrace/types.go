@@ -14,4 +14,13 @@ type AppRunOpt struct {
 	RunAsUser           bool
 	RTASourcePT         bool
 	ReportOnMainPidExit bool
+	RecordFileRanges    bool
+}
+
+// LiveStats contains the syscall counters available while the app is running
+// (ListenCount is the number of the successful listen() calls)
+type LiveStats struct {
+	SyscallCount uint64
+	SyscallNum   uint32
+	ListenCount  uint32
 }
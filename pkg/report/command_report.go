@@ -32,9 +32,20 @@ type Command struct {
 	Containerized  bool       `json:"containerized"`
 	HostDistro     DistroInfo `json:"host_distro"`
 	//Docker         string  `json:"docker,omitempty"`
-	Type  command.Type  `json:"type"`
-	State command.State `json:"state"`
-	Error string        `json:"error,omitempty"`
+	Type   command.Type  `json:"type"`
+	State  command.State `json:"state"`
+	Error  string        `json:"error,omitempty"`
+	Sensor *SensorInfo   `json:"sensor,omitempty"`
+}
+
+// SensorInfo contains the sensor version and compatibility information
+// (from the master-sensor IPC handshake)
+type SensorInfo struct {
+	Version               string   `json:"version,omitempty"`
+	ProtocolVersion       int      `json:"protocol_version"`
+	MasterProtocolVersion int      `json:"master_protocol_version"`
+	Monitors              []string `json:"monitors,omitempty"`
+	UnsupportedFields     []string `json:"unsupported_fields,omitempty"`
 }
 
 // ImageIdentity includes the container image identity fields
@@ -127,6 +138,21 @@ type BuildCommand struct {
 	ImageStack             []*reverse.ImageInfo `json:"image_stack"`
 	ImageCreated           bool                 `json:"image_created"`
 	ImageBuildEngine       string               `json:"image_build_engine"`
+	ResourceUsage          *ResourceUsageReport `json:"resource_usage,omitempty"`
+	APISpecCoverage        []APISpecOpCoverage  `json:"api_spec_coverage,omitempty"`
+}
+
+// APISpecOpCoverage is the API spec operation probing result
+// (from the HTTP probe calls for the API spec operations)
+type APISpecOpCoverage struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operation_id,omitempty"`
+	Endpoint    string `json:"endpoint"`
+	StatusCode  int    `json:"status_code"` //0 if the call failed
+	Result      string `json:"result"`      //ok | unauthorized | client.error | server.error | error
+	Error       string `json:"error,omitempty"`
+	Attempts    int    `json:"attempts"`
 }
 
 // Output Version for 'profile'
@@ -135,18 +161,19 @@ const OVProfileCommand = "1.0"
 // ProfileCommand is the 'profile' command report data
 type ProfileCommand struct {
 	Command
-	OriginalImage          string  `json:"original_image"`
-	OriginalImageSize      int64   `json:"original_image_size"`
-	OriginalImageSizeHuman string  `json:"original_image_size_human"`
-	MinifiedImageSize      int64   `json:"minified_image_size"`
-	MinifiedImageSizeHuman string  `json:"minified_image_size_human"`
-	MinifiedImage          string  `json:"minified_image"`
-	MinifiedImageHasData   bool    `json:"minified_image_has_data"`
-	MinifiedBy             float64 `json:"minified_by"`
-	ArtifactLocation       string  `json:"artifact_location"`
-	ContainerReportName    string  `json:"container_report_name"`
-	SeccompProfileName     string  `json:"seccomp_profile_name"`
-	AppArmorProfileName    string  `json:"apparmor_profile_name"`
+	OriginalImage          string              `json:"original_image"`
+	OriginalImageSize      int64               `json:"original_image_size"`
+	OriginalImageSizeHuman string              `json:"original_image_size_human"`
+	MinifiedImageSize      int64               `json:"minified_image_size"`
+	MinifiedImageSizeHuman string              `json:"minified_image_size_human"`
+	MinifiedImage          string              `json:"minified_image"`
+	MinifiedImageHasData   bool                `json:"minified_image_has_data"`
+	MinifiedBy             float64             `json:"minified_by"`
+	ArtifactLocation       string              `json:"artifact_location"`
+	ContainerReportName    string              `json:"container_report_name"`
+	SeccompProfileName     string              `json:"seccomp_profile_name"`
+	AppArmorProfileName    string              `json:"apparmor_profile_name"`
+	APISpecCoverage        []APISpecOpCoverage `json:"api_spec_coverage,omitempty"`
 }
 
 // Output Version for 'xray'
@@ -164,6 +191,8 @@ type XrayCommand struct {
 	ImageArchiveLocation string                      `json:"image_archive_location"`
 	RawImageManifest     *dockerimage.ManifestObject `json:"raw_image_manifest,omitempty"`
 	RawImageConfig       *dockerimage.ConfigObject   `json:"raw_image_config,omitempty"`
+	// Sparse access candidates (with the file ranges) from the 'build' container report
+	SparseAccessCandidates []*ArtifactProps `json:"sparse_access_candidates,omitempty"`
 }
 
 // Output Version for 'lint'
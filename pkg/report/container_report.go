@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"sort"
+	"time"
 )
 
 // ArtifactType is an artifact type ID
@@ -57,12 +59,25 @@ type ProcessInfo struct {
 
 // FileInfo contains various file object and activity metadata
 type FileInfo struct {
-	EventCount   uint32 `json:"event_count"`
-	FirstEventID uint32 `json:"first_eid"`
-	Name         string `json:"-"`
-	ReadCount    uint32 `json:"reads,omitempty"`
-	WriteCount   uint32 `json:"writes,omitempty"`
-	ExeCount     uint32 `json:"execs,omitempty"`
+	EventCount   uint32   `json:"event_count"`
+	FirstEventID uint32   `json:"first_eid"`
+	Name         string   `json:"-"`
+	ReadCount    uint32   `json:"reads,omitempty"`
+	WriteCount   uint32   `json:"writes,omitempty"`
+	ExeCount     uint32   `json:"execs,omitempty"`
+	Phases       []string `json:"phases,omitempty"`
+}
+
+// MountNamespaceInfo contains the metadata for the mount namespaces
+// (and chroot-ed roots) observed while monitoring the target app
+type MountNamespaceInfo struct {
+	MountNS      string `json:"mount_ns"`
+	Root         string `json:"root"`
+	FirstPid     int32  `json:"first_pid"`
+	IsMarked     bool   `json:"is_marked"`
+	MarkError    string `json:"mark_error,omitempty"`
+	MappedFiles  uint32 `json:"mapped_files,omitempty"`
+	UnmappedRefs uint32 `json:"unmapped_refs,omitempty"`
 }
 
 // FanMonitorReport is a file monitoring report
@@ -73,6 +88,33 @@ type FanMonitorReport struct {
 	MainProcess      *ProcessInfo                    `json:"main_process"`
 	Processes        map[string]*ProcessInfo         `json:"processes"`
 	ProcessFiles     map[string]map[string]*FileInfo `json:"process_files"`
+	MountNamespaces  map[string]*MountNamespaceInfo  `json:"mount_namespaces,omitempty"`
+	// Lost coverage (the file events fanotify failed to deliver or resolve)
+	OverflowCount        uint32      `json:"overflow_count,omitempty"`
+	ErrorCount           uint32      `json:"error_count,omitempty"`
+	PermissionErrorCount uint32      `json:"permission_error_count,omitempty"`
+	LostCoverage         []TimeRange `json:"lost_coverage,omitempty"`
+	RecoveredFiles       []string    `json:"recovered_files,omitempty"`
+}
+
+// CoverageIncompleteWarning is the container report warning
+// for the monitoring sessions with the lost file events
+const CoverageIncompleteWarning = "coverage may be incomplete"
+
+// HasLostCoverage returns true if some file events might be missing in the report
+func (r *FanMonitorReport) HasLostCoverage() bool {
+	return r.OverflowCount > 0 || r.ErrorCount > 0
+}
+
+// TimeRange is a time interval
+type TimeRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Overlaps returns true if the time ranges overlap (with the extra slack on both ends)
+func (r TimeRange) Overlaps(other TimeRange, slack time.Duration) bool {
+	return !other.From.After(r.To.Add(slack)) && !other.To.Before(r.From.Add(-slack))
 }
 
 // PeMonitorReport is a processing monitoring report
@@ -104,22 +146,163 @@ type FSActivityInfo struct {
 	Syscalls     map[int]struct{} `json:"syscalls"`
 	Pids         map[int]struct{} `json:"pids"`
 	IsSubdir     bool             `json:"is_subdir"`
+	FirstOpTime  time.Time        `json:"-"`
+	LastOpTime   time.Time        `json:"-"`
+	// Read and mmap ranges (recorded only if requested).
+	// The mapped ranges are tracked separately because the app
+	// might touch only a few pages of the mapped data.
+	Ranges        []FileRange `json:"ranges,omitempty"`
+	BytesAccessed uint64      `json:"bytes_accessed,omitempty"`
+	MappedRanges  []FileRange `json:"mapped_ranges,omitempty"`
+	BytesMapped   uint64      `json:"bytes_mapped,omitempty"`
+}
+
+// MaxFileRanges is the max number of the recorded ranges per file
+// (the closest ranges are merged when the limit is reached)
+const MaxFileRanges = 256
+
+// FileRange is a file data range
+type FileRange struct {
+	Offset uint64 `json:"offset"`
+	Size   uint64 `json:"size"`
+}
+
+// End returns the range end offset (exclusive)
+func (r FileRange) End() uint64 {
+	return r.Offset + r.Size
+}
+
+// AddRange adds the read data range (merging the overlapping and the adjacent ranges)
+func (info *FSActivityInfo) AddRange(offset, size uint64) {
+	if size == 0 {
+		return
+	}
+
+	info.Ranges, info.BytesAccessed = addFileRange(info.Ranges, offset, size)
+}
+
+// AddMappedRange adds the mmap-ed data range (merging the overlapping and the adjacent ranges)
+func (info *FSActivityInfo) AddMappedRange(offset, size uint64) {
+	if size == 0 {
+		return
+	}
+
+	info.MappedRanges, info.BytesMapped = addFileRange(info.MappedRanges, offset, size)
+}
+
+// addFileRange adds the range to the sorted range list
+// and returns the updated list with its total size
+func addFileRange(ranges []FileRange, offset, size uint64) ([]FileRange, uint64) {
+	r := FileRange{Offset: offset, Size: size}
+	idx := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].Offset > r.Offset
+	})
+
+	ranges = append(ranges, FileRange{})
+	copy(ranges[idx+1:], ranges[idx:])
+	ranges[idx] = r
+
+	merged := ranges[:1]
+	for _, current := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if current.Offset <= last.End() {
+			if current.End() > last.End() {
+				last.Size = current.End() - last.Offset
+			}
+
+			continue
+		}
+
+		merged = append(merged, current)
+	}
+
+	for len(merged) > MaxFileRanges {
+		merged = mergeClosestRanges(merged)
+	}
+
+	var total uint64
+	for _, current := range merged {
+		total += current.Size
+	}
+
+	return merged, total
+}
+
+func mergeClosestRanges(ranges []FileRange) []FileRange {
+	closest := 0
+	for i := 1; i < len(ranges)-1; i++ {
+		if ranges[i+1].Offset-ranges[i].End() < ranges[closest+1].Offset-ranges[closest].End() {
+			closest = i
+		}
+	}
+
+	ranges[closest].Size = ranges[closest+1].End() - ranges[closest].Offset
+	return append(ranges[:closest+1], ranges[closest+2:]...)
+}
+
+// Sparse file access candidate selection defaults
+const (
+	SparseAccessMinFileSize = 1024 * 1024
+	SparseAccessMaxRatio    = 0.5
+)
+
+// SparseAccessCandidates returns the large files where the app accessed
+// only a small part of the file data (ordered by the unaccessed bytes).
+// Only the read bytes are counted as accessed: the mmap-ed files are
+// the candidates too (unless the app reads their data too).
+func (r *ImageReport) SparseAccessCandidates(minFileSize int64, maxRatio float64, limit int) []*ArtifactProps {
+	var candidates []*ArtifactProps
+	for _, props := range r.Files {
+		if props == nil ||
+			(props.BytesAccessed == 0 && props.BytesMapped == 0) ||
+			props.FileSize < minFileSize {
+			continue
+		}
+
+		if float64(props.BytesAccessed) < float64(props.FileSize)*maxRatio {
+			candidates = append(candidates, props)
+		}
+	}
+
+	unaccessed := func(props *ArtifactProps) int64 {
+		return props.FileSize - int64(props.BytesAccessed)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if unaccessed(candidates[i]) != unaccessed(candidates[j]) {
+			return unaccessed(candidates[i]) > unaccessed(candidates[j])
+		}
+
+		return candidates[i].FilePath < candidates[j].FilePath
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates
 }
 
 // ArtifactProps contains various file system artifact properties
 type ArtifactProps struct {
-	FileType   ArtifactType    `json:"-"` //todo
-	FilePath   string          `json:"file_path"`
-	Mode       os.FileMode     `json:"-"` //todo
-	ModeText   string          `json:"mode"`
-	LinkRef    string          `json:"link_ref,omitempty"`
-	Flags      map[string]bool `json:"flags,omitempty"`
-	DataType   string          `json:"data_type,omitempty"`
-	FileSize   int64           `json:"file_size"`
-	Sha1Hash   string          `json:"sha1_hash,omitempty"`
-	AppType    string          `json:"app_type,omitempty"`
-	FileInode  uint64          `json:"-"` //todo
-	FSActivity *FSActivityInfo `json:"-"`
+	FileType ArtifactType    `json:"-"` //todo
+	FilePath string          `json:"file_path"`
+	Mode     os.FileMode     `json:"-"` //todo
+	ModeText string          `json:"mode"`
+	LinkRef  string          `json:"link_ref,omitempty"`
+	Flags    map[string]bool `json:"flags,omitempty"`
+	DataType string          `json:"data_type,omitempty"`
+	FileSize int64           `json:"file_size"`
+	Sha1Hash string          `json:"sha1_hash,omitempty"`
+	AppType  string          `json:"app_type,omitempty"`
+	Phases   []string        `json:"phases,omitempty"`
+	// Bytes read and mmap-ed by the app (only if the file ranges are recorded)
+	BytesAccessed uint64          `json:"bytes_accessed,omitempty"`
+	BytesMapped   uint64          `json:"bytes_mapped,omitempty"`
+	ReadRanges    []FileRange     `json:"read_ranges,omitempty"`
+	MappedRanges  []FileRange     `json:"mapped_ranges,omitempty"`
+	FileInode     uint64          `json:"-"` //todo
+	FSActivity    *FSActivityInfo `json:"-"`
 }
 
 // UnmarshalJSON decodes artifact property data
@@ -169,6 +352,42 @@ type MonitorReports struct {
 	Pt  *PtMonitorReport  `json:"pt"`
 }
 
+// SensorMetricsReport contains the sensor self-observability metrics summary
+type SensorMetricsReport struct {
+	Duration             string  `json:"duration"`
+	FanEvents            uint64  `json:"fan_events"`
+	FanDroppedEvents     uint64  `json:"fan_dropped_events"`
+	FanErrors            uint64  `json:"fan_errors"`
+	FanMaxQueueDepth     int64   `json:"fan_max_queue_depth"`
+	ActivityDropped      uint64  `json:"activity_dropped"`
+	Syscalls             uint64  `json:"syscalls"`
+	AvgSyscallsPerSecond float64 `json:"avg_syscalls_per_second"`
+	ReportFiles          int64   `json:"report_files"`
+	ReportProcesses      int64   `json:"report_processes"`
+	HeapAllocBytes       uint64  `json:"heap_alloc_bytes"`
+	HeapSysBytes         uint64  `json:"heap_sys_bytes"`
+}
+
+// ResourceUsageReport contains the container resource usage summary
+// (from the cgroup v2 files) for the monitored app execution.
+// The peak values include the sensor itself.
+type ResourceUsageReport struct {
+	CgroupPath       string  `json:"cgroup_path"`
+	Duration         string  `json:"duration"`
+	Samples          int     `json:"samples"`
+	MemoryPeakBytes  uint64  `json:"memory_peak_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes,omitempty"`
+	CPUUsageUsec     uint64  `json:"cpu_usage_usec"`
+	CPUUserUsec      uint64  `json:"cpu_user_usec"`
+	CPUSystemUsec    uint64  `json:"cpu_system_usec"`
+	CPUThrottledUsec uint64  `json:"cpu_throttled_usec,omitempty"`
+	AvgCPUCores      float64 `json:"avg_cpu_cores"`
+	IOReadBytes      uint64  `json:"io_read_bytes"`
+	IOWriteBytes     uint64  `json:"io_write_bytes"`
+	PidsPeak         uint64  `json:"pids_peak"`
+	PidsLimit        uint64  `json:"pids_limit,omitempty"`
+}
+
 // SystemReport provides a basic system report for the container environment
 type SystemReport struct {
 	Type    string     `json:"type"`
@@ -178,10 +397,13 @@ type SystemReport struct {
 
 // ContainerReport contains container report fields
 type ContainerReport struct {
-	SensorVersion string         `json:"system"`
-	System        SystemReport   `json:"system"`
-	Monitors      MonitorReports `json:"monitors"`
-	Image         ImageReport    `json:"image"`
+	SensorVersion string               `json:"system"`
+	System        SystemReport         `json:"system"`
+	Monitors      MonitorReports       `json:"monitors"`
+	Image         ImageReport          `json:"image"`
+	SensorMetrics *SensorMetricsReport `json:"sensor_metrics,omitempty"`
+	ResourceUsage *ResourceUsageReport `json:"resource_usage,omitempty"`
+	Warnings      []string             `json:"warnings,omitempty"`
 }
 
 // PermSetFromFlags maps artifact flags to permissions
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFSActivityInfoAddRange(t *testing.T) {
+	info := &FSActivityInfo{}
+	info.AddRange(100, 50)
+	info.AddRange(0, 10)
+	info.AddRange(150, 10) //adjacent
+	info.AddRange(120, 10) //overlapping
+	info.AddRange(500, 0)  //empty
+
+	expected := []FileRange{{Offset: 0, Size: 10}, {Offset: 100, Size: 60}}
+	if !reflect.DeepEqual(info.Ranges, expected) {
+		t.Fatalf("unexpected ranges: %+v", info.Ranges)
+	}
+
+	if info.BytesAccessed != 70 {
+		t.Fatalf("unexpected bytes accessed: %d", info.BytesAccessed)
+	}
+}
+
+func TestFSActivityInfoAddRangeLimit(t *testing.T) {
+	info := &FSActivityInfo{}
+	for i := uint64(0); i <= MaxFileRanges; i++ {
+		info.AddRange(i*100, 10)
+	}
+
+	if len(info.Ranges) != MaxFileRanges {
+		t.Fatalf("unexpected range count: %d", len(info.Ranges))
+	}
+
+	//the merged range covers the gap between the closest ranges
+	if info.BytesAccessed != (MaxFileRanges+1)*10+90 {
+		t.Fatalf("unexpected bytes accessed: %d", info.BytesAccessed)
+	}
+}
+
+func TestFSActivityInfoAddMappedRange(t *testing.T) {
+	info := &FSActivityInfo{}
+	info.AddRange(0, 10)
+	info.AddMappedRange(0, 4096)
+	info.AddMappedRange(4096, 4096) //adjacent
+
+	if info.BytesAccessed != 10 {
+		t.Fatalf("unexpected bytes accessed: %d", info.BytesAccessed)
+	}
+
+	expected := []FileRange{{Offset: 0, Size: 8192}}
+	if !reflect.DeepEqual(info.MappedRanges, expected) {
+		t.Fatalf("unexpected mapped ranges: %+v", info.MappedRanges)
+	}
+
+	if info.BytesMapped != 8192 {
+		t.Fatalf("unexpected bytes mapped: %d", info.BytesMapped)
+	}
+}
+
+func TestSparseAccessCandidates(t *testing.T) {
+	const fileSize = 10 * SparseAccessMinFileSize
+	r := &ImageReport{
+		Files: []*ArtifactProps{
+			{FilePath: "/data/read.dat", FileSize: fileSize, BytesAccessed: 1024},
+			{FilePath: "/data/mapped.dat", FileSize: fileSize, BytesMapped: fileSize},
+			{FilePath: "/data/full.dat", FileSize: fileSize, BytesAccessed: fileSize},
+			{FilePath: "/data/small.dat", FileSize: 1024, BytesAccessed: 10},
+			{FilePath: "/data/unknown.dat", FileSize: fileSize},
+		},
+	}
+
+	candidates := r.SparseAccessCandidates(SparseAccessMinFileSize, SparseAccessMaxRatio, 0)
+
+	var names []string
+	for _, props := range candidates {
+		names = append(names, props.FilePath)
+	}
+
+	//the mmap-ed bytes are not counted as accessed
+	expected := []string{"/data/mapped.dat", "/data/read.dat"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected candidates: %v", names)
+	}
+}
@@ -0,0 +1,198 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	procMountNSPat = "/proc/%d/ns/mnt"
+	procRootPat    = "/proc/%d/root"
+	procPrefix     = "/proc/"
+	procRootSuffix = "/root"
+)
+
+// MountNamespace returns the mount namespace ID of a process (e.g., "mnt:[4026531840]")
+func MountNamespace(pid int) (string, error) {
+	return os.Readlink(fmt.Sprintf(procMountNSPat, pid))
+}
+
+// RootDir returns the root directory of a process as seen from the current process.
+// It's "/" unless the process is chroot-ed (or pivot_root-ed into a reachable directory).
+func RootDir(pid int) (string, error) {
+	return os.Readlink(fmt.Sprintf(procRootPat, pid))
+}
+
+// RootPath returns the "/proc/<pid>/root" path that can be used to access
+// the file system of a process even if it's in a different mount namespace.
+func RootPath(pid int) string {
+	return fmt.Sprintf(procRootPat, pid)
+}
+
+// ProcessNSInfo describes the file system view of a process
+type ProcessNSInfo struct {
+	Pid     int
+	MountNS string
+	Root    string
+}
+
+// IsChroot returns true if the process has a root directory different from the current process
+func (ref *ProcessNSInfo) IsChroot() bool {
+	return ref.Root != "" && ref.Root != "/"
+}
+
+// GetProcessNSInfo collects the mount namespace and root directory info for a process
+func GetProcessNSInfo(pid int) (*ProcessNSInfo, error) {
+	ns, err := MountNamespace(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := RootDir(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProcessNSInfo{
+		Pid:     pid,
+		MountNS: ns,
+		Root:    root,
+	}, nil
+}
+
+// ToImagePath maps a path from the view of a process (pid) to the view of the current process
+// (the image root). It handles chroot-ed processes (absolute paths get the process root prefix)
+// and the "/proc/<pid>/root/..." paths (including "/proc/self/root/...").
+// The function expects an absolute path.
+func ToImagePath(pid int, fpath string) string {
+	return toImagePath(pid, fpath, RootDir)
+}
+
+type rootDirFunc func(pid int) (string, error)
+
+func toImagePath(pid int, fpath string, rootDir rootDirFunc) string {
+	if !strings.HasPrefix(fpath, "/") {
+		return fpath
+	}
+
+	if strings.HasPrefix(fpath, procPrefix) {
+		if mapped, ok := procRootToImagePath(pid, fpath, rootDir); ok {
+			return mapped
+		}
+
+		return fpath
+	}
+
+	root, err := rootDir(pid)
+	if err != nil || root == "" || root == "/" {
+		return fpath
+	}
+
+	if fpath == root || strings.HasPrefix(fpath, root+"/") {
+		//already in the current process view
+		//(can't tell for sure, but it's a better guess)
+		return fpath
+	}
+
+	return filepath.Join(root, fpath)
+}
+
+func procRootToImagePath(pid int, fpath string, rootDir rootDirFunc) (string, bool) {
+	rest := strings.TrimPrefix(fpath, procPrefix)
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) < 2 {
+		return "", false
+	}
+
+	rootPid := pid
+	if parts[0] != "self" && parts[0] != "thread-self" {
+		n, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return "", false
+		}
+		rootPid = n
+	}
+
+	tail := "/" + parts[1]
+	if !(tail == procRootSuffix || strings.HasPrefix(tail, procRootSuffix+"/")) {
+		return "", false
+	}
+
+	tail = strings.TrimPrefix(tail, procRootSuffix)
+	if tail == "" {
+		tail = "/"
+	}
+
+	root, err := rootDir(rootPid)
+	if err != nil || root == "" {
+		root = "/"
+	}
+
+	return filepath.Join(root, tail), true
+}
+
+// RootDirCache caches the process root directories
+// (to avoid a readlink call for each path mapped with ToImagePath).
+// The cached roots expire after the TTL, so the roots changed with chroot/pivot_root
+// are picked up even if the caller doesn't call Forget.
+type RootDirCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	rootDir rootDirFunc
+	now     func() time.Time
+	entries map[int]rootDirEntry
+}
+
+type rootDirEntry struct {
+	root      string
+	checkedAt time.Time
+}
+
+// NewRootDirCache creates a new process root directory cache
+func NewRootDirCache(ttl time.Duration) *RootDirCache {
+	return &RootDirCache{
+		ttl:     ttl,
+		rootDir: RootDir,
+		now:     time.Now,
+		entries: map[int]rootDirEntry{},
+	}
+}
+
+// RootDir returns the (cached) root directory of a process
+func (c *RootDirCache) RootDir(pid int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if entry, ok := c.entries[pid]; ok && now.Sub(entry.checkedAt) < c.ttl {
+		return entry.root, nil
+	}
+
+	root, err := c.rootDir(pid)
+	if err != nil {
+		delete(c.entries, pid)
+		return "", err
+	}
+
+	c.entries[pid] = rootDirEntry{root: root, checkedAt: now}
+	return root, nil
+}
+
+// Forget removes the cached root directory of a process
+// (used when the process exits or when it changes its root)
+func (c *RootDirCache) Forget(pid int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, pid)
+}
+
+// ToImagePath is the ToImagePath version that uses the cached root directories
+func (c *RootDirCache) ToImagePath(pid int, fpath string) string {
+	return toImagePath(pid, fpath, c.RootDir)
+}
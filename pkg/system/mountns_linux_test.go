@@ -0,0 +1,101 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToImagePath(t *testing.T) {
+	roots := map[int]string{
+		100: "/",
+		200: "/chroot",
+	}
+
+	rootDir := func(pid int) (string, error) {
+		if root, ok := roots[pid]; ok {
+			return root, nil
+		}
+
+		return "", errors.New("no such process")
+	}
+
+	tt := []struct {
+		pid      int
+		path     string
+		expected string
+	}{
+		{pid: 100, path: "/etc/passwd", expected: "/etc/passwd"},
+		{pid: 200, path: "/etc/passwd", expected: "/chroot/etc/passwd"},
+		{pid: 200, path: "/chroot/etc/passwd", expected: "/chroot/etc/passwd"},
+		{pid: 200, path: "etc/passwd", expected: "etc/passwd"},
+		{pid: 300, path: "/etc/passwd", expected: "/etc/passwd"},
+		{pid: 200, path: "/proc/self/root/etc/hosts", expected: "/chroot/etc/hosts"},
+		{pid: 200, path: "/proc/thread-self/root", expected: "/chroot"},
+		{pid: 100, path: "/proc/200/root/etc/hosts", expected: "/chroot/etc/hosts"},
+		{pid: 200, path: "/proc/100/root/etc/hosts", expected: "/etc/hosts"},
+		{pid: 200, path: "/proc/self/rootfs/etc", expected: "/proc/self/rootfs/etc"},
+		{pid: 200, path: "/proc/self/fd/1", expected: "/proc/self/fd/1"},
+		{pid: 200, path: "/proc/cpuinfo", expected: "/proc/cpuinfo"},
+		{pid: 100, path: "/proc/300/root/etc/hosts", expected: "/etc/hosts"},
+	}
+
+	for _, test := range tt {
+		if got := toImagePath(test.pid, test.path, rootDir); got != test.expected {
+			t.Errorf("pid=%d path=%s: expected %s, got %s", test.pid, test.path, test.expected, got)
+		}
+	}
+}
+
+func TestProcRootToImagePath(t *testing.T) {
+	rootDir := func(pid int) (string, error) { return "/chroot", nil }
+
+	if _, ok := procRootToImagePath(1, "/proc/abc/root/etc", rootDir); ok {
+		t.Error("expected no mapping for the bad pid")
+	}
+
+	if _, ok := procRootToImagePath(1, "/proc/1", rootDir); ok {
+		t.Error("expected no mapping for the path without the root")
+	}
+
+	if mapped, ok := procRootToImagePath(1, "/proc/1/root/", rootDir); !ok || mapped != "/chroot" {
+		t.Errorf("unexpected mapping: %s (%v)", mapped, ok)
+	}
+}
+
+func TestRootDirCache(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	root := "/"
+
+	cache := NewRootDirCache(time.Second)
+	cache.now = func() time.Time { return now }
+	cache.rootDir = func(pid int) (string, error) {
+		calls++
+		return root, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		cache.ToImagePath(100, "/etc/passwd")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected one root lookup, got %d", calls)
+	}
+
+	root = "/chroot"
+	if got := cache.ToImagePath(100, "/etc/passwd"); got != "/etc/passwd" {
+		t.Errorf("expected cached root mapping, got %s", got)
+	}
+
+	cache.Forget(100)
+	if got := cache.ToImagePath(100, "/etc/passwd"); got != "/chroot/etc/passwd" {
+		t.Errorf("expected new root mapping after Forget, got %s", got)
+	}
+
+	root = "/other"
+	now = now.Add(time.Second)
+	if got := cache.ToImagePath(100, "/etc/passwd"); got != "/other/etc/passwd" {
+		t.Errorf("expected new root mapping after TTL, got %s", got)
+	}
+}
@@ -46,8 +46,18 @@ func CallThirdParam(regs syscall.PtraceRegs) uint64 {
 	return regs.Rdx
 }
 
+// The syscall instruction clobbers rcx, so the 4th syscall param is passed in r10
+// (unlike the regular function calls).
 func CallFourthParam(regs syscall.PtraceRegs) uint64 {
-	return regs.Rcx
+	return regs.R10
+}
+
+func CallFifthParam(regs syscall.PtraceRegs) uint64 {
+	return regs.R8
+}
+
+func CallSixthParam(regs syscall.PtraceRegs) uint64 {
+	return regs.R9
 }
 
 /*
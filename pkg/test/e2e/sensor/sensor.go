@@ -207,7 +207,7 @@ func (s *Sensor) StartControlled(ctx context.Context) error {
 	}
 
 	// TODO: Refactor the IPC code to use context with a deadline.
-	client, err := ipc.NewClient("127.0.0.1", cmdPort, evtPort, 10) // Seconds, I guess
+	client, err := ipc.NewClient("127.0.0.1", cmdPort, evtPort, 10, "") // Seconds, I guess
 	if err != nil {
 		return fmt.Errorf("cannot start IPC client: %w", err)
 	}
@@ -319,7 +319,7 @@ func (s *Sensor) SendCommand(ctx context.Context, cmd command.Message) error {
 	// TODO: Use timeout from ctx.
 	resp, err := s.client.SendCommand(cmd)
 	if err != nil || resp.Status != command.ResponseStatusOk {
-		return fmt.Errorf("IPC client.SendCommand() failed with response %q: %w", resp, err)
+		return fmt.Errorf("IPC client.SendCommand() failed with response %+v: %w", resp, err)
 	}
 
 	return nil
@@ -329,7 +329,15 @@ func (s *Sensor) SendStartCommand(
 	ctx context.Context,
 	cmdOverride ...command.StartMonitor,
 ) error {
+	if s.client == nil {
+		return errors.New("IPC client isn't initialized - is sensor running?")
+	}
+
 	cmd := startCommandControlled(s.image, cmdOverride...)
+	if _, err := s.client.Negotiate(&cmd); err != nil {
+		return fmt.Errorf("IPC client.Negotiate() failed: %w", err)
+	}
+
 	return s.SendCommand(ctx, &cmd)
 }
 
@@ -1,6 +1,8 @@
 package execution
 
 import (
+	"sync"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker-slim/docker-slim/pkg/app/sensor/execution"
@@ -8,8 +10,17 @@ import (
 	"github.com/docker-slim/docker-slim/pkg/ipc/event"
 )
 
+// PubEventInfo is a published event (recorded by the execution stub)
+type PubEventInfo struct {
+	Type event.Type
+	Data []interface{}
+}
+
 type executionStub struct {
 	commands chan command.Message
+
+	mu     sync.Mutex
+	events []PubEventInfo
 }
 
 var _ execution.Interface = &executionStub{}
@@ -33,6 +44,17 @@ func (e *executionStub) PubEvent(etype event.Type, data ...interface{}) {
 		WithField("type", etype).
 		WithField("data", data).
 		Debug("execution stub - new event")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, PubEventInfo{Type: etype, Data: data})
+}
+
+// PubEvents returns the published events
+func (e *executionStub) PubEvents() []PubEventInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]PubEventInfo(nil), e.events...)
 }
 
 func (e *executionStub) Close() {
@@ -47,11 +69,11 @@ func (e *executionStub) HookSensorPreShutdown() {
 	// noop
 }
 
-func (e *executionStub) HookMonitorPreStart() {
-	// noop
+func (e *executionStub) HookMonitorPreStart(cmd *command.StartMonitor) error {
+	return nil // noop
 }
 
-func (e *executionStub) HookMonitorPostShutdown() {
+func (e *executionStub) HookMonitorPostShutdown(summary *execution.ReportSummary) {
 	// noop
 }
 
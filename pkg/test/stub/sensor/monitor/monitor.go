@@ -60,6 +60,14 @@ func (m *FanMonitorStub) Status() (*report.FanMonitorReport, error) {
 	return nil, nil
 }
 
+func (m *FanMonitorStub) MarkPhase(name string) {}
+
+func (m *FanMonitorStub) Snapshot() *report.FanMonitorReport {
+	return nil
+}
+
+func (m *FanMonitorStub) ResetCounters() {}
+
 // ptrace monitor stub implements ptrace.Monitor
 type PtMonitorStub struct {
 	*monitorStub
@@ -73,6 +81,10 @@ func NewPtMonitor(ctx context.Context) *PtMonitorStub {
 	}
 }
 
+func (m *PtMonitorStub) LiveStats() ptrace.LiveStats {
+	return ptrace.LiveStats{}
+}
+
 func (m *PtMonitorStub) Status() (*report.PtMonitorReport, error) {
 	return nil, nil
 }